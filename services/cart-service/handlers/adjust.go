@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"cart-service/models"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdjustItem changes an item's quantity by a relative delta, removing the
+// item when it reaches zero
+func AdjustItem(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	productID, err := strconv.Atoi(c.Param("product_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return
+	}
+
+	// A zero delta fails the required check
+	var req models.AdjustItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	cartKey, _, ok := requestCart(c, userID)
+	if !ok {
+		return
+	}
+	lock, ok := lockCart(c, cartKey)
+	if !ok {
+		return
+	}
+	defer lock.Release(c.Request.Context())
+	if !ensureNotFrozen(c, cartKey) {
+		return
+	}
+
+	cart, err := loadCart(c.Request.Context(), cartKey)
+	if err == errCartCorrupt {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse cart data"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Cart not found", "code": codeCartEmpty})
+		return
+	}
+
+	i := cart.FindItem(productID)
+	if i == -1 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Item not found in cart", "code": codeItemNotFound})
+		return
+	}
+
+	item := cart.Items[i]
+	quantity := item.Quantity + req.Delta
+	if quantity < 0 {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error":    "Adjustment would make quantity negative",
+			"quantity": cart.Items[i].Quantity,
+		})
+		return
+	}
+
+	message := "Cart updated"
+	if quantity == 0 {
+		cart.Items = append(cart.Items[:i], cart.Items[i+1:]...)
+		message = "Item removed from cart (quantity reached 0)"
+	} else {
+		if !checkQuantity(c, &cart.Items[i], quantity) {
+			return
+		}
+		cart.Items[i].Quantity = quantity
+		cart.Items[i].TrimHold(quantity)
+	}
+
+	// Units added by a positive delta count against the daily quota
+	now := time.Now()
+	claimedQuota, ok := claimDailyQuota(c, userID, &item, req.Delta, now)
+	if !ok {
+		return
+	}
+
+	cart.CalculateTotals()
+
+	if err := saveCart(c.Request.Context(), cartKey, cart); err != nil {
+		if claimedQuota {
+			releaseDailyQuota(c.Request.Context(), userID, productID, req.Delta, now)
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save cart"})
+		return
+	}
+	trimFlashHold(c.Request.Context(), cartKey, item, quantity)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": message,
+		"cart":    cart,
+	})
+}
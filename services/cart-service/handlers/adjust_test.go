@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestAdjustItem(t *testing.T) {
+	tests := []struct {
+		name       string
+		delta      string
+		wantStatus int
+		want       map[int]int
+	}{
+		{name: "increment", delta: "1", wantStatus: http.StatusOK, want: map[int]int{1: 3, 2: 1}},
+		{name: "decrement", delta: "-1", wantStatus: http.StatusOK, want: map[int]int{1: 1, 2: 1}},
+		{name: "decrement to removal", delta: "-2", wantStatus: http.StatusOK, want: map[int]int{2: 1}},
+		{name: "decrement below zero", delta: "-3", wantStatus: http.StatusUnprocessableEntity, want: map[int]int{1: 2, 2: 1}},
+		{name: "zero delta", delta: "0", wantStatus: http.StatusBadRequest, want: map[int]int{1: 2, 2: 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newTestRedis(t)
+			cartKey := cartKeyFor(testUserID)
+			seedCart(t, cartKey, testItem(1, 10, 2), testItem(2, 5, 1))
+
+			w := serve(t, AdjustItem, testRequest{
+				method: http.MethodPost,
+				route:  "/items/:product_id/adjust",
+				target: "/items/1/adjust",
+				body:   `{"delta": ` + tt.delta + `}`,
+			})
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+			assertQuantities(t, "stored", storedCart(t, cartKey), tt.want)
+		})
+	}
+}
+
+func TestAdjustItemNotInCart(t *testing.T) {
+	newTestRedis(t)
+	seedCart(t, cartKeyFor(testUserID), testItem(1, 10, 2))
+
+	w := serve(t, AdjustItem, testRequest{
+		method: http.MethodPost,
+		route:  "/items/:product_id/adjust",
+		target: "/items/9/adjust",
+		body:   `{"delta": 1}`,
+	})
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+	if body := decodeResponse(t, w); body["code"] != codeItemNotFound {
+		t.Errorf("code = %v, want %s", body["code"], codeItemNotFound)
+	}
+}
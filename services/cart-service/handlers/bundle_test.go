@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"cart-service/models"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestAddItemAppliesBundles(t *testing.T) {
+	bundles := []models.Bundle{
+		{ID: "breakfast", Name: "Breakfast set", ProductIDs: []int{1, 2}, DiscountPercent: 10},
+		{ID: "trio", Name: "Trio", ProductIDs: []int{1, 3, 4}, DiscountAmount: 5},
+	}
+
+	tests := []struct {
+		name         string
+		add          string
+		wantBundles  []string
+		wantDiscount float64
+		wantFinal    float64
+	}{
+		{
+			name:         "complete bundle",
+			add:          `{"product_id": 2, "quantity": 1}`,
+			wantBundles:  []string{"breakfast"},
+			wantDiscount: 3,
+			wantFinal:    27,
+		},
+		{
+			name:        "partial bundle",
+			add:         `{"product_id": 3, "quantity": 1}`,
+			wantBundles: []string{},
+			wantFinal:   15,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newTestRedis(t)
+			newProductService(t, map[int]gin.H{
+				2: {"name": "Mug", "price": 20, "quantity": 10},
+				3: {"name": "Spoon", "price": 5, "quantity": 10},
+			})
+			newPromotionsService(t, promotionsFixture{bundles: bundles})
+			cartKey := cartKeyFor(testUserID)
+			seedCart(t, cartKey, testItem(1, 10, 1))
+
+			w := serve(t, AddItem, testRequest{method: http.MethodPost, route: "/items", body: tt.add})
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d: %s", w.Code, w.Body)
+			}
+
+			cart := storedCart(t, cartKey)
+			applied := []string{}
+			for _, bundle := range cart.Bundles {
+				applied = append(applied, bundle.ID)
+			}
+			if len(applied) != len(tt.wantBundles) || (len(applied) > 0 && applied[0] != tt.wantBundles[0]) {
+				t.Errorf("bundles = %v, want %v", applied, tt.wantBundles)
+			}
+			if cart.BundleDiscount != tt.wantDiscount || cart.FinalPrice != tt.wantFinal {
+				t.Errorf("bundle discount/final = %v/%v, want %v/%v", cart.BundleDiscount, cart.FinalPrice, tt.wantDiscount, tt.wantFinal)
+			}
+		})
+	}
+}
+
+func TestRemovingBundleItemDropsDiscount(t *testing.T) {
+	newTestRedis(t)
+	cartKey := cartKeyFor(testUserID)
+	cart := models.NewCart(testUserID)
+	cart.Items = []models.CartItem{testItem(1, 10, 1), testItem(2, 20, 1)}
+	cart.Bundles = []models.Bundle{{ID: "breakfast", ProductIDs: []int{1, 2}, DiscountPercent: 10}}
+	storeCart(t, cartKey, cart)
+	if stored := storedCart(t, cartKey); stored.BundleDiscount != 3 {
+		t.Fatalf("seeded bundle discount = %v, want 3", stored.BundleDiscount)
+	}
+
+	w := serve(t, RemoveItem, testRequest{method: http.MethodDelete, route: "/items/:product_id", target: "/items/2"})
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body)
+	}
+	if stored := storedCart(t, cartKey); stored.BundleDiscount != 0 || len(stored.Bundles) != 0 {
+		t.Errorf("bundle kept after breaking it: discount %v, bundles %v", stored.BundleDiscount, stored.Bundles)
+	}
+}
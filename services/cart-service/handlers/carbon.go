@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"cart-service/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetCartCarbon returns the cart's estimated carbon footprint and, if
+// CARBON_OFFSET_PRICE_PER_KG is set, what offsetting it would cost
+func GetCartCarbon(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	cartKey, _, ok := requestCart(c, userID)
+	if !ok {
+		return
+	}
+
+	cart, err := loadCart(c.Request.Context(), cartKey)
+	if err == errCartCorrupt {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse cart data"})
+		return
+	}
+	if err != nil || len(cart.Items) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Cart is empty", "code": codeCartEmpty})
+		return
+	}
+
+	offsetPrice := utils.GetEnvFloat("CARBON_OFFSET_PRICE_PER_KG", 0)
+	c.JSON(http.StatusOK, gin.H{"carbon": cart.CarbonFootprint(offsetPrice)})
+}
@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestGetCartCarbon(t *testing.T) {
+	tests := []struct {
+		name        string
+		offsetPrice string
+		wantGrams   float64
+		wantOffset  interface{}
+		wantMissing string
+	}{
+		{name: "summed across items", wantGrams: 2*250 + 3*100, wantMissing: "[3]"},
+		{name: "with an offset price", offsetPrice: "0.5", wantGrams: 800, wantOffset: 0.4, wantMissing: "[3]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newTestRedis(t)
+			newProductService(t, map[int]gin.H{
+				1: {"name": "Kettle", "price": 20, "quantity": 10, "carbon_grams": 250},
+				2: {"name": "Mug", "price": 5, "quantity": 10, "carbon_grams": "100"},
+				3: {"name": "E-book", "price": 8, "quantity": 10},
+			})
+			newPromotionsService(t, promotionsFixture{})
+			t.Setenv("CARBON_OFFSET_PRICE_PER_KG", tt.offsetPrice)
+
+			for _, add := range []string{
+				`{"product_id": 1, "quantity": 2}`,
+				`{"product_id": 2, "quantity": 3}`,
+				`{"product_id": 3, "quantity": 1}`,
+			} {
+				if w := serve(t, AddItem, testRequest{method: http.MethodPost, route: "/items", body: add}); w.Code != http.StatusOK {
+					t.Fatalf("add status = %d: %s", w.Code, w.Body)
+				}
+			}
+
+			w := serve(t, GetCartCarbon, testRequest{route: "/carbon"})
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d: %s", w.Code, w.Body)
+			}
+			carbon := decodeResponse(t, w)["carbon"].(map[string]interface{})
+			if carbon["total_grams"] != tt.wantGrams {
+				t.Errorf("total_grams = %v, want %v", carbon["total_grams"], tt.wantGrams)
+			}
+			if carbon["offset_cost"] != tt.wantOffset {
+				t.Errorf("offset_cost = %v, want %v", carbon["offset_cost"], tt.wantOffset)
+			}
+			if missing := fmt.Sprint(carbon["missing_footprint"]); missing != tt.wantMissing {
+				t.Errorf("missing_footprint = %s, want %s", missing, tt.wantMissing)
+			}
+		})
+	}
+}
@@ -3,15 +3,466 @@ package handlers
 import (
 	"cart-service/models"
 	"cart-service/utils"
-	"encoding/json"
+	"context"
+	"errors"
 	"fmt"
+	"log"
 	"net/http"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
 )
 
-const cartKeyPrefix = "cart:"
+const cartTTL = 24 * time.Hour
+
+// AddItem on_duplicate modes for a product already in the cart
+const (
+	onDuplicateMerge   = "merge"
+	onDuplicateError   = "error"
+	onDuplicateReplace = "replace"
+)
+
+// Codes telling clients whether a 404 is for the cart or an item in it
+const (
+	codeCartEmpty    = "cart_empty"
+	codeItemNotFound = "item_not_found"
+)
+
+// errCartCorrupt is returned when stored cart data cannot be decoded
+var errCartCorrupt = errors.New("failed to parse cart data")
+
+// cartNamePattern restricts named cart names to safe key characters
+var cartNamePattern = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,50}$`)
+
+// cartKeyFor builds the Redis key holding a user's cart
+func cartKeyFor(userID interface{}) string {
+	return utils.Key("cart", utils.UserKey(fmt.Sprintf("%v", userID)))
+}
+
+// namedCartKeyFor builds the Redis key holding one of a user's named carts
+func namedCartKeyFor(userID interface{}, name string) string {
+	return utils.Key("cart", utils.UserKey(fmt.Sprintf("%v", userID)), name)
+}
+
+// requestCart resolves the cart key addressed by the request, honoring the
+// optional ?name= query parameter for named carts. Writes a 400 response and
+// returns false if the name is invalid.
+func requestCart(c *gin.Context, userID interface{}) (cartKey string, name string, ok bool) {
+	name = c.Query("name")
+	if name == "" {
+		return cartKeyFor(userID), "", true
+	}
+
+	if !cartNamePattern.MatchString(name) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cart name"})
+		return "", "", false
+	}
+	return namedCartKeyFor(userID, name), name, true
+}
+
+// newCart creates an empty cart for the user with the given name
+func newCart(userID interface{}, name string) *models.Cart {
+	cart := models.NewCart(fmt.Sprintf("%v", userID))
+	cart.Name = name
+	return cart
+}
+
+// loadCart fetches and decodes the cart stored at cartKey.
+// Returns redis.Nil if the cart does not exist.
+func loadCart(ctx context.Context, cartKey string) (*models.Cart, error) {
+	cartData, err := utils.RedisClient.Get(ctx, cartKey).Result()
+	if err != nil {
+		return nil, err
+	}
+	return decodeCart(cartData)
+}
+
+// loadCartWithTTL fetches the cart and its remaining time-to-live in a
+// single pipelined round trip. The TTL is -1 when the cart has no
+// expiration or does not exist. Returns redis.Nil if the cart does not exist.
+func loadCartWithTTL(ctx context.Context, cartKey string) (*models.Cart, int64, error) {
+	pipe := utils.RedisClient.Pipeline()
+	getCmd := pipe.Get(ctx, cartKey)
+	ttlCmd := pipe.TTL(ctx, cartKey)
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, -1, err
+	}
+
+	ttl := int64(-1)
+	if d := ttlCmd.Val(); d > 0 {
+		ttl = int64(d.Seconds())
+	}
+
+	cartData, err := getCmd.Result()
+	if err != nil {
+		return nil, ttl, err
+	}
+
+	cart, err := decodeCart(cartData)
+	return cart, ttl, err
+}
+
+// decodeCart decodes raw cart data read from Redis in either storage
+// format, migrating carts stored under older schema versions
+func decodeCart(cartData string) (*models.Cart, error) {
+	cart, err := models.Deserialize([]byte(cartData))
+	if err != nil {
+		return nil, errCartCorrupt
+	}
+	return cart, nil
+}
+
+// saveCart serializes the cart and stores it with the standard expiration,
+// updating its item count in the same transaction
+func saveCart(ctx context.Context, cartKey string, cart *models.Cart) error {
+	stampActor(ctx, cart)
+	cart.CommitVersion()
+	cartData, err := models.Serialize(cart)
+	if err != nil {
+		return err
+	}
+	_, err = utils.RedisClient.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, cartKey, cartData, cartTTL)
+		pipe.Set(ctx, countKeyFor(cartKey), cart.TotalItems, cartTTL)
+		trackCouponClaim(ctx, pipe, cartKey, cart)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	recordCartSave(cartKey, cart, len(cartData))
+	publishCartUpdated(ctx, cart)
+	return nil
+}
+
+// priceDisplay returns whether the response presents prices inclusive or
+// exclusive of tax. With PRICE_DISPLAY_BY_REGION the mode follows the
+// customer's country: the cart's shipping address, else the geo header
+// set by the edge (GEO_COUNTRY_HEADER), else the Accept-Language region.
+// Otherwise, or if no country is known, the PRICE_DISPLAY default applies.
+func priceDisplay(c *gin.Context, cart *models.Cart) string {
+	if !utils.FeatureEnabled(c.Request.Context(), utils.FlagPriceDisplayByRegion) {
+		return models.DefaultPriceDisplay()
+	}
+	return models.PriceDisplayForCountry(requestCountry(c, cart))
+}
+
+// requestCountry works out the customer's ISO country code, or "" if
+// nothing indicates one
+func requestCountry(c *gin.Context, cart *models.Cart) string {
+	if cart.ShippingAddress != nil && cart.ShippingAddress.Country != "" {
+		return cart.ShippingAddress.Country
+	}
+
+	geoHeader := os.Getenv("GEO_COUNTRY_HEADER")
+	if geoHeader == "" {
+		geoHeader = "CF-IPCountry"
+	}
+	if country := strings.TrimSpace(c.GetHeader(geoHeader)); len(country) == 2 {
+		return country
+	}
+
+	// The region subtag of the preferred language, e.g. "de-AT" -> AT
+	language := c.GetHeader("Accept-Language")
+	language, _, _ = strings.Cut(language, ",")
+	language, _, _ = strings.Cut(language, ";")
+	if _, region, ok := strings.Cut(strings.TrimSpace(language), "-"); ok && len(region) == 2 {
+		return region
+	}
+	return ""
+}
+
+// stampActor records who is saving the cart and prices it for their role
+// and loyalty tier, so member and tier discounts follow the authenticated
+// user
+func stampActor(ctx context.Context, cart *models.Cart) {
+	actor := utils.Actor(ctx)
+	if actor == "" {
+		return
+	}
+	cart.UpdatedBy = actor
+
+	role, tier := utils.Role(ctx), utils.LoyaltyTier(ctx, actor)
+	if role != cart.MemberRole || tier != cart.LoyaltyTier {
+		cart.MemberRole = role
+		cart.LoyaltyTier = tier
+		cart.CalculateTotals()
+	}
+}
+
+// applyPromotion copies the product's current promotion onto the item.
+// A failing promotions service leaves the item at full price.
+func applyPromotion(ctx context.Context, item *models.CartItem) {
+	promo, err := utils.FetchPromotion(ctx, item.ProductID)
+	if err != nil {
+		log.Printf("Failed to fetch promotion for product %d: %v", item.ProductID, err)
+		return
+	}
+
+	item.DiscountPercent = 0
+	item.DiscountAmount = 0
+	item.PromoPrice = 0
+	item.PromoLimit = 0
+	if promo != nil {
+		item.DiscountPercent = promo.DiscountPercent
+		item.DiscountAmount = promo.DiscountAmount
+		item.PromoPrice = promo.PromoPrice
+		item.PromoLimit = promo.PromoLimit
+		if promo.FlashStock > 0 {
+			item.PromoLimit = item.FlashReserved
+		}
+	}
+}
+
+// applyBundles refreshes which bundle promotions the cart completes.
+// A failing promotions service leaves the current bundles in place.
+func applyBundles(ctx context.Context, cart *models.Cart) {
+	bundles, err := utils.FetchBundles(ctx)
+	if err != nil {
+		log.Printf("Failed to fetch bundles: %v", err)
+		return
+	}
+	cart.ApplyBundles(bundles)
+}
+
+// priceLockDuration returns how long an item's captured price is
+// guaranteed before it can be repriced
+func priceLockDuration() time.Duration {
+	return time.Duration(utils.GetEnvInt("PRICE_LOCK_MINUTES", 30)) * time.Minute
+}
+
+// refreshProductDetails copies the product's current details onto the
+// item. The price only changes once the item's price lock has expired.
+func refreshProductDetails(item *models.CartItem, product *utils.Product) {
+	item.ProductName = product.Name
+	price, validUntil := product.CurrentPrice(time.Now())
+	if item.Reprice(price, time.Now(), priceLockDuration()) {
+		item.PriceReverted = false
+	}
+	item.PriceValidUntil = validUntil
+	item.RegularPrice = float64(product.RegularPrice)
+	item.QuantityStep = product.QuantityStep
+	item.MaxPerOrder = product.MaxPerOrder
+	item.Weight = float64(product.Weight)
+	item.Dimensions = product.Dimensions
+	item.ImageURL = product.ImageURL
+	item.SKU = product.SKU
+	item.Category = product.Category
+	item.AgeRestricted = product.AgeRestricted
+	item.MinimumAge = product.MinimumAge
+	item.Discontinued = product.Discontinued
+	item.TaxCategory = product.TaxCategory
+	item.CarbonGrams = float64(product.CarbonGrams)
+	item.VendorID = product.VendorID
+	item.HandlingFee = float64(product.HandlingFee)
+	item.FulfillmentType = product.FulfillmentType
+	item.DailyQuota = product.DailyQuota
+	if item.IsSubscription {
+		item.SubscriptionDiscountPercent = float64(product.SubscriptionDiscountPercent)
+	}
+}
+
+// repriceUnlockedItems moves items whose price lock has expired to the
+// product's current price, and items whose sale price has expired back to
+// the regular price. Products that have been discontinued (or no
+// longer exist) are flagged, or removed and reported when
+// AUTO_REMOVE_DISCONTINUED is set. Returns true if the cart changed.
+func repriceUnlockedItems(ctx context.Context, cart *models.Cart) (bool, []models.ItemNotice) {
+	now := time.Now()
+	autoRemove := utils.FeatureEnabled(ctx, utils.FlagAutoRemoveDiscontinued)
+	changed := false
+	removed := []models.ItemNotice{}
+
+	kept := cart.Items[:0]
+	for _, item := range cart.Items {
+		if item.ExpireSalePrice(now) {
+			changed = true
+		}
+		if item.Pending || item.PromoGift || item.PriceLocked(now) {
+			kept = append(kept, item)
+			continue
+		}
+
+		product, err := utils.FetchProduct(ctx, item.ProductID)
+		if err != nil && err != utils.ErrProductNotFound {
+			log.Printf("Failed to fetch product %d: %v", item.ProductID, err)
+			kept = append(kept, item)
+			continue
+		}
+
+		if err == utils.ErrProductNotFound || product.Discontinued {
+			if autoRemove {
+				removed = append(removed, models.ItemNotice{
+					ProductID:   item.ProductID,
+					ProductName: item.ProductName,
+					Reason:      models.NoticeReasonDiscontinued,
+				})
+				changed = true
+				continue
+			}
+			if !item.Discontinued {
+				item.Discontinued = true
+				changed = true
+			}
+			kept = append(kept, item)
+			continue
+		}
+
+		price, validUntil := product.CurrentPrice(now)
+		if item.Reprice(price, now, priceLockDuration()) || item.Discontinued {
+			item.Discontinued = false
+			changed = true
+		}
+		if item.PriceValidUntil != validUntil {
+			item.PriceValidUntil = validUntil
+			item.RegularPrice = float64(product.RegularPrice)
+			changed = true
+		}
+		kept = append(kept, item)
+	}
+	cart.Items = kept
+
+	if changed {
+		cart.CalculateTotals()
+	}
+	return changed, removed
+}
+
+// applyGiftPromotions refreshes the free-gift promotions the cart is
+// evaluated against. A failing promotions service leaves the current ones.
+func applyGiftPromotions(ctx context.Context, cart *models.Cart) {
+	gifts, err := utils.FetchGiftPromotions(ctx)
+	if err != nil {
+		log.Printf("Failed to fetch gift promotions: %v", err)
+		return
+	}
+	cart.ApplyGiftPromotions(gifts)
+}
+
+// reconcilePendingItems replaces placeholder details on items added in
+// degraded mode with real product data. Returns true if any item changed.
+func reconcilePendingItems(ctx context.Context, cart *models.Cart) bool {
+	changed := false
+	for i := range cart.Items {
+		item := &cart.Items[i]
+		if !item.Pending {
+			continue
+		}
+
+		product, err := utils.FetchProduct(ctx, item.ProductID)
+		if err != nil {
+			// Still unavailable; try again on the next read
+			continue
+		}
+
+		refreshProductDetails(item, product)
+		item.Pending = false
+		applyPromotion(ctx, item)
+		changed = true
+	}
+
+	if changed {
+		cart.CalculateTotals()
+	}
+	return changed
+}
+
+// requestAttribution reads UTM campaign parameters from the query string,
+// falling back to X-UTM-* headers. Returns nil if none are present.
+func requestAttribution(c *gin.Context) *models.Attribution {
+	utm := func(name, header string) string {
+		if value := c.Query(name); value != "" {
+			return value
+		}
+		return c.GetHeader(header)
+	}
+
+	attribution := models.Attribution{
+		Source:   utm("utm_source", "X-UTM-Source"),
+		Medium:   utm("utm_medium", "X-UTM-Medium"),
+		Campaign: utm("utm_campaign", "X-UTM-Campaign"),
+	}
+	if attribution.Source == "" && attribution.Medium == "" && attribution.Campaign == "" {
+		return nil
+	}
+
+	attribution.CapturedAt = time.Now().Format(time.RFC3339)
+	return &attribution
+}
+
+// checkQuantity writes a 422 response and returns false if the quantity
+// violates the item's product constraints
+func checkQuantity(c *gin.Context, item *models.CartItem, quantity int) bool {
+	if !item.ValidStep(quantity) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error":         fmt.Sprintf("Quantity must be a multiple of %d", item.QuantityStep),
+			"quantity_step": item.QuantityStep,
+		})
+		return false
+	}
+	if !item.WithinOrderLimit(quantity) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error":         fmt.Sprintf("At most %d of this product can be ordered at once", item.MaxPerOrder),
+			"max_per_order": item.MaxPerOrder,
+		})
+		return false
+	}
+	return true
+}
+
+// shedUnderMemoryPressure writes a 503 and returns true if Redis is short
+// of memory and the write would create a cart or add a large quantity.
+// Reads, removals and small changes to existing carts still go through.
+func shedUnderMemoryPressure(c *gin.Context, creating bool, quantity int) bool {
+	if !utils.MemoryPressure() {
+		return false
+	}
+	if !creating && quantity <= utils.GetEnvInt("MEMORY_PRESSURE_MAX_ADD_QUANTITY", 10) {
+		return false
+	}
+
+	c.Header("Retry-After", "30")
+	c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Cart storage is under pressure, please retry later"})
+	return true
+}
+
+// saveCarts stores several carts atomically in a single MULTI/EXEC
+func saveCarts(ctx context.Context, carts map[string]*models.Cart) error {
+	encoded := make(map[string][]byte, len(carts))
+	for cartKey, cart := range carts {
+		stampActor(ctx, cart)
+		cart.CommitVersion()
+		cartData, err := models.Serialize(cart)
+		if err != nil {
+			return err
+		}
+		encoded[cartKey] = cartData
+	}
+
+	_, err := utils.RedisClient.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		for cartKey, cartData := range encoded {
+			pipe.Set(ctx, cartKey, cartData, cartTTL)
+			pipe.Set(ctx, countKeyFor(cartKey), carts[cartKey].TotalItems, cartTTL)
+			trackCouponClaim(ctx, pipe, cartKey, carts[cartKey])
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for cartKey, cartData := range encoded {
+		recordCartSave(cartKey, carts[cartKey], len(cartData))
+		publishCartUpdated(ctx, carts[cartKey])
+	}
+	return nil
+}
 
 // GetCart retrieves the user's cart
 func GetCart(c *gin.Context) {
@@ -21,25 +472,207 @@ func GetCart(c *gin.Context) {
 		return
 	}
 
-	cartKey := fmt.Sprintf("%s%v", cartKeyPrefix, userID)
+	cartKey, name, ok := requestCart(c, userID)
+	if !ok {
+		return
+	}
 
-	// Get cart from Redis
-	cartData, err := utils.RedisClient.Get(utils.Ctx, cartKey).Result()
+	// Optional ?fields= projection trims each item to the listed fields
+	var fields []string
+	if fieldsParam := c.Query("fields"); fieldsParam != "" {
+		fields = strings.Split(fieldsParam, ",")
+	}
+
+	cart, expiresIn, err := loadCartWithTTL(c.Request.Context(), cartKey)
+	if err == errCartCorrupt {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse cart data"})
+		return
+	}
 	if err != nil {
 		// Cart doesn't exist, return empty cart
-		cart := models.NewCart(fmt.Sprintf("%v", userID))
-		c.JSON(http.StatusOK, gin.H{"cart": cart})
+		cart = newCart(userID, name)
+	} else {
+		healCartCount(c.Request.Context(), cartKey, cart)
+	}
+
+	// Fill in items added while product-service was down and, if enabled,
+	// reprice items whose price lock has expired
+	changed := cart.HasPendingItems() && reconcilePendingItems(c.Request.Context(), cart)
+	var removed []models.ItemNotice
+	if utils.FeatureEnabled(c.Request.Context(), utils.FlagRepriceOnRead) {
+		var repriced bool
+		repriced, removed = repriceUnlockedItems(c.Request.Context(), cart)
+		changed = changed || repriced
+	}
+	if changed {
+		saveRefreshedCart(c.Request.Context(), cartKey, cart)
+	}
+
+	// Present items in a stable order
+	cart.SortItems()
+
+	// Incremental sync: only the items changed since the client's version
+	if sinceParam := c.Query("since_version"); sinceParam != "" {
+		since, err := strconv.Atoi(sinceParam)
+		if err != nil || since < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "since_version must be a non-negative integer"})
+			return
+		}
+
+		if delta, ok := cart.DeltaSince(since); ok {
+			c.JSON(http.StatusOK, gin.H{
+				"delta":              delta,
+				"full_refresh":       false,
+				"expires_in_seconds": expiresIn,
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"cart":               cart,
+			"full_refresh":       true,
+			"expires_in_seconds": expiresIn,
+		})
+		return
+	}
+
+	// Warn about items that got more expensive since the last full view
+	var increases []models.PriceIncrease
+	if err == nil {
+		increases = priceIncreasesSinceLastView(c.Request.Context(), cartKey, cart)
+	}
+
+	// Optional ?category= shows only matching items; totals stay those of
+	// the whole cart
+	if category := c.Query("category"); category != "" {
+		filtered := *cart
+		filtered.Items = cart.ItemsInCategory(category)
+		cart = &filtered
+	}
+
+	response := gin.H{
+		"cart":               cart,
+		"prices":             cart.PriceView(priceDisplay(c, cart)),
+		"holds":              cart.Holds(time.Now()),
+		"expires_in_seconds": expiresIn,
+	}
+	if len(removed) > 0 {
+		response["removed_items"] = removed
+	}
+	if len(increases) > 0 {
+		response["price_increases"] = increases
+	}
+
+	// Optional ?use_profile_currency=true estimates the totals in the
+	// user's preferred currency
+	if c.Query("use_profile_currency") == "true" {
+		currency := utils.PreferredCurrency(c.Request.Context(), fmt.Sprintf("%v", userID), c.GetHeader("Authorization"))
+		if currency != "" && currency != cart.Currency {
+			rate, err := utils.GetFXRate(c.Request.Context(), cart.Currency, currency)
+			if err != nil {
+				log.Printf("Failed to get FX rate %s->%s: %v", cart.Currency, currency, err)
+			} else {
+				response["converted"] = cart.ConvertedTotals(currency, rate)
+			}
+		}
+	}
+
+	if fields != nil {
+		projected, err := projectCart(cart, fields)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to encode cart"})
+			return
+		}
+		response["cart"] = projected
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// saveRefreshedCart stores a cart that was refreshed while being read. The
+// save is skipped if a writer holds the cart lock or has changed the cart
+// since it was read; the next read refreshes it again.
+func saveRefreshedCart(ctx context.Context, cartKey string, cart *models.Cart) {
+	lock, err := acquireCartLock(ctx, cartKey)
+	if err != nil {
+		log.Printf("Skipping save of refreshed cart %s: %v", cartKey, err)
+		return
+	}
+	defer lock.Release(ctx)
+
+	stored, err := loadCart(ctx, cartKey)
+	if err != nil || stored.Version != cart.Version {
+		return
+	}
+	if err := saveCart(ctx, cartKey, cart); err != nil {
+		log.Printf("Failed to save refreshed cart %s: %v", cartKey, err)
+	}
+}
+
+// GetCartMeta returns the cart's version and last-modified details, a
+// cheap way for clients to tell whether their copy is stale
+func GetCartMeta(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	cartKey, _, ok := requestCart(c, userID)
+	if !ok {
 		return
 	}
 
-	// Unmarshal cart data
-	var cart models.Cart
-	if err := json.Unmarshal([]byte(cartData), &cart); err != nil {
+	cart, err := loadCart(c.Request.Context(), cartKey)
+	if err == errCartCorrupt {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse cart data"})
 		return
 	}
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Cart not found", "code": codeCartEmpty})
+		return
+	}
 
-	c.JSON(http.StatusOK, gin.H{"cart": cart})
+	c.JSON(http.StatusOK, gin.H{"meta": cart.Meta()})
+}
+
+// GetItem returns a single line item, letting clients check whether a
+// product is in the cart without fetching the whole cart
+func GetItem(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	productID, err := strconv.Atoi(c.Param("product_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return
+	}
+
+	cartKey, _, ok := requestCart(c, userID)
+	if !ok {
+		return
+	}
+
+	cart, err := loadCart(c.Request.Context(), cartKey)
+	if err == errCartCorrupt {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse cart data"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Cart not found", "code": codeCartEmpty})
+		return
+	}
+
+	i := cart.FindItem(productID)
+	if i == -1 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Item not found in cart", "code": codeItemNotFound})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"item": cart.Items[i]})
 }
 
 // AddItem adds an item to the cart
@@ -56,75 +689,190 @@ func AddItem(c *gin.Context) {
 		return
 	}
 
-	// TODO: In production, fetch product details from product-service
-	// For now, using mock data
-	productName := fmt.Sprintf("Product %d", req.ProductID)
-	price := 99.99
+	if req.IsSubscription && !models.ValidInterval(req.Interval) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "interval must be 'weekly' or 'monthly'"})
+		return
+	}
+	if !req.IsSubscription && req.Interval != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "interval is only valid for subscriptions"})
+		return
+	}
 
-	cartKey := fmt.Sprintf("%s%v", cartKeyPrefix, userID)
+	// How to handle a product that is already in the cart
+	onDuplicate := c.DefaultQuery("on_duplicate", onDuplicateMerge)
+	if onDuplicate != onDuplicateMerge && onDuplicate != onDuplicateError && onDuplicate != onDuplicateReplace {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "on_duplicate must be 'merge', 'error' or 'replace'"})
+		return
+	}
 
-	// Get existing cart or create new one
-	var cart models.Cart
-	cartData, err := utils.RedisClient.Get(utils.Ctx, cartKey).Result()
+	// Fetch current product details from product-service. If it is
+	// unreachable and degraded adds are allowed, add a pending placeholder
+	// to be reconciled once product-service recovers.
+	degraded := false
+	product, err := utils.FetchProduct(c.Request.Context(), req.ProductID)
+	if err == utils.ErrProductNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+		return
+	}
 	if err != nil {
-		// Create new cart
-		cart = *models.NewCart(fmt.Sprintf("%v", userID))
-	} else {
-		// Parse existing cart
-		if err := json.Unmarshal([]byte(cartData), &cart); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse cart data"})
+		log.Printf("Failed to fetch product %d: %v", req.ProductID, err)
+		// Subscription eligibility can't be checked without product details
+		if !utils.FeatureEnabled(c.Request.Context(), utils.FlagDegradedAdd) || req.IsSubscription {
+			c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to fetch product details"})
 			return
 		}
+		degraded = true
+		product = &utils.Product{ID: req.ProductID, Name: fmt.Sprintf("Product %d", req.ProductID)}
+	}
+	if req.IsSubscription && !product.SubscriptionEligible {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Product is not eligible for subscription"})
+		return
 	}
 
-	// Check if item already exists in cart
-	itemExists := false
-	for i, item := range cart.Items {
-		if item.ProductID == req.ProductID {
-			// Update quantity
-			cart.Items[i].Quantity += req.Quantity
-			cart.Items[i].Subtotal = float64(cart.Items[i].Quantity) * cart.Items[i].Price
-			itemExists = true
-			break
-		}
+	cartKey, name, ok := requestCart(c, userID)
+	if !ok {
+		return
+	}
+	lock, ok := lockCart(c, cartKey)
+	if !ok {
+		return
+	}
+	defer lock.Release(c.Request.Context())
+	if !ensureNotFrozen(c, cartKey) {
+		return
 	}
 
-	// Add new item if it doesn't exist
-	if !itemExists {
-		newItem := models.CartItem{
-			ProductID:   req.ProductID,
-			ProductName: productName,
-			Price:       price,
-			Quantity:    req.Quantity,
-			Subtotal:    float64(req.Quantity) * price,
-			AddedAt:     time.Now().Format(time.RFC3339),
-		}
-		cart.Items = append(cart.Items, newItem)
+	// Get existing cart or create new one
+	cart, err := loadCart(c.Request.Context(), cartKey)
+	if err == errCartCorrupt {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse cart data"})
+		return
+	}
+	creating := err != nil
+	if creating {
+		cart = newCart(userID, name)
 	}
 
-	// Recalculate totals
-	cart.CalculateTotals()
+	// Attribute the cart to the campaign that led to its first item
+	if cart.Attribution == nil {
+		cart.Attribution = requestAttribution(c)
+	}
 
-	// Save cart to Redis
-	cartJSON, err := json.Marshal(cart)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save cart"})
+	// Find the item or start a new line for it
+	itemIndex := cart.FindItem(req.ProductID)
+	if itemIndex != -1 && onDuplicate == onDuplicateError {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "Product is already in the cart",
+			"item":  cart.Items[itemIndex],
+		})
 		return
 	}
 
-	// Set cart with 24-hour expiration
-	err = utils.RedisClient.Set(utils.Ctx, cartKey, cartJSON, 24*time.Hour).Err()
-	if err != nil {
+	addQuantity := req.QuantityOrDefault(utils.GetEnvInt("CART_DEFAULT_QUANTITY", 1))
+	if shedUnderMemoryPressure(c, creating, addQuantity) {
+		return
+	}
+	if !withinCartLimit(c, userID, name, creating) {
+		return
+	}
+
+	// Only the units the add puts in the cart count against the daily
+	// quota; a replace may add fewer than requested, or none
+	previousQuantity := 0
+	if itemIndex != -1 && !cart.Items[itemIndex].PromoGift {
+		previousQuantity = cart.Items[itemIndex].Quantity
+	}
+	if !stageItem(c, cart, product, addQuantity, onDuplicate == onDuplicateReplace, degraded) {
+		return
+	}
+	item := &cart.Items[cart.FindItem(req.ProductID)]
+	if req.IsSubscription {
+		item.SetSubscription(req.Interval, float64(product.SubscriptionDiscountPercent))
+		cart.CalculateTotals()
+	}
+
+	now := time.Now()
+	added := item.Quantity - previousQuantity
+	claimedQuota, ok := claimDailyQuota(c, userID, item, added, now)
+	if !ok {
+		return
+	}
+
+	// Save cart with 24-hour expiration
+	if err := saveCart(c.Request.Context(), cartKey, cart); err != nil {
+		if claimedQuota {
+			releaseDailyQuota(c.Request.Context(), userID, req.ProductID, added, now)
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save cart to Redis"})
 		return
 	}
 
+	message := "Item added to cart"
+	if degraded {
+		message = "Item added to cart; product details are pending"
+	}
+
 	c.JSON(http.StatusOK, gin.H{
-		"message": "Item added to cart",
+		"message": message,
 		"cart":    cart,
 	})
 }
 
+// priceAllowed reports whether a product's price may be added to a cart.
+// A zero price usually means bad upstream data, so it is refused unless
+// the product is a deliberate freebie or ALLOW_ZERO_PRICE is set.
+func priceAllowed(product *utils.Product) bool {
+	return product.Price > 0 || product.Freebie || utils.GetEnvBool("ALLOW_ZERO_PRICE", false)
+}
+
+// stageItem adds quantity of product to the in-memory cart (or sets it, with
+// replace) and recalculates totals. Pending items keep placeholder details.
+// Writes the error response and returns false if the quantity is invalid.
+func stageItem(c *gin.Context, cart *models.Cart, product *utils.Product, quantity int, replace, pending bool) bool {
+	userID, _ := c.Get("user_id")
+
+	if !pending && !priceAllowed(product) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Product has no price"})
+		return false
+	}
+
+	itemIndex := cart.FindItem(product.ID)
+	if itemIndex == -1 {
+		cart.Items = append(cart.Items, models.CartItem{
+			ProductID:   product.ID,
+			ProductName: product.Name,
+			Price:       float64(product.Price),
+			AddedAt:     time.Now().Format(time.RFC3339),
+			AddedBy:     fmt.Sprintf("%v", userID),
+			Pending:     pending,
+		})
+		itemIndex = len(cart.Items) - 1
+	}
+	item := &cart.Items[itemIndex]
+
+	// Refresh the price and product constraints, then validate the
+	// resulting quantity. Units received as a gift aren't added to.
+	if !pending {
+		refreshProductDetails(item, product)
+	}
+	if !replace {
+		quantity += item.PaidQuantity()
+	}
+	if !checkQuantity(c, item, quantity) {
+		return false
+	}
+	item.SetPaidQuantity(quantity)
+
+	// Refresh any item-level promotion and bundles the cart now completes
+	applyPromotion(c.Request.Context(), item)
+	applyBundles(c.Request.Context(), cart)
+	applyGiftPromotions(c.Request.Context(), cart)
+
+	// Recalculate totals
+	cart.CalculateTotals()
+	return true
+}
+
 // UpdateItem updates the quantity of an item in the cart
 func UpdateItem(c *gin.Context) {
 	userID, exists := c.Get("user_id")
@@ -141,40 +889,72 @@ func UpdateItem(c *gin.Context) {
 		return
 	}
 
-	cartKey := fmt.Sprintf("%s%v", cartKeyPrefix, userID)
+	quantity := *req.Quantity
 
-	// Get cart
-	var cart models.Cart
-	cartData, err := utils.RedisClient.Get(utils.Ctx, cartKey).Result()
-	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Cart not found"})
+	// Setting quantity to 0 removes the item unless operators disable it
+	zeroRemoves := utils.FeatureEnabled(c.Request.Context(), utils.FlagZeroQuantityRemoves)
+	if quantity == 0 && !zeroRemoves {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Quantity must be at least 1; use DELETE /api/cart/items/:product_id to remove an item",
+		})
+		return
+	}
+
+	cartKey, _, ok := requestCart(c, userID)
+	if !ok {
+		return
+	}
+	lock, ok := lockCart(c, cartKey)
+	if !ok {
+		return
+	}
+	defer lock.Release(c.Request.Context())
+	if !ensureNotFrozen(c, cartKey) {
 		return
 	}
 
-	if err := json.Unmarshal([]byte(cartData), &cart); err != nil {
+	// Get cart
+	cart, err := loadCart(c.Request.Context(), cartKey)
+	if err == errCartCorrupt {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse cart data"})
 		return
 	}
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Cart not found", "code": codeCartEmpty})
+		return
+	}
 
 	// Find and update item
 	itemFound := false
+	var previous models.CartItem
 	for i, item := range cart.Items {
 		if fmt.Sprintf("%d", item.ProductID) == productID {
-			if req.Quantity == 0 {
+			if quantity == 0 {
 				// Remove item if quantity is 0
 				cart.Items = append(cart.Items[:i], cart.Items[i+1:]...)
 			} else {
 				// Update quantity
-				cart.Items[i].Quantity = req.Quantity
-				cart.Items[i].Subtotal = float64(req.Quantity) * cart.Items[i].Price
+				if !checkQuantity(c, &cart.Items[i], quantity) {
+					return
+				}
+				cart.Items[i].Quantity = quantity
+				cart.Items[i].TrimHold(quantity)
 			}
+			previous = item
 			itemFound = true
 			break
 		}
 	}
 
 	if !itemFound {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Item not found in cart"})
+		c.JSON(http.StatusNotFound, gin.H{"error": "Item not found in cart", "code": codeItemNotFound})
+		return
+	}
+
+	// Units added by an increase count against the daily quota
+	now := time.Now()
+	claimedQuota, ok := claimDailyQuota(c, userID, &previous, quantity-previous.Quantity, now)
+	if !ok {
 		return
 	}
 
@@ -182,20 +962,22 @@ func UpdateItem(c *gin.Context) {
 	cart.CalculateTotals()
 
 	// Save cart
-	cartJSON, err := json.Marshal(cart)
-	if err != nil {
+	if err := saveCart(c.Request.Context(), cartKey, cart); err != nil {
+		if claimedQuota {
+			releaseDailyQuota(c.Request.Context(), userID, previous.ProductID, quantity-previous.Quantity, now)
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save cart"})
 		return
 	}
+	trimFlashHold(c.Request.Context(), cartKey, previous, quantity)
 
-	err = utils.RedisClient.Set(utils.Ctx, cartKey, cartJSON, 24*time.Hour).Err()
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save cart"})
-		return
+	message := "Cart updated"
+	if quantity == 0 {
+		message = "Item removed from cart (quantity set to 0)"
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"message": "Cart updated",
+		"message": message,
 		"cart":    cart,
 	})
 }
@@ -209,33 +991,44 @@ func RemoveItem(c *gin.Context) {
 	}
 
 	productID := c.Param("product_id")
-	cartKey := fmt.Sprintf("%s%v", cartKeyPrefix, userID)
-
-	// Get cart
-	var cart models.Cart
-	cartData, err := utils.RedisClient.Get(utils.Ctx, cartKey).Result()
-	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Cart not found"})
+	cartKey, _, ok := requestCart(c, userID)
+	if !ok {
+		return
+	}
+	lock, ok := lockCart(c, cartKey)
+	if !ok {
+		return
+	}
+	defer lock.Release(c.Request.Context())
+	if !ensureNotFrozen(c, cartKey) {
 		return
 	}
 
-	if err := json.Unmarshal([]byte(cartData), &cart); err != nil {
+	// Get cart
+	cart, err := loadCart(c.Request.Context(), cartKey)
+	if err == errCartCorrupt {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse cart data"})
 		return
 	}
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Cart not found", "code": codeCartEmpty})
+		return
+	}
 
 	// Find and remove item
 	itemFound := false
+	var removed models.CartItem
 	for i, item := range cart.Items {
 		if fmt.Sprintf("%d", item.ProductID) == productID {
 			cart.Items = append(cart.Items[:i], cart.Items[i+1:]...)
+			removed = item
 			itemFound = true
 			break
 		}
 	}
 
 	if !itemFound {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Item not found in cart"})
+		c.JSON(http.StatusNotFound, gin.H{"error": "Item not found in cart", "code": codeItemNotFound})
 		return
 	}
 
@@ -243,17 +1036,11 @@ func RemoveItem(c *gin.Context) {
 	cart.CalculateTotals()
 
 	// Save cart
-	cartJSON, err := json.Marshal(cart)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save cart"})
-		return
-	}
-
-	err = utils.RedisClient.Set(utils.Ctx, cartKey, cartJSON, 24*time.Hour).Err()
-	if err != nil {
+	if err := saveCart(c.Request.Context(), cartKey, cart); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save cart"})
 		return
 	}
+	trimFlashHold(c.Request.Context(), cartKey, removed, 0)
 
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Item removed from cart",
@@ -269,16 +1056,101 @@ func ClearCart(c *gin.Context) {
 		return
 	}
 
-	cartKey := fmt.Sprintf("%s%v", cartKeyPrefix, userID)
+	// Clear a subset of named carts at once
+	if names := c.Query("names"); names != "" {
+		clearNamedCarts(c, userID, strings.Split(names, ","))
+		return
+	}
+
+	cartKey, _, ok := requestCart(c, userID)
+	if !ok {
+		return
+	}
+	lock, ok := lockCart(c, cartKey)
+	if !ok {
+		return
+	}
+	defer lock.Release(c.Request.Context())
+	if !ensureNotFrozen(c, cartKey) {
+		return
+	}
+
+	// Load the cart to give back what its items hold. A corrupt cart is
+	// still cleared.
+	cart, loadErr := loadCart(c.Request.Context(), cartKey)
 
 	// Delete cart from Redis
-	err := utils.RedisClient.Del(utils.Ctx, cartKey).Err()
+	err := utils.RedisClient.Del(c.Request.Context(), cartKey, countKeyFor(cartKey), lastViewedKeyFor(cartKey)).Err()
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to clear cart"})
 		return
 	}
+	if loadErr == nil {
+		releaseClearedCart(c.Request.Context(), cartKey, cart)
+	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Cart cleared successfully",
 	})
-}
\ No newline at end of file
+}
+
+// releaseClearedCart gives back what a deleted cart held: its items'
+// flash-sale units, its coupon's use and its redeemed points
+func releaseClearedCart(ctx context.Context, cartKey string, cart *models.Cart) {
+	releaseCartHolds(ctx, cartKey, cart.Items)
+	if cart.Coupon != nil {
+		releaseCouponUse(ctx, cartKey, cart.Coupon)
+	}
+	if redemption := cart.PointsRedemption; redemption != nil {
+		if err := utils.ReleasePoints(ctx, cart.UserID, redemption.Points); err != nil {
+			log.Printf("Failed to release %d loyalty points for user %s: %v", redemption.Points, cart.UserID, err)
+		}
+	}
+}
+
+// RecalculateCart re-derives every subtotal and the cart totals and saves
+// the result, repairing carts whose stored totals have drifted
+func RecalculateCart(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	cartKey, _, ok := requestCart(c, userID)
+	if !ok {
+		return
+	}
+	lock, ok := lockCart(c, cartKey)
+	if !ok {
+		return
+	}
+	defer lock.Release(c.Request.Context())
+	if !ensureNotFrozen(c, cartKey) {
+		return
+	}
+
+	cart, err := loadCart(c.Request.Context(), cartKey)
+	if err == errCartCorrupt {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse cart data"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Cart not found", "code": codeCartEmpty})
+		return
+	}
+
+	previous := cart.Summary()
+	cart.CalculateTotals()
+
+	if err := saveCart(c.Request.Context(), cartKey, cart); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save cart"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "Cart totals recalculated",
+		"previous": previous,
+		"cart":     cart,
+	})
+}
@@ -0,0 +1,1008 @@
+package handlers
+
+import (
+	"cart-service/models"
+	"cart-service/utils"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestAddItemAppliesItemPromotion(t *testing.T) {
+	tests := []struct {
+		name          string
+		promotion     gin.H
+		wantSubtotal  float64
+		wantSavings   float64
+		wantCartTotal float64
+	}{
+		{
+			name:          "20% off",
+			promotion:     gin.H{"product_id": 1, "discount_percent": 20},
+			wantSubtotal:  80,
+			wantSavings:   20,
+			wantCartTotal: 80,
+		},
+		{
+			name:          "fixed amount off each unit",
+			promotion:     gin.H{"product_id": 1, "discount_amount": 5},
+			wantSubtotal:  90,
+			wantSavings:   10,
+			wantCartTotal: 90,
+		},
+		{
+			name:          "no promotion",
+			wantSubtotal:  100,
+			wantSavings:   0,
+			wantCartTotal: 100,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newTestRedis(t)
+			newProductService(t, map[int]gin.H{1: {"name": "Kettle", "price": 50, "quantity": 10}})
+			promotions := map[int]gin.H{}
+			if tt.promotion != nil {
+				promotions[1] = tt.promotion
+			}
+			newPromotionsService(t, promotionsFixture{promotions: promotions})
+
+			w := serve(t, AddItem, testRequest{
+				method: http.MethodPost,
+				route:  "/items",
+				body:   `{"product_id": 1, "quantity": 2}`,
+			})
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d: %s", w.Code, w.Body)
+			}
+
+			cart := storedCart(t, cartKeyFor(testUserID))
+			item := cart.Items[0]
+			if item.OriginalSubtotal != 100 || item.Subtotal != tt.wantSubtotal {
+				t.Errorf("item subtotals = %v/%v, want 100/%v", item.OriginalSubtotal, item.Subtotal, tt.wantSubtotal)
+			}
+			if cart.ItemSavings != tt.wantSavings {
+				t.Errorf("cart item_savings = %v, want %v", cart.ItemSavings, tt.wantSavings)
+			}
+			if cart.OriginalPrice != 100 || cart.TotalPrice != tt.wantCartTotal {
+				t.Errorf("cart totals = %v/%v, want 100/%v", cart.OriginalPrice, cart.TotalPrice, tt.wantCartTotal)
+			}
+		})
+	}
+}
+
+func TestUpdateItemToZero(t *testing.T) {
+	tests := []struct {
+		name        string
+		zeroRemoves string
+		wantStatus  int
+		wantItems   int
+	}{
+		{name: "removes by default", wantStatus: http.StatusOK, wantItems: 1},
+		{name: "removes when enabled", zeroRemoves: "true", wantStatus: http.StatusOK, wantItems: 1},
+		{name: "rejected when disabled", zeroRemoves: "false", wantStatus: http.StatusBadRequest, wantItems: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newTestRedis(t)
+			if tt.zeroRemoves != "" {
+				t.Setenv("CART_ZERO_QTY_REMOVES", tt.zeroRemoves)
+			}
+			cartKey := cartKeyFor(testUserID)
+			seedCart(t, cartKey, testItem(1, 10, 2), testItem(2, 5, 1))
+
+			w := serve(t, UpdateItem, testRequest{
+				method: http.MethodPut,
+				route:  "/items/:product_id",
+				target: "/items/1",
+				body:   `{"quantity": 0}`,
+			})
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+			if cart := storedCart(t, cartKey); len(cart.Items) != tt.wantItems {
+				t.Errorf("cart has %d items, want %d", len(cart.Items), tt.wantItems)
+			}
+		})
+	}
+}
+
+func TestQuantityStep(t *testing.T) {
+	tests := []struct {
+		name       string
+		handler    gin.HandlerFunc
+		req        testRequest
+		wantStatus int
+	}{
+		{
+			name:       "add a multiple of the step",
+			handler:    AddItem,
+			req:        testRequest{method: http.MethodPost, route: "/items", body: `{"product_id": 1, "quantity": 12}`},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "add off the step",
+			handler:    AddItem,
+			req:        testRequest{method: http.MethodPost, route: "/items", body: `{"product_id": 1, "quantity": 7}`},
+			wantStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:       "update to a multiple of the step",
+			handler:    UpdateItem,
+			req:        testRequest{method: http.MethodPut, route: "/items/:product_id", target: "/items/1", body: `{"quantity": 12}`},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "update off the step",
+			handler:    UpdateItem,
+			req:        testRequest{method: http.MethodPut, route: "/items/:product_id", target: "/items/1", body: `{"quantity": 7}`},
+			wantStatus: http.StatusUnprocessableEntity,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newTestRedis(t)
+			newProductService(t, map[int]gin.H{1: {"name": "Soda", "price": 1.5, "quantity": 100, "quantity_step": 6}})
+			if tt.req.method == http.MethodPut {
+				item := testItem(1, 1.5, 6)
+				item.QuantityStep = 6
+				seedCart(t, cartKeyFor(testUserID), item)
+			}
+
+			w := serve(t, tt.handler, tt.req)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+			if tt.wantStatus == http.StatusUnprocessableEntity {
+				if step := decodeResponse(t, w)["quantity_step"]; step != float64(6) {
+					t.Errorf("quantity_step = %v, want 6", step)
+				}
+			}
+		})
+	}
+}
+
+func TestGetCartExpiresIn(t *testing.T) {
+	tests := []struct {
+		name       string
+		ttl        time.Duration
+		wantExpiry float64
+	}{
+		{name: "cart with a ttl", ttl: 90 * time.Second, wantExpiry: 90},
+		{name: "persistent cart", wantExpiry: -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mr := newTestRedis(t)
+			cartKey := cartKeyFor(testUserID)
+			seedCart(t, cartKey, testItem(1, 10, 1))
+			if tt.ttl > 0 {
+				mr.SetTTL(cartKey, tt.ttl)
+			} else {
+				utils.RedisClient.Persist(utils.Ctx, cartKey)
+			}
+
+			w := serve(t, GetCart, testRequest{route: "/"})
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d: %s", w.Code, w.Body)
+			}
+			if got := decodeResponse(t, w)["expires_in_seconds"]; got != tt.wantExpiry {
+				t.Errorf("expires_in_seconds = %v, want %v", got, tt.wantExpiry)
+			}
+		})
+	}
+}
+
+func TestGetCartSinceVersion(t *testing.T) {
+	tests := []struct {
+		name            string
+		since           string
+		logStart        int
+		wantStatus      int
+		wantFullRefresh bool
+		wantChanged     []float64
+		wantRemoved     []float64
+	}{
+		{
+			name:        "incremental delta",
+			since:       "1",
+			wantStatus:  http.StatusOK,
+			wantChanged: []float64{2},
+			wantRemoved: []float64{3},
+		},
+		{
+			name:        "up to date",
+			since:       "3",
+			wantStatus:  http.StatusOK,
+			wantChanged: []float64{},
+			wantRemoved: []float64{},
+		},
+		{
+			name:            "log truncated past the client's version",
+			since:           "1",
+			logStart:        2,
+			wantStatus:      http.StatusOK,
+			wantFullRefresh: true,
+		},
+		{
+			name:       "invalid version",
+			since:      "abc",
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newTestRedis(t)
+			cartKey := cartKeyFor(testUserID)
+			// Version 1 holds three items; 2 changes product 2; 3 removes product 3
+			seedCart(t, cartKey, testItem(1, 10, 1), testItem(2, 5, 1), testItem(3, 2, 1))
+			cart := storedCart(t, cartKey)
+			cart.Items[1].Quantity = 4
+			storeCart(t, cartKey, cart)
+			cart = storedCart(t, cartKey)
+			cart.Items = cart.Items[:2]
+			cart.LogStartVersion = tt.logStart
+			storeCart(t, cartKey, cart)
+
+			w := serve(t, GetCart, testRequest{route: "/", target: "/?since_version=" + tt.since})
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			body := decodeResponse(t, w)
+			if body["full_refresh"] != tt.wantFullRefresh {
+				t.Fatalf("full_refresh = %v, want %v", body["full_refresh"], tt.wantFullRefresh)
+			}
+			if tt.wantFullRefresh {
+				if _, ok := body["cart"]; !ok {
+					t.Error("full refresh did not include the cart")
+				}
+				return
+			}
+
+			delta := body["delta"].(map[string]interface{})
+			changed := []float64{}
+			for _, item := range delta["changed_items"].([]interface{}) {
+				changed = append(changed, item.(map[string]interface{})["product_id"].(float64))
+			}
+			if fmt.Sprint(changed) != fmt.Sprint(tt.wantChanged) {
+				t.Errorf("changed products = %v, want %v", changed, tt.wantChanged)
+			}
+			if removed := delta["removed_product_ids"]; fmt.Sprint(removed) != fmt.Sprint(tt.wantRemoved) {
+				t.Errorf("removed products = %v, want %v", removed, tt.wantRemoved)
+			}
+		})
+	}
+}
+
+func TestRecalculateCart(t *testing.T) {
+	tests := []struct {
+		name       string
+		seed       bool
+		wantStatus int
+	}{
+		{name: "drifted totals are repaired", seed: true, wantStatus: http.StatusOK},
+		{name: "missing cart", wantStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newTestRedis(t)
+			cartKey := cartKeyFor(testUserID)
+			if tt.seed {
+				// Saved without recalculating, so the stored totals are wrong
+				cart := models.NewCart(testUserID)
+				cart.Items = []models.CartItem{testItem(1, 10, 3), testItem(2, 2.5, 2)}
+				cart.Items[0].Subtotal = 99
+				cart.TotalItems = 1
+				cart.TotalPrice = 12
+				cart.FinalPrice = 12
+				if err := saveCart(utils.Ctx, cartKey, cart); err != nil {
+					t.Fatalf("failed to seed cart: %v", err)
+				}
+			}
+
+			w := serve(t, RecalculateCart, testRequest{method: http.MethodPost, route: "/recalculate"})
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+			if !tt.seed {
+				return
+			}
+
+			previous := decodeResponse(t, w)["previous"].(map[string]interface{})
+			if previous["total_price"] != float64(12) {
+				t.Errorf("previous total_price = %v, want 12", previous["total_price"])
+			}
+			cart := storedCart(t, cartKey)
+			if cart.Items[0].Subtotal != 30 || cart.TotalItems != 5 || cart.TotalPrice != 35 || cart.FinalPrice != 35 {
+				t.Errorf("persisted totals = subtotal %v, items %d, total %v, final %v; want 30, 5, 35, 35",
+					cart.Items[0].Subtotal, cart.TotalItems, cart.TotalPrice, cart.FinalPrice)
+			}
+			if cart.Items[0].Quantity != 3 || cart.Items[1].Quantity != 2 {
+				t.Error("recalculating changed item quantities")
+			}
+		})
+	}
+}
+
+func TestAddItemDefaultQuantity(t *testing.T) {
+	tests := []struct {
+		name            string
+		body            string
+		defaultQuantity string
+		wantStatus      int
+		wantQuantity    int
+	}{
+		{name: "omitted quantity defaults to 1", body: `{"product_id": 1}`, wantStatus: http.StatusOK, wantQuantity: 1},
+		{name: "configured default", body: `{"product_id": 1}`, defaultQuantity: "3", wantStatus: http.StatusOK, wantQuantity: 3},
+		{name: "explicit quantity", body: `{"product_id": 1, "quantity": 2}`, defaultQuantity: "3", wantStatus: http.StatusOK, wantQuantity: 2},
+		{name: "explicit zero rejected", body: `{"product_id": 1, "quantity": 0}`, wantStatus: http.StatusBadRequest},
+		{name: "negative rejected", body: `{"product_id": 1, "quantity": -2}`, wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newTestRedis(t)
+			newProductService(t, map[int]gin.H{1: {"name": "Kettle", "price": 20, "quantity": 10}})
+			if tt.defaultQuantity != "" {
+				t.Setenv("CART_DEFAULT_QUANTITY", tt.defaultQuantity)
+			}
+
+			w := serve(t, AddItem, testRequest{method: http.MethodPost, route: "/items", body: tt.body})
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			if got := storedCart(t, cartKeyFor(testUserID)).Items[0].Quantity; got != tt.wantQuantity {
+				t.Errorf("quantity = %d, want %d", got, tt.wantQuantity)
+			}
+		})
+	}
+}
+
+func TestGetItem(t *testing.T) {
+	tests := []struct {
+		name         string
+		seed         bool
+		target       string
+		wantStatus   int
+		wantQuantity float64
+		wantSubtotal float64
+	}{
+		{name: "product in the cart", seed: true, target: "/items/2", wantStatus: http.StatusOK, wantQuantity: 3, wantSubtotal: 15},
+		{name: "product not in the cart", seed: true, target: "/items/9", wantStatus: http.StatusNotFound},
+		{name: "no cart", target: "/items/2", wantStatus: http.StatusNotFound},
+		{name: "invalid product id", seed: true, target: "/items/abc", wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newTestRedis(t)
+			if tt.seed {
+				seedCart(t, cartKeyFor(testUserID), testItem(1, 10, 1), testItem(2, 5, 3))
+			}
+
+			w := serve(t, GetItem, testRequest{route: "/items/:product_id", target: tt.target})
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			item := decodeResponse(t, w)["item"].(map[string]interface{})
+			if item["quantity"] != tt.wantQuantity || item["subtotal"] != tt.wantSubtotal {
+				t.Errorf("item = qty %v subtotal %v, want %v and %v", item["quantity"], item["subtotal"], tt.wantQuantity, tt.wantSubtotal)
+			}
+		})
+	}
+}
+
+func TestStageItemRecordsAddedBy(t *testing.T) {
+	newTestRedis(t)
+	cart := models.NewCart(testUserID)
+
+	// Members of a shared cart add lines in turn
+	adds := []struct {
+		userID    string
+		productID int
+	}{
+		{userID: "7", productID: 1},
+		{userID: "8", productID: 2},
+		{userID: "8", productID: 1},
+	}
+	for _, add := range adds {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodPost, "/items", nil)
+		c.Set("user_id", add.userID)
+		product := &utils.Product{ID: add.productID, Name: "Product", Price: 10, Quantity: 10}
+		if !stageItem(c, cart, product, 1, false, false) {
+			t.Fatalf("stageItem for user %s failed", add.userID)
+		}
+	}
+
+	want := map[int]string{1: "7", 2: "8"}
+	for _, item := range cart.Items {
+		if item.AddedBy != want[item.ProductID] {
+			t.Errorf("product %d added_by = %q, want %q", item.ProductID, item.AddedBy, want[item.ProductID])
+		}
+	}
+	if got := cart.Items[cart.FindItem(1)].Quantity; got != 2 {
+		t.Errorf("product 1 quantity = %d, want 2", got)
+	}
+}
+
+func TestAddItemRecordsAddedBy(t *testing.T) {
+	tests := []struct {
+		name   string
+		userID string
+	}{
+		{name: "first user", userID: "7"},
+		{name: "second user", userID: "8"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newTestRedis(t)
+			newProductService(t, map[int]gin.H{1: {"name": "Kettle", "price": 20, "quantity": 10}})
+
+			w := serve(t, AddItem, testRequest{method: http.MethodPost, route: "/items", body: `{"product_id": 1}`, userID: tt.userID})
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d: %s", w.Code, w.Body)
+			}
+			items := decodeResponse(t, w)["cart"].(map[string]interface{})["items"].([]interface{})
+			if addedBy := items[0].(map[string]interface{})["added_by"]; addedBy != tt.userID {
+				t.Errorf("added_by = %v, want %s", addedBy, tt.userID)
+			}
+		})
+	}
+}
+
+func TestAddItemOnDuplicate(t *testing.T) {
+	tests := []struct {
+		name         string
+		onDuplicate  string
+		wantStatus   int
+		wantQuantity int
+	}{
+		{name: "merge by default", wantStatus: http.StatusOK, wantQuantity: 5},
+		{name: "merge", onDuplicate: "merge", wantStatus: http.StatusOK, wantQuantity: 5},
+		{name: "error", onDuplicate: "error", wantStatus: http.StatusConflict, wantQuantity: 2},
+		{name: "replace", onDuplicate: "replace", wantStatus: http.StatusOK, wantQuantity: 3},
+		{name: "unknown mode", onDuplicate: "skip", wantStatus: http.StatusBadRequest, wantQuantity: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newTestRedis(t)
+			newProductService(t, map[int]gin.H{1: {"name": "Kettle", "price": 20, "quantity": 10}})
+			cartKey := cartKeyFor(testUserID)
+			seedCart(t, cartKey, testItem(1, 20, 2))
+
+			target := "/items"
+			if tt.onDuplicate != "" {
+				target += "?on_duplicate=" + tt.onDuplicate
+			}
+			w := serve(t, AddItem, testRequest{method: http.MethodPost, route: "/items", target: target, body: `{"product_id": 1, "quantity": 3}`})
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+			if tt.wantStatus == http.StatusConflict {
+				if _, ok := decodeResponse(t, w)["item"]; !ok {
+					t.Error("conflict response does not include the existing item")
+				}
+			}
+			if got := storedCart(t, cartKey).Items[0].Quantity; got != tt.wantQuantity {
+				t.Errorf("quantity = %d, want %d", got, tt.wantQuantity)
+			}
+		})
+	}
+}
+
+func TestAddItemQuantityLimitedPromotion(t *testing.T) {
+	tests := []struct {
+		name         string
+		quantity     int
+		wantPromo    int
+		wantRegular  int
+		wantSubtotal float64
+	}{
+		{name: "below the limit", quantity: 1, wantPromo: 1, wantRegular: 0, wantSubtotal: 8},
+		{name: "at the limit", quantity: 2, wantPromo: 2, wantRegular: 0, wantSubtotal: 16},
+		{name: "above the limit", quantity: 5, wantPromo: 2, wantRegular: 3, wantSubtotal: 46},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newTestRedis(t)
+			newProductService(t, map[int]gin.H{1: {"name": "Kettle", "price": 10, "quantity": 10}})
+			newPromotionsService(t, promotionsFixture{promotions: map[int]gin.H{
+				1: {"product_id": 1, "promo_price": 8, "promo_limit": 2},
+			}})
+
+			w := serve(t, AddItem, testRequest{
+				method: http.MethodPost,
+				route:  "/items",
+				body:   fmt.Sprintf(`{"product_id": 1, "quantity": %d}`, tt.quantity),
+			})
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d: %s", w.Code, w.Body)
+			}
+
+			item := storedCart(t, cartKeyFor(testUserID)).Items[0]
+			if item.PromoUnits != tt.wantPromo || item.RegularUnits != tt.wantRegular {
+				t.Errorf("promo/regular units = %d/%d, want %d/%d", item.PromoUnits, item.RegularUnits, tt.wantPromo, tt.wantRegular)
+			}
+			if item.Subtotal != tt.wantSubtotal || item.OriginalSubtotal != float64(tt.quantity*10) {
+				t.Errorf("subtotal = %v (original %v), want %v", item.Subtotal, item.OriginalSubtotal, tt.wantSubtotal)
+			}
+		})
+	}
+}
+
+func TestAddItemCapturesAttribution(t *testing.T) {
+	newTestRedis(t)
+	newProductService(t, map[int]gin.H{
+		1: {"name": "Kettle", "price": 20, "quantity": 10},
+		2: {"name": "Mug", "price": 5, "quantity": 10},
+	})
+	cartKey := cartKeyFor(testUserID)
+
+	adds := []struct {
+		name         string
+		req          testRequest
+		wantSource   string
+		wantCampaign string
+	}{
+		{
+			name:         "first add records query attribution",
+			req:          testRequest{target: "/items?utm_source=newsletter&utm_campaign=spring", body: `{"product_id": 1}`},
+			wantSource:   "newsletter",
+			wantCampaign: "spring",
+		},
+		{
+			name:         "later add does not overwrite it",
+			req:          testRequest{body: `{"product_id": 2}`, headers: map[string]string{"X-UTM-Source": "ads", "X-UTM-Campaign": "summer"}},
+			wantSource:   "newsletter",
+			wantCampaign: "spring",
+		},
+	}
+
+	for _, add := range adds {
+		add.req.method, add.req.route = http.MethodPost, "/items"
+		if w := serve(t, AddItem, add.req); w.Code != http.StatusOK {
+			t.Fatalf("%s: status = %d: %s", add.name, w.Code, w.Body)
+		}
+		attribution := storedCart(t, cartKey).Attribution
+		if attribution == nil || attribution.Source != add.wantSource || attribution.Campaign != add.wantCampaign {
+			t.Errorf("%s: attribution = %+v, want %s/%s", add.name, attribution, add.wantSource, add.wantCampaign)
+		}
+	}
+}
+
+func TestAddItemAttributionFromHeaders(t *testing.T) {
+	tests := []struct {
+		name       string
+		headers    map[string]string
+		wantSource string
+	}{
+		{name: "headers", headers: map[string]string{"X-UTM-Source": "ads", "X-UTM-Medium": "cpc"}, wantSource: "ads"},
+		{name: "none"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newTestRedis(t)
+			newProductService(t, map[int]gin.H{1: {"name": "Kettle", "price": 20, "quantity": 10}})
+
+			serve(t, AddItem, testRequest{method: http.MethodPost, route: "/items", body: `{"product_id": 1}`, headers: tt.headers})
+			attribution := storedCart(t, cartKeyFor(testUserID)).Attribution
+			if tt.wantSource == "" {
+				if attribution != nil {
+					t.Errorf("attribution = %+v, want none", attribution)
+				}
+				return
+			}
+			if attribution == nil || attribution.Source != tt.wantSource || attribution.CapturedAt == "" {
+				t.Errorf("attribution = %+v, want source %s with a capture time", attribution, tt.wantSource)
+			}
+		})
+	}
+}
+
+func TestMaxPerOrder(t *testing.T) {
+	tests := []struct {
+		name       string
+		handler    gin.HandlerFunc
+		req        testRequest
+		inCart     int
+		wantStatus int
+		want       map[int]int
+	}{
+		{
+			name:       "add up to the limit",
+			handler:    AddItem,
+			req:        testRequest{method: http.MethodPost, route: "/items", body: `{"product_id": 1, "quantity": 2}`},
+			wantStatus: http.StatusOK,
+			want:       map[int]int{1: 2},
+		},
+		{
+			name:       "add over the limit",
+			handler:    AddItem,
+			req:        testRequest{method: http.MethodPost, route: "/items", body: `{"product_id": 1, "quantity": 3}`},
+			wantStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:       "add past the limit with units already in the cart",
+			handler:    AddItem,
+			req:        testRequest{method: http.MethodPost, route: "/items", body: `{"product_id": 1, "quantity": 1}`},
+			inCart:     2,
+			wantStatus: http.StatusUnprocessableEntity,
+			want:       map[int]int{1: 2},
+		},
+		{
+			name:       "update over the limit",
+			handler:    UpdateItem,
+			req:        testRequest{method: http.MethodPut, route: "/items/:product_id", target: "/items/1", body: `{"quantity": 5}`},
+			inCart:     1,
+			wantStatus: http.StatusUnprocessableEntity,
+			want:       map[int]int{1: 1},
+		},
+		{
+			name:       "update within the limit",
+			handler:    UpdateItem,
+			req:        testRequest{method: http.MethodPut, route: "/items/:product_id", target: "/items/1", body: `{"quantity": 2}`},
+			inCart:     1,
+			wantStatus: http.StatusOK,
+			want:       map[int]int{1: 2},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newTestRedis(t)
+			// Plenty of stock, but only two per order
+			newProductService(t, map[int]gin.H{1: {"name": "Print", "price": 80, "quantity": 500, "max_per_order": 2}})
+			cartKey := cartKeyFor(testUserID)
+			if tt.inCart > 0 {
+				item := testItem(1, 80, tt.inCart)
+				item.MaxPerOrder = 2
+				seedCart(t, cartKey, item)
+			}
+
+			w := serve(t, tt.handler, tt.req)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+			if tt.wantStatus == http.StatusUnprocessableEntity {
+				if limit := decodeResponse(t, w)["max_per_order"]; limit != float64(2) {
+					t.Errorf("max_per_order = %v, want 2", limit)
+				}
+			}
+			if tt.want != nil {
+				assertQuantities(t, "stored", storedCart(t, cartKey), tt.want)
+			}
+		})
+	}
+}
+
+func TestGetCartMeta(t *testing.T) {
+	newTestRedis(t)
+	newProductService(t, map[int]gin.H{
+		1: {"name": "Kettle", "price": 20, "quantity": 10},
+		2: {"name": "Mug", "price": 5, "quantity": 10},
+	})
+
+	if w := serve(t, GetCartMeta, testRequest{route: "/meta"}); w.Code != http.StatusNotFound {
+		t.Fatalf("status before any change = %d, want %d", w.Code, http.StatusNotFound)
+	}
+
+	steps := []struct {
+		name           string
+		handler        gin.HandlerFunc
+		req            testRequest
+		wantItemCount  float64
+		wantTotalItems float64
+	}{
+		{
+			name:           "add kettle",
+			handler:        AddItem,
+			req:            testRequest{method: http.MethodPost, route: "/items", body: `{"product_id": 1, "quantity": 2}`},
+			wantItemCount:  1,
+			wantTotalItems: 2,
+		},
+		{
+			name:           "add mug",
+			handler:        AddItem,
+			req:            testRequest{method: http.MethodPost, route: "/items", body: `{"product_id": 2, "quantity": 3}`},
+			wantItemCount:  2,
+			wantTotalItems: 5,
+		},
+		{
+			name:           "update kettle",
+			handler:        UpdateItem,
+			req:            testRequest{method: http.MethodPut, route: "/items/:product_id", target: "/items/1", body: `{"quantity": 1}`},
+			wantItemCount:  2,
+			wantTotalItems: 4,
+		},
+		{
+			name:           "remove mug",
+			handler:        RemoveItem,
+			req:            testRequest{method: http.MethodDelete, route: "/items/:product_id", target: "/items/2"},
+			wantItemCount:  1,
+			wantTotalItems: 1,
+		},
+	}
+
+	var createdAt interface{}
+	lastVersion := 0.0
+	for _, step := range steps {
+		if w := serve(t, step.handler, step.req); w.Code != http.StatusOK {
+			t.Fatalf("%s: status = %d: %s", step.name, w.Code, w.Body)
+		}
+
+		w := serve(t, GetCartMeta, testRequest{route: "/meta"})
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: meta status = %d: %s", step.name, w.Code, w.Body)
+		}
+		meta := decodeResponse(t, w)["meta"].(map[string]interface{})
+		cart := storedCart(t, cartKeyFor(testUserID))
+
+		if meta["version"] != float64(cart.Version) || meta["version"].(float64) != lastVersion+1 {
+			t.Errorf("%s: version = %v, want %d after %v", step.name, meta["version"], cart.Version, lastVersion)
+		}
+		if meta["item_count"] != step.wantItemCount || meta["total_items"] != step.wantTotalItems {
+			t.Errorf("%s: meta = %v, want %v items totalling %v", step.name, meta, step.wantItemCount, step.wantTotalItems)
+		}
+		if meta["updated_by"] != testUserID || meta["updated_at"] != cart.UpdatedAt {
+			t.Errorf("%s: meta = %v, want updated by %s at %s", step.name, meta, testUserID, cart.UpdatedAt)
+		}
+		if createdAt == nil {
+			createdAt = meta["created_at"]
+		} else if meta["created_at"] != createdAt {
+			t.Errorf("%s: created_at changed from %v to %v", step.name, createdAt, meta["created_at"])
+		}
+		lastVersion = meta["version"].(float64)
+	}
+}
+
+func TestGetCartProductDetails(t *testing.T) {
+	tests := []struct {
+		name       string
+		query      string
+		wantFields []string
+	}{
+		{name: "full items", wantFields: []string{"product_id", "product_name", "price", "quantity", "image_url", "sku", "category"}},
+		{name: "projected items", query: "?fields=image_url,price", wantFields: []string{"product_id", "image_url", "price"}},
+		{name: "unknown fields ignored", query: "?fields=sku,colour", wantFields: []string{"product_id", "sku"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newTestRedis(t)
+			newProductService(t, map[int]gin.H{1: {
+				"name": "Kettle", "price": 20, "quantity": 10,
+				"image_url": "https://img.example.com/kettle.jpg", "sku": "KT-1", "category": "kitchen",
+			}})
+			if w := serve(t, AddItem, testRequest{method: http.MethodPost, route: "/items", body: `{"product_id": 1}`}); w.Code != http.StatusOK {
+				t.Fatalf("add status = %d: %s", w.Code, w.Body)
+			}
+
+			w := serve(t, GetCart, testRequest{route: "/", target: "/" + tt.query})
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d: %s", w.Code, w.Body)
+			}
+			cart := decodeResponse(t, w)["cart"].(map[string]interface{})
+			if cart["total_price"] != float64(20) {
+				t.Errorf("cart totals were trimmed: %v", cart)
+			}
+			item := cart["items"].([]interface{})[0].(map[string]interface{})
+			for _, field := range tt.wantFields {
+				if _, ok := item[field]; !ok {
+					t.Errorf("item is missing %s: %v", field, item)
+				}
+			}
+			if tt.query != "" && len(item) != len(tt.wantFields) {
+				t.Errorf("item = %v, want only %v", item, tt.wantFields)
+			}
+			if tt.query == "" && (item["image_url"] != "https://img.example.com/kettle.jpg" || item["sku"] != "KT-1" || item["category"] != "kitchen") {
+				t.Errorf("item details = %v", item)
+			}
+		})
+	}
+}
+
+func TestGetCartItemOrder(t *testing.T) {
+	newTestRedis(t)
+	newProductService(t, map[int]gin.H{9: {"name": "Mug", "price": 5, "quantity": 10}})
+	cartKey := cartKeyFor(testUserID)
+
+	// Stored out of order, as in-place mutations can leave them
+	start := time.Now().Add(-time.Hour)
+	addedAt := func(productID int, offset time.Duration) models.CartItem {
+		item := testItem(productID, 5, 1)
+		item.AddedAt = start.Add(offset).Format(time.RFC3339)
+		return item
+	}
+	legacy := testItem(7, 5, 1)
+	legacy.AddedAt = ""
+	seedCart(t, cartKey, addedAt(5, 2*time.Minute), addedAt(9, 0), legacy, addedAt(3, time.Minute), addedAt(1, time.Minute))
+
+	readOrder := func() []int {
+		w := serve(t, GetCart, testRequest{route: "/"})
+		if w.Code != http.StatusOK {
+			t.Fatalf("status = %d: %s", w.Code, w.Body)
+		}
+		var order []int
+		for _, item := range decodeResponse(t, w)["cart"].(map[string]interface{})["items"].([]interface{}) {
+			order = append(order, int(item.(map[string]interface{})["product_id"].(float64)))
+		}
+		return order
+	}
+
+	steps := []struct {
+		name    string
+		handler gin.HandlerFunc
+		req     testRequest
+		want    []int
+	}{
+		{name: "initial read", want: []int{7, 9, 1, 3, 5}},
+		{
+			name:    "remove",
+			handler: RemoveItem,
+			req:     testRequest{method: http.MethodDelete, route: "/items/:product_id", target: "/items/9"},
+			want:    []int{7, 1, 3, 5},
+		},
+		{
+			name:    "re-add goes last",
+			handler: AddItem,
+			req:     testRequest{method: http.MethodPost, route: "/items", body: `{"product_id": 9}`},
+			want:    []int{7, 1, 3, 5, 9},
+		},
+	}
+
+	for _, step := range steps {
+		if step.handler != nil {
+			if w := serve(t, step.handler, step.req); w.Code != http.StatusOK {
+				t.Fatalf("%s: status = %d: %s", step.name, w.Code, w.Body)
+			}
+		}
+		for read := 0; read < 2; read++ {
+			if got := readOrder(); fmt.Sprint(got) != fmt.Sprint(step.want) {
+				t.Errorf("%s: read %d order = %v, want %v", step.name, read, got, step.want)
+			}
+		}
+	}
+}
+
+func TestGetCartByCategory(t *testing.T) {
+	tests := []struct {
+		name      string
+		category  string
+		wantItems string
+	}{
+		{name: "matching category", category: "electronics", wantItems: "[1 3]"},
+		{name: "case-insensitive", category: "Kitchen", wantItems: "[2]"},
+		{name: "no matches", category: "garden", wantItems: "[]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newTestRedis(t)
+			radio, kettle, cable := testItem(1, 40, 1), testItem(2, 20, 2), testItem(3, 5, 3)
+			radio.Category, kettle.Category, cable.Category = "electronics", "kitchen", "electronics"
+			seedCart(t, cartKeyFor(testUserID), radio, kettle, cable)
+
+			w := serve(t, GetCart, testRequest{route: "/", target: "/?category=" + tt.category})
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d: %s", w.Code, w.Body)
+			}
+			cart := decodeResponse(t, w)["cart"].(map[string]interface{})
+			var items []int
+			for _, item := range cart["items"].([]interface{}) {
+				items = append(items, int(item.(map[string]interface{})["product_id"].(float64)))
+			}
+			if fmt.Sprint(items) != tt.wantItems {
+				t.Errorf("items = %v, want %s", items, tt.wantItems)
+			}
+			// Totals stay those of the whole cart
+			if cart["total_items"] != float64(6) || cart["total_price"] != float64(95) {
+				t.Errorf("totals = %v items at %v, want 6 at 95", cart["total_items"], cart["total_price"])
+			}
+		})
+	}
+}
+
+func TestMissingCartAndItemCodes(t *testing.T) {
+	handlers := []struct {
+		name    string
+		handler gin.HandlerFunc
+		method  string
+		route   string
+		body    string
+	}{
+		{name: "get item", handler: GetItem, method: http.MethodGet, route: "/items/:product_id"},
+		{name: "update item", handler: UpdateItem, method: http.MethodPut, route: "/items/:product_id", body: `{"quantity": 2}`},
+		{name: "remove item", handler: RemoveItem, method: http.MethodDelete, route: "/items/:product_id"},
+		{name: "adjust item", handler: AdjustItem, method: http.MethodPost, route: "/items/:product_id/adjust", body: `{"delta": 1}`},
+	}
+	carts := []struct {
+		name     string
+		seed     bool
+		wantCode string
+	}{
+		{name: "no cart", seed: false, wantCode: codeCartEmpty},
+		{name: "item not in cart", seed: true, wantCode: codeItemNotFound},
+	}
+
+	for _, h := range handlers {
+		for _, tc := range carts {
+			t.Run(h.name+"/"+tc.name, func(t *testing.T) {
+				newTestRedis(t)
+				if tc.seed {
+					seedCart(t, cartKeyFor(testUserID), testItem(1, 10, 1))
+				}
+
+				w := serve(t, h.handler, testRequest{
+					method: h.method,
+					route:  h.route,
+					target: strings.Replace(h.route, ":product_id", "9", 1),
+					body:   h.body,
+				})
+				if w.Code != http.StatusNotFound {
+					t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusNotFound, w.Body)
+				}
+				if code := decodeResponse(t, w)["code"]; code != tc.wantCode {
+					t.Errorf("code = %v, want %s", code, tc.wantCode)
+				}
+			})
+		}
+	}
+}
+
+func TestAddZeroPricedItem(t *testing.T) {
+	tests := []struct {
+		name       string
+		product    gin.H
+		allowZero  string
+		wantStatus int
+	}{
+		{name: "priced product", product: gin.H{"name": "Kettle", "price": 20, "quantity": 10}, wantStatus: http.StatusOK},
+		{name: "zero price refused by default", product: gin.H{"name": "Kettle", "price": 0, "quantity": 10}, wantStatus: http.StatusUnprocessableEntity},
+		{name: "zero price allowed by policy", product: gin.H{"name": "Kettle", "price": 0, "quantity": 10}, allowZero: "true", wantStatus: http.StatusOK},
+		{name: "freebie added by default", product: gin.H{"name": "Sticker", "price": 0, "quantity": 10, "freebie": true}, wantStatus: http.StatusOK},
+		{name: "zero price sent as a string", product: gin.H{"name": "Kettle", "price": "0.00", "quantity": 10, "freebie": false}, allowZero: "false", wantStatus: http.StatusUnprocessableEntity},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newTestRedis(t)
+			newProductService(t, map[int]gin.H{1: tt.product})
+			newPromotionsService(t, promotionsFixture{})
+			t.Setenv("ALLOW_ZERO_PRICE", tt.allowZero)
+
+			w := serve(t, AddItem, testRequest{method: http.MethodPost, route: "/items", body: `{"product_id": 1, "quantity": 1}`})
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+			exists := utils.RedisClient.Exists(utils.Ctx, cartKeyFor(testUserID)).Val() == 1
+			if exists != (tt.wantStatus == http.StatusOK) {
+				t.Errorf("cart saved = %v with status %d", exists, w.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,173 @@
+package handlers
+
+import (
+	"cart-service/models"
+	"cart-service/utils"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetCheckoutTotal returns the authoritative breakdown of what the user
+// will pay at checkout
+func GetCheckoutTotal(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	cartKey, name, ok := requestCart(c, userID)
+	if !ok {
+		return
+	}
+
+	cart, err := loadCart(c.Request.Context(), cartKey)
+	if err == errCartCorrupt {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse cart data"})
+		return
+	}
+	if err != nil {
+		cart = newCart(userID, name)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"checkout": cart.CheckoutTotal(shippingPolicy()),
+	})
+}
+
+// SetShippingAddress stores the shipping address on the cart
+func SetShippingAddress(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var address models.Address
+	if err := c.ShouldBindJSON(&address); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	cartKey, name, ok := requestCart(c, userID)
+	if !ok {
+		return
+	}
+	lock, ok := lockCart(c, cartKey)
+	if !ok {
+		return
+	}
+	defer lock.Release(c.Request.Context())
+	if !ensureNotFrozen(c, cartKey) {
+		return
+	}
+
+	cart, err := loadCart(c.Request.Context(), cartKey)
+	if err == errCartCorrupt {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse cart data"})
+		return
+	}
+	if err != nil {
+		cart = newCart(userID, name)
+	}
+
+	cart.ShippingAddress = &address
+	cart.CalculateTotals()
+
+	if err := saveCart(c.Request.Context(), cartKey, cart); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save cart"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Shipping address saved",
+		"cart":    cart,
+	})
+}
+
+// GetOrderPayload returns the cart reshaped into order-service's schema,
+// rejecting carts that are not ready to be ordered, including marketplace
+// carts where a vendor's share is below its minimum order value
+func GetOrderPayload(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	cartKey, name, ok := requestCart(c, userID)
+	if !ok {
+		return
+	}
+
+	cart, err := loadCart(c.Request.Context(), cartKey)
+	if err == errCartCorrupt {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse cart data"})
+		return
+	}
+	if err != nil {
+		cart = newCart(userID, name)
+	}
+
+	payload, err := cart.OrderPayload(cart.CheckoutTotal(shippingPolicy()))
+	if err != nil {
+		var incomplete *models.IncompleteCartError
+		if errors.As(err, &incomplete) {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{
+				"error":   "Cart is not ready for checkout",
+				"missing": incomplete.Missing,
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build order payload"})
+		return
+	}
+
+	// In marketplace mode each vendor's share must meet its own minimum.
+	// An unreachable vendor service doesn't block checkout.
+	minimums, err := utils.FetchVendorMinimums(c.Request.Context(), cart.VendorIDs())
+	if err != nil {
+		log.Printf("Failed to fetch vendor minimums: %v", err)
+	}
+	if below := cart.VendorsBelowMinimum(minimums); len(below) > 0 {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error":                 "Cart is not ready for checkout",
+			"vendors_below_minimum": below,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"order": payload})
+}
+
+// GetPaymentSplit previews how the cart's gift cards and store credit
+// cover the checkout total and what remains due on the primary payment
+// method
+func GetPaymentSplit(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	cartKey, name, ok := requestCart(c, userID)
+	if !ok {
+		return
+	}
+
+	cart, err := loadCart(c.Request.Context(), cartKey)
+	if err == errCartCorrupt {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse cart data"})
+		return
+	}
+	if err != nil {
+		cart = newCart(userID, name)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"payment_split": cart.PaymentSplit(shippingPolicy()),
+	})
+}
@@ -0,0 +1,360 @@
+package handlers
+
+import (
+	"cart-service/models"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestGetCheckoutTotal(t *testing.T) {
+	if err := models.SetTaxRates(0.1, ""); err != nil {
+		t.Fatalf("SetTaxRates: %v", err)
+	}
+	t.Cleanup(func() { models.SetTaxRates(0, "") })
+
+	promoted := testItem(1, 25, 2)
+	promoted.DiscountPercent = 20
+
+	tests := []struct {
+		name    string
+		items   []models.CartItem
+		coupon  *models.Coupon
+		wrap    *models.GiftWrap
+		credits []models.Credit
+		want    map[string]float64
+	}{
+		{
+			name:    "every component",
+			items:   []models.CartItem{promoted},
+			coupon:  &models.Coupon{Code: "FIVE", Type: models.CouponTypeFixed, Value: 5},
+			wrap:    &models.GiftWrap{Fee: 4},
+			credits: []models.Credit{{Type: "gift_card", Code: "GC1", Amount: 10}},
+			want: map[string]float64{
+				"subtotal":        50,
+				"item_discounts":  10,
+				"coupon_discount": 5,
+				"tax":             3.5,
+				"shipping":        5.99,
+				"gift_wrap":       4,
+				"credit_applied":  10,
+				"unused_credit":   0,
+				"amount_due":      38.49,
+			},
+		},
+		{
+			name:    "credit larger than the order",
+			items:   []models.CartItem{testItem(2, 60, 1)},
+			credits: []models.Credit{{Type: "store_credit", Amount: 100}},
+			want: map[string]float64{
+				"subtotal":       60,
+				"tax":            6,
+				"shipping":       0,
+				"credit_applied": 66,
+				"unused_credit":  34,
+				"amount_due":     0,
+			},
+		},
+		{
+			name: "empty cart",
+			want: map[string]float64{"subtotal": 0, "tax": 0, "amount_due": 0},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newTestRedis(t)
+			if tt.items != nil {
+				cart := models.NewCart(testUserID)
+				cart.Items = tt.items
+				cart.Coupon = tt.coupon
+				cart.GiftWrap = tt.wrap
+				cart.Credits = tt.credits
+				storeCart(t, cartKeyFor(testUserID), cart)
+			}
+
+			w := serve(t, GetCheckoutTotal, testRequest{route: "/checkout-total"})
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d: %s", w.Code, w.Body)
+			}
+			checkout := decodeResponse(t, w)["checkout"].(map[string]interface{})
+			for field, want := range tt.want {
+				if checkout[field] != want {
+					t.Errorf("%s = %v, want %v", field, checkout[field], want)
+				}
+			}
+
+			// The amount due is the discounted total plus every charge, less credit
+			field := func(name string) float64 { return checkout[name].(float64) }
+			due := field("subtotal") - field("item_discounts") - field("bundle_discounts") - field("coupon_discount") -
+				field("member_discount") - field("loyalty_discount") - field("points_discount") +
+				field("tax") + field("shipping") + field("gift_wrap") + field("handling") + field("donation") -
+				field("credit_applied")
+			if math.Abs(due-field("amount_due")) > 0.005 {
+				t.Errorf("components add up to %.2f, amount_due is %v", due, checkout["amount_due"])
+			}
+		})
+	}
+}
+
+// testAddress returns a complete shipping address
+func testAddress() *models.Address {
+	return &models.Address{Name: "Ada", Line1: "1 Main St", City: "Springfield", PostalCode: "12345", Country: "US"}
+}
+
+func TestGetOrderPayload(t *testing.T) {
+	promoted := testItem(1, 25, 2)
+	promoted.DiscountPercent = 20
+
+	tests := []struct {
+		name        string
+		items       []models.CartItem
+		address     *models.Address
+		coupon      *models.Coupon
+		wantStatus  int
+		wantMissing []interface{}
+	}{
+		{
+			name:       "complete cart",
+			items:      []models.CartItem{promoted, testItem(2, 10, 1)},
+			address:    testAddress(),
+			coupon:     &models.Coupon{Code: "FIVE", Type: models.CouponTypeFixed, Value: 5},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:        "no shipping address",
+			items:       []models.CartItem{testItem(2, 10, 1)},
+			wantStatus:  http.StatusUnprocessableEntity,
+			wantMissing: []interface{}{"shipping_address"},
+		},
+		{
+			name:        "empty cart",
+			wantStatus:  http.StatusUnprocessableEntity,
+			wantMissing: []interface{}{"items", "shipping_address"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newTestRedis(t)
+			if tt.items != nil {
+				cart := models.NewCart(testUserID)
+				cart.Items = tt.items
+				cart.ShippingAddress = tt.address
+				cart.Coupon = tt.coupon
+				storeCart(t, cartKeyFor(testUserID), cart)
+			}
+
+			w := serve(t, GetOrderPayload, testRequest{route: "/order-payload"})
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+			body := decodeResponse(t, w)
+			if tt.wantStatus != http.StatusOK {
+				if fmt.Sprint(body["missing"]) != fmt.Sprint(tt.wantMissing) {
+					t.Errorf("missing = %v, want %v", body["missing"], tt.wantMissing)
+				}
+				return
+			}
+
+			order := body["order"].(map[string]interface{})
+			for _, field := range []string{"user_id", "currency", "line_items", "discounts", "shipping_address", "totals"} {
+				if _, ok := order[field]; !ok {
+					t.Errorf("order payload is missing %s", field)
+				}
+			}
+			lines := order["line_items"].([]interface{})
+			if len(lines) != 2 {
+				t.Fatalf("line_items has %d lines, want 2", len(lines))
+			}
+			first := lines[0].(map[string]interface{})
+			if first["unit_price"] != float64(25) || first["discount"] != float64(10) || first["total"] != float64(40) {
+				t.Errorf("first line = %v, want unit_price 25, discount 10, total 40", first)
+			}
+			discounts := order["discounts"].([]interface{})
+			if len(discounts) != 1 || discounts[0].(map[string]interface{})["code"] != "FIVE" {
+				t.Errorf("discounts = %v, want the FIVE coupon", discounts)
+			}
+			totals := order["totals"].(map[string]interface{})
+			if totals["subtotal"] != float64(60) || totals["discount"] != float64(15) {
+				t.Errorf("totals = %v, want subtotal 60 and discount 15", totals)
+			}
+		})
+	}
+}
+
+func TestPerItemTax(t *testing.T) {
+	if err := models.SetTaxRates(0.1, `{"US-CA:food": 0, "US-CA:*": 0.075, "US:*": 0.04}`); err != nil {
+		t.Fatalf("SetTaxRates: %v", err)
+	}
+	t.Cleanup(func() { models.SetTaxRates(0, "") })
+
+	tests := []struct {
+		name         string
+		state        string
+		country      string
+		wantFoodTax  float64
+		wantRadioTax float64
+		wantTax      float64
+	}{
+		{name: "zero-rated food", country: "US", state: "CA", wantFoodTax: 0, wantRadioTax: 3, wantTax: 3},
+		{name: "country rate", country: "US", state: "NY", wantFoodTax: 0.8, wantRadioTax: 1.6, wantTax: 2.4},
+		{name: "default rate", country: "FR", wantFoodTax: 2, wantRadioTax: 4, wantTax: 6},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newTestRedis(t)
+			newProductService(t, map[int]gin.H{
+				1: {"name": "Bread", "price": 20, "quantity": 10, "tax_category": "food"},
+				2: {"name": "Radio", "price": 40, "quantity": 10, "tax_category": "electronics"},
+			})
+			for _, body := range []string{`{"product_id": 1}`, `{"product_id": 2}`} {
+				if w := serve(t, AddItem, testRequest{method: http.MethodPost, route: "/items", body: body}); w.Code != http.StatusOK {
+					t.Fatalf("add status = %d: %s", w.Code, w.Body)
+				}
+			}
+			address := testAddress()
+			address.Country, address.State = tt.country, tt.state
+			data, _ := json.Marshal(address)
+			if w := serve(t, SetShippingAddress, testRequest{method: http.MethodPut, route: "/shipping-address", body: string(data)}); w.Code != http.StatusOK {
+				t.Fatalf("address status = %d: %s", w.Code, w.Body)
+			}
+
+			w := serve(t, GetCart, testRequest{route: "/"})
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d: %s", w.Code, w.Body)
+			}
+			cart := decodeResponse(t, w)["cart"].(map[string]interface{})
+			taxes := map[float64]interface{}{}
+			for _, item := range cart["items"].([]interface{}) {
+				fields := item.(map[string]interface{})
+				taxes[fields["product_id"].(float64)] = fields["tax"]
+			}
+			if taxes[1] != tt.wantFoodTax || taxes[2] != tt.wantRadioTax {
+				t.Errorf("item taxes = %v, want food %v and radio %v", taxes, tt.wantFoodTax, tt.wantRadioTax)
+			}
+			if cart["tax"] != tt.wantTax {
+				t.Errorf("cart tax = %v, want %v", cart["tax"], tt.wantTax)
+			}
+		})
+	}
+}
+
+func TestGetPaymentSplit(t *testing.T) {
+	t.Setenv("SHIPPING_FLAT_RATE", "5")
+	t.Setenv("FREE_SHIPPING_THRESHOLD", "50")
+
+	tests := []struct {
+		name        string
+		items       []models.CartItem
+		credits     []models.Credit
+		wantTotal   float64
+		wantCredits []string
+		wantUnused  float64
+		wantDue     float64
+	}{
+		{
+			name:        "gift card covers part",
+			items:       []models.CartItem{testItem(1, 30, 1)},
+			credits:     []models.Credit{{Type: "gift_card", Code: "GC1", Amount: 20}},
+			wantTotal:   35,
+			wantCredits: []string{"gift_card GC1: 20 of 20, 0 left"},
+			wantDue:     15,
+		},
+		{
+			name:        "gift card covers everything",
+			items:       []models.CartItem{testItem(1, 30, 1)},
+			credits:     []models.Credit{{Type: "gift_card", Code: "GC1", Amount: 50}},
+			wantTotal:   35,
+			wantCredits: []string{"gift_card GC1: 35 of 50, 15 left"},
+			wantUnused:  15,
+		},
+		{
+			name:  "credits spent in order",
+			items: []models.CartItem{testItem(1, 30, 1)},
+			credits: []models.Credit{
+				{Type: "gift_card", Code: "GC1", Amount: 20},
+				{Type: "store_credit", Amount: 30},
+				{Type: "gift_card", Code: "GC2", Amount: 10},
+			},
+			wantTotal: 35,
+			wantCredits: []string{
+				"gift_card GC1: 20 of 20, 0 left",
+				"store_credit : 15 of 30, 15 left",
+				"gift_card GC2: 0 of 10, 10 left",
+			},
+			wantUnused: 25,
+		},
+		{
+			name:  "negative balance pays nothing",
+			items: []models.CartItem{testItem(1, 30, 1)},
+			credits: []models.Credit{
+				{Type: "gift_card", Code: "GC1", Amount: -5},
+				{Type: "gift_card", Code: "GC2", Amount: 10},
+			},
+			wantTotal: 35,
+			wantCredits: []string{
+				"gift_card GC1: 0 of 0, 0 left",
+				"gift_card GC2: 10 of 10, 0 left",
+			},
+			wantDue: 25,
+		},
+		{
+			name:      "no credits",
+			items:     []models.CartItem{testItem(1, 60, 1)},
+			wantTotal: 60,
+			wantDue:   60,
+		},
+		{
+			name: "no cart",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newTestRedis(t)
+			if tt.items != nil {
+				cart := models.NewCart(testUserID)
+				cart.Items = tt.items
+				cart.Credits = tt.credits
+				storeCart(t, cartKeyFor(testUserID), cart)
+			}
+
+			w := serve(t, GetPaymentSplit, testRequest{route: "/payment-split"})
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d: %s", w.Code, w.Body)
+			}
+			var body struct {
+				PaymentSplit models.PaymentSplit `json:"payment_split"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatalf("response is not JSON: %v", err)
+			}
+			split := body.PaymentSplit
+
+			credits := []string{}
+			applied := 0.0
+			for _, credit := range split.Credits {
+				credits = append(credits, fmt.Sprintf("%s %s: %v of %v, %v left", credit.Type, credit.Code, credit.Applied, credit.Offered, credit.Remaining))
+				applied += credit.Applied
+			}
+			if fmt.Sprint(credits) != fmt.Sprint(tt.wantCredits) {
+				t.Errorf("credits = %q, want %q", credits, tt.wantCredits)
+			}
+			if split.Total != tt.wantTotal || split.UnusedCredit != tt.wantUnused || split.PrimaryDue != tt.wantDue {
+				t.Errorf("total %v, unused %v, due %v; want %v, %v, %v",
+					split.Total, split.UnusedCredit, split.PrimaryDue, tt.wantTotal, tt.wantUnused, tt.wantDue)
+			}
+
+			// Credits and the primary method pay the total between them
+			if math.Abs(applied-split.CreditApplied) > 0.005 || math.Abs(split.CreditApplied+split.PrimaryDue-split.Total) > 0.005 {
+				t.Errorf("credits pay %v (%v reported) and primary %v of %v", applied, split.CreditApplied, split.PrimaryDue, split.Total)
+			}
+		})
+	}
+}
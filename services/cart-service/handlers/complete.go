@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"cart-service/models"
+	"cart-service/utils"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+)
+
+// eventCartConverted is published when a cart becomes an order
+const eventCartConverted = "cart.converted"
+
+// historyKeyFor builds the Redis key listing a user's converted carts
+func historyKeyFor(userID interface{}) string {
+	return utils.Key("history", utils.UserKey(fmt.Sprintf("%v", userID)))
+}
+
+// CompleteCheckout finishes checkout for the cart: it is snapshotted to the
+// user's order history and deleted in one transaction while the cart lock
+// is held, so no concurrent add can land between snapshot and delete.
+// Redeemed loyalty points are debited for the order first. A
+// cart.converted event is published afterwards.
+func CompleteCheckout(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	// The body is optional unless the cart redeems points
+	var req models.CompleteCheckoutRequest
+	if err := c.ShouldBindJSON(&req); err != nil && err != io.EOF {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	cartKey, _, ok := requestCart(c, userID)
+	if !ok {
+		return
+	}
+	lock, ok := lockCart(c, cartKey)
+	if !ok {
+		return
+	}
+	defer lock.Release(c.Request.Context())
+
+	cart, err := loadCart(c.Request.Context(), cartKey)
+	if err == errCartCorrupt {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse cart data"})
+		return
+	}
+	if err != nil || len(cart.Items) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Cart is empty", "code": codeCartEmpty})
+		return
+	}
+
+	// Points are only debited once the order exists, keyed by its ID so a
+	// retried completion doesn't debit them twice
+	uid := fmt.Sprintf("%v", userID)
+	redemption := cart.PointsRedemption
+	if redemption != nil {
+		if req.OrderID == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "order_id is required to redeem loyalty points"})
+			return
+		}
+		err := utils.DebitLoyaltyPoints(c.Request.Context(), uid, redemption.Points, req.OrderID)
+		if err == utils.ErrLoyaltyUnavailable {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Loyalty points are not available"})
+			return
+		}
+		if err != nil {
+			log.Printf("Failed to debit %d loyalty points for order %s: %v", redemption.Points, req.OrderID, err)
+			c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to debit loyalty points"})
+			return
+		}
+	}
+
+	snapshot := models.CartSnapshot{
+		ConvertedAt: time.Now().Format(time.RFC3339),
+		OrderID:     req.OrderID,
+		Cart:        cart,
+	}
+	snapshotData, err := json.Marshal(snapshot)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to snapshot cart"})
+		return
+	}
+
+	historyKey := historyKeyFor(userID)
+	historyLimit := int64(utils.GetEnvInt("CART_HISTORY_LIMIT", 20))
+	historyTTL := time.Duration(utils.GetEnvInt("CART_HISTORY_TTL_DAYS", 90)) * 24 * time.Hour
+
+	// The checkout freeze is lifted along with the cart
+	_, err = utils.RedisClient.TxPipelined(c.Request.Context(), func(pipe redis.Pipeliner) error {
+		pipe.LPush(c.Request.Context(), historyKey, snapshotData)
+		pipe.LTrim(c.Request.Context(), historyKey, 0, historyLimit-1)
+		pipe.Expire(c.Request.Context(), historyKey, historyTTL)
+		pipe.Del(c.Request.Context(), cartKey, frozenKeyFor(cartKey), countKeyFor(cartKey), lastViewedKeyFor(cartKey))
+		return nil
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to complete checkout"})
+		return
+	}
+
+	// The debited points have left the balance, so they no longer need
+	// holding
+	if redemption != nil {
+		releasePoints(c, uid, redemption.Points)
+	}
+
+	// Units held at the flash-sale price were bought, so they never go back
+	for _, item := range cart.Items {
+		if item.FlashReserved == 0 {
+			continue
+		}
+		if _, err := utils.UntrackFlashHold(c.Request.Context(), flashHoldOf(cartKey, item)); err != nil {
+			log.Printf("Failed to cancel flash-sale hold on product %d: %v", item.ProductID, err)
+		}
+	}
+
+	// The order redeemed the coupon's use, so it's never given back
+	if cart.Coupon != nil && cart.Coupon.UsageLimit > 0 {
+		claim := utils.CouponClaim{CartKey: cartKey, Code: cart.Coupon.Code}
+		if _, err := utils.UntrackCouponClaim(c.Request.Context(), claim); err != nil {
+			log.Printf("Failed to cancel claim on coupon %s: %v", cart.Coupon.Code, err)
+		}
+	}
+
+	if err := utils.PublishEvent(c.Request.Context(), eventCartConverted, uid, snapshot); err != nil {
+		log.Printf("Failed to publish %s event: %v", eventCartConverted, err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "Checkout complete",
+		"snapshot": snapshot,
+	})
+}
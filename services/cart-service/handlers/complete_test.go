@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"cart-service/models"
+	"cart-service/utils"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// historySnapshots returns the snapshots in the test user's order history
+func historySnapshots(t *testing.T) []models.CartSnapshot {
+	t.Helper()
+	entries, err := utils.RedisClient.LRange(utils.Ctx, historyKeyFor(testUserID), 0, -1).Result()
+	if err != nil {
+		t.Fatalf("failed to read history: %v", err)
+	}
+	snapshots := make([]models.CartSnapshot, len(entries))
+	for i, entry := range entries {
+		if err := json.Unmarshal([]byte(entry), &snapshots[i]); err != nil {
+			t.Fatalf("history entry is not a snapshot: %v", err)
+		}
+	}
+	return snapshots
+}
+
+func TestCompleteCheckout(t *testing.T) {
+	newTestRedis(t)
+	cartKey := cartKeyFor(testUserID)
+	seedCart(t, cartKey, testItem(1, 10, 2), testItem(2, 5, 1))
+
+	events := utils.RedisClient.Subscribe(utils.Ctx, utils.EventsChannel(testUserID))
+	t.Cleanup(func() { events.Close() })
+	if _, err := events.Receive(utils.Ctx); err != nil {
+		t.Fatalf("failed to subscribe: %v", err)
+	}
+
+	w := serve(t, CompleteCheckout, testRequest{method: http.MethodPost, route: "/checkout-complete"})
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body)
+	}
+
+	if exists := utils.RedisClient.Exists(utils.Ctx, cartKey).Val(); exists != 0 {
+		t.Error("cart survived checkout completion")
+	}
+	snapshots := historySnapshots(t)
+	if len(snapshots) != 1 || snapshots[0].Cart.TotalPrice != 25 {
+		t.Fatalf("history = %+v, want the 25.00 cart", snapshots)
+	}
+
+	select {
+	case message := <-events.Channel():
+		var event utils.Event
+		json.Unmarshal([]byte(message.Payload), &event)
+		if event.Type != eventCartConverted || event.UserID != testUserID {
+			t.Errorf("event = %+v", event)
+		}
+	case <-time.After(time.Second):
+		t.Errorf("no %s event published", eventCartConverted)
+	}
+
+	if w := serve(t, CompleteCheckout, testRequest{method: http.MethodPost, route: "/checkout-complete"}); w.Code != http.StatusNotFound {
+		t.Errorf("second completion status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+}
+
+func TestCompleteCheckoutConcurrentAdd(t *testing.T) {
+	for round := 0; round < 20; round++ {
+		newTestRedis(t)
+		newProductService(t, map[int]gin.H{3: {"name": "Socks", "price": 4, "quantity": 100}})
+		cartKey := cartKeyFor(testUserID)
+		seedCart(t, cartKey, testItem(1, 10, 2), testItem(2, 5, 1))
+
+		var wg sync.WaitGroup
+		var complete, add *httptest.ResponseRecorder
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			complete = serve(t, CompleteCheckout, testRequest{method: http.MethodPost, route: "/checkout-complete"})
+		}()
+		go func() {
+			defer wg.Done()
+			add = serve(t, AddItem, testRequest{method: http.MethodPost, route: "/items", body: `{"product_id": 3}`})
+		}()
+		wg.Wait()
+
+		if complete.Code != http.StatusOK || add.Code != http.StatusOK {
+			t.Fatalf("round %d: complete status = %d, add status = %d: %s %s", round, complete.Code, add.Code, complete.Body, add.Body)
+		}
+
+		// The add lands either in the converted cart or in a fresh one,
+		// and nothing from the converted cart is left behind
+		snapshot := historySnapshots(t)[0].Cart
+		addedBefore := snapshot.FindItem(3) != -1
+		cart, err := loadCart(utils.Ctx, cartKey)
+		if addedBefore {
+			if err == nil {
+				t.Errorf("round %d: cart survived with %+v", round, cart.Items)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("round %d: add after completion was lost: %v", round, err)
+		}
+		assertQuantities(t, "recreated", cart, map[int]int{3: 1})
+	}
+}
@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"cart-service/models"
+	"cart-service/utils"
+	"context"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+)
+
+// countKeyFor builds the Redis key holding a cart's item count, which lets
+// badges read the count without loading the cart
+func countKeyFor(cartKey string) string {
+	return utils.Key("count", utils.StripKeyPrefix(cartKey))
+}
+
+// healCartCount compares the stored item count with the cart it was read
+// alongside and rewrites it if they have drifted, e.g. after a partial
+// write or a count key that expired separately
+func healCartCount(ctx context.Context, cartKey string, cart *models.Cart) {
+	countKey := countKeyFor(cartKey)
+	stored, err := utils.RedisClient.Get(ctx, countKey).Int()
+	if err != nil && err != redis.Nil {
+		log.Printf("Failed to read cart count %s: %v", countKey, err)
+		return
+	}
+	if err == nil && stored == cart.TotalItems {
+		return
+	}
+
+	// Keep the count expiring with the cart
+	ttl, err := utils.RedisClient.PTTL(ctx, cartKey).Result()
+	if err != nil || ttl <= 0 {
+		ttl = cartTTL
+	}
+	if err := utils.RedisClient.Set(ctx, countKey, cart.TotalItems, ttl).Err(); err != nil {
+		log.Printf("Failed to repair cart count %s: %v", countKey, err)
+	}
+}
+
+// GetCartCount returns the number of items in the cart from the count key
+// alone, falling back to the cart itself (and repairing the count) when the
+// key is missing
+func GetCartCount(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	cartKey, _, ok := requestCart(c, userID)
+	if !ok {
+		return
+	}
+
+	countData, err := utils.RedisClient.Get(c.Request.Context(), countKeyFor(cartKey)).Result()
+	if err == nil {
+		if count, err := strconv.Atoi(countData); err == nil {
+			c.JSON(http.StatusOK, gin.H{"count": count})
+			return
+		}
+	} else if err != redis.Nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read cart count"})
+		return
+	}
+
+	cart, err := loadCart(c.Request.Context(), cartKey)
+	if err == errCartCorrupt {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse cart data"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{"count": 0})
+		return
+	}
+
+	healCartCount(c.Request.Context(), cartKey, cart)
+	c.JSON(http.StatusOK, gin.H{"count": cart.TotalItems})
+}
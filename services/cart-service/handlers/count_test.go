@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"cart-service/utils"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// storedCount returns the cart's count key, or -1 if it is missing
+func storedCount(t *testing.T, cartKey string) int {
+	t.Helper()
+	count, err := utils.RedisClient.Get(utils.Ctx, countKeyFor(cartKey)).Int()
+	if err != nil {
+		return -1
+	}
+	return count
+}
+
+func TestCartCountUnderConcurrentMutations(t *testing.T) {
+	newTestRedis(t)
+	newProductService(t, map[int]gin.H{
+		1: {"name": "Kettle", "price": 20, "quantity": 100},
+		2: {"name": "Mug", "price": 5, "quantity": 100},
+	})
+	newPromotionsService(t, promotionsFixture{})
+	cartKey := cartKeyFor(testUserID)
+	seedCart(t, cartKey, testItem(1, 20, 20))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 24; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			switch i % 3 {
+			case 0:
+				serve(t, AddItem, testRequest{method: http.MethodPost, route: "/items", body: `{"product_id": 2, "quantity": 1}`})
+			case 1:
+				serve(t, AdjustItem, testRequest{
+					method: http.MethodPost,
+					route:  "/items/:product_id/adjust",
+					target: "/items/1/adjust",
+					body:   `{"delta": -1}`,
+				})
+			default:
+				serve(t, AdjustItem, testRequest{
+					method: http.MethodPost,
+					route:  "/items/:product_id/adjust",
+					target: "/items/1/adjust",
+					body:   `{"delta": 2}`,
+				})
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	cart := storedCart(t, cartKey)
+	if count := storedCount(t, cartKey); count != cart.TotalItems {
+		t.Errorf("count key = %d, cart holds %d items", count, cart.TotalItems)
+	}
+}
+
+func TestCartCountHeals(t *testing.T) {
+	tests := []struct {
+		name    string
+		corrupt func(cartKey string)
+	}{
+		{name: "drifted count", corrupt: func(cartKey string) {
+			utils.RedisClient.Set(utils.Ctx, countKeyFor(cartKey), 99, 0)
+		}},
+		{name: "missing count", corrupt: func(cartKey string) {
+			utils.RedisClient.Del(utils.Ctx, countKeyFor(cartKey))
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newTestRedis(t)
+			cartKey := cartKeyFor(testUserID)
+			seedCart(t, cartKey, testItem(1, 10, 2), testItem(2, 5, 3))
+			tt.corrupt(cartKey)
+
+			if w := serve(t, GetCart, testRequest{route: "/"}); w.Code != http.StatusOK {
+				t.Fatalf("status = %d: %s", w.Code, w.Body)
+			}
+			if count := storedCount(t, cartKey); count != 5 {
+				t.Errorf("count after read = %d, want 5", count)
+			}
+		})
+	}
+}
+
+func TestGetCartCount(t *testing.T) {
+	tests := []struct {
+		name      string
+		seed      bool
+		dropCount bool
+		wantCount float64
+	}{
+		{name: "from the count key", seed: true, wantCount: 5},
+		{name: "count key missing", seed: true, dropCount: true, wantCount: 5},
+		{name: "no cart", wantCount: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newTestRedis(t)
+			cartKey := cartKeyFor(testUserID)
+			if tt.seed {
+				seedCart(t, cartKey, testItem(1, 10, 2), testItem(2, 5, 3))
+			}
+			if tt.dropCount {
+				utils.RedisClient.Del(utils.Ctx, countKeyFor(cartKey))
+			}
+
+			w := serve(t, GetCartCount, testRequest{route: "/count"})
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d: %s", w.Code, w.Body)
+			}
+			if count := decodeResponse(t, w)["count"]; count != tt.wantCount {
+				t.Errorf("count = %v, want %v", count, tt.wantCount)
+			}
+			if tt.dropCount && storedCount(t, cartKey) != 5 {
+				t.Error("missing count key was not repaired")
+			}
+		})
+	}
+}
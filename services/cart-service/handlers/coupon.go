@@ -0,0 +1,391 @@
+package handlers
+
+import (
+	"cart-service/models"
+	"cart-service/utils"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+)
+
+// couponKeyFor builds the Redis key holding a coupon definition
+func couponKeyFor(code string) string {
+	return utils.Key("coupon", strings.ToUpper(code))
+}
+
+// couponUsesKeyFor builds the Redis key counting a coupon's redemptions
+func couponUsesKeyFor(code string) string {
+	return utils.Key("coupon_uses", strings.ToUpper(code))
+}
+
+// releaseCouponUse gives back the use a limited coupon claimed for the
+// cart at cartKey when it was applied. If the sweep has already taken the
+// claim, the sweep gives the use back instead. Failures are logged; the
+// count is only ever too high, which errs on the side of not
+// over-redeeming.
+func releaseCouponUse(ctx context.Context, cartKey string, coupon *models.Coupon) {
+	if coupon.UsageLimit <= 0 {
+		return
+	}
+	untracked, err := utils.UntrackCouponClaim(ctx, utils.CouponClaim{CartKey: cartKey, Code: coupon.Code})
+	if err != nil {
+		log.Printf("Failed to cancel claim on coupon %s by %s: %v", coupon.Code, cartKey, err)
+		return
+	}
+	if untracked {
+		giveBackCouponUse(ctx, coupon.Code)
+	}
+}
+
+// giveBackCouponUse returns one claimed use of a coupon, logging failures
+func giveBackCouponUse(ctx context.Context, code string) {
+	if err := utils.ReleaseCouponUse(ctx, couponUsesKeyFor(code)); err != nil {
+		log.Printf("Failed to release use of coupon %s: %v", code, err)
+	}
+}
+
+// trackCouponClaim schedules the use held by the cart's limited coupon for
+// release when the cart expires. saveCart writes it with every save, so
+// the release follows the cart's TTL.
+func trackCouponClaim(ctx context.Context, pipe redis.Pipeliner, cartKey string, cart *models.Cart) {
+	if cart.Coupon == nil || cart.Coupon.UsageLimit <= 0 {
+		return
+	}
+	claim := utils.CouponClaim{CartKey: cartKey, Code: cart.Coupon.Code}
+	utils.TrackCouponClaim(ctx, pipe, claim, time.Now().Add(cartTTL))
+}
+
+// releaseExpiredCouponClaims gives back the coupon uses held by carts that
+// have expired
+func releaseExpiredCouponClaims(ctx context.Context) {
+	claims, err := utils.ClaimExpiredCouponClaims(ctx, time.Now(), 100)
+	if err != nil {
+		log.Printf("Failed to claim expired coupon claims: %v", err)
+	}
+
+	for _, claim := range claims {
+		if err := releaseCouponClaim(ctx, claim); err != nil {
+			log.Printf("Failed to release claim on coupon %s by %s, retrying: %v", claim.Code, claim.CartKey, err)
+			retryAt := time.Now().Add(flashHoldRetryDelay)
+			if err := utils.TrackCouponClaim(ctx, utils.RedisClient, claim, retryAt); err != nil {
+				log.Printf("Failed to reschedule coupon claim: %v", err)
+			}
+		}
+	}
+}
+
+// releaseCouponClaim gives back a swept claim's use. A cart still holding
+// the coupon, e.g. one saved as its claim came due, keeps the use and its
+// claim is scheduled again for when the cart now expires.
+func releaseCouponClaim(ctx context.Context, claim utils.CouponClaim) error {
+	lock, err := acquireCartLock(ctx, claim.CartKey)
+	if err != nil {
+		return err
+	}
+	defer lock.Release(ctx)
+
+	cart, ttl, err := loadCartWithTTL(ctx, claim.CartKey)
+	if err != nil && err != redis.Nil {
+		return err
+	}
+	if err == nil && cart.Coupon != nil && strings.EqualFold(cart.Coupon.Code, claim.Code) {
+		expiresAt := time.Now().Add(cartTTL)
+		if ttl >= 0 {
+			expiresAt = time.Now().Add(time.Duration(ttl) * time.Second)
+		}
+		return utils.TrackCouponClaim(ctx, utils.RedisClient, claim, expiresAt)
+	}
+	return utils.ReleaseCouponUse(ctx, couponUsesKeyFor(claim.Code))
+}
+
+// loadCouponAndCart fetches a coupon and the user's cart in one round trip.
+// Returns redis.Nil if the coupon does not exist; a missing cart is
+// returned as a new empty cart.
+func loadCouponAndCart(ctx context.Context, code string, userID interface{}) (*models.Coupon, *models.Cart, error) {
+	couponKey := couponKeyFor(code)
+	cartKey := cartKeyFor(userID)
+
+	values, err := utils.GetMany(ctx, couponKey, cartKey)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	couponData, ok := values[couponKey]
+	if !ok {
+		return nil, nil, redis.Nil
+	}
+
+	var coupon models.Coupon
+	if err := json.Unmarshal([]byte(couponData), &coupon); err != nil {
+		return nil, nil, err
+	}
+
+	cart := models.NewCart(fmt.Sprintf("%v", userID))
+	if cartData, ok := values[cartKey]; ok {
+		if cart, err = decodeCart(cartData); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return &coupon, cart, nil
+}
+
+// couponErrorResponse writes the response for a coupon that failed validation
+func couponErrorResponse(c *gin.Context, err error) {
+	var couponErr *models.CouponError
+	if errors.As(err, &couponErr) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error":  couponErr.Message,
+			"reason": couponErr.Reason,
+		})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to validate coupon"})
+}
+
+// PreviewCoupon shows the effect of a coupon on the cart without applying it
+func PreviewCoupon(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	code := c.Query("code")
+	if code == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Coupon code is required"})
+		return
+	}
+
+	coupon, cart, err := loadCouponAndCart(c.Request.Context(), code, userID)
+	if err == redis.Nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Coupon not found"})
+		return
+	}
+	if err == errCartCorrupt {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse cart data"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load coupon"})
+		return
+	}
+
+	if err := coupon.Validate(cart); err != nil {
+		couponErrorResponse(c, err)
+		return
+	}
+
+	// Price a copy of the cart with the coupon applied, so member discounts,
+	// points and the floor at zero are accounted for exactly as on apply
+	preview := *cart
+	preview.Items = append([]models.CartItem(nil), cart.Items...)
+	preview.Coupon = coupon
+	preview.MemberRole = utils.Role(c.Request.Context())
+	preview.CalculateTotals()
+
+	c.JSON(http.StatusOK, gin.H{
+		"coupon":          coupon.Code,
+		"subtotal":        preview.TotalPrice,
+		"member_discount": preview.MemberDiscount,
+		"discount":        preview.CouponDiscount,
+		"final_price":     preview.FinalPrice,
+	})
+}
+
+// CheckCoupon reports whether a coupon could be applied to the cart, with
+// every reason it can't, for inline validation as the user types
+func CheckCoupon(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	code := c.Query("code")
+	if code == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Coupon code is required"})
+		return
+	}
+
+	coupon, cart, err := loadCouponAndCart(c.Request.Context(), code, userID)
+	if err == redis.Nil {
+		c.JSON(http.StatusOK, gin.H{
+			"code":     code,
+			"eligible": false,
+			"reasons":  []gin.H{{"reason": "not_found", "message": "Coupon not found"}},
+		})
+		return
+	}
+	if err == errCartCorrupt {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse cart data"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load coupon"})
+		return
+	}
+
+	problems := coupon.Check(cart)
+
+	if coupon.UsageLimit > 0 {
+		uses, err := utils.RedisClient.Get(c.Request.Context(), couponUsesKeyFor(code)).Int()
+		if err != nil && err != redis.Nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load coupon usage"})
+			return
+		}
+		var couponErr *models.CouponError
+		if errors.As(coupon.CheckUsage(uses), &couponErr) {
+			problems = append(problems, couponErr)
+		}
+	}
+
+	reasons := make([]gin.H, 0, len(problems))
+	for _, problem := range problems {
+		reasons = append(reasons, gin.H{"reason": problem.Reason, "message": problem.Message})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":     coupon.Code,
+		"eligible": len(reasons) == 0,
+		"reasons":  reasons,
+	})
+}
+
+// ApplyCoupon validates a coupon and attaches it to the cart
+func ApplyCoupon(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req models.ApplyCouponRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	cartKey := cartKeyFor(userID)
+	lock, ok := lockCart(c, cartKey)
+	if !ok {
+		return
+	}
+	defer lock.Release(c.Request.Context())
+	if !ensureNotFrozen(c, cartKey) {
+		return
+	}
+
+	coupon, cart, err := loadCouponAndCart(c.Request.Context(), req.Code, userID)
+	if err == redis.Nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Coupon not found"})
+		return
+	}
+	if err == errCartCorrupt {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse cart data"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load coupon"})
+		return
+	}
+
+	if err := coupon.Validate(cart); err != nil {
+		couponErrorResponse(c, err)
+		return
+	}
+
+	// Limited coupons claim a use up front; re-applying the coupon the cart
+	// already holds doesn't claim another
+	previous := cart.Coupon
+	reapplied := previous != nil && strings.EqualFold(previous.Code, coupon.Code)
+	claimed := false
+	if coupon.UsageLimit > 0 && !reapplied {
+		ok, err := utils.ClaimCouponUse(c.Request.Context(), couponUsesKeyFor(coupon.Code), coupon.UsageLimit)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load coupon usage"})
+			return
+		}
+		if !ok {
+			couponErrorResponse(c, coupon.CheckUsage(coupon.UsageLimit))
+			return
+		}
+		claimed = true
+	}
+
+	cart.Coupon = coupon
+	cart.CalculateTotals()
+
+	if err := saveCart(c.Request.Context(), cartKey, cart); err != nil {
+		if claimed {
+			giveBackCouponUse(c.Request.Context(), coupon.Code)
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save cart"})
+		return
+	}
+
+	// The coupon this one replaced no longer needs its use
+	if previous != nil && !reapplied {
+		releaseCouponUse(c.Request.Context(), cartKey, previous)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Coupon applied",
+		"cart":    cart,
+	})
+}
+
+// RemoveCoupon detaches any coupon from the cart
+func RemoveCoupon(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	cartKey := cartKeyFor(userID)
+	lock, ok := lockCart(c, cartKey)
+	if !ok {
+		return
+	}
+	defer lock.Release(c.Request.Context())
+	if !ensureNotFrozen(c, cartKey) {
+		return
+	}
+
+	cart, err := loadCart(c.Request.Context(), cartKey)
+	if err == errCartCorrupt {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse cart data"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Cart not found", "code": codeCartEmpty})
+		return
+	}
+
+	removed := cart.Coupon
+	cart.Coupon = nil
+	cart.CalculateTotals()
+
+	if err := saveCart(c.Request.Context(), cartKey, cart); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save cart"})
+		return
+	}
+
+	if removed != nil {
+		releaseCouponUse(c.Request.Context(), cartKey, removed)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Coupon removed",
+		"cart":    cart,
+	})
+}
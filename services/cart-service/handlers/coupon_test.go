@@ -0,0 +1,235 @@
+package handlers
+
+import (
+	"cart-service/models"
+	"cart-service/utils"
+	"net/http"
+	"testing"
+)
+
+func TestPreviewCoupon(t *testing.T) {
+	tests := []struct {
+		name       string
+		coupon     models.Coupon
+		code       string
+		wantStatus int
+		wantReason string
+		wantFinal  float64
+	}{
+		{
+			name:       "valid percent coupon",
+			coupon:     models.Coupon{Code: "SAVE10", Type: models.CouponTypePercent, Value: 10},
+			code:       "save10",
+			wantStatus: http.StatusOK,
+			wantFinal:  45,
+		},
+		{
+			name:       "expired coupon",
+			coupon:     models.Coupon{Code: "OLD", Type: models.CouponTypeFixed, Value: 5, ExpiresAt: "2000-01-01T00:00:00Z"},
+			code:       "OLD",
+			wantStatus: http.StatusUnprocessableEntity,
+			wantReason: models.CouponReasonExpired,
+		},
+		{
+			name:       "minimum order not met",
+			coupon:     models.Coupon{Code: "BIG", Type: models.CouponTypeFixed, Value: 5, MinOrderValue: 100},
+			code:       "BIG",
+			wantStatus: http.StatusUnprocessableEntity,
+			wantReason: models.CouponReasonMinOrderNotMet,
+		},
+		{
+			name:       "unknown coupon",
+			coupon:     models.Coupon{Code: "SAVE10", Type: models.CouponTypePercent, Value: 10},
+			code:       "NOPE",
+			wantStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newTestRedis(t)
+			cartKey := cartKeyFor(testUserID)
+			seedCart(t, cartKey, testItem(1, 25, 2))
+			storeCoupon(t, tt.coupon)
+
+			w := serve(t, PreviewCoupon, testRequest{
+				route:  "/coupon/preview",
+				target: "/coupon/preview?code=" + tt.code,
+			})
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+			body := decodeResponse(t, w)
+			if tt.wantReason != "" && body["reason"] != tt.wantReason {
+				t.Errorf("reason = %v, want %s", body["reason"], tt.wantReason)
+			}
+			if tt.wantStatus == http.StatusOK && body["final_price"] != tt.wantFinal {
+				t.Errorf("final_price = %v, want %v", body["final_price"], tt.wantFinal)
+			}
+
+			if cart := storedCart(t, cartKey); cart.Coupon != nil {
+				t.Errorf("preview persisted coupon %+v on the cart", cart.Coupon)
+			}
+		})
+	}
+}
+
+func TestPreviewCouponRequiresCode(t *testing.T) {
+	newTestRedis(t)
+
+	w := serve(t, PreviewCoupon, testRequest{route: "/coupon/preview"})
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+}
+
+func TestLoadCouponAndCartSingleRoundTrip(t *testing.T) {
+	newTestRedis(t)
+	seedCart(t, cartKeyFor(testUserID), testItem(1, 25, 2))
+	storeCoupon(t, models.Coupon{Code: "SAVE10", Type: models.CouponTypePercent, Value: 10})
+
+	hook := &roundTripHook{}
+	utils.RedisClient.AddHook(hook)
+
+	coupon, cart, err := loadCouponAndCart(utils.Ctx, "SAVE10", testUserID)
+	if err != nil {
+		t.Fatalf("loadCouponAndCart: %v", err)
+	}
+	if hook.trips != 1 {
+		t.Errorf("round trips = %d, want 1", hook.trips)
+	}
+	if coupon.Code != "SAVE10" || len(cart.Items) != 1 {
+		t.Errorf("got coupon %+v and %d items", coupon, len(cart.Items))
+	}
+}
+
+func TestCheckCoupon(t *testing.T) {
+	tests := []struct {
+		name        string
+		coupon      models.Coupon
+		code        string
+		uses        int
+		wantReasons []string
+	}{
+		{
+			name:   "eligible",
+			coupon: models.Coupon{Code: "SAVE10", Type: models.CouponTypePercent, Value: 10, ProductIDs: []int{1}},
+			code:   "save10",
+		},
+		{
+			name:        "expired",
+			coupon:      models.Coupon{Code: "SAVE10", Type: models.CouponTypePercent, Value: 10, ExpiresAt: "2000-01-01T00:00:00Z"},
+			code:        "SAVE10",
+			wantReasons: []string{models.CouponReasonExpired},
+		},
+		{
+			name:        "minimum order not met",
+			coupon:      models.Coupon{Code: "SAVE10", Type: models.CouponTypePercent, Value: 10, MinOrderValue: 100},
+			code:        "SAVE10",
+			wantReasons: []string{models.CouponReasonMinOrderNotMet},
+		},
+		{
+			name:        "not applicable to cart items",
+			coupon:      models.Coupon{Code: "SAVE10", Type: models.CouponTypePercent, Value: 10, ProductIDs: []int{9}},
+			code:        "SAVE10",
+			wantReasons: []string{models.CouponReasonNotApplicable},
+		},
+		{
+			name:        "usage limit reached",
+			coupon:      models.Coupon{Code: "SAVE10", Type: models.CouponTypePercent, Value: 10, UsageLimit: 2},
+			code:        "SAVE10",
+			uses:        2,
+			wantReasons: []string{models.CouponReasonUsageLimit},
+		},
+		{
+			name:   "usage limit not yet reached",
+			coupon: models.Coupon{Code: "SAVE10", Type: models.CouponTypePercent, Value: 10, UsageLimit: 2},
+			code:   "SAVE10",
+			uses:   1,
+		},
+		{
+			name:        "every reason at once",
+			coupon:      models.Coupon{Code: "SAVE10", Type: models.CouponTypePercent, Value: 10, ExpiresAt: "2000-01-01T00:00:00Z", MinOrderValue: 100, ProductIDs: []int{9}, UsageLimit: 1},
+			code:        "SAVE10",
+			uses:        1,
+			wantReasons: []string{models.CouponReasonExpired, models.CouponReasonMinOrderNotMet, models.CouponReasonNotApplicable, models.CouponReasonUsageLimit},
+		},
+		{
+			name:        "unknown coupon",
+			coupon:      models.Coupon{Code: "SAVE10", Type: models.CouponTypePercent, Value: 10},
+			code:        "NOPE",
+			wantReasons: []string{"not_found"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newTestRedis(t)
+			seedCart(t, cartKeyFor(testUserID), testItem(1, 25, 2))
+			storeCoupon(t, tt.coupon)
+			if tt.uses > 0 {
+				utils.RedisClient.Set(utils.Ctx, couponUsesKeyFor(tt.coupon.Code), tt.uses, 0)
+			}
+
+			w := serve(t, CheckCoupon, testRequest{route: "/coupon/check", target: "/coupon/check?code=" + tt.code})
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d: %s", w.Code, w.Body)
+			}
+			body := decodeResponse(t, w)
+			if body["eligible"] != (len(tt.wantReasons) == 0) {
+				t.Errorf("eligible = %v with reasons %v", body["eligible"], body["reasons"])
+			}
+			reasons := body["reasons"].([]interface{})
+			if len(reasons) != len(tt.wantReasons) {
+				t.Fatalf("reasons = %v, want %v", reasons, tt.wantReasons)
+			}
+			for i, reason := range reasons {
+				entry := reason.(map[string]interface{})
+				if entry["reason"] != tt.wantReasons[i] || entry["message"] == "" {
+					t.Errorf("reason %d = %v, want %s", i, entry, tt.wantReasons[i])
+				}
+			}
+		})
+	}
+}
+
+func TestPreviewCouponWithMemberDiscount(t *testing.T) {
+	tests := []struct {
+		name       string
+		role       string
+		stacks     bool
+		wantMember float64
+		wantCoupon float64
+		wantFinal  float64
+	}{
+		{name: "no member discount", wantCoupon: 5, wantFinal: 45},
+		{name: "member discount beats the coupon", role: "employee", wantMember: 10, wantFinal: 40},
+		{name: "coupon stacks on the member discount", role: "employee", stacks: true, wantMember: 10, wantCoupon: 4, wantFinal: 36},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := models.SetMemberDiscounts("employee=20", tt.stacks); err != nil {
+				t.Fatalf("SetMemberDiscounts: %v", err)
+			}
+			t.Cleanup(func() { models.SetMemberDiscounts("", false) })
+			newTestRedis(t)
+			seedCart(t, cartKeyFor(testUserID), testItem(1, 25, 2))
+			storeCoupon(t, models.Coupon{Code: "SAVE10", Type: models.CouponTypePercent, Value: 10})
+
+			w := serve(t, PreviewCoupon, testRequest{
+				route:  "/coupon/preview",
+				target: "/coupon/preview?code=SAVE10",
+				role:   tt.role,
+			})
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d: %s", w.Code, w.Body)
+			}
+			body := decodeResponse(t, w)
+			if body["member_discount"] != tt.wantMember || body["discount"] != tt.wantCoupon || body["final_price"] != tt.wantFinal {
+				t.Errorf("member_discount = %v, discount = %v, final_price = %v; want %v, %v, %v",
+					body["member_discount"], body["discount"], body["final_price"], tt.wantMember, tt.wantCoupon, tt.wantFinal)
+			}
+		})
+	}
+}
@@ -0,0 +1,168 @@
+package handlers
+
+import (
+	"cart-service/models"
+	"cart-service/utils"
+	"net/http"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// couponUses returns how many uses of a coupon are claimed
+func couponUses(t *testing.T, code string) int {
+	t.Helper()
+	uses, _ := utils.RedisClient.Get(utils.Ctx, couponUsesKeyFor(code)).Int()
+	return uses
+}
+
+func TestApplyCouponUsageLimitConcurrent(t *testing.T) {
+	newTestRedis(t)
+	storeCoupon(t, models.Coupon{Code: "FIRST3", Type: models.CouponTypeFixed, Value: 5, UsageLimit: 3})
+
+	const shoppers = 10
+	for i := 1; i <= shoppers; i++ {
+		seedCart(t, cartKeyFor(i), testItem(1, 20, 1))
+	}
+
+	var wg sync.WaitGroup
+	codes := make([]int, shoppers)
+	for i := 1; i <= shoppers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			w := serve(t, ApplyCoupon, testRequest{method: http.MethodPost, route: "/coupon", body: `{"code": "FIRST3"}`, userID: strconv.Itoa(i)})
+			codes[i-1] = w.Code
+		}(i)
+	}
+	wg.Wait()
+
+	applied := 0
+	for i := 1; i <= shoppers; i++ {
+		cart := storedCart(t, cartKeyFor(i))
+		switch {
+		case codes[i-1] == http.StatusOK && cart.Coupon != nil:
+			applied++
+		case codes[i-1] != http.StatusOK && cart.Coupon == nil:
+		default:
+			t.Errorf("user %d: status %d with coupon %+v", i, codes[i-1], cart.Coupon)
+		}
+	}
+	if applied != 3 {
+		t.Errorf("%d users applied the coupon, want 3", applied)
+	}
+	if uses := couponUses(t, "FIRST3"); uses != 3 {
+		t.Errorf("claimed uses = %d, want 3", uses)
+	}
+}
+
+func TestCouponUseReleased(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler gin.HandlerFunc
+		request testRequest
+		cartKey string
+	}{
+		{
+			name:    "coupon removed",
+			handler: RemoveCoupon,
+			request: testRequest{method: http.MethodDelete, route: "/coupon"},
+			cartKey: cartKeyFor(testUserID),
+		},
+		{
+			name:    "coupon replaced",
+			handler: ApplyCoupon,
+			request: testRequest{method: http.MethodPost, route: "/coupon", body: `{"code": "OTHER"}`},
+			cartKey: cartKeyFor(testUserID),
+		},
+		{
+			name:    "cart cleared",
+			handler: ClearCart,
+			request: testRequest{method: http.MethodDelete, route: "/"},
+			cartKey: cartKeyFor(testUserID),
+		},
+		{
+			name:    "named carts cleared",
+			handler: ClearCart,
+			request: testRequest{method: http.MethodDelete, route: "/", target: "/?names=work"},
+			cartKey: namedCartKeyFor(testUserID, "work"),
+		},
+		{
+			name:    "all named carts cleared",
+			handler: ClearAllCarts,
+			request: testRequest{method: http.MethodDelete, route: "/all"},
+			cartKey: namedCartKeyFor(testUserID, "work"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newTestRedis(t)
+			limited := models.Coupon{Code: "LIMITED", Type: models.CouponTypeFixed, Value: 5, UsageLimit: 10}
+			storeCoupon(t, limited)
+			storeCoupon(t, models.Coupon{Code: "OTHER", Type: models.CouponTypeFixed, Value: 2})
+
+			cart := models.NewCart(testUserID)
+			cart.Items = []models.CartItem{testItem(1, 20, 1)}
+			cart.Coupon = &limited
+			storeCart(t, tt.cartKey, cart)
+			if ok, err := utils.ClaimCouponUse(utils.Ctx, couponUsesKeyFor("LIMITED"), limited.UsageLimit); !ok || err != nil {
+				t.Fatalf("failed to claim a use: %v", err)
+			}
+
+			if w := serve(t, tt.handler, tt.request); w.Code != http.StatusOK {
+				t.Fatalf("status = %d: %s", w.Code, w.Body)
+			}
+			if uses := couponUses(t, "LIMITED"); uses != 0 {
+				t.Errorf("claimed uses = %d, want the cart's use given back", uses)
+			}
+		})
+	}
+}
+
+func TestCouponUseReleasedWhenCartExpires(t *testing.T) {
+	tests := []struct {
+		name        string
+		expire      bool
+		wantUses    int
+		wantTracked bool
+	}{
+		{name: "cart expired", expire: true, wantUses: 0},
+		{name: "cart still holds the coupon", wantUses: 1, wantTracked: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newTestRedis(t)
+			storeCoupon(t, models.Coupon{Code: "LIMITED", Type: models.CouponTypeFixed, Value: 5, UsageLimit: 10})
+			cartKey := cartKeyFor(testUserID)
+			seedCart(t, cartKey, testItem(1, 20, 1))
+			if w := serve(t, ApplyCoupon, testRequest{method: http.MethodPost, route: "/coupon", body: `{"code": "LIMITED"}`}); w.Code != http.StatusOK {
+				t.Fatalf("apply status = %d: %s", w.Code, w.Body)
+			}
+			if tt.expire {
+				utils.RedisClient.Del(utils.Ctx, cartKey)
+			}
+
+			// Sweep as if the cart's TTL had run out
+			claims, err := utils.ClaimExpiredCouponClaims(utils.Ctx, time.Now().Add(cartTTL+time.Minute), 100)
+			if err != nil || len(claims) != 1 {
+				t.Fatalf("claims = %+v, err = %v, want the cart's claim", claims, err)
+			}
+			if err := releaseCouponClaim(utils.Ctx, claims[0]); err != nil {
+				t.Fatalf("releaseCouponClaim: %v", err)
+			}
+
+			if uses := couponUses(t, "LIMITED"); uses != tt.wantUses {
+				t.Errorf("claimed uses = %d, want %d", uses, tt.wantUses)
+			}
+			tracked, _ := utils.UntrackCouponClaim(utils.Ctx, utils.CouponClaim{CartKey: cartKey, Code: "LIMITED"})
+			if tracked != tt.wantTracked {
+				t.Errorf("claim tracked = %v, want %v", tracked, tt.wantTracked)
+			}
+		})
+	}
+}
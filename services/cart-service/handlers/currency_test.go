@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"cart-service/utils"
+	"encoding/json"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// cacheFXRate caches a fresh rate between two currencies
+func cacheFXRate(t *testing.T, from, to string, rate float64) {
+	t.Helper()
+	data, _ := json.Marshal(gin.H{"rate": rate, "fetched_at": time.Now().Unix()})
+	if err := utils.RedisClient.Set(utils.Ctx, utils.Key("fx", from, to), data, 0).Err(); err != nil {
+		t.Fatalf("failed to cache FX rate: %v", err)
+	}
+}
+
+func TestGetCartProfileCurrency(t *testing.T) {
+	tests := []struct {
+		name         string
+		query        string
+		claimed      string
+		profile      string
+		profileFails bool
+		wantCurrency string
+		wantRate     float64
+		wantTotal    float64
+		wantLookups  int32
+	}{
+		{name: "profile currency", query: "?use_profile_currency=true", profile: "eur", wantCurrency: "EUR", wantRate: 0.9, wantTotal: 18, wantLookups: 1},
+		{name: "claimed currency wins", query: "?use_profile_currency=true", claimed: "gbp", profile: "EUR", wantCurrency: "GBP", wantRate: 0.8, wantTotal: 16},
+		{name: "same as the cart", query: "?use_profile_currency=true", profile: "USD", wantLookups: 1},
+		{name: "no preference", query: "?use_profile_currency=true", wantLookups: 1},
+		{name: "no rate available", query: "?use_profile_currency=true", profile: "JPY", wantLookups: 1},
+		{name: "profile lookup failing", query: "?use_profile_currency=true", profileFails: true, wantLookups: 2},
+		{name: "not requested", profile: "EUR"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newTestRedis(t)
+			t.Setenv("FX_SERVICE_URL", "")
+			var lookups int32
+			newJSONService(t, "USER_SERVICE_URL", func(w http.ResponseWriter, r *http.Request) {
+				atomic.AddInt32(&lookups, 1)
+				if r.URL.Path != "/api/auth/me" || r.Header.Get("Authorization") != "Bearer token" {
+					w.WriteHeader(http.StatusUnauthorized)
+					return
+				}
+				if tt.profileFails {
+					w.WriteHeader(http.StatusInternalServerError)
+					return
+				}
+				json.NewEncoder(w).Encode(gin.H{"user": gin.H{"preferred_currency": tt.profile}})
+			})
+			cacheFXRate(t, "USD", "EUR", 0.9)
+			cacheFXRate(t, "USD", "GBP", 0.8)
+			seedCart(t, cartKeyFor(testUserID), testItem(1, 10, 2))
+
+			handler := GetCart
+			if tt.claimed != "" {
+				handler = func(c *gin.Context) {
+					c.Request = c.Request.WithContext(utils.WithPreferredCurrency(c.Request.Context(), tt.claimed))
+					GetCart(c)
+				}
+			}
+			request := testRequest{route: "/", target: "/" + tt.query, headers: map[string]string{"Authorization": "Bearer token"}}
+
+			// The second read is answered from the cached preference; failed
+			// lookups are not cached
+			for i := 0; i < 2; i++ {
+				w := serve(t, handler, request)
+				if w.Code != http.StatusOK {
+					t.Fatalf("status = %d: %s", w.Code, w.Body)
+				}
+				converted, ok := decodeResponse(t, w)["converted"].(map[string]interface{})
+				if tt.wantCurrency == "" {
+					if ok {
+						t.Errorf("converted = %v, want none", converted)
+					}
+					continue
+				}
+				if !ok {
+					t.Fatalf("no converted totals, want %s", tt.wantCurrency)
+				}
+				if converted["currency"] != tt.wantCurrency || converted["rate"] != tt.wantRate || converted["total_price"] != tt.wantTotal {
+					t.Errorf("converted = %v, want %v %s at %v", converted, tt.wantTotal, tt.wantCurrency, tt.wantRate)
+				}
+			}
+			if got := atomic.LoadInt32(&lookups); got != tt.wantLookups {
+				t.Errorf("profile lookups = %d, want %d", got, tt.wantLookups)
+			}
+		})
+	}
+}
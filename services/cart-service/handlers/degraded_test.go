@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"cart-service/models"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newFlakyProductService serves product 1 unless down is set
+func newFlakyProductService(t *testing.T) *atomic.Bool {
+	t.Helper()
+	var down atomic.Bool
+	newJSONService(t, "PRODUCT_SERVICE_URL", func(w http.ResponseWriter, r *http.Request) {
+		if down.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		json.NewEncoder(w).Encode(gin.H{"product": gin.H{"name": "Kettle", "price": 20, "quantity": 10}})
+	})
+	return &down
+}
+
+func TestAddItemWhileProductServiceDown(t *testing.T) {
+	tests := []struct {
+		name        string
+		allow       string
+		body        string
+		wantStatus  int
+		wantPending bool
+	}{
+		{name: "degraded adds disabled", body: `{"product_id": 1}`, wantStatus: http.StatusBadGateway},
+		{name: "degraded add", allow: "true", body: `{"product_id": 1}`, wantStatus: http.StatusOK, wantPending: true},
+		{
+			name:       "subscriptions need product details",
+			allow:      "true",
+			body:       `{"product_id": 1, "is_subscription": true, "interval": "weekly"}`,
+			wantStatus: http.StatusBadGateway,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newTestRedis(t)
+			newFlakyProductService(t).Store(true)
+			if tt.allow != "" {
+				t.Setenv("ALLOW_DEGRADED_ADD", tt.allow)
+			}
+
+			w := serve(t, AddItem, testRequest{method: http.MethodPost, route: "/items", body: tt.body})
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+			if !tt.wantPending {
+				return
+			}
+			item := storedCart(t, cartKeyFor(testUserID)).Items[0]
+			if !item.Pending || item.ProductName != "Product 1" || item.Price != 0 {
+				t.Errorf("item = pending %v, name %q, price %v; want a pending placeholder", item.Pending, item.ProductName, item.Price)
+			}
+		})
+	}
+}
+
+func TestPendingItemsReconcileOnRead(t *testing.T) {
+	newTestRedis(t)
+	t.Setenv("ALLOW_DEGRADED_ADD", "true")
+	down := newFlakyProductService(t)
+	down.Store(true)
+	cartKey := cartKeyFor(testUserID)
+
+	if w := serve(t, AddItem, testRequest{method: http.MethodPost, route: "/items", body: `{"product_id": 1, "quantity": 2}`}); w.Code != http.StatusOK {
+		t.Fatalf("degraded add status = %d: %s", w.Code, w.Body)
+	}
+
+	// Still down: the item stays pending
+	serve(t, GetCart, testRequest{route: "/"})
+	if item := storedCart(t, cartKey).Items[0]; !item.Pending {
+		t.Fatal("item reconciled while product-service was down")
+	}
+
+	down.Store(false)
+	w := serve(t, GetCart, testRequest{route: "/"})
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body)
+	}
+	cart := storedCart(t, cartKey)
+	item := cart.Items[0]
+	if item.Pending || item.ProductName != "Kettle" || item.Price != 20 {
+		t.Errorf("item = pending %v, name %q, price %v; want reconciled details", item.Pending, item.ProductName, item.Price)
+	}
+	if cart.TotalPrice != 40 {
+		t.Errorf("total_price = %v, want 40", cart.TotalPrice)
+	}
+}
+
+func TestOrderPayloadRejectsUnconfirmedItems(t *testing.T) {
+	pending := testItem(1, 0, 1)
+	pending.Pending = true
+	discontinued := testItem(2, 10, 1)
+	discontinued.Discontinued = true
+
+	tests := []struct {
+		name        string
+		items       []models.CartItem
+		wantMissing []string
+	}{
+		{name: "pending item", items: []models.CartItem{pending, testItem(3, 5, 1)}, wantMissing: []string{"pending_items"}},
+		{name: "discontinued item", items: []models.CartItem{discontinued}, wantMissing: []string{"discontinued_items"}},
+		{name: "both", items: []models.CartItem{pending, discontinued}, wantMissing: []string{"pending_items", "discontinued_items"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newTestRedis(t)
+			cart := models.NewCart(testUserID)
+			cart.Items = tt.items
+			cart.ShippingAddress = testAddress()
+			storeCart(t, cartKeyFor(testUserID), cart)
+
+			w := serve(t, GetOrderPayload, testRequest{route: "/order-payload"})
+			if w.Code != http.StatusUnprocessableEntity {
+				t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusUnprocessableEntity, w.Body)
+			}
+			if missing := decodeResponse(t, w)["missing"]; fmt.Sprint(missing) != fmt.Sprint(tt.wantMissing) {
+				t.Errorf("missing = %v, want %v", missing, tt.wantMissing)
+			}
+		})
+	}
+}
@@ -0,0 +1,156 @@
+package handlers
+
+import (
+	"cart-service/models"
+	"cart-service/utils"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetDeliveryEstimate returns the window in which the whole cart should
+// arrive with the chosen shipping method (?method=, default standard)
+func GetDeliveryEstimate(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	method := c.DefaultQuery("method", "standard")
+	transit, known := models.ShippingMethods[method]
+	if !known {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown shipping method"})
+		return
+	}
+
+	cartKey, _, ok := requestCart(c, userID)
+	if !ok {
+		return
+	}
+
+	cart, err := loadCart(c.Request.Context(), cartKey)
+	if err == errCartCorrupt {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse cart data"})
+		return
+	}
+	if err != nil || len(cart.Items) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Cart is empty", "code": codeCartEmpty})
+		return
+	}
+
+	// Lead times are not stored on the cart; a product that can't be
+	// fetched is treated as having an unknown lead time
+	leadTimes := make([]*int, len(cart.Items))
+	for i, item := range cart.Items {
+		product, err := utils.FetchProduct(c.Request.Context(), item.ProductID)
+		if err != nil {
+			log.Printf("Failed to fetch product %d: %v", item.ProductID, err)
+			continue
+		}
+		leadTimes[i] = product.LeadTimeDays
+	}
+
+	unknownLeadDays := utils.GetEnvInt("UNKNOWN_LEAD_TIME_DAYS", 14)
+	estimate := models.EstimateDelivery(leadTimes, unknownLeadDays, method, transit, time.Now())
+
+	c.JSON(http.StatusOK, gin.H{"estimate": estimate})
+}
+
+// SetItemDeliveryDate schedules delivery of an item for a future date
+// within the product's allowed window. The earliest date is the product's
+// lead time away.
+func SetItemDeliveryDate(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	productID, err := strconv.Atoi(c.Param("product_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return
+	}
+
+	var req models.SetDeliveryDateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	product, err := utils.FetchProduct(c.Request.Context(), productID)
+	if err == utils.ErrProductNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to fetch product %d: %v", productID, err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to fetch product details"})
+		return
+	}
+
+	minDays := 1
+	if product.LeadTimeDays != nil && *product.LeadTimeDays > minDays {
+		minDays = *product.LeadTimeDays
+	}
+	maxDays := product.DeliveryWindowDays
+	if maxDays <= 0 {
+		maxDays = utils.GetEnvInt("DELIVERY_WINDOW_DAYS", 90)
+	}
+
+	var dateErr *models.DeliveryDateError
+	if errors.As(models.ValidateDeliveryDate(req.Date, time.Now().UTC(), minDays, maxDays), &dateErr) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error":    dateErr.Message,
+			"reason":   dateErr.Reason,
+			"earliest": dateErr.Earliest,
+			"latest":   dateErr.Latest,
+		})
+		return
+	}
+
+	cartKey, _, ok := requestCart(c, userID)
+	if !ok {
+		return
+	}
+	lock, ok := lockCart(c, cartKey)
+	if !ok {
+		return
+	}
+	defer lock.Release(c.Request.Context())
+	if !ensureNotFrozen(c, cartKey) {
+		return
+	}
+
+	cart, err := loadCart(c.Request.Context(), cartKey)
+	if err == errCartCorrupt {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse cart data"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Cart not found", "code": codeCartEmpty})
+		return
+	}
+
+	i := cart.FindItem(productID)
+	if i == -1 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Item not found in cart", "code": codeItemNotFound})
+		return
+	}
+	cart.Items[i].RequestedDeliveryDate = req.Date
+
+	if err := saveCart(c.Request.Context(), cartKey, cart); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save cart"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Delivery date scheduled",
+		"item":    cart.Items[i],
+	})
+}
@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"cart-service/models"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestGetDeliveryEstimate(t *testing.T) {
+	tests := []struct {
+		name          string
+		items         []int
+		method        string
+		wantStatus    int
+		wantLeadDays  float64
+		wantMinDays   int
+		wantMaxDays   int
+		wantEstimated bool
+	}{
+		{name: "fast items only", items: []int{1, 2}, method: "standard", wantStatus: http.StatusOK, wantLeadDays: 2, wantMinDays: 5, wantMaxDays: 9},
+		{name: "slow item pushes the window", items: []int{1, 2, 3}, method: "standard", wantStatus: http.StatusOK, wantLeadDays: 10, wantMinDays: 13, wantMaxDays: 17},
+		{name: "express", items: []int{1, 3}, method: "express", wantStatus: http.StatusOK, wantLeadDays: 10, wantMinDays: 11, wantMaxDays: 13},
+		{name: "unknown lead time is conservative", items: []int{1, 4}, method: "standard", wantStatus: http.StatusOK, wantLeadDays: 14, wantMinDays: 17, wantMaxDays: 21, wantEstimated: true},
+		{name: "missing product is conservative", items: []int{1, 9}, method: "overnight", wantStatus: http.StatusOK, wantLeadDays: 14, wantMinDays: 15, wantMaxDays: 15, wantEstimated: true},
+		{name: "unknown method", items: []int{1}, method: "drone", wantStatus: http.StatusBadRequest},
+		{name: "empty cart", method: "standard", wantStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newTestRedis(t)
+			newProductService(t, map[int]gin.H{
+				1: {"name": "Kettle", "price": 10, "lead_time_days": 1},
+				2: {"name": "Mug", "price": 5, "lead_time_days": 2},
+				3: {"name": "Sofa", "price": 500, "lead_time_days": 10},
+				4: {"name": "Lamp", "price": 30},
+			})
+			var items []models.CartItem
+			for _, productID := range tt.items {
+				items = append(items, testItem(productID, 10, 1))
+			}
+			seedCart(t, cartKeyFor(testUserID), items...)
+
+			w := serve(t, GetDeliveryEstimate, testRequest{
+				route:  "/delivery-estimate",
+				target: "/delivery-estimate?method=" + tt.method,
+			})
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			estimate := decodeResponse(t, w)["estimate"].(map[string]interface{})
+			now := time.Now()
+			earliest := now.AddDate(0, 0, tt.wantMinDays).Format("2006-01-02")
+			latest := now.AddDate(0, 0, tt.wantMaxDays).Format("2006-01-02")
+			if estimate["earliest"] != earliest || estimate["latest"] != latest {
+				t.Errorf("window = %v to %v, want %s to %s", estimate["earliest"], estimate["latest"], earliest, latest)
+			}
+			if estimate["lead_time_days"] != tt.wantLeadDays || estimate["estimated"] != tt.wantEstimated {
+				t.Errorf("estimate = %v", estimate)
+			}
+		})
+	}
+}
+
+func TestSetItemDeliveryDate(t *testing.T) {
+	today := time.Now().UTC()
+	day := func(offset int) string { return today.AddDate(0, 0, offset).Format("2006-01-02") }
+
+	tests := []struct {
+		name       string
+		date       string
+		wantStatus int
+		wantReason string
+	}{
+		{name: "valid future date", date: day(10), wantStatus: http.StatusOK},
+		{name: "earliest date", date: day(3), wantStatus: http.StatusOK},
+		{name: "past date", date: day(-1), wantStatus: http.StatusUnprocessableEntity, wantReason: models.DeliveryDateReasonPast},
+		{name: "today", date: day(0), wantStatus: http.StatusUnprocessableEntity, wantReason: models.DeliveryDateReasonPast},
+		{name: "within the lead time", date: day(2), wantStatus: http.StatusUnprocessableEntity, wantReason: models.DeliveryDateReasonOutsideWindow},
+		{name: "beyond the window", date: day(31), wantStatus: http.StatusUnprocessableEntity, wantReason: models.DeliveryDateReasonOutsideWindow},
+		{name: "not a date", date: "next tuesday", wantStatus: http.StatusUnprocessableEntity, wantReason: models.DeliveryDateReasonInvalid},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newTestRedis(t)
+			newProductService(t, map[int]gin.H{1: {"name": "Sofa", "price": 500, "lead_time_days": 3, "delivery_window_days": 30}})
+			cartKey := cartKeyFor(testUserID)
+			cart := seedCart(t, cartKey, testItem(1, 500, 1))
+			cart.ShippingAddress = testAddress()
+			storeCart(t, cartKey, cart)
+
+			w := serve(t, SetItemDeliveryDate, testRequest{
+				method: http.MethodPut,
+				route:  "/items/:product_id/delivery-date",
+				target: "/items/1/delivery-date",
+				body:   `{"date": "` + tt.date + `"}`,
+			})
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+
+			stored := storedCart(t, cartKey).Items[0].RequestedDeliveryDate
+			if tt.wantStatus != http.StatusOK {
+				body := decodeResponse(t, w)
+				if body["reason"] != tt.wantReason || body["earliest"] != day(3) || body["latest"] != day(30) {
+					t.Errorf("body = %v, want %s with window %s to %s", body, tt.wantReason, day(3), day(30))
+				}
+				if stored != "" {
+					t.Errorf("rejected date %s was stored", stored)
+				}
+				return
+			}
+			if stored != tt.date {
+				t.Errorf("stored date = %q, want %s", stored, tt.date)
+			}
+
+			// The date is carried into the order payload
+			order := decodeResponse(t, serve(t, GetOrderPayload, testRequest{route: "/order-payload"}))["order"].(map[string]interface{})
+			line := order["line_items"].([]interface{})[0].(map[string]interface{})
+			if line["requested_delivery_date"] != tt.date {
+				t.Errorf("order line = %v, want requested_delivery_date %s", line, tt.date)
+			}
+		})
+	}
+}
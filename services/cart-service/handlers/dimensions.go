@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"cart-service/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetCartDimensions returns the cart's total weight and volume and the
+// weight a carrier would bill for it
+func GetCartDimensions(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	cartKey, _, ok := requestCart(c, userID)
+	if !ok {
+		return
+	}
+
+	cart, err := loadCart(c.Request.Context(), cartKey)
+	if err == errCartCorrupt {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse cart data"})
+		return
+	}
+	if err != nil || len(cart.Items) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Cart is empty", "code": codeCartEmpty})
+		return
+	}
+
+	divisor := utils.GetEnvFloat("VOLUMETRIC_DIVISOR", 5000)
+	c.JSON(http.StatusOK, gin.H{"dimensions": cart.Dimensions(divisor)})
+}
@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestGetCartDimensions(t *testing.T) {
+	products := map[int]gin.H{
+		// Dense: 4kg in a 20x10x10 box
+		1: {"name": "Dumbbell", "price": 30, "quantity": 10, "weight": 4, "dimensions": gin.H{"length": 20, "width": 10, "height": 10}},
+		// Bulky: 1kg in a 50x40x30 box
+		2: {"name": "Lampshade", "price": 25, "quantity": 10, "weight": "1.0", "dimensions": gin.H{"length": 50, "width": 40, "height": 30}},
+		// No measurements
+		3: {"name": "Gift card", "price": 10, "quantity": 10, "weight": 0.01},
+	}
+
+	tests := []struct {
+		name         string
+		adds         map[int]int
+		wantWeight   float64
+		wantVolume   float64
+		wantBillable float64
+		wantMissing  string
+	}{
+		{name: "actual weight billed", adds: map[int]int{1: 2}, wantWeight: 8, wantVolume: 4000, wantBillable: 8, wantMissing: "[]"},
+		{name: "volumetric weight billed", adds: map[int]int{1: 1, 2: 2}, wantWeight: 6, wantVolume: 122000, wantBillable: 24.4, wantMissing: "[]"},
+		{name: "item without dimensions", adds: map[int]int{2: 1, 3: 3}, wantWeight: 1.03, wantVolume: 60000, wantBillable: 12, wantMissing: "[3]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newTestRedis(t)
+			newProductService(t, products)
+			for productID, quantity := range tt.adds {
+				body := fmt.Sprintf(`{"product_id": %d, "quantity": %d}`, productID, quantity)
+				if w := serve(t, AddItem, testRequest{method: http.MethodPost, route: "/items", body: body}); w.Code != http.StatusOK {
+					t.Fatalf("add status = %d: %s", w.Code, w.Body)
+				}
+			}
+
+			w := serve(t, GetCartDimensions, testRequest{route: "/dimensions"})
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d: %s", w.Code, w.Body)
+			}
+			dims := decodeResponse(t, w)["dimensions"].(map[string]interface{})
+			if dims["total_weight"] != tt.wantWeight || dims["total_volume"] != tt.wantVolume || dims["billable_weight"] != tt.wantBillable {
+				t.Errorf("dimensions = %v, want weight %v, volume %v, billable %v", dims, tt.wantWeight, tt.wantVolume, tt.wantBillable)
+			}
+			if missing := fmt.Sprint(dims["missing_dimensions"]); missing != tt.wantMissing {
+				t.Errorf("missing_dimensions = %s, want %s", missing, tt.wantMissing)
+			}
+		})
+	}
+}
+
+func TestGetCartDimensionsEmptyCart(t *testing.T) {
+	newTestRedis(t)
+
+	if w := serve(t, GetCartDimensions, testRequest{route: "/dimensions"}); w.Code != http.StatusNotFound {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+}
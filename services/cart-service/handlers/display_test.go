@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"cart-service/models"
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+func TestPriceDisplay(t *testing.T) {
+	if err := models.SetTaxRates(0.2, ""); err != nil {
+		t.Fatalf("SetTaxRates: %v", err)
+	}
+	if err := models.SetInclusiveCountries(""); err != nil {
+		t.Fatalf("SetInclusiveCountries: %v", err)
+	}
+	t.Cleanup(func() {
+		models.SetTaxRates(0, "")
+		models.SetPriceDisplay("")
+	})
+
+	tests := []struct {
+		name         string
+		display      string
+		byRegion     string
+		country      string
+		wantDisplay  string
+		wantUnit     float64
+		wantSubtotal float64
+		wantTotal    float64
+	}{
+		{name: "exclusive", display: "exclusive", country: "US", wantDisplay: "exclusive", wantUnit: 10, wantSubtotal: 20, wantTotal: 20},
+		{name: "inclusive", display: "inclusive", country: "US", wantDisplay: "inclusive", wantUnit: 12, wantSubtotal: 24, wantTotal: 24},
+		{name: "by region in the EU", byRegion: "true", country: "DE", wantDisplay: "inclusive", wantUnit: 12, wantSubtotal: 24, wantTotal: 24},
+		{name: "by region elsewhere", display: "inclusive", byRegion: "true", country: "US", wantDisplay: "exclusive", wantUnit: 10, wantSubtotal: 20, wantTotal: 20},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := models.SetPriceDisplay(tt.display); err != nil {
+				t.Fatalf("SetPriceDisplay: %v", err)
+			}
+			t.Setenv("PRICE_DISPLAY_BY_REGION", tt.byRegion)
+			newTestRedis(t)
+			cartKey := cartKeyFor(testUserID)
+			cart := models.NewCart(testUserID)
+			cart.Items = []models.CartItem{testItem(1, 10, 2)}
+			cart.ShippingAddress = testAddress()
+			cart.ShippingAddress.Country = tt.country
+			storeCart(t, cartKey, cart)
+
+			w := serve(t, GetCart, testRequest{route: "/"})
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d: %s", w.Code, w.Body)
+			}
+			var body struct {
+				Prices models.PriceView `json:"prices"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatalf("response is not JSON: %v", err)
+			}
+			view := body.Prices
+			if view.Display != tt.wantDisplay {
+				t.Errorf("display = %s, want %s", view.Display, tt.wantDisplay)
+			}
+			// Net and gross are both derived whatever the display
+			if view.Net != 20 || view.Tax != 4 || view.Gross != 24 {
+				t.Errorf("net = %v, tax = %v, gross = %v; want 20, 4, 24", view.Net, view.Tax, view.Gross)
+			}
+			line := view.Items[0]
+			if line.UnitNet != 10 || line.UnitGross != 12 || line.SubtotalNet != 20 || line.SubtotalGross != 24 {
+				t.Errorf("item net/gross = %+v", line)
+			}
+			if line.UnitPrice != tt.wantUnit || line.Subtotal != tt.wantSubtotal || view.Total != tt.wantTotal {
+				t.Errorf("displayed unit %v, subtotal %v, total %v; want %v, %v, %v",
+					line.UnitPrice, line.Subtotal, view.Total, tt.wantUnit, tt.wantSubtotal, tt.wantTotal)
+			}
+			// Only the canonical tax-exclusive price is stored
+			if stored := storedCart(t, cartKey); stored.Items[0].Price != 10 {
+				t.Errorf("stored price = %v, want 10", stored.Items[0].Price)
+			}
+		})
+	}
+}
+
+func TestPriceDisplayRegionDetection(t *testing.T) {
+	if err := models.SetInclusiveCountries(""); err != nil {
+		t.Fatalf("SetInclusiveCountries: %v", err)
+	}
+	t.Cleanup(func() { models.SetPriceDisplay("") })
+
+	tests := []struct {
+		name        string
+		address     string
+		headers     map[string]string
+		geoHeader   string
+		wantDisplay string
+	}{
+		{name: "EU shipping address", address: "FR", wantDisplay: models.PriceDisplayInclusive},
+		{name: "US shipping address", address: "US", wantDisplay: models.PriceDisplayExclusive},
+		{name: "address beats headers", address: "US", headers: map[string]string{"CF-IPCountry": "DE"}, wantDisplay: models.PriceDisplayExclusive},
+		{name: "EU geo header", headers: map[string]string{"CF-IPCountry": "DE", "Accept-Language": "en-US"}, wantDisplay: models.PriceDisplayInclusive},
+		{name: "US geo header", headers: map[string]string{"CF-IPCountry": "US", "Accept-Language": "de-DE"}, wantDisplay: models.PriceDisplayExclusive},
+		{name: "configured geo header", geoHeader: "X-Country", headers: map[string]string{"X-Country": "AT"}, wantDisplay: models.PriceDisplayInclusive},
+		{name: "EU Accept-Language region", headers: map[string]string{"Accept-Language": "de-AT,de;q=0.9"}, wantDisplay: models.PriceDisplayInclusive},
+		{name: "US Accept-Language region", headers: map[string]string{"Accept-Language": "en-US,en;q=0.8"}, wantDisplay: models.PriceDisplayExclusive},
+		{name: "no country falls back to the default", headers: map[string]string{"Accept-Language": "de"}, wantDisplay: models.PriceDisplayInclusive},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newTestRedis(t)
+			if err := models.SetPriceDisplay(models.PriceDisplayInclusive); err != nil {
+				t.Fatalf("SetPriceDisplay: %v", err)
+			}
+			t.Setenv("PRICE_DISPLAY_BY_REGION", "true")
+			t.Setenv("GEO_COUNTRY_HEADER", tt.geoHeader)
+			cart := models.NewCart(testUserID)
+			cart.Items = []models.CartItem{testItem(1, 10, 1)}
+			if tt.address != "" {
+				cart.ShippingAddress = testAddress()
+				cart.ShippingAddress.Country = tt.address
+			}
+			storeCart(t, cartKeyFor(testUserID), cart)
+
+			w := serve(t, GetCart, testRequest{route: "/", headers: tt.headers})
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d: %s", w.Code, w.Body)
+			}
+			prices := decodeResponse(t, w)["prices"].(map[string]interface{})
+			if prices["display"] != tt.wantDisplay {
+				t.Errorf("display = %v, want %s", prices["display"], tt.wantDisplay)
+			}
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"cart-service/models"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetRoundupDonation adds a charity donation to the cart: the amount needed
+// to round the checkout total up to the next whole unit, or a fixed amount
+// if one is given. The round-up follows the total as the cart changes.
+func SetRoundupDonation(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	// The body is optional; without one the total is rounded up
+	var req models.RoundupRequest
+	if err := c.ShouldBindJSON(&req); err != nil && err != io.EOF {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	donation := &models.Donation{RoundUp: true}
+	if req.Amount != nil {
+		donation = &models.Donation{Amount: models.RoundPrice(*req.Amount)}
+	}
+	updateDonation(c, userID, donation, "Donation added")
+}
+
+// RemoveRoundupDonation removes the cart's donation
+func RemoveRoundupDonation(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	updateDonation(c, userID, nil, "Donation removed")
+}
+
+// updateDonation stores the donation on the cart and writes the resulting
+// checkout total
+func updateDonation(c *gin.Context, userID interface{}, donation *models.Donation, message string) {
+	cartKey, _, ok := requestCart(c, userID)
+	if !ok {
+		return
+	}
+	lock, ok := lockCart(c, cartKey)
+	if !ok {
+		return
+	}
+	defer lock.Release(c.Request.Context())
+	if !ensureNotFrozen(c, cartKey) {
+		return
+	}
+
+	cart, err := loadCart(c.Request.Context(), cartKey)
+	if err == errCartCorrupt {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse cart data"})
+		return
+	}
+	if err != nil || len(cart.Items) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Cart is empty", "code": codeCartEmpty})
+		return
+	}
+
+	cart.Donation = donation
+	cart.CalculateTotals()
+
+	if err := saveCart(c.Request.Context(), cartKey, cart); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save cart"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  message,
+		"checkout": cart.CheckoutTotal(shippingPolicy()),
+	})
+}
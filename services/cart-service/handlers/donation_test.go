@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"cart-service/models"
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+func TestRoundupDonation(t *testing.T) {
+	tests := []struct {
+		name         string
+		price        float64
+		body         string
+		wantDonation float64
+		wantDue      float64
+	}{
+		{name: "round up a fractional total", price: 42.30, wantDonation: 0.70, wantDue: 43},
+		{name: "whole total needs no round-up", price: 42, wantDonation: 0, wantDue: 42},
+		{name: "fixed donation", price: 42.30, body: `{"amount": 2}`, wantDonation: 2, wantDue: 44.30},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newTestRedis(t)
+			t.Setenv("SHIPPING_FLAT_RATE", "0")
+			cartKey := cartKeyFor(testUserID)
+			seedCart(t, cartKey, testItem(1, tt.price, 1))
+
+			w := serve(t, SetRoundupDonation, testRequest{method: http.MethodPost, route: "/roundup", body: tt.body})
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d: %s", w.Code, w.Body)
+			}
+			var body struct {
+				Checkout models.CheckoutTotal `json:"checkout"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatalf("response is not JSON: %v", err)
+			}
+			if body.Checkout.Donation != tt.wantDonation || body.Checkout.AmountDue != tt.wantDue {
+				t.Errorf("donation = %v, amount due = %v; want %v, %v",
+					body.Checkout.Donation, body.Checkout.AmountDue, tt.wantDonation, tt.wantDue)
+			}
+			if storedCart(t, cartKey).Donation == nil {
+				t.Fatal("donation was not stored")
+			}
+
+			w = serve(t, RemoveRoundupDonation, testRequest{method: http.MethodDelete, route: "/roundup"})
+			if w.Code != http.StatusOK {
+				t.Fatalf("remove status = %d: %s", w.Code, w.Body)
+			}
+			if cart := storedCart(t, cartKey); cart.Donation != nil {
+				t.Errorf("donation %+v still on the cart", cart.Donation)
+			}
+		})
+	}
+}
+
+func TestRoundupDonationEmptyCart(t *testing.T) {
+	newTestRedis(t)
+
+	w := serve(t, SetRoundupDonation, testRequest{method: http.MethodPost, route: "/roundup"})
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+}
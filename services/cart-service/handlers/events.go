@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"cart-service/models"
+	"cart-service/utils"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// eventCartUpdated is published whenever a cart is saved
+const eventCartUpdated = "cart.updated"
+
+// publishCartUpdated announces a saved cart to the user's event
+// subscribers. Failures are logged; they never fail the save.
+func publishCartUpdated(ctx context.Context, cart *models.Cart) {
+	data := gin.H{
+		"name":        cart.Name,
+		"version":     cart.Version,
+		"total_items": cart.TotalItems,
+		"final_price": cart.FinalPrice,
+	}
+	if err := utils.PublishEvent(ctx, eventCartUpdated, cart.UserID, data); err != nil {
+		log.Printf("Failed to publish %s event: %v", eventCartUpdated, err)
+	}
+}
+
+// StreamCartEvents streams the user's cart events as server-sent events
+// until the client disconnects, with periodic heartbeat comments to keep
+// proxies from closing an idle connection
+func StreamCartEvents(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	pubsub := utils.RedisClient.Subscribe(ctx, utils.EventsChannel(fmt.Sprintf("%v", userID)))
+	defer pubsub.Close()
+
+	// Wait for the subscription so no event published after the response
+	// starts is missed
+	if _, err := pubsub.Receive(ctx); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to subscribe to cart events"})
+		return
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(time.Duration(utils.GetEnvInt("SSE_HEARTBEAT_SECONDS", 15)) * time.Second)
+	defer heartbeat.Stop()
+	messages := pubsub.Channel()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-heartbeat.C:
+			fmt.Fprint(c.Writer, ": heartbeat\n\n")
+		case msg, ok := <-messages:
+			if !ok {
+				return
+			}
+			var event utils.Event
+			if err := json.Unmarshal([]byte(msg.Payload), &event); err != nil {
+				continue
+			}
+			fmt.Fprintf(c.Writer, "event: %s\ndata: %s\n\n", event.Type, msg.Payload)
+		}
+		c.Writer.Flush()
+	}
+}
@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestStreamCartEvents(t *testing.T) {
+	newTestRedis(t)
+	cartKey := cartKeyFor(testUserID)
+	seedCart(t, cartKey, testItem(1, 10, 1))
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) { c.Set("user_id", testUserID) })
+	router.GET("/api/cart/events", StreamCartEvents)
+	server := httptest.NewServer(router)
+	t.Cleanup(server.Close)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/api/cart/events", nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("failed to open stream: %v", err)
+	}
+	defer resp.Body.Close()
+	if got := resp.Header.Get("Content-Type"); got != "text/event-stream" {
+		t.Fatalf("Content-Type = %q, want text/event-stream", got)
+	}
+
+	// The headers are only sent once the subscription is live
+	w := serve(t, UpdateItem, testRequest{
+		method: http.MethodPut,
+		route:  "/items/:product_id",
+		target: "/items/1",
+		body:   `{"quantity": 3}`,
+	})
+	if w.Code != http.StatusOK {
+		t.Fatalf("update status = %d: %s", w.Code, w.Body)
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	var event, data string
+	for scanner.Scan() && data == "" {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "event: "):
+			event = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			data = strings.TrimPrefix(line, "data: ")
+		}
+	}
+	if event != eventCartUpdated {
+		t.Fatalf("event = %q, want %s (stream error: %v)", event, eventCartUpdated, scanner.Err())
+	}
+	if !strings.Contains(data, `"total_items":3`) {
+		t.Errorf("data = %s, want the updated cart", data)
+	}
+}
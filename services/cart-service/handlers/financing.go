@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"cart-service/models"
+	"cart-service/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetFinancingOptions returns the installment plans available for the
+// cart's amount due. Carts below FINANCING_MIN_TOTAL get no options.
+func GetFinancingOptions(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	cartKey, name, ok := requestCart(c, userID)
+	if !ok {
+		return
+	}
+
+	cart, err := loadCart(c.Request.Context(), cartKey)
+	if err == errCartCorrupt {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse cart data"})
+		return
+	}
+	if err != nil {
+		cart = newCart(userID, name)
+	}
+
+	amount := cart.CheckoutTotal(shippingPolicy()).AmountDue
+	minimum := utils.GetEnvFloat("FINANCING_MIN_TOTAL", 100)
+	c.JSON(http.StatusOK, gin.H{
+		"amount":        amount,
+		"minimum_total": minimum,
+		"currency":      cart.Currency,
+		"options":       models.FinancingOptions(amount, minimum),
+	})
+}
@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"cart-service/models"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestGetFinancingOptions(t *testing.T) {
+	tests := []struct {
+		name        string
+		items       []models.CartItem
+		wantAmount  float64
+		wantOptions []models.FinancingOption
+	}{
+		{
+			name:       "qualifying total",
+			items:      []models.CartItem{testItem(1, 600, 2)},
+			wantAmount: 1200,
+			wantOptions: []models.FinancingOption{
+				{Months: 3, APR: 0, Installment: 400, TotalCost: 1200, Interest: 0},
+				{Months: 12, APR: 12, Installment: 106.62, TotalCost: 1279.44, Interest: 79.44},
+			},
+		},
+		{
+			name:        "below the minimum",
+			items:       []models.CartItem{testItem(1, 20, 2)},
+			wantAmount:  45.99,
+			wantOptions: []models.FinancingOption{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := models.SetFinancingPlans("12:12,3:0"); err != nil {
+				t.Fatalf("SetFinancingPlans: %v", err)
+			}
+			t.Cleanup(func() { models.SetFinancingPlans("") })
+			t.Setenv("FINANCING_MIN_TOTAL", "100")
+			newTestRedis(t)
+			seedCart(t, cartKeyFor(testUserID), tt.items...)
+
+			w := serve(t, GetFinancingOptions, testRequest{route: "/financing"})
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d: %s", w.Code, w.Body)
+			}
+			var body struct {
+				Amount  float64                  `json:"amount"`
+				Options []models.FinancingOption `json:"options"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatalf("response is not JSON: %v", err)
+			}
+			if body.Amount != tt.wantAmount {
+				t.Errorf("amount = %v, want %v", body.Amount, tt.wantAmount)
+			}
+			if !reflect.DeepEqual(body.Options, tt.wantOptions) {
+				t.Errorf("options = %+v, want %+v", body.Options, tt.wantOptions)
+			}
+		})
+	}
+}
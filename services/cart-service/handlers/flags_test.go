@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"cart-service/middleware"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newFlaggedRouter mounts the item handlers behind the FeatureFlags
+// middleware, authenticated as the test user
+func newFlaggedRouter() *gin.Engine {
+	router := gin.New()
+	api := router.Group("/api/cart")
+	api.Use(func(c *gin.Context) { c.Set("user_id", testUserID) })
+	api.Use(middleware.FeatureFlags())
+	api.POST("/items", AddItem)
+	api.PUT("/items/:product_id", UpdateItem)
+	return router
+}
+
+func TestFeatureFlagHeader(t *testing.T) {
+	tests := []struct {
+		name           string
+		env            map[string]string
+		method         string
+		path           string
+		body           string
+		header         string
+		wantWithHeader int
+		wantWithout    int
+	}{
+		{
+			name:           "turns a default on behavior off",
+			method:         http.MethodPut,
+			path:           "/api/cart/items/1",
+			body:           `{"quantity": 0}`,
+			header:         "zero_qty_removes=false",
+			wantWithHeader: http.StatusBadRequest,
+			wantWithout:    http.StatusOK,
+		},
+		{
+			name:           "turns a default off behavior on",
+			env:            map[string]string{"CART_ZERO_QTY_REMOVES": "false"},
+			method:         http.MethodPut,
+			path:           "/api/cart/items/1",
+			body:           `{"quantity": 0}`,
+			header:         "zero_qty_removes",
+			wantWithHeader: http.StatusOK,
+			wantWithout:    http.StatusBadRequest,
+		},
+		{
+			name:           "operator-only flag cannot be turned on",
+			method:         http.MethodPost,
+			path:           "/api/cart/items",
+			body:           `{"product_id": 5}`,
+			header:         "degraded_add",
+			wantWithHeader: http.StatusBadGateway,
+			wantWithout:    http.StatusBadGateway,
+		},
+		{
+			name:           "operator-only flag cannot be turned off",
+			env:            map[string]string{"ALLOW_DEGRADED_ADD": "true"},
+			method:         http.MethodPost,
+			path:           "/api/cart/items",
+			body:           `{"product_id": 5}`,
+			header:         "degraded_add=false",
+			wantWithHeader: http.StatusOK,
+			wantWithout:    http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newTestRedis(t)
+			t.Setenv("FEATURE_FLAGS_SERVICE_URL", "")
+			for name, value := range tt.env {
+				t.Setenv(name, value)
+			}
+			newJSONService(t, "PRODUCT_SERVICE_URL", func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusInternalServerError)
+			})
+			router := newFlaggedRouter()
+
+			// The header only applies to the request carrying it
+			for _, header := range []string{tt.header, ""} {
+				seedCart(t, cartKeyFor(testUserID), testItem(1, 5, 2))
+				r := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+				r.Header.Set("Content-Type", "application/json")
+				want := tt.wantWithout
+				if header != "" {
+					r.Header.Set("X-Feature-Flags", header)
+					want = tt.wantWithHeader
+				}
+				w := httptest.NewRecorder()
+				router.ServeHTTP(w, r)
+				if w.Code != want {
+					t.Errorf("X-Feature-Flags %q: status = %d, want %d: %s", header, w.Code, want, w.Body)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,159 @@
+package handlers
+
+import (
+	"cart-service/models"
+	"cart-service/utils"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// FlashSaleAddItem adds a flash-sale product to the cart, reserving units
+// from the sale's limited stock so only reserved units get the promo price.
+// Once the stock runs out the item is still added, at the regular price and
+// flagged as sold out. Reserved units are held for the cart for
+// FLASH_HOLD_MINUTES, after which they are released back to the sale.
+func FlashSaleAddItem(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req models.AddItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	quantity := req.QuantityOrDefault(utils.GetEnvInt("CART_DEFAULT_QUANTITY", 1))
+
+	product, err := utils.FetchProduct(c.Request.Context(), req.ProductID)
+	if err == utils.ErrProductNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to fetch product %d: %v", req.ProductID, err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to fetch product details"})
+		return
+	}
+
+	promo, err := utils.FetchPromotion(c.Request.Context(), req.ProductID)
+	if err != nil {
+		log.Printf("Failed to fetch promotion for product %d: %v", req.ProductID, err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to fetch promotion details"})
+		return
+	}
+	if promo == nil || promo.FlashStock <= 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Product is not in a flash sale"})
+		return
+	}
+
+	cartKey, name, ok := requestCart(c, userID)
+	if !ok {
+		return
+	}
+	lock, ok := lockCart(c, cartKey)
+	if !ok {
+		return
+	}
+	defer lock.Release(c.Request.Context())
+	if !ensureNotFrozen(c, cartKey) {
+		return
+	}
+
+	cart, err := loadCart(c.Request.Context(), cartKey)
+	if err == errCartCorrupt {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse cart data"})
+		return
+	}
+	creating := err != nil
+	if creating {
+		cart = newCart(userID, name)
+	}
+
+	if shedUnderMemoryPressure(c, creating, quantity) {
+		return
+	}
+	if !withinCartLimit(c, userID, name, creating) {
+		return
+	}
+
+	// Validate the item and its daily quota before touching the sale's stock
+	if !stageItem(c, cart, product, quantity, false, false) {
+		return
+	}
+	item := &cart.Items[cart.FindItem(req.ProductID)]
+	now := time.Now()
+	claimedQuota, ok := claimDailyQuota(c, userID, item, quantity, now)
+	if !ok {
+		return
+	}
+
+	reserved, err := utils.ReserveFlashStock(c.Request.Context(), req.ProductID, quantity, promo.FlashStock)
+	if err != nil {
+		if claimedQuota {
+			releaseDailyQuota(c.Request.Context(), userID, req.ProductID, quantity, now)
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reserve flash-sale stock"})
+		return
+	}
+
+	previous := *item
+	merged := false
+	if reserved {
+		// Units already held join the new hold. If the reaper has claimed
+		// their hold it returns them to the sale, so they're dropped here.
+		if item.FlashReserved > 0 {
+			merged, err = utils.UntrackFlashHold(c.Request.Context(), flashHoldOf(cartKey, previous))
+			if err != nil {
+				log.Printf("Failed to cancel flash-sale hold on product %d: %v", req.ProductID, err)
+			}
+			if !merged {
+				item.TrimHold(0)
+			}
+		}
+		// A new hold starts the countdown; more units join the current one
+		if item.FlashReserved == 0 || item.HoldExpiredAt(now) {
+			item.ReservedUntil = now.Add(flashHoldDuration()).Format(time.RFC3339)
+		}
+		item.FlashReserved += quantity
+		item.PromoLimit = item.FlashReserved
+		item.HoldExpired = false
+	} else {
+		item.FlashSoldOut = true
+	}
+	cart.CalculateTotals()
+
+	if err := saveCart(c.Request.Context(), cartKey, cart); err != nil {
+		if claimedQuota {
+			releaseDailyQuota(c.Request.Context(), userID, req.ProductID, quantity, now)
+		}
+		if reserved {
+			if err := utils.ReleaseFlashStock(c.Request.Context(), req.ProductID, quantity); err != nil {
+				log.Printf("Failed to release flash-sale stock for product %d: %v", req.ProductID, err)
+			}
+			if merged {
+				trackFlashHold(c.Request.Context(), cartKey, previous)
+			}
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save cart to Redis"})
+		return
+	}
+
+	if reserved {
+		trackFlashHold(c.Request.Context(), cartKey, *item)
+	}
+
+	message := "Item added to cart at the flash-sale price"
+	if !reserved {
+		message = "Flash sale sold out; item added to cart at the regular price"
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": message,
+		"cart":    cart,
+	})
+}
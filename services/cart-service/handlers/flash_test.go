@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"cart-service/utils"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newFlashSale serves product 1 at 100, on flash sale at 60 for stock units
+func newFlashSale(t *testing.T, stock int) {
+	t.Helper()
+	newProductService(t, map[int]gin.H{
+		1: {"name": "Console", "price": 100, "quantity": 1000},
+		2: {"name": "Cable", "price": 10, "quantity": 1000},
+	})
+	newPromotionsService(t, promotionsFixture{promotions: map[int]gin.H{
+		1: {"product_id": 1, "promo_price": 60, "flash_stock": stock},
+	}})
+}
+
+func flashAdd(t *testing.T, userID, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	return serve(t, FlashSaleAddItem, testRequest{method: http.MethodPost, route: "/flash-sale/items", body: body, userID: userID})
+}
+
+func TestFlashSaleConcurrentAdds(t *testing.T) {
+	newTestRedis(t)
+	newFlashSale(t, 3)
+
+	const shoppers = 8
+	var wg sync.WaitGroup
+	for i := 1; i <= shoppers; i++ {
+		wg.Add(1)
+		go func(userID string) {
+			defer wg.Done()
+			if w := flashAdd(t, userID, `{"product_id": 1, "quantity": 1}`); w.Code != http.StatusOK {
+				t.Errorf("user %s: status = %d: %s", userID, w.Code, w.Body)
+			}
+		}(strconv.Itoa(i))
+	}
+	wg.Wait()
+
+	promo, regular := 0, 0
+	for i := 1; i <= shoppers; i++ {
+		cart := storedCart(t, cartKeyFor(i))
+		item := cart.Items[0]
+		switch {
+		case item.FlashReserved == 1 && cart.TotalPrice == 60 && !item.FlashSoldOut:
+			promo++
+		case item.FlashReserved == 0 && cart.TotalPrice == 100 && item.FlashSoldOut:
+			regular++
+		default:
+			t.Errorf("user %d: unexpected item %+v totalling %v", i, item, cart.TotalPrice)
+		}
+	}
+	if promo != 3 || regular != shoppers-3 {
+		t.Errorf("%d carts got the promo price and %d the regular price, want 3 and %d", promo, regular, shoppers-3)
+	}
+	if stock := utils.RedisClient.Get(utils.Ctx, utils.Key("flash_stock", "1")).Val(); stock != "0" {
+		t.Errorf("flash stock left = %s, want 0", stock)
+	}
+}
+
+func TestFlashSaleAddItem(t *testing.T) {
+	tests := []struct {
+		name         string
+		body         string
+		wantStatus   int
+		wantReserved int
+		wantSoldOut  bool
+		wantTotal    float64
+	}{
+		{name: "within stock", body: `{"product_id": 1, "quantity": 2}`, wantStatus: http.StatusOK, wantReserved: 2, wantTotal: 120},
+		{name: "more than stock", body: `{"product_id": 1, "quantity": 3}`, wantStatus: http.StatusOK, wantSoldOut: true, wantTotal: 300},
+		{name: "not on flash sale", body: `{"product_id": 2}`, wantStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newTestRedis(t)
+			newFlashSale(t, 2)
+
+			w := flashAdd(t, testUserID, tt.body)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			cart := storedCart(t, cartKeyFor(testUserID))
+			item := cart.Items[0]
+			if item.FlashReserved != tt.wantReserved || item.FlashSoldOut != tt.wantSoldOut || cart.TotalPrice != tt.wantTotal {
+				t.Errorf("item = %+v totalling %v", item, cart.TotalPrice)
+			}
+			if tt.wantReserved > 0 && item.ReservedUntil == "" {
+				t.Error("reserved units have no hold expiry")
+			}
+		})
+	}
+}
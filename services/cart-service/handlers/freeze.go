@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"cart-service/utils"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// frozenKeyFor builds the Redis key flagging a cart as frozen
+func frozenKeyFor(cartKey string) string {
+	return utils.Key("frozen", utils.StripKeyPrefix(cartKey))
+}
+
+// cartFreezeTTL returns how long a freeze lasts before it is released
+func cartFreezeTTL() time.Duration {
+	return time.Duration(utils.GetEnvInt("CART_FREEZE_TTL_SECONDS", 300)) * time.Second
+}
+
+// ensureNotFrozen writes a 423 response and returns false if any of the
+// carts is frozen for checkout
+func ensureNotFrozen(c *gin.Context, cartKeys ...string) bool {
+	frozenKeys := make([]string, len(cartKeys))
+	for i, cartKey := range cartKeys {
+		frozenKeys[i] = frozenKeyFor(cartKey)
+	}
+
+	frozen, err := utils.RedisClient.Exists(c.Request.Context(), frozenKeys...).Result()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check cart status"})
+		return false
+	}
+
+	if frozen > 0 {
+		c.JSON(http.StatusLocked, gin.H{"error": "Cart is locked for checkout"})
+		return false
+	}
+	return true
+}
+
+// FreezeCart locks the cart as read-only while checkout finalizes the order
+func FreezeCart(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	cartKey, _, ok := requestCart(c, userID)
+	if !ok {
+		return
+	}
+
+	ttl := cartFreezeTTL()
+	err := utils.RedisClient.Set(c.Request.Context(), frozenKeyFor(cartKey), "1", ttl).Err()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to freeze cart"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":      "Cart frozen",
+		"frozen":       true,
+		"frozen_until": time.Now().Add(ttl).Format(time.RFC3339),
+	})
+}
+
+// UnfreezeCart releases a checkout lock on the cart
+func UnfreezeCart(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	cartKey, _, ok := requestCart(c, userID)
+	if !ok {
+		return
+	}
+
+	err := utils.RedisClient.Del(c.Request.Context(), frozenKeyFor(cartKey)).Err()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unfreeze cart"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Cart unfrozen",
+		"frozen":  false,
+	})
+}
@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"cart-service/models"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestFrozenCartRejectsMutations(t *testing.T) {
+	mutations := []struct {
+		name    string
+		handler gin.HandlerFunc
+		req     testRequest
+	}{
+		{
+			name:    "update item",
+			handler: UpdateItem,
+			req:     testRequest{method: http.MethodPut, route: "/items/:product_id", target: "/items/1", body: `{"quantity": 3}`},
+		},
+		{
+			name:    "adjust item",
+			handler: AdjustItem,
+			req:     testRequest{method: http.MethodPost, route: "/items/:product_id/adjust", target: "/items/1/adjust", body: `{"delta": 1}`},
+		},
+		{
+			name:    "remove item",
+			handler: RemoveItem,
+			req:     testRequest{method: http.MethodDelete, route: "/items/:product_id", target: "/items/1"},
+		},
+		{
+			name:    "clear cart",
+			handler: ClearCart,
+			req:     testRequest{method: http.MethodDelete, route: "/"},
+		},
+		{
+			name:    "set metadata",
+			handler: SetCartMetadata,
+			req:     testRequest{method: http.MethodPut, route: "/metadata", body: `{"metadata": {"po_number": "PO-2"}}`},
+		},
+		{
+			name:    "delete metadata",
+			handler: DeleteCartMetadata,
+			req:     testRequest{method: http.MethodDelete, route: "/metadata/:key", target: "/metadata/po_number"},
+		},
+	}
+
+	for _, tt := range mutations {
+		t.Run(tt.name, func(t *testing.T) {
+			newTestRedis(t)
+			cartKey := cartKeyFor(testUserID)
+			cart := models.NewCart(testUserID)
+			cart.Items = []models.CartItem{testItem(1, 10, 2)}
+			cart.Metadata = map[string]string{"po_number": "PO-1"}
+			storeCart(t, cartKey, cart)
+
+			if w := serve(t, FreezeCart, testRequest{method: http.MethodPost, route: "/freeze"}); w.Code != http.StatusOK {
+				t.Fatalf("freeze status = %d: %s", w.Code, w.Body)
+			}
+			if w := serve(t, tt.handler, tt.req); w.Code != http.StatusLocked {
+				t.Fatalf("status while frozen = %d, want %d: %s", w.Code, http.StatusLocked, w.Body)
+			}
+			if cart := storedCart(t, cartKey); cart.Items[0].Quantity != 2 || cart.Metadata["po_number"] != "PO-1" {
+				t.Errorf("frozen cart was modified: quantity %d, metadata %v", cart.Items[0].Quantity, cart.Metadata)
+			}
+
+			if w := serve(t, UnfreezeCart, testRequest{method: http.MethodPost, route: "/unfreeze"}); w.Code != http.StatusOK {
+				t.Fatalf("unfreeze status = %d: %s", w.Code, w.Body)
+			}
+			if w := serve(t, tt.handler, tt.req); w.Code != http.StatusOK {
+				t.Errorf("status after unfreeze = %d, want %d: %s", w.Code, http.StatusOK, w.Body)
+			}
+		})
+	}
+}
+
+func TestFreezeExpires(t *testing.T) {
+	mr := newTestRedis(t)
+	t.Setenv("CART_FREEZE_TTL_SECONDS", "60")
+	seedCart(t, cartKeyFor(testUserID), testItem(1, 10, 2))
+
+	serve(t, FreezeCart, testRequest{method: http.MethodPost, route: "/freeze"})
+	mr.FastForward(61 * time.Second)
+
+	w := serve(t, UpdateItem, testRequest{
+		method: http.MethodPut,
+		route:  "/items/:product_id",
+		target: "/items/1",
+		body:   `{"quantity": 3}`,
+	})
+	if w.Code != http.StatusOK {
+		t.Errorf("status after freeze expired = %d, want %d: %s", w.Code, http.StatusOK, w.Body)
+	}
+}
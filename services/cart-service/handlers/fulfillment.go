@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetCartByFulfillment returns the cart's items grouped by how they are
+// fulfilled, with each group's totals, since shipping and tax differ by
+// method
+func GetCartByFulfillment(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	cartKey, name, ok := requestCart(c, userID)
+	if !ok {
+		return
+	}
+
+	cart, err := loadCart(c.Request.Context(), cartKey)
+	if err == errCartCorrupt {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse cart data"})
+		return
+	}
+	if err != nil {
+		cart = newCart(userID, name)
+	}
+
+	cart.SortItems()
+	c.JSON(http.StatusOK, gin.H{
+		"groups":      cart.ItemsByFulfillment(),
+		"total_price": cart.TotalPrice,
+	})
+}
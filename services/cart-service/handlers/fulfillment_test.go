@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"cart-service/models"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestGetCartByFulfillment(t *testing.T) {
+	tests := []struct {
+		name       string
+		adds       []string
+		wantGroups []string
+		wantItems  []int
+		wantTotals []float64
+	}{
+		{
+			name:       "ship, pickup and digital items",
+			adds:       []string{`{"product_id": 4}`, `{"product_id": 3}`, `{"product_id": 2, "quantity": 2}`, `{"product_id": 1}`},
+			wantGroups: []string{models.FulfillmentShip, models.FulfillmentPickup, models.FulfillmentDigital},
+			wantItems:  []int{2, 1, 1},
+			wantTotals: []float64{20 + 5, 2 * 8, 15},
+		},
+		{
+			name:       "digital only",
+			adds:       []string{`{"product_id": 4}`},
+			wantGroups: []string{models.FulfillmentDigital},
+			wantItems:  []int{1},
+			wantTotals: []float64{15},
+		},
+		{
+			name:       "empty cart",
+			wantGroups: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newTestRedis(t)
+			newProductService(t, map[int]gin.H{
+				1: {"name": "Tent", "price": 20, "quantity": 10, "fulfillment_type": "ship"},
+				2: {"name": "Firewood", "price": 8, "quantity": 10, "fulfillment_type": "pickup"},
+				3: {"name": "Stakes", "price": 5, "quantity": 10},
+				4: {"name": "Trail guide", "price": 15, "quantity": 10, "fulfillment_type": "digital"},
+			})
+			for _, body := range tt.adds {
+				if w := serve(t, AddItem, testRequest{method: http.MethodPost, route: "/items", body: body}); w.Code != http.StatusOK {
+					t.Fatalf("add status = %d: %s", w.Code, w.Body)
+				}
+			}
+
+			w := serve(t, GetCartByFulfillment, testRequest{route: "/by-fulfillment"})
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d: %s", w.Code, w.Body)
+			}
+			var body struct {
+				Groups []models.FulfillmentGroup `json:"groups"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatalf("response is not JSON: %v", err)
+			}
+
+			groups, items, totals := []string{}, []int{}, []float64{}
+			for _, group := range body.Groups {
+				groups = append(groups, group.FulfillmentType)
+				items = append(items, len(group.Items))
+				totals = append(totals, group.Subtotal)
+			}
+			if fmt.Sprint(groups) != fmt.Sprint(tt.wantGroups) {
+				t.Errorf("groups = %v, want %v", groups, tt.wantGroups)
+			}
+			if fmt.Sprint(items) != fmt.Sprint(tt.wantItems) {
+				t.Errorf("items per group = %v, want %v", items, tt.wantItems)
+			}
+			if fmt.Sprint(totals) != fmt.Sprint(tt.wantTotals) {
+				t.Errorf("group subtotals = %v, want %v", totals, tt.wantTotals)
+			}
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"cart-service/models"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestGiftPromotionThreshold(t *testing.T) {
+	newTestRedis(t)
+	newProductService(t, map[int]gin.H{1: {"name": "Tea", "price": 30, "quantity": 100}})
+	newPromotionsService(t, promotionsFixture{gifts: []models.GiftPromotion{
+		{ID: "tote", ProductID: 99, ProductName: "Tote bag", MinOrderValue: 50},
+	}})
+	cartKey := cartKeyFor(testUserID)
+
+	steps := []struct {
+		name      string
+		handler   gin.HandlerFunc
+		req       testRequest
+		wantGift  bool
+		wantFinal float64
+	}{
+		{
+			name:      "below the threshold",
+			handler:   AddItem,
+			req:       testRequest{method: http.MethodPost, route: "/items", body: `{"product_id": 1}`},
+			wantFinal: 30,
+		},
+		{
+			name:      "crossing the threshold adds the gift",
+			handler:   AddItem,
+			req:       testRequest{method: http.MethodPost, route: "/items", body: `{"product_id": 1}`},
+			wantGift:  true,
+			wantFinal: 60,
+		},
+		{
+			name:      "dropping below removes the gift",
+			handler:   UpdateItem,
+			req:       testRequest{method: http.MethodPut, route: "/items/:product_id", target: "/items/1", body: `{"quantity": 1}`},
+			wantFinal: 30,
+		},
+		{
+			name:      "crossing again brings it back",
+			handler:   UpdateItem,
+			req:       testRequest{method: http.MethodPut, route: "/items/:product_id", target: "/items/1", body: `{"quantity": 3}`},
+			wantGift:  true,
+			wantFinal: 90,
+		},
+		{
+			name:      "removing the paid item removes the gift",
+			handler:   RemoveItem,
+			req:       testRequest{method: http.MethodDelete, route: "/items/:product_id", target: "/items/1"},
+			wantFinal: 0,
+		},
+	}
+
+	for _, step := range steps {
+		if w := serve(t, step.handler, step.req); w.Code != http.StatusOK {
+			t.Fatalf("%s: status = %d: %s", step.name, w.Code, w.Body)
+		}
+
+		cart := storedCart(t, cartKey)
+		i := cart.FindItem(99)
+		if (i != -1) != step.wantGift {
+			t.Fatalf("%s: gift in cart = %v, want %v", step.name, i != -1, step.wantGift)
+		}
+		if i != -1 {
+			gift := cart.Items[i]
+			if !gift.PromoGift || gift.Price != 0 || gift.Quantity != 1 {
+				t.Errorf("%s: gift line = %+v", step.name, gift)
+			}
+		}
+		if cart.FinalPrice != step.wantFinal {
+			t.Errorf("%s: final price = %v, want %v", step.name, cart.FinalPrice, step.wantFinal)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"cart-service/models"
+	"cart-service/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetGiftWrap turns whole-order gift wrapping on, with an optional message,
+// or off. The order is charged the flat CART_GIFT_WRAP_FEE in effect when
+// wrapping is turned on.
+func SetGiftWrap(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req models.GiftWrapRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	cartKey, _, ok := requestCart(c, userID)
+	if !ok {
+		return
+	}
+	lock, ok := lockCart(c, cartKey)
+	if !ok {
+		return
+	}
+	defer lock.Release(c.Request.Context())
+	if !ensureNotFrozen(c, cartKey) {
+		return
+	}
+
+	cart, err := loadCart(c.Request.Context(), cartKey)
+	if err == errCartCorrupt {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse cart data"})
+		return
+	}
+	if err != nil || len(cart.Items) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Cart is empty", "code": codeCartEmpty})
+		return
+	}
+
+	message := "Gift wrapping removed"
+	cart.GiftWrap = nil
+	if *req.Enabled {
+		cart.GiftWrap = &models.GiftWrap{
+			Message: req.Message,
+			Fee:     utils.GetEnvFloat("CART_GIFT_WRAP_FEE", 4.99),
+		}
+		message = "Gift wrapping added"
+	}
+	cart.CalculateTotals()
+
+	if err := saveCart(c.Request.Context(), cartKey, cart); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save cart"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": message,
+		"cart":    cart,
+	})
+}
@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"cart-service/models"
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+func TestSetGiftWrap(t *testing.T) {
+	tests := []struct {
+		name        string
+		body        string
+		wantWrapped bool
+		wantFee     float64
+		wantDue     float64
+	}{
+		{name: "enable", body: `{"enabled": true, "message": "Happy birthday"}`, wantWrapped: true, wantFee: 3.5, wantDue: 63.5},
+		{name: "disable", body: `{"enabled": false}`, wantDue: 60},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newTestRedis(t)
+			t.Setenv("CART_GIFT_WRAP_FEE", "3.5")
+			cartKey := cartKeyFor(testUserID)
+			cart := models.NewCart(testUserID)
+			cart.Items = []models.CartItem{testItem(1, 20, 3)}
+			cart.ShippingAddress = testAddress()
+			cart.GiftWrap = &models.GiftWrap{Fee: 3.5}
+			storeCart(t, cartKey, cart)
+
+			w := serve(t, SetGiftWrap, testRequest{method: http.MethodPost, route: "/gift-wrap", body: tt.body})
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d: %s", w.Code, w.Body)
+			}
+			stored := storedCart(t, cartKey)
+			if (stored.GiftWrap != nil) != tt.wantWrapped || stored.GiftWrapFee != tt.wantFee {
+				t.Errorf("gift wrap = %+v with fee %v, want wrapped %v with fee %v", stored.GiftWrap, stored.GiftWrapFee, tt.wantWrapped, tt.wantFee)
+			}
+			// The fee is charged once for the order, whatever it holds
+			if stored.FinalPrice != 60 {
+				t.Errorf("final_price = %v, want 60", stored.FinalPrice)
+			}
+
+			w = serve(t, GetOrderPayload, testRequest{route: "/order-payload"})
+			if w.Code != http.StatusOK {
+				t.Fatalf("order payload status = %d: %s", w.Code, w.Body)
+			}
+			var body struct {
+				Order models.OrderPayload `json:"order"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatalf("response is not JSON: %v", err)
+			}
+			totals := body.Order.Totals
+			if totals.GiftWrap != tt.wantFee || totals.Total != tt.wantDue {
+				t.Errorf("order gift_wrap = %v, total = %v; want %v, %v", totals.GiftWrap, totals.Total, tt.wantFee, tt.wantDue)
+			}
+		})
+	}
+}
+
+func TestSetGiftWrapRequiresEnabled(t *testing.T) {
+	newTestRedis(t)
+	seedCart(t, cartKeyFor(testUserID), testItem(1, 20, 1))
+
+	w := serve(t, SetGiftWrap, testRequest{method: http.MethodPost, route: "/gift-wrap", body: `{"message": "Hi"}`})
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+}
@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestHandlingFees(t *testing.T) {
+	tests := []struct {
+		name          string
+		adds          []string
+		wantHandling  float64
+		wantAmountDue float64
+	}{
+		{
+			name:          "fee-bearing and fee-free items",
+			adds:          []string{`{"product_id": 1, "quantity": 2}`, `{"product_id": 2}`, `{"product_id": 3}`},
+			wantHandling:  2*15 + 2.5,
+			wantAmountDue: 200*2 + 10 + 50 + 2*15 + 2.5,
+		},
+		{
+			name:          "fee-free items only",
+			adds:          []string{`{"product_id": 2, "quantity": 3}`},
+			wantHandling:  0,
+			wantAmountDue: 30 + 5.99,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newTestRedis(t)
+			newProductService(t, map[int]gin.H{
+				1: {"name": "Sofa", "price": 200, "quantity": 10, "handling_fee": 15},
+				2: {"name": "Cushion", "price": 10, "quantity": 10},
+				3: {"name": "Lamp oil", "price": 50, "quantity": 10, "handling_fee": "2.50"},
+			})
+			for _, body := range tt.adds {
+				if w := serve(t, AddItem, testRequest{method: http.MethodPost, route: "/items", body: body}); w.Code != http.StatusOK {
+					t.Fatalf("add status = %d: %s", w.Code, w.Body)
+				}
+			}
+
+			if cart := storedCart(t, cartKeyFor(testUserID)); cart.HandlingTotal != tt.wantHandling {
+				t.Errorf("handling_total = %v, want %v", cart.HandlingTotal, tt.wantHandling)
+			}
+
+			w := serve(t, GetCheckoutTotal, testRequest{route: "/checkout-total"})
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d: %s", w.Code, w.Body)
+			}
+			checkout := decodeResponse(t, w)["checkout"].(map[string]interface{})
+			if checkout["handling"] != tt.wantHandling || checkout["amount_due"] != tt.wantAmountDue {
+				t.Errorf("handling = %v, amount_due = %v, want %v and %v", checkout["handling"], checkout["amount_due"], tt.wantHandling, tt.wantAmountDue)
+			}
+		})
+	}
+}
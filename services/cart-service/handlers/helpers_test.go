@@ -0,0 +1,245 @@
+package handlers
+
+import (
+	"cart-service/models"
+	"cart-service/utils"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+)
+
+const testUserID = "42"
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// newTestRedis points utils.RedisClient at an in-memory Redis for the
+// duration of the test
+func newTestRedis(t *testing.T) *miniredis.Miniredis {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	previous := utils.RedisClient
+	utils.RedisClient = redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() {
+		utils.RedisClient.Close()
+		utils.RedisClient = previous
+	})
+	return mr
+}
+
+// newProductService serves products the way product-service does, as
+// {"product": {...}} under /api/products/:id, and points the handlers at it
+func newProductService(t *testing.T, products map[int]gin.H) {
+	t.Helper()
+	newJSONService(t, "PRODUCT_SERVICE_URL", func(w http.ResponseWriter, r *http.Request) {
+		productID, _ := strconv.Atoi(path.Base(r.URL.Path))
+		product, ok := products[productID]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(gin.H{"product": product})
+	})
+}
+
+// promotionsFixture is what the fake promotions service serves
+type promotionsFixture struct {
+	promotions map[int]gin.H
+	bundles    []models.Bundle
+	gifts      []models.GiftPromotion
+	available  *utils.AvailablePromotions
+}
+
+// newPromotionsService serves fixture the way the promotions service does
+func newPromotionsService(t *testing.T, fixture promotionsFixture) {
+	t.Helper()
+	newJSONService(t, "PROMOTIONS_SERVICE_URL", func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/api/promotions/bundles":
+			json.NewEncoder(w).Encode(gin.H{"bundles": fixture.bundles})
+		case r.URL.Path == "/api/promotions/gifts":
+			json.NewEncoder(w).Encode(gin.H{"gifts": fixture.gifts})
+		case r.URL.Path == "/api/promotions/available" && fixture.available != nil:
+			json.NewEncoder(w).Encode(fixture.available)
+		case strings.HasPrefix(r.URL.Path, "/api/promotions/products/"):
+			productID, _ := strconv.Atoi(path.Base(r.URL.Path))
+			promotion, ok := fixture.promotions[productID]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			json.NewEncoder(w).Encode(gin.H{"promotion": promotion})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+}
+
+// newJSONService starts a fake dependency and sets envVar to its URL
+func newJSONService(t *testing.T, envVar string, handler http.HandlerFunc) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	t.Setenv(envVar, server.URL)
+	return server
+}
+
+// testRequest describes one call to a handler. route is the gin route the
+// handler is mounted on and target the URL requested; target defaults to
+// route. userID defaults to testUserID; set anonymous to send none.
+type testRequest struct {
+	method    string
+	route     string
+	target    string
+	body      string
+	headers   map[string]string
+	userID    string
+	role      string
+	anonymous bool
+}
+
+// serve runs handler for a single request, authenticated the way
+// AuthMiddleware would, and records the response
+func serve(t *testing.T, handler gin.HandlerFunc, req testRequest) *httptest.ResponseRecorder {
+	t.Helper()
+	if req.method == "" {
+		req.method = http.MethodGet
+	}
+	if req.target == "" {
+		req.target = req.route
+	}
+	if req.userID == "" {
+		req.userID = testUserID
+	}
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		if req.anonymous {
+			return
+		}
+		c.Set("user_id", req.userID)
+		ctx := utils.WithActor(c.Request.Context(), req.userID)
+		if req.role != "" {
+			c.Set("role", req.role)
+			ctx = utils.WithRole(ctx, req.role)
+		}
+		c.Request = c.Request.WithContext(ctx)
+	})
+	router.Handle(req.method, req.route, handler)
+
+	r := httptest.NewRequest(req.method, req.target, strings.NewReader(req.body))
+	if req.body != "" {
+		r.Header.Set("Content-Type", "application/json")
+	}
+	for name, value := range req.headers {
+		r.Header.Set(name, value)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+	return w
+}
+
+// decodeResponse decodes a JSON response body
+func decodeResponse(t *testing.T, w *httptest.ResponseRecorder) map[string]interface{} {
+	t.Helper()
+	var body map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("response is not JSON: %v: %s", err, w.Body.String())
+	}
+	return body
+}
+
+// testItem returns a cart item priced at price, quantity units
+func testItem(productID int, price float64, quantity int) models.CartItem {
+	return models.CartItem{
+		ProductID:   productID,
+		ProductName: "Product " + strconv.Itoa(productID),
+		Price:       price,
+		Quantity:    quantity,
+		Version:     1,
+	}
+}
+
+// seedCart stores a cart for the test user holding items
+func seedCart(t *testing.T, cartKey string, items ...models.CartItem) *models.Cart {
+	t.Helper()
+	cart := models.NewCart(testUserID)
+	cart.Items = append(cart.Items, items...)
+	storeCart(t, cartKey, cart)
+	return cart
+}
+
+// storeCart recalculates and saves cart at cartKey
+func storeCart(t *testing.T, cartKey string, cart *models.Cart) {
+	t.Helper()
+	cart.CalculateTotals()
+	if err := saveCart(utils.Ctx, cartKey, cart); err != nil {
+		t.Fatalf("failed to seed cart: %v", err)
+	}
+}
+
+// storedCart loads the cart saved at cartKey
+func storedCart(t *testing.T, cartKey string) *models.Cart {
+	t.Helper()
+	cart, err := loadCart(utils.Ctx, cartKey)
+	if err != nil {
+		t.Fatalf("failed to load cart %s: %v", cartKey, err)
+	}
+	return cart
+}
+
+// assertQuantities checks the cart holds exactly the given product quantities
+func assertQuantities(t *testing.T, label string, cart *models.Cart, want map[int]int) {
+	t.Helper()
+	if len(cart.Items) != len(want) {
+		t.Errorf("%s cart has %d items, want %d", label, len(cart.Items), len(want))
+	}
+	for _, item := range cart.Items {
+		if item.Quantity != want[item.ProductID] {
+			t.Errorf("%s cart product %d quantity = %d, want %d", label, item.ProductID, item.Quantity, want[item.ProductID])
+		}
+	}
+}
+
+// storeCoupon saves a coupon definition
+func storeCoupon(t *testing.T, coupon models.Coupon) {
+	t.Helper()
+	data, _ := json.Marshal(coupon)
+	if err := utils.RedisClient.Set(utils.Ctx, couponKeyFor(coupon.Code), data, 0).Err(); err != nil {
+		t.Fatalf("failed to seed coupon: %v", err)
+	}
+}
+
+// roundTripHook counts round trips to Redis; a pipeline is one trip. It
+// matches the utils package's hook of the same name.
+type roundTripHook struct {
+	trips int
+}
+
+func (h *roundTripHook) BeforeProcess(ctx context.Context, cmd redis.Cmder) (context.Context, error) {
+	h.trips++
+	return ctx, nil
+}
+
+func (h *roundTripHook) AfterProcess(ctx context.Context, cmd redis.Cmder) error {
+	return nil
+}
+
+func (h *roundTripHook) BeforeProcessPipeline(ctx context.Context, cmds []redis.Cmder) (context.Context, error) {
+	h.trips++
+	return ctx, nil
+}
+
+func (h *roundTripHook) AfterProcessPipeline(ctx context.Context, cmds []redis.Cmder) error {
+	return nil
+}
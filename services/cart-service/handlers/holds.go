@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"cart-service/models"
+	"cart-service/utils"
+	"context"
+	"errors"
+	"log"
+	"time"
+)
+
+// flashHoldDuration returns how long reserved flash-sale units are held
+// for a cart before they go back on sale
+func flashHoldDuration() time.Duration {
+	return time.Duration(utils.GetEnvInt("FLASH_HOLD_MINUTES", 10)) * time.Minute
+}
+
+// errCartFrozen is returned when a cart can't be changed during checkout
+var errCartFrozen = errors.New("cart is frozen for checkout")
+
+// flashHoldRetryDelay is how long a hold that couldn't be released waits
+// before the next attempt
+const flashHoldRetryDelay = 5 * time.Second
+
+// StartHoldReaper releases expired flash-sale holds every
+// FLASH_HOLD_SWEEP_SECONDS, returning their units to the sale, along with
+// the coupon uses held by carts that expired. 0 disables the sweep.
+func StartHoldReaper() {
+	interval := utils.GetEnvInt("FLASH_HOLD_SWEEP_SECONDS", 15)
+	if interval <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(time.Duration(interval) * time.Second)
+		defer ticker.Stop()
+		for range ticker.C {
+			releaseExpiredHolds(context.Background())
+			releaseExpiredCouponClaims(context.Background())
+		}
+	}()
+}
+
+// releaseExpiredHolds claims and releases every hold that has expired
+func releaseExpiredHolds(ctx context.Context) {
+	holds, err := utils.ClaimExpiredFlashHolds(ctx, time.Now(), 100)
+	if err != nil {
+		log.Printf("Failed to claim expired flash-sale holds: %v", err)
+	}
+
+	for _, hold := range holds {
+		if err := releaseHold(ctx, hold); err != nil {
+			log.Printf("Failed to release flash-sale hold on product %d in %s, retrying: %v", hold.ProductID, hold.CartKey, err)
+			retryAt := time.Now().Add(flashHoldRetryDelay)
+			if err := utils.TrackFlashHold(ctx, hold, retryAt); err != nil {
+				log.Printf("Failed to reschedule flash-sale hold: %v", err)
+			}
+		}
+	}
+}
+
+// releaseHold returns a claimed hold's units to the sale, dropping them
+// from the cart if its item's hold has expired. The units go back even if
+// the cart expired or the item was removed, re-held or trimmed meanwhile:
+// those paths leave the units to whoever claimed the hold.
+func releaseHold(ctx context.Context, hold utils.FlashHold) error {
+	lock, err := acquireCartLock(ctx, hold.CartKey)
+	if err != nil {
+		return err
+	}
+	defer lock.Release(ctx)
+
+	// Don't pull units out from under a checkout in progress
+	frozen, err := utils.RedisClient.Exists(ctx, frozenKeyFor(hold.CartKey)).Result()
+	if err != nil {
+		return err
+	}
+	if frozen > 0 {
+		return errCartFrozen
+	}
+
+	cart, err := loadCart(ctx, hold.CartKey)
+	if err == errCartCorrupt {
+		return err
+	}
+	if err == nil {
+		if i := cart.FindItem(hold.ProductID); i != -1 && cart.Items[i].HoldExpiredAt(time.Now()) {
+			cart.Items[i].ReleaseHold()
+			cart.CalculateTotals()
+			if err := saveCart(ctx, hold.CartKey, cart); err != nil {
+				return err
+			}
+		}
+	}
+
+	// A retry finds the item already released and only returns the units
+	return utils.ReleaseFlashStock(ctx, hold.ProductID, hold.Quantity)
+}
+
+// flashHoldOf returns the hold tracking an item's reserved units
+func flashHoldOf(cartKey string, item models.CartItem) utils.FlashHold {
+	return utils.FlashHold{CartKey: cartKey, ProductID: item.ProductID, Quantity: item.FlashReserved}
+}
+
+// trackFlashHold schedules an item's reserved units for release when its
+// hold expires
+func trackFlashHold(ctx context.Context, cartKey string, item models.CartItem) {
+	reservedUntil, _ := time.Parse(time.RFC3339, item.ReservedUntil)
+	if err := utils.TrackFlashHold(ctx, flashHoldOf(cartKey, item), reservedUntil); err != nil {
+		log.Printf("Failed to schedule flash-sale hold release for product %d: %v", item.ProductID, err)
+	}
+}
+
+// trimFlashHold returns the reserved units a saved item gave up when it
+// dropped to keep units. item is the item as it was before; the units it
+// keeps stay held until the same time. If the reaper has already claimed
+// the hold, it returns the units instead.
+func trimFlashHold(ctx context.Context, cartKey string, item models.CartItem, keep int) {
+	if item.FlashReserved <= keep {
+		return
+	}
+
+	untracked, err := utils.UntrackFlashHold(ctx, flashHoldOf(cartKey, item))
+	if err != nil {
+		log.Printf("Failed to cancel flash-sale hold on product %d in %s: %v", item.ProductID, cartKey, err)
+		return
+	}
+	if !untracked {
+		return
+	}
+
+	released := item.FlashReserved - keep
+	if keep > 0 {
+		item.FlashReserved = keep
+		trackFlashHold(ctx, cartKey, item)
+	}
+	if err := utils.ReleaseFlashStock(ctx, item.ProductID, released); err != nil {
+		log.Printf("Failed to return %d flash-sale units of product %d: %v", released, item.ProductID, err)
+	}
+}
+
+// releaseCartHolds returns the reserved units of saved items that have
+// been cleared from a cart
+func releaseCartHolds(ctx context.Context, cartKey string, items []models.CartItem) {
+	for _, item := range items {
+		trimFlashHold(ctx, cartKey, item, 0)
+	}
+}
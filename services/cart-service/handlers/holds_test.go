@@ -0,0 +1,249 @@
+package handlers
+
+import (
+	"cart-service/utils"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// flashStock returns product 1's flash-sale stock left
+func flashStock(t *testing.T) int {
+	t.Helper()
+	stock, err := utils.RedisClient.Get(utils.Ctx, utils.Key("flash_stock", "1")).Int()
+	if err != nil {
+		t.Fatalf("failed to read flash stock: %v", err)
+	}
+	return stock
+}
+
+// trackedHolds returns the members of the flash-sale hold schedule
+func trackedHolds(t *testing.T) []string {
+	t.Helper()
+	return utils.RedisClient.ZRange(utils.Ctx, utils.Key("flash_holds"), 0, -1).Val()
+}
+
+// expireAllHolds releases every tracked hold as though its time was up
+func expireAllHolds(t *testing.T) {
+	t.Helper()
+	holds, err := utils.ClaimExpiredFlashHolds(utils.Ctx, time.Now().Add(time.Hour), 100)
+	if err != nil {
+		t.Fatalf("failed to claim holds: %v", err)
+	}
+	for _, hold := range holds {
+		if err := releaseHold(utils.Ctx, hold); err != nil {
+			t.Fatalf("failed to release hold %+v: %v", hold, err)
+		}
+	}
+}
+
+func TestReleaseExpiredHolds(t *testing.T) {
+	tests := []struct {
+		name       string
+		deleteCart bool
+	}{
+		{name: "item still in the cart"},
+		{name: "cart expired"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newTestRedis(t)
+			t.Setenv("FLASH_HOLD_MINUTES", "0")
+			newFlashSale(t, 5)
+			cartKey := cartKeyFor(testUserID)
+
+			if w := flashAdd(t, testUserID, `{"product_id": 1, "quantity": 2}`); w.Code != http.StatusOK {
+				t.Fatalf("add status = %d: %s", w.Code, w.Body)
+			}
+			if want := []string{"1|2|" + cartKey}; fmt.Sprint(trackedHolds(t)) != fmt.Sprint(want) {
+				t.Fatalf("tracked holds = %v, want %v", trackedHolds(t), want)
+			}
+			if tt.deleteCart {
+				utils.RedisClient.Del(utils.Ctx, cartKey)
+			}
+
+			releaseExpiredHolds(utils.Ctx)
+
+			if stock := flashStock(t); stock != 5 {
+				t.Errorf("flash stock = %d, want 5", stock)
+			}
+			if holds := trackedHolds(t); len(holds) != 0 {
+				t.Errorf("holds still tracked: %v", holds)
+			}
+			if tt.deleteCart {
+				return
+			}
+			cart := storedCart(t, cartKey)
+			if item := cart.Items[0]; item.FlashReserved != 0 || !item.HoldExpired || cart.TotalPrice != 200 {
+				t.Errorf("item = %+v totalling %v, want its hold released at the regular price", item, cart.TotalPrice)
+			}
+		})
+	}
+}
+
+func TestFlashUnitsLeavingTheCart(t *testing.T) {
+	tests := []struct {
+		name         string
+		handler      gin.HandlerFunc
+		request      testRequest
+		wantStock    int
+		wantHolds    []string
+		wantReserved int
+	}{
+		{
+			name:      "remove item",
+			handler:   RemoveItem,
+			request:   testRequest{method: http.MethodDelete, route: "/items/:product_id", target: "/items/1"},
+			wantStock: 5,
+			wantHolds: []string{},
+		},
+		{
+			name:         "decrease below the reserved units",
+			handler:      UpdateItem,
+			request:      testRequest{method: http.MethodPut, route: "/items/:product_id", target: "/items/1", body: `{"quantity": 1}`},
+			wantStock:    4,
+			wantHolds:    []string{"1|1|"},
+			wantReserved: 1,
+		},
+		{
+			name:         "increase keeps the hold",
+			handler:      UpdateItem,
+			request:      testRequest{method: http.MethodPut, route: "/items/:product_id", target: "/items/1", body: `{"quantity": 3}`},
+			wantStock:    3,
+			wantHolds:    []string{"1|2|"},
+			wantReserved: 2,
+		},
+		{
+			name:      "quantity set to 0",
+			handler:   UpdateItem,
+			request:   testRequest{method: http.MethodPut, route: "/items/:product_id", target: "/items/1", body: `{"quantity": 0}`},
+			wantStock: 5,
+			wantHolds: []string{},
+		},
+		{
+			name:         "adjust below the reserved units",
+			handler:      AdjustItem,
+			request:      testRequest{method: http.MethodPatch, route: "/items/:product_id", target: "/items/1", body: `{"delta": -1}`},
+			wantStock:    4,
+			wantHolds:    []string{"1|1|"},
+			wantReserved: 1,
+		},
+		{
+			name:      "adjust to 0",
+			handler:   AdjustItem,
+			request:   testRequest{method: http.MethodPatch, route: "/items/:product_id", target: "/items/1", body: `{"delta": -2}`},
+			wantStock: 5,
+			wantHolds: []string{},
+		},
+		{
+			name:      "clear cart",
+			handler:   ClearCart,
+			request:   testRequest{method: http.MethodDelete, route: "/"},
+			wantStock: 5,
+			wantHolds: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newTestRedis(t)
+			newFlashSale(t, 5)
+			cartKey := cartKeyFor(testUserID)
+			if w := flashAdd(t, testUserID, `{"product_id": 1, "quantity": 2}`); w.Code != http.StatusOK {
+				t.Fatalf("add status = %d: %s", w.Code, w.Body)
+			}
+
+			if w := serve(t, tt.handler, tt.request); w.Code != http.StatusOK {
+				t.Fatalf("status = %d: %s", w.Code, w.Body)
+			}
+
+			if stock := flashStock(t); stock != tt.wantStock {
+				t.Errorf("flash stock = %d, want %d", stock, tt.wantStock)
+			}
+			wantHolds := []string{}
+			for _, hold := range tt.wantHolds {
+				wantHolds = append(wantHolds, hold+cartKey)
+			}
+			if holds := trackedHolds(t); fmt.Sprint(holds) != fmt.Sprint(wantHolds) {
+				t.Errorf("tracked holds = %v, want %v", holds, wantHolds)
+			}
+			if tt.wantReserved > 0 {
+				if item := storedCart(t, cartKey).Items[0]; item.FlashReserved != tt.wantReserved || item.PromoLimit != tt.wantReserved {
+					t.Errorf("item = %+v, want %d reserved units", item, tt.wantReserved)
+				}
+			}
+
+			// Every unit goes back to the sale exactly once
+			expireAllHolds(t)
+			if stock := flashStock(t); stock != 5 {
+				t.Errorf("flash stock after the holds expire = %d, want 5", stock)
+			}
+		})
+	}
+}
+
+func TestFlashAddJoinsCurrentHold(t *testing.T) {
+	newTestRedis(t)
+	newFlashSale(t, 5)
+	cartKey := cartKeyFor(testUserID)
+
+	for i := 0; i < 2; i++ {
+		if w := flashAdd(t, testUserID, `{"product_id": 1, "quantity": 2}`); w.Code != http.StatusOK {
+			t.Fatalf("add status = %d: %s", w.Code, w.Body)
+		}
+	}
+	if want := []string{"1|4|" + cartKey}; fmt.Sprint(trackedHolds(t)) != fmt.Sprint(want) {
+		t.Errorf("tracked holds = %v, want %v", trackedHolds(t), want)
+	}
+
+	expireAllHolds(t)
+	if stock := flashStock(t); stock != 5 {
+		t.Errorf("flash stock after the hold expires = %d, want 5", stock)
+	}
+}
+
+func TestCompleteCheckoutKeepsHeldUnits(t *testing.T) {
+	newTestRedis(t)
+	newFlashSale(t, 5)
+	if w := flashAdd(t, testUserID, `{"product_id": 1, "quantity": 2}`); w.Code != http.StatusOK {
+		t.Fatalf("add status = %d: %s", w.Code, w.Body)
+	}
+
+	if w := serve(t, CompleteCheckout, testRequest{method: http.MethodPost, route: "/checkout-complete"}); w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body)
+	}
+	if holds := trackedHolds(t); len(holds) != 0 {
+		t.Errorf("holds still tracked after checkout: %v", holds)
+	}
+	expireAllHolds(t)
+	if stock := flashStock(t); stock != 3 {
+		t.Errorf("flash stock = %d, want the 2 bought units kept out of the sale", stock)
+	}
+}
+
+func TestGetCartHoldCountdown(t *testing.T) {
+	newTestRedis(t)
+	t.Setenv("FLASH_HOLD_MINUTES", "10")
+	newFlashSale(t, 5)
+	if w := flashAdd(t, testUserID, `{"product_id": 1, "quantity": 2}`); w.Code != http.StatusOK {
+		t.Fatalf("add status = %d: %s", w.Code, w.Body)
+	}
+
+	w := serve(t, GetCart, testRequest{route: "/"})
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body)
+	}
+	holds := decodeResponse(t, w)["holds"].([]interface{})
+	if len(holds) != 1 {
+		t.Fatalf("holds = %v, want one", holds)
+	}
+	hold := holds[0].(map[string]interface{})
+	remaining := int(hold["remaining_seconds"].(float64))
+	if hold["product_id"] != float64(1) || hold["reserved"] != float64(2) || remaining < 590 || remaining > 600 {
+		t.Errorf("hold = %v, want 2 units with about 600s left", hold)
+	}
+}
@@ -0,0 +1,189 @@
+package handlers
+
+import (
+	"cart-service/models"
+	"cart-service/utils"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Import modes: replace empties the cart first, merge adds to it
+const (
+	importModeReplace = "replace"
+	importModeMerge   = "merge"
+)
+
+// importRow is one product_id,quantity line of an import file
+type importRow struct {
+	Row       int
+	ProductID int
+	Quantity  int
+}
+
+// readImportCSV parses product_id,quantity rows, skipping an optional
+// header line. Rows that cannot be parsed are returned as row errors.
+func readImportCSV(r io.Reader, maxRows int) ([]importRow, []gin.H, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	var rows []importRow
+	rowErrors := []gin.H{}
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		if line == 1 && len(record) > 0 && strings.EqualFold(strings.TrimSpace(record[0]), "product_id") {
+			continue
+		}
+		if len(rows)+len(rowErrors) >= maxRows {
+			return nil, nil, fmt.Errorf("file has more than %d rows", maxRows)
+		}
+
+		if len(record) != 2 {
+			rowErrors = append(rowErrors, gin.H{"row": line, "error": "expected product_id,quantity"})
+			continue
+		}
+		productID, err := strconv.Atoi(strings.TrimSpace(record[0]))
+		if err != nil || productID <= 0 {
+			rowErrors = append(rowErrors, gin.H{"row": line, "error": "invalid product_id"})
+			continue
+		}
+		quantity, err := strconv.Atoi(strings.TrimSpace(record[1]))
+		if err != nil || quantity <= 0 {
+			rowErrors = append(rowErrors, gin.H{"row": line, "product_id": productID, "error": "invalid quantity"})
+			continue
+		}
+		rows = append(rows, importRow{Row: line, ProductID: productID, Quantity: quantity})
+	}
+	return rows, rowErrors, nil
+}
+
+// ImportCart fills the cart from an uploaded CSV of product_id,quantity
+// rows, sent as the request body or as the "file" form field
+func ImportCart(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	if format := c.DefaultQuery("format", "csv"); format != "csv" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unsupported import format"})
+		return
+	}
+	mode := c.DefaultQuery("mode", importModeMerge)
+	if mode != importModeReplace && mode != importModeMerge {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Mode must be replace or merge"})
+		return
+	}
+
+	body := io.Reader(c.Request.Body)
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		header, err := c.FormFile("file")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Import file is required"})
+			return
+		}
+		file, err := header.Open()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read import file"})
+			return
+		}
+		defer file.Close()
+		body = file
+	}
+
+	rows, rowErrors, err := readImportCSV(body, utils.GetEnvInt("CART_IMPORT_MAX_ROWS", 500))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid CSV: %v", err)})
+		return
+	}
+	if len(rows) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No valid rows to import", "row_errors": rowErrors})
+		return
+	}
+
+	cartKey, name, ok := requestCart(c, userID)
+	if !ok {
+		return
+	}
+	lock, ok := lockCart(c, cartKey)
+	if !ok {
+		return
+	}
+	defer lock.Release(c.Request.Context())
+	if !ensureNotFrozen(c, cartKey) {
+		return
+	}
+
+	cart, err := loadCart(c.Request.Context(), cartKey)
+	if err == errCartCorrupt {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse cart data"})
+		return
+	}
+	creating := err != nil
+	if creating {
+		cart = newCart(userID, name)
+	}
+
+	importQuantity := 0
+	for _, row := range rows {
+		importQuantity += row.Quantity
+	}
+	if shedUnderMemoryPressure(c, creating, importQuantity) {
+		return
+	}
+	if !withinCartLimit(c, userID, name, creating) {
+		return
+	}
+
+	claims := newQuotaClaims(userID)
+	var replaced []models.CartItem
+	if mode == importModeReplace {
+		replaced = cart.Items
+		claims.replacing(replaced)
+		cart.Items = []models.CartItem{}
+	}
+
+	imported := 0
+	for _, row := range rows {
+		if reason := stageListedItem(c.Request.Context(), cart, userID, row.ProductID, row.Quantity, claims); reason != "" {
+			rowErrors = append(rowErrors, gin.H{"row": row.Row, "product_id": row.ProductID, "error": reason})
+			continue
+		}
+		imported++
+	}
+	sort.SliceStable(rowErrors, func(i, j int) bool {
+		return rowErrors[i]["row"].(int) < rowErrors[j]["row"].(int)
+	})
+
+	applyBundles(c.Request.Context(), cart)
+	cart.CalculateTotals()
+
+	if err := saveCart(c.Request.Context(), cartKey, cart); err != nil {
+		claims.release(c.Request.Context())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save cart"})
+		return
+	}
+	releaseCartHolds(c.Request.Context(), cartKey, replaced)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":    "Cart imported",
+		"mode":       mode,
+		"imported":   imported,
+		"row_errors": rowErrors,
+		"cart":       cart,
+	})
+}
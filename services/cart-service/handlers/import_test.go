@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// multipartImport returns a multipart body carrying csv as the "file" field
+// and its Content-Type
+func multipartImport(t *testing.T, csv string) (string, string) {
+	t.Helper()
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	file, err := form.CreateFormFile("file", "order.csv")
+	if err != nil {
+		t.Fatalf("failed to build form: %v", err)
+	}
+	file.Write([]byte(csv))
+	form.Close()
+	return body.String(), form.FormDataContentType()
+}
+
+func TestImportCart(t *testing.T) {
+	upload, uploadType := multipartImport(t, "1,4\n2,1\n")
+
+	tests := []struct {
+		name          string
+		query         string
+		body          string
+		contentType   string
+		wantStatus    int
+		want          map[int]int
+		wantImported  float64
+		wantRowErrors []string
+	}{
+		{
+			name:         "merge with a header line",
+			body:         "product_id,quantity\n1,3\n2,2\n",
+			wantStatus:   http.StatusOK,
+			want:         map[int]int{1: 4, 2: 2, 3: 1},
+			wantImported: 2,
+		},
+		{
+			name:         "replace",
+			query:        "?mode=replace",
+			body:         "1,3\n",
+			wantStatus:   http.StatusOK,
+			want:         map[int]int{1: 3},
+			wantImported: 1,
+		},
+		{
+			name:         "multipart upload",
+			body:         upload,
+			contentType:  uploadType,
+			wantStatus:   http.StatusOK,
+			want:         map[int]int{1: 5, 3: 1, 2: 1},
+			wantImported: 2,
+		},
+		{
+			name:         "malformed rows",
+			body:         "1,2\nabc,1\n2,-1\n2\n9,1\n2,50\n",
+			wantStatus:   http.StatusOK,
+			want:         map[int]int{1: 3, 3: 1},
+			wantImported: 1,
+			wantRowErrors: []string{
+				"2: invalid product_id",
+				"3: invalid quantity",
+				"4: expected product_id,quantity",
+				"5: " + listSkipNotFound,
+				"6: " + listSkipInsufficientStock,
+			},
+		},
+		{
+			name:          "no valid rows",
+			body:          "x,1\n",
+			wantStatus:    http.StatusBadRequest,
+			want:          map[int]int{1: 1, 3: 1},
+			wantRowErrors: []string{"1: invalid product_id"},
+		},
+		{
+			name:       "unknown mode",
+			query:      "?mode=append",
+			body:       "1,1\n",
+			wantStatus: http.StatusBadRequest,
+			want:       map[int]int{1: 1, 3: 1},
+		},
+		{
+			name:       "unsupported format",
+			query:      "?format=xlsx",
+			body:       "1,1\n",
+			wantStatus: http.StatusBadRequest,
+			want:       map[int]int{1: 1, 3: 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newTestRedis(t)
+			newProductService(t, map[int]gin.H{
+				1: {"name": "Paper", "price": 5, "quantity": 100},
+				2: {"name": "Toner", "price": 40, "quantity": 10},
+				3: {"name": "Stapler", "price": 12, "quantity": 10},
+			})
+			cartKey := cartKeyFor(testUserID)
+			seedCart(t, cartKey, testItem(1, 5, 1), testItem(3, 12, 1))
+
+			request := testRequest{method: http.MethodPost, route: "/import", target: "/import" + tt.query, body: tt.body}
+			if tt.contentType != "" {
+				request.headers = map[string]string{"Content-Type": tt.contentType}
+			}
+			w := serve(t, ImportCart, request)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+			assertQuantities(t, "stored", storedCart(t, cartKey), tt.want)
+
+			body := decodeResponse(t, w)
+			if tt.wantStatus == http.StatusOK && body["imported"] != tt.wantImported {
+				t.Errorf("imported = %v, want %v", body["imported"], tt.wantImported)
+			}
+			rowErrors := []string{}
+			if list, ok := body["row_errors"].([]interface{}); ok {
+				for _, entry := range list {
+					rowError := entry.(map[string]interface{})
+					rowErrors = append(rowErrors, fmt.Sprintf("%v: %v", rowError["row"], rowError["error"]))
+				}
+			}
+			if fmt.Sprint(rowErrors) != fmt.Sprint(tt.wantRowErrors) {
+				t.Errorf("row errors = %v, want %v", rowErrors, tt.wantRowErrors)
+			}
+		})
+	}
+}
+
+func TestImportCartReplaceReleasesHolds(t *testing.T) {
+	newTestRedis(t)
+	newFlashSale(t, 5)
+	if w := flashAdd(t, testUserID, `{"product_id": 1, "quantity": 2}`); w.Code != http.StatusOK {
+		t.Fatalf("add status = %d: %s", w.Code, w.Body)
+	}
+
+	w := serve(t, ImportCart, testRequest{method: http.MethodPost, route: "/import", target: "/import?mode=replace", body: "2,1\n"})
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body)
+	}
+	assertQuantities(t, "stored", storedCart(t, cartKeyFor(testUserID)), map[int]int{2: 1})
+	if stock := flashStock(t); stock != 5 {
+		t.Errorf("flash stock = %d, want the replaced item's 2 units back", stock)
+	}
+	if holds := trackedHolds(t); len(holds) != 0 {
+		t.Errorf("holds still tracked: %v", holds)
+	}
+}
@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"cart-service/models"
+	"cart-service/utils"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestKeyPrefixAppliedEverywhere(t *testing.T) {
+	operations := []struct {
+		name    string
+		handler gin.HandlerFunc
+		req     testRequest
+	}{
+		{name: "add item", handler: AddItem, req: testRequest{method: http.MethodPost, route: "/items", body: `{"product_id": 1}`}},
+		{name: "add to named cart", handler: AddItem, req: testRequest{method: http.MethodPost, route: "/items", target: "/items?name=work", body: `{"product_id": 1}`}},
+		{name: "apply coupon", handler: ApplyCoupon, req: testRequest{method: http.MethodPost, route: "/coupon", body: `{"code": "LIMITED"}`}},
+		{name: "view cart", handler: GetCart, req: testRequest{route: "/"}},
+		{name: "freeze", handler: FreezeCart, req: testRequest{method: http.MethodPost, route: "/freeze"}},
+	}
+
+	mr := newTestRedis(t)
+	utils.SetKeyPrefix("staging")
+	t.Cleanup(func() { utils.SetKeyPrefix("") })
+	newProductService(t, map[int]gin.H{1: {"name": "Kettle", "price": 20, "quantity": 10}})
+	storeCoupon(t, models.Coupon{Code: "LIMITED", Type: models.CouponTypeFixed, Value: 1, UsageLimit: 10})
+
+	for _, op := range operations {
+		if w := serve(t, op.handler, op.req); w.Code != http.StatusOK {
+			t.Fatalf("%s: status = %d: %s", op.name, w.Code, w.Body)
+		}
+	}
+
+	keys := mr.Keys()
+	if len(keys) < len(operations) {
+		t.Fatalf("only %d keys written: %v", len(keys), keys)
+	}
+	for _, key := range keys {
+		if !strings.HasPrefix(key, "staging:") {
+			t.Errorf("key %q is missing the staging: prefix", key)
+		}
+		if strings.Count(key, "staging:") > 1 {
+			t.Errorf("key %q has the prefix applied twice", key)
+		}
+	}
+}
+
+// hashTag returns the part of a key Redis Cluster hashes to pick a slot
+func hashTag(key string) string {
+	start := strings.Index(key, "{")
+	if start == -1 {
+		return key
+	}
+	end := strings.Index(key[start+1:], "}")
+	if end <= 0 {
+		return key
+	}
+	return key[start+1 : start+1+end]
+}
+
+func TestRelatedKeysShareHashTag(t *testing.T) {
+	for _, mode := range []string{utils.KeyHashingTag, utils.KeyHashingHash} {
+		t.Run(mode, func(t *testing.T) {
+			if err := utils.SetKeyHashing(mode); err != nil {
+				t.Fatal(err)
+			}
+			t.Cleanup(func() { utils.SetKeyHashing("") })
+			utils.SetKeyPrefix("prod")
+			t.Cleanup(func() { utils.SetKeyPrefix("") })
+
+			cartKey := cartKeyFor(testUserID)
+			namedKey := namedCartKeyFor(testUserID, "work")
+			related := map[string]string{
+				"cart":             cartKey,
+				"named cart":       namedKey,
+				"lock":             cartLockKeyFor(cartKey),
+				"freeze":           frozenKeyFor(cartKey),
+				"count":            countKeyFor(cartKey),
+				"last viewed":      lastViewedKeyFor(cartKey),
+				"named cart lock":  cartLockKeyFor(namedKey),
+				"named cart count": countKeyFor(namedKey),
+				"history":          historyKeyFor(testUserID),
+				"list":             listKeyFor(testUserID, "weekly"),
+			}
+
+			tag := hashTag(cartKey)
+			if tag == cartKey {
+				t.Fatalf("cart key %q has no hash tag", cartKey)
+			}
+			for name, key := range related {
+				if got := hashTag(key); got != tag {
+					t.Errorf("%s key %q hashes on %q, want %q", name, key, got, tag)
+				}
+			}
+			if other := hashTag(cartKeyFor("43")); other == tag {
+				t.Errorf("users 42 and 43 share hash tag %q", tag)
+			}
+		})
+	}
+}
+
+func TestKeyHashingDisabled(t *testing.T) {
+	if key := cartKeyFor(testUserID); strings.ContainsAny(key, "{}") {
+		t.Errorf("cart key %q has a hash tag with hashing off", key)
+	}
+}
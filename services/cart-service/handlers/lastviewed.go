@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"cart-service/models"
+	"cart-service/utils"
+	"context"
+	"log"
+	"strconv"
+)
+
+// lastViewedKeyFor builds the Redis hash of the item prices the customer
+// saw when they last viewed a cart, by product ID
+func lastViewedKeyFor(cartKey string) string {
+	return utils.Key("last_viewed", utils.StripKeyPrefix(cartKey))
+}
+
+// priceIncreasesSinceLastView flags items whose price went up since the
+// customer last viewed the cart, then records the current prices as the
+// new baseline. Unlike repricing, nothing in the cart changes.
+func priceIncreasesSinceLastView(ctx context.Context, cartKey string, cart *models.Cart) []models.PriceIncrease {
+	viewedKey := lastViewedKeyFor(cartKey)
+	stored, err := utils.RedisClient.HGetAll(ctx, viewedKey).Result()
+	if err != nil {
+		log.Printf("Failed to read last viewed prices %s: %v", viewedKey, err)
+		return nil
+	}
+
+	lastViewed := make(map[int]float64, len(stored))
+	for field, value := range stored {
+		productID, err := strconv.Atoi(field)
+		if err != nil {
+			continue
+		}
+		if price, err := strconv.ParseFloat(value, 64); err == nil {
+			lastViewed[productID] = price
+		}
+	}
+	increases := cart.PriceIncreasesSince(lastViewed)
+
+	prices := make(map[string]interface{}, len(cart.Items))
+	for _, item := range cart.Items {
+		prices[strconv.Itoa(item.ProductID)] = item.Price
+	}
+	pipe := utils.RedisClient.TxPipeline()
+	pipe.Del(ctx, viewedKey)
+	if len(prices) > 0 {
+		pipe.HSet(ctx, viewedKey, prices)
+		pipe.Expire(ctx, viewedKey, cartTTL)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		log.Printf("Failed to record last viewed prices %s: %v", viewedKey, err)
+	}
+	return increases
+}
@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"cart-service/utils"
+	"net/http"
+	"strconv"
+	"testing"
+)
+
+// getPriceIncreases reads the cart and returns the price increases it flags
+// by product ID
+func getPriceIncreases(t *testing.T) map[float64]map[string]interface{} {
+	t.Helper()
+	w := serve(t, GetCart, testRequest{route: "/"})
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body)
+	}
+	increases := map[float64]map[string]interface{}{}
+	list, _ := decodeResponse(t, w)["price_increases"].([]interface{})
+	for _, entry := range list {
+		increase := entry.(map[string]interface{})
+		increases[increase["product_id"].(float64)] = increase
+	}
+	return increases
+}
+
+func TestPriceIncreasesSinceLastView(t *testing.T) {
+	tests := []struct {
+		name         string
+		newPrice     float64
+		wantIncrease float64
+	}{
+		{name: "increased", newPrice: 12.5, wantIncrease: 2.5},
+		{name: "decreased", newPrice: 8},
+		{name: "unchanged", newPrice: 10},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newTestRedis(t)
+			cartKey := cartKeyFor(testUserID)
+			seedCart(t, cartKey, testItem(1, 10, 1), testItem(2, 5, 1))
+
+			if increases := getPriceIncreases(t); len(increases) != 0 {
+				t.Fatalf("first view flagged %v", increases)
+			}
+
+			cart := storedCart(t, cartKey)
+			cart.Items[0].Price = tt.newPrice
+			cart.CalculateTotals()
+			storeCart(t, cartKey, cart)
+
+			increases := getPriceIncreases(t)
+			if tt.wantIncrease == 0 {
+				if len(increases) != 0 {
+					t.Errorf("price_increases = %v, want none", increases)
+				}
+			} else {
+				increase := increases[1]
+				if len(increases) != 1 || increase["last_viewed_price"] != float64(10) || increase["price"] != tt.newPrice || increase["increase"] != tt.wantIncrease {
+					t.Errorf("price_increases = %v, want product 1 up %v from 10", increases, tt.wantIncrease)
+				}
+			}
+
+			// The view becomes the new baseline
+			if increases := getPriceIncreases(t); len(increases) != 0 {
+				t.Errorf("repeat view flagged %v", increases)
+			}
+			if price := utils.RedisClient.HGet(utils.Ctx, lastViewedKeyFor(cartKey), "1").Val(); price != strconv.FormatFloat(tt.newPrice, 'f', -1, 64) {
+				t.Errorf("baseline = %s, want %v", price, tt.newPrice)
+			}
+		})
+	}
+}
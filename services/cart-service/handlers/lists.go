@@ -0,0 +1,236 @@
+package handlers
+
+import (
+	"cart-service/models"
+	"cart-service/utils"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+)
+
+// Reasons a list item was not added to the cart
+const (
+	listSkipNotFound          = "not_found"
+	listSkipUnavailable       = "unavailable"
+	listSkipInsufficientStock = "insufficient_stock"
+	listSkipInvalidQuantity   = "invalid_quantity"
+	listSkipOrderLimit        = "exceeds_order_limit"
+	listSkipNoPrice           = "no_price"
+	listSkipDailyQuota        = "daily_quota_exceeded"
+)
+
+// listKeyFor builds the Redis key holding one of a user's list templates
+func listKeyFor(userID interface{}, listID string) string {
+	return utils.Key("list", utils.UserKey(fmt.Sprintf("%v", userID)), listID)
+}
+
+// stageListedItem adds quantity of a product to the in-memory cart at its
+// current price, on top of any quantity already there, counting the units
+// against the user's daily quota in claims. Returns the reason the product
+// was skipped, or "" if it was added.
+func stageListedItem(ctx context.Context, cart *models.Cart, userID interface{}, productID, quantity int, claims *quotaClaims) string {
+	product, err := utils.FetchProduct(ctx, productID)
+	if err == utils.ErrProductNotFound {
+		return listSkipNotFound
+	}
+	if err != nil {
+		log.Printf("Failed to fetch product %d: %v", productID, err)
+		return listSkipUnavailable
+	}
+	if !priceAllowed(product) {
+		return listSkipNoPrice
+	}
+
+	added := quantity
+	itemIndex := cart.FindItem(productID)
+	if itemIndex != -1 {
+		quantity += cart.Items[itemIndex].PaidQuantity()
+	}
+	if product.Quantity < quantity {
+		return listSkipInsufficientStock
+	}
+	if product.QuantityStep > 1 && quantity%product.QuantityStep != 0 {
+		return listSkipInvalidQuantity
+	}
+	if product.MaxPerOrder > 0 && quantity > product.MaxPerOrder {
+		return listSkipOrderLimit
+	}
+	allowed, err := claims.claim(ctx, productID, product.DailyQuota, added)
+	if err != nil {
+		log.Printf("Failed to check daily quota for product %d: %v", productID, err)
+		return listSkipUnavailable
+	}
+	if !allowed {
+		return listSkipDailyQuota
+	}
+
+	if itemIndex == -1 {
+		cart.Items = append(cart.Items, models.CartItem{
+			ProductID: productID,
+			AddedAt:   time.Now().Format(time.RFC3339),
+			AddedBy:   fmt.Sprintf("%v", userID),
+		})
+		itemIndex = len(cart.Items) - 1
+	}
+
+	// Re-price from the current product details
+	item := &cart.Items[itemIndex]
+	refreshProductDetails(item, product)
+	item.SetPaidQuantity(quantity)
+	item.Pending = false
+	applyPromotion(ctx, item)
+	return ""
+}
+
+// SaveCartAsList stores the cart's products and quantities as a reusable
+// list template, replacing any list with the same ID
+func SaveCartAsList(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req models.SaveListRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !cartNamePattern.MatchString(req.ListID) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid list ID"})
+		return
+	}
+
+	cartKey, _, ok := requestCart(c, userID)
+	if !ok {
+		return
+	}
+
+	cart, err := loadCart(c.Request.Context(), cartKey)
+	if err == errCartCorrupt {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse cart data"})
+		return
+	}
+	if err != nil || len(cart.Items) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Cart is empty", "code": codeCartEmpty})
+		return
+	}
+
+	list := cart.ToList(req.ListID)
+	listData, err := json.Marshal(list)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to encode list"})
+		return
+	}
+
+	// Lists are kept until overwritten, unlike carts
+	if err := utils.RedisClient.Set(c.Request.Context(), listKeyFor(userID, req.ListID), listData, 0).Err(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save list"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Cart saved as list",
+		"list":    list,
+	})
+}
+
+// ApplyList adds every product in a saved list template to the cart at
+// current prices. Products that are gone, out of stock or unreachable are
+// skipped and reported rather than failing the whole list.
+func ApplyList(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	listID := c.Param("list_id")
+	if !cartNamePattern.MatchString(listID) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid list ID"})
+		return
+	}
+
+	listData, err := utils.RedisClient.Get(c.Request.Context(), listKeyFor(userID, listID)).Result()
+	if err == redis.Nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "List not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load list"})
+		return
+	}
+
+	var list models.ListTemplate
+	if err := json.Unmarshal([]byte(listData), &list); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse list data"})
+		return
+	}
+
+	cartKey, name, ok := requestCart(c, userID)
+	if !ok {
+		return
+	}
+	lock, ok := lockCart(c, cartKey)
+	if !ok {
+		return
+	}
+	defer lock.Release(c.Request.Context())
+	if !ensureNotFrozen(c, cartKey) {
+		return
+	}
+
+	cart, err := loadCart(c.Request.Context(), cartKey)
+	if err == errCartCorrupt {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse cart data"})
+		return
+	}
+	creating := err != nil
+	if creating {
+		cart = newCart(userID, name)
+	}
+
+	listQuantity := 0
+	for _, listItem := range list.Items {
+		listQuantity += listItem.Quantity
+	}
+	if shedUnderMemoryPressure(c, creating, listQuantity) {
+		return
+	}
+	if !withinCartLimit(c, userID, name, creating) {
+		return
+	}
+
+	skipped := []gin.H{}
+	skip := func(productID int, reason string) {
+		skipped = append(skipped, gin.H{"product_id": productID, "reason": reason})
+	}
+
+	claims := newQuotaClaims(userID)
+	for _, listItem := range list.Items {
+		if reason := stageListedItem(c.Request.Context(), cart, userID, listItem.ProductID, listItem.Quantity, claims); reason != "" {
+			skip(listItem.ProductID, reason)
+		}
+	}
+
+	applyBundles(c.Request.Context(), cart)
+	cart.CalculateTotals()
+
+	if err := saveCart(c.Request.Context(), cartKey, cart); err != nil {
+		claims.release(c.Request.Context())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save cart"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "List applied to cart",
+		"cart":    cart,
+		"skipped": skipped,
+	})
+}
@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"cart-service/models"
+	"cart-service/utils"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestSaveAndApplyList(t *testing.T) {
+	newTestRedis(t)
+	newProductService(t, map[int]gin.H{
+		1: {"name": "Milk", "price": 2.5, "quantity": 10},
+		2: {"name": "Bread", "price": 3, "quantity": 1},
+	})
+	cartKey := cartKeyFor(testUserID)
+	seedCart(t, cartKey, testItem(1, 2, 2), testItem(2, 3, 1), testItem(3, 9, 1))
+
+	w := serve(t, SaveCartAsList, testRequest{method: http.MethodPost, route: "/save-as-list", body: `{"list_id": "weekly"}`})
+	if w.Code != http.StatusOK {
+		t.Fatalf("save status = %d: %s", w.Code, w.Body)
+	}
+	if ttl := utils.RedisClient.TTL(utils.Ctx, listKeyFor(testUserID, "weekly")).Val(); ttl >= 0 {
+		t.Errorf("list TTL = %v, want none", ttl)
+	}
+	utils.RedisClient.Del(utils.Ctx, cartKey)
+
+	applies := []struct {
+		name        string
+		want        map[int]int
+		wantSkipped map[float64]string
+	}{
+		{
+			name:        "apply to an empty cart",
+			want:        map[int]int{1: 2, 2: 1},
+			wantSkipped: map[float64]string{3: listSkipNotFound},
+		},
+		{
+			name:        "apply again on top",
+			want:        map[int]int{1: 4, 2: 1},
+			wantSkipped: map[float64]string{2: listSkipInsufficientStock, 3: listSkipNotFound},
+		},
+	}
+
+	for _, apply := range applies {
+		w := serve(t, ApplyList, testRequest{method: http.MethodPost, route: "/apply-list/:list_id", target: "/apply-list/weekly"})
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: status = %d: %s", apply.name, w.Code, w.Body)
+		}
+
+		cart := storedCart(t, cartKey)
+		assertQuantities(t, apply.name, cart, apply.want)
+		if i := cart.FindItem(1); i == -1 || cart.Items[i].Price != 2.5 {
+			t.Errorf("%s: product 1 was not re-priced: %+v", apply.name, cart.Items)
+		}
+
+		skipped := decodeResponse(t, w)["skipped"].([]interface{})
+		if len(skipped) != len(apply.wantSkipped) {
+			t.Errorf("%s: skipped = %v, want %v", apply.name, skipped, apply.wantSkipped)
+		}
+		for _, s := range skipped {
+			entry := s.(map[string]interface{})
+			if reason := apply.wantSkipped[entry["product_id"].(float64)]; entry["reason"] != reason {
+				t.Errorf("%s: product %v skipped for %v, want %q", apply.name, entry["product_id"], entry["reason"], reason)
+			}
+		}
+	}
+}
+
+func TestApplyListNotFound(t *testing.T) {
+	newTestRedis(t)
+
+	w := serve(t, ApplyList, testRequest{method: http.MethodPost, route: "/apply-list/:list_id", target: "/apply-list/nope"})
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+}
+
+func TestSaveEmptyCartAsList(t *testing.T) {
+	newTestRedis(t)
+
+	w := serve(t, SaveCartAsList, testRequest{method: http.MethodPost, route: "/save-as-list", body: `{"list_id": "weekly"}`})
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+}
+
+func TestApplyListBuysGiftProduct(t *testing.T) {
+	newTestRedis(t)
+	newProductService(t, map[int]gin.H{
+		1:  {"name": "Tea", "price": 30, "quantity": 100},
+		99: {"name": "Tote bag", "price": 8, "quantity": 100},
+	})
+	cartKey := cartKeyFor(testUserID)
+	cart := models.NewCart(testUserID)
+	cart.Items = []models.CartItem{testItem(1, 30, 2)}
+	cart.ApplyGiftPromotions([]models.GiftPromotion{{ID: "tote", ProductID: 99, ProductName: "Tote bag", MinOrderValue: 50}})
+	storeCart(t, cartKey, cart)
+	if i := storedCart(t, cartKey).FindItem(99); i == -1 {
+		t.Fatal("gift line was not added")
+	}
+	list, _ := json.Marshal(models.ListTemplate{ID: "weekly", Items: []models.ListItem{{ProductID: 99, Quantity: 2}}})
+	utils.RedisClient.Set(utils.Ctx, listKeyFor(testUserID, "weekly"), list, 0)
+
+	w := serve(t, ApplyList, testRequest{method: http.MethodPost, route: "/apply-list/:list_id", target: "/apply-list/weekly"})
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body)
+	}
+
+	cart = storedCart(t, cartKey)
+	assertQuantities(t, "stored", cart, map[int]int{1: 2, 99: 2})
+	if item := cart.Items[cart.FindItem(99)]; item.PromoGift || item.Price != 8 {
+		t.Errorf("tote line = %+v, want a paid line at 8", item)
+	}
+	if cart.FinalPrice != 76 {
+		t.Errorf("final price = %v, want 76", cart.FinalPrice)
+	}
+}
@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"cart-service/utils"
+	"context"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// cartLockKeyFor builds the Redis key guarding concurrent cart writes
+func cartLockKeyFor(cartKey string) string {
+	return utils.Key("lock", utils.StripKeyPrefix(cartKey))
+}
+
+// acquireCartLock takes the cart's lock, waiting up to CART_LOCK_WAIT_MS
+// for a current holder
+func acquireCartLock(ctx context.Context, cartKey string) (*utils.Lock, error) {
+	ttl := time.Duration(utils.GetEnvInt("CART_LOCK_TTL_MS", 5000)) * time.Millisecond
+	wait := time.Duration(utils.GetEnvInt("CART_LOCK_WAIT_MS", 2000)) * time.Millisecond
+	return utils.AcquireLock(ctx, cartLockKeyFor(cartKey), ttl, wait)
+}
+
+// lockCart serializes read-modify-write cycles on a cart across instances.
+// On contention it writes a 409 with a Retry-After hint and returns false;
+// the caller must Release the returned lock when done.
+func lockCart(c *gin.Context, cartKey string) (*utils.Lock, bool) {
+	lock, err := acquireCartLock(c.Request.Context(), cartKey)
+	if err == utils.ErrLockTimeout {
+		retryAfter := utils.GetEnvInt("CART_LOCK_RETRY_AFTER_SECONDS", 1)
+		c.Header("Retry-After", strconv.Itoa(retryAfter))
+		c.JSON(http.StatusConflict, gin.H{
+			"error":               "Cart is being modified by another request",
+			"code":                "cart_lock_timeout",
+			"retry_after_seconds": retryAfter,
+		})
+		return nil, false
+	}
+	if err != nil {
+		log.Printf("Failed to lock cart %s: %v", cartKey, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to lock cart"})
+		return nil, false
+	}
+	return lock, true
+}
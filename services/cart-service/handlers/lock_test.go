@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"cart-service/utils"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestLockContention(t *testing.T) {
+	address, _ := json.Marshal(testAddress())
+
+	tests := []struct {
+		name    string
+		handler gin.HandlerFunc
+		req     testRequest
+		heldKey string
+	}{
+		{
+			name:    "adjust item",
+			handler: AdjustItem,
+			req:     testRequest{method: http.MethodPost, route: "/items/:product_id/adjust", target: "/items/1/adjust", body: `{"delta": 1}`},
+			heldKey: cartKeyFor(testUserID),
+		},
+		{
+			name:    "recalculate",
+			handler: RecalculateCart,
+			req:     testRequest{method: http.MethodPost, route: "/recalculate"},
+			heldKey: cartKeyFor(testUserID),
+		},
+		{
+			name:    "shipping address",
+			handler: SetShippingAddress,
+			req:     testRequest{method: http.MethodPut, route: "/shipping-address", body: string(address)},
+			heldKey: cartKeyFor(testUserID),
+		},
+		{
+			name:    "split held on source",
+			handler: SplitCart,
+			req:     testRequest{method: http.MethodPost, route: "/split", body: `{"product_ids": [1]}`},
+			heldKey: cartKeyFor(testUserID),
+		},
+		{
+			name:    "split held on target",
+			handler: SplitCart,
+			req:     testRequest{method: http.MethodPost, route: "/split", body: `{"product_ids": [1]}`},
+			heldKey: namedCartKeyFor(testUserID, defaultSplitTarget),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newTestRedis(t)
+			t.Setenv("CART_LOCK_WAIT_MS", "20")
+			t.Setenv("CART_LOCK_RETRY_AFTER_SECONDS", "2")
+			cartKey := cartKeyFor(testUserID)
+			seedCart(t, cartKey, testItem(1, 10, 2))
+
+			held, err := acquireCartLock(utils.Ctx, tt.heldKey)
+			if err != nil {
+				t.Fatalf("failed to hold lock: %v", err)
+			}
+
+			w := serve(t, tt.handler, tt.req)
+			if w.Code != http.StatusConflict {
+				t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusConflict, w.Body)
+			}
+			if got := w.Header().Get("Retry-After"); got != "2" {
+				t.Errorf("Retry-After = %q, want 2", got)
+			}
+			body := decodeResponse(t, w)
+			if body["code"] != "cart_lock_timeout" || body["retry_after_seconds"] != float64(2) {
+				t.Errorf("body = %v", body)
+			}
+			assertQuantities(t, "stored", storedCart(t, cartKey), map[int]int{1: 2})
+
+			// Once the holder is done the request goes through
+			held.Release(utils.Ctx)
+			if w := serve(t, tt.handler, tt.req); w.Code != http.StatusOK {
+				t.Errorf("status after release = %d, want %d: %s", w.Code, http.StatusOK, w.Body)
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"cart-service/models"
+	"encoding/json"
+	"net/http"
+	"path"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestLoyaltyTierPricing(t *testing.T) {
+	if err := models.SetLoyaltyDiscounts("gold=10,silver=5"); err != nil {
+		t.Fatalf("SetLoyaltyDiscounts: %v", err)
+	}
+	t.Cleanup(func() { models.SetLoyaltyDiscounts("") })
+
+	tests := []struct {
+		name      string
+		userID    string
+		wantTier  string
+		wantFinal float64
+	}{
+		{name: "gold", userID: "1", wantTier: "gold", wantFinal: 90},
+		{name: "silver", userID: "2", wantTier: "silver", wantFinal: 95},
+		{name: "not enrolled", userID: "3", wantFinal: 100},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newTestRedis(t)
+			tiers := map[string]string{"1": "gold", "2": "silver"}
+			lookups := 0
+			newJSONService(t, "LOYALTY_SERVICE_URL", func(w http.ResponseWriter, r *http.Request) {
+				lookups++
+				tier, ok := tiers[path.Base(path.Dir(r.URL.Path))]
+				if !ok {
+					w.WriteHeader(http.StatusNotFound)
+					return
+				}
+				json.NewEncoder(w).Encode(gin.H{"tier": tier})
+			})
+			cartKey := cartKeyFor(tt.userID)
+			seedCart(t, cartKey, testItem(1, 25, 4))
+
+			// Two writes in one session look the tier up once
+			for i := 0; i < 2; i++ {
+				w := serve(t, RecalculateCart, testRequest{method: http.MethodPost, route: "/recalculate", userID: tt.userID})
+				if w.Code != http.StatusOK {
+					t.Fatalf("status = %d: %s", w.Code, w.Body)
+				}
+			}
+			if tt.wantTier != "" && lookups != 1 {
+				t.Errorf("loyalty service called %d times, want 1", lookups)
+			}
+
+			cart := storedCart(t, cartKey)
+			if cart.LoyaltyTier != tt.wantTier || cart.FinalPrice != tt.wantFinal {
+				t.Errorf("tier %q priced at %v, want %q at %v", cart.LoyaltyTier, cart.FinalPrice, tt.wantTier, tt.wantFinal)
+			}
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"cart-service/models"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestDisplayPrices(t *testing.T) {
+	tests := []struct {
+		name         string
+		ending       string
+		wantDisplays map[float64]interface{}
+	}{
+		{name: "99 ending", ending: "0.99", wantDisplays: map[float64]interface{}{1: 9.99, 2: 4.99}},
+		{name: "95 ending", ending: "0.95", wantDisplays: map[float64]interface{}{1: 9.95, 2: 4.95}},
+		{name: "display prices off", wantDisplays: map[float64]interface{}{1: nil, 2: nil}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newTestRedis(t)
+			if err := models.SetPriceEnding(tt.ending); err != nil {
+				t.Fatalf("SetPriceEnding: %v", err)
+			}
+			t.Cleanup(func() { models.SetPriceEnding("") })
+			newProductService(t, map[int]gin.H{
+				1: {"name": "Lamp", "price": 10, "quantity": 10},
+				2: {"name": "Bulb", "price": 4.5, "quantity": 10},
+			})
+			for _, body := range []string{`{"product_id": 1, "quantity": 2}`, `{"product_id": 2}`} {
+				if w := serve(t, AddItem, testRequest{method: http.MethodPost, route: "/items", body: body}); w.Code != http.StatusOK {
+					t.Fatalf("add status = %d: %s", w.Code, w.Body)
+				}
+			}
+
+			w := serve(t, GetCart, testRequest{route: "/"})
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d: %s", w.Code, w.Body)
+			}
+			cart := decodeResponse(t, w)["cart"].(map[string]interface{})
+			for _, entry := range cart["items"].([]interface{}) {
+				item := entry.(map[string]interface{})
+				productID := item["product_id"].(float64)
+				if item["display_price"] != tt.wantDisplays[productID] {
+					t.Errorf("product %v display_price = %v, want %v", productID, item["display_price"], tt.wantDisplays[productID])
+				}
+			}
+			// Totals use the exact prices
+			if cart["total_price"] != 24.5 {
+				t.Errorf("total_price = %v, want 24.5", cart["total_price"])
+			}
+		})
+	}
+}
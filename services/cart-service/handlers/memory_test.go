@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"cart-service/utils"
+	"net/http"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/alicebob/miniredis/v2/server"
+	"github.com/gin-gonic/gin"
+)
+
+// simulateMemoryPressure makes Redis report 95% of maxmemory used and
+// runs a memory check, clearing the pressure again when the test ends
+func simulateMemoryPressure(t *testing.T, mr *miniredis.Miniredis) {
+	t.Helper()
+	used := "950"
+	mr.Server().SetPreHook(func(c *server.Peer, cmd string, args ...string) bool {
+		if cmd != "INFO" {
+			return false
+		}
+		c.WriteBulk("# Memory\r\nused_memory:" + used + "\r\nmaxmemory:1000\r\n")
+		return true
+	})
+	t.Setenv("REDIS_MEMORY_CHECK_SECONDS", "3600")
+	utils.StartMemoryMonitor()
+	if !utils.MemoryPressure() {
+		t.Fatal("memory pressure was not detected")
+	}
+	t.Cleanup(func() {
+		used = "10"
+		utils.StartMemoryMonitor()
+	})
+}
+
+func TestShedWritesUnderMemoryPressure(t *testing.T) {
+	tests := []struct {
+		name       string
+		handler    gin.HandlerFunc
+		req        testRequest
+		noCart     bool
+		wantStatus int
+	}{
+		{
+			name:       "create a cart",
+			handler:    AddItem,
+			req:        testRequest{method: http.MethodPost, route: "/items", body: `{"product_id": 2}`},
+			noCart:     true,
+			wantStatus: http.StatusServiceUnavailable,
+		},
+		{
+			name:       "large add",
+			handler:    AddItem,
+			req:        testRequest{method: http.MethodPost, route: "/items", body: `{"product_id": 2, "quantity": 11}`},
+			wantStatus: http.StatusServiceUnavailable,
+		},
+		{
+			name:       "small add to an existing cart",
+			handler:    AddItem,
+			req:        testRequest{method: http.MethodPost, route: "/items", body: `{"product_id": 2, "quantity": 2}`},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "read",
+			handler:    GetCart,
+			req:        testRequest{route: "/"},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "remove",
+			handler:    RemoveItem,
+			req:        testRequest{method: http.MethodDelete, route: "/items/:product_id", target: "/items/1"},
+			wantStatus: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mr := newTestRedis(t)
+			newProductService(t, map[int]gin.H{
+				1: {"name": "Kettle", "price": 20, "quantity": 100},
+				2: {"name": "Mug", "price": 5, "quantity": 100},
+			})
+			if !tt.noCart {
+				seedCart(t, cartKeyFor(testUserID), testItem(1, 20, 1))
+			}
+			simulateMemoryPressure(t, mr)
+
+			w := serve(t, tt.handler, tt.req)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+			if tt.wantStatus == http.StatusServiceUnavailable && w.Header().Get("Retry-After") == "" {
+				t.Error("shed request has no Retry-After")
+			}
+		})
+	}
+}
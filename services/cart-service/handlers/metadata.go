@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"cart-service/models"
+	"cart-service/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// metadataLimits returns the configured bounds on cart metadata
+func metadataLimits() models.MetadataLimits {
+	return models.MetadataLimits{
+		MaxKeys:        utils.GetEnvInt("CART_METADATA_MAX_KEYS", 20),
+		MaxKeyLength:   utils.GetEnvInt("CART_METADATA_MAX_KEY_LENGTH", 64),
+		MaxValueLength: utils.GetEnvInt("CART_METADATA_MAX_VALUE_LENGTH", 512),
+	}
+}
+
+// SetCartMetadata merges custom key-value metadata into the cart
+func SetCartMetadata(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req models.SetMetadataRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	cartKey, _, ok := requestCart(c, userID)
+	if !ok {
+		return
+	}
+	lock, ok := lockCart(c, cartKey)
+	if !ok {
+		return
+	}
+	defer lock.Release(c.Request.Context())
+	if !ensureNotFrozen(c, cartKey) {
+		return
+	}
+
+	cart, err := loadCart(c.Request.Context(), cartKey)
+	if err == errCartCorrupt {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse cart data"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Cart not found", "code": codeCartEmpty})
+		return
+	}
+
+	if err := cart.MergeMetadata(req.Metadata, metadataLimits()); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := saveCart(c.Request.Context(), cartKey, cart); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save cart"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "Cart metadata updated",
+		"metadata": cart.Metadata,
+	})
+}
+
+// DeleteCartMetadata removes a single metadata key from the cart
+func DeleteCartMetadata(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	key := c.Param("key")
+	cartKey, _, ok := requestCart(c, userID)
+	if !ok {
+		return
+	}
+	lock, ok := lockCart(c, cartKey)
+	if !ok {
+		return
+	}
+	defer lock.Release(c.Request.Context())
+	if !ensureNotFrozen(c, cartKey) {
+		return
+	}
+
+	cart, err := loadCart(c.Request.Context(), cartKey)
+	if err == errCartCorrupt {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse cart data"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Cart not found", "code": codeCartEmpty})
+		return
+	}
+
+	if _, found := cart.Metadata[key]; !found {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Metadata key not found"})
+		return
+	}
+	delete(cart.Metadata, key)
+
+	if err := saveCart(c.Request.Context(), cartKey, cart); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save cart"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "Cart metadata key removed",
+		"metadata": cart.Metadata,
+	})
+}
@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestCartMetadata(t *testing.T) {
+	newTestRedis(t)
+	t.Setenv("CART_METADATA_MAX_KEYS", "3")
+	t.Setenv("CART_METADATA_MAX_VALUE_LENGTH", "16")
+	cartKey := cartKeyFor(testUserID)
+	seedCart(t, cartKey, testItem(1, 10, 1))
+
+	steps := []struct {
+		name       string
+		handler    gin.HandlerFunc
+		req        testRequest
+		wantStatus int
+		want       map[string]string
+	}{
+		{
+			name:       "set keys",
+			handler:    SetCartMetadata,
+			req:        testRequest{method: http.MethodPut, route: "/metadata", body: `{"metadata": {"quote_id": "Q-1", "rep": "sam"}}`},
+			wantStatus: http.StatusOK,
+			want:       map[string]string{"quote_id": "Q-1", "rep": "sam"},
+		},
+		{
+			name:       "merge overwrites and adds",
+			handler:    SetCartMetadata,
+			req:        testRequest{method: http.MethodPut, route: "/metadata", body: `{"metadata": {"rep": "alex", "channel": "phone"}}`},
+			wantStatus: http.StatusOK,
+			want:       map[string]string{"quote_id": "Q-1", "rep": "alex", "channel": "phone"},
+		},
+		{
+			name:       "too many keys",
+			handler:    SetCartMetadata,
+			req:        testRequest{method: http.MethodPut, route: "/metadata", body: `{"metadata": {"region": "eu"}}`},
+			wantStatus: http.StatusUnprocessableEntity,
+			want:       map[string]string{"quote_id": "Q-1", "rep": "alex", "channel": "phone"},
+		},
+		{
+			name:       "value too long",
+			handler:    SetCartMetadata,
+			req:        testRequest{method: http.MethodPut, route: "/metadata", body: `{"metadata": {"rep": "` + strings.Repeat("x", 17) + `"}}`},
+			wantStatus: http.StatusUnprocessableEntity,
+			want:       map[string]string{"quote_id": "Q-1", "rep": "alex", "channel": "phone"},
+		},
+		{
+			name:       "delete a key",
+			handler:    DeleteCartMetadata,
+			req:        testRequest{method: http.MethodDelete, route: "/metadata/:key", target: "/metadata/rep"},
+			wantStatus: http.StatusOK,
+			want:       map[string]string{"quote_id": "Q-1", "channel": "phone"},
+		},
+		{
+			name:       "delete a missing key",
+			handler:    DeleteCartMetadata,
+			req:        testRequest{method: http.MethodDelete, route: "/metadata/:key", target: "/metadata/rep"},
+			wantStatus: http.StatusNotFound,
+			want:       map[string]string{"quote_id": "Q-1", "channel": "phone"},
+		},
+		{
+			name:       "room for a key again after delete",
+			handler:    SetCartMetadata,
+			req:        testRequest{method: http.MethodPut, route: "/metadata", body: `{"metadata": {"region": "eu"}}`},
+			wantStatus: http.StatusOK,
+			want:       map[string]string{"quote_id": "Q-1", "channel": "phone", "region": "eu"},
+		},
+	}
+
+	for _, step := range steps {
+		w := serve(t, step.handler, step.req)
+		if w.Code != step.wantStatus {
+			t.Fatalf("%s: status = %d, want %d: %s", step.name, w.Code, step.wantStatus, w.Body)
+		}
+		if got := storedCart(t, cartKey).Metadata; !reflect.DeepEqual(got, step.want) {
+			t.Errorf("%s: metadata = %v, want %v", step.name, got, step.want)
+		}
+	}
+}
+
+func TestCartMetadataWithoutCart(t *testing.T) {
+	newTestRedis(t)
+
+	w := serve(t, SetCartMetadata, testRequest{method: http.MethodPut, route: "/metadata", body: `{"metadata": {"rep": "sam"}}`})
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+}
@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"cart-service/models"
+	"cart-service/utils"
+	"log"
+)
+
+var (
+	cartItemsHistogram = utils.NewHistogram(
+		"cart_items_per_save",
+		"Number of items in a cart when it is saved",
+		[]float64{1, 5, 10, 25, 50, 100, 250},
+	)
+	cartSizeHistogram = utils.NewHistogram(
+		"cart_size_bytes",
+		"Serialized size of a cart when it is saved",
+		[]float64{512, 1024, 4096, 16384, 65536, 262144, 1048576},
+	)
+	cartValueHistogram = utils.NewHistogram(
+		"cart_value",
+		"Cart total price when it is saved, in the cart's currency",
+		[]float64{10, 25, 50, 100, 250, 500, 1000, 2500, 5000},
+	)
+	cartSizeWarnings = utils.NewCounter(
+		"cart_size_warnings_total",
+		"Number of cart saves exceeding CART_SIZE_WARN_BYTES",
+	)
+)
+
+// recordCartSave emits size and value metrics for a saved cart and warns when a
+// single cart grows large enough to put pressure on Redis
+func recordCartSave(cartKey string, cart *models.Cart, size int) {
+	cartItemsHistogram.Observe(float64(cart.TotalItems))
+	cartSizeHistogram.Observe(float64(size))
+	cartValueHistogram.Observe(cart.TotalPrice)
+
+	warnBytes := utils.GetEnvInt("CART_SIZE_WARN_BYTES", 65536)
+	if size > warnBytes {
+		cartSizeWarnings.Inc()
+		log.Printf("WARNING: cart %s is %d bytes (%d items), exceeding CART_SIZE_WARN_BYTES=%d",
+			cartKey, size, cart.TotalItems, warnBytes)
+	}
+}
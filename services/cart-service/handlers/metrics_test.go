@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"bytes"
+	"cart-service/models"
+	"cart-service/utils"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+// scrapeMetric returns the current value of a metric from /metrics
+func scrapeMetric(t *testing.T, name string) float64 {
+	t.Helper()
+	w := httptest.NewRecorder()
+	utils.MetricsHandler(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	for _, line := range strings.Split(w.Body.String(), "\n") {
+		var value float64
+		if _, err := fmt.Sscanf(line, name+" %g", &value); err == nil {
+			return value
+		}
+	}
+	t.Fatalf("metric %s not exported", name)
+	return 0
+}
+
+func TestSaveCartSizeWarning(t *testing.T) {
+	tests := []struct {
+		name      string
+		warnBytes string
+		wantWarn  bool
+	}{
+		{name: "small cart", warnBytes: "65536", wantWarn: false},
+		{name: "oversized cart", warnBytes: "100", wantWarn: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newTestRedis(t)
+			t.Setenv("CART_SIZE_WARN_BYTES", tt.warnBytes)
+			var logs bytes.Buffer
+			log.SetOutput(&logs)
+			t.Cleanup(func() { log.SetOutput(os.Stderr) })
+
+			warningsBefore := scrapeMetric(t, "cart_size_warnings_total")
+			savesBefore := scrapeMetric(t, "cart_size_bytes_count")
+
+			cart := models.NewCart(testUserID)
+			cart.Items = []models.CartItem{testItem(1, 10, 2), testItem(2, 5, 1)}
+			storeCart(t, cartKeyFor(testUserID), cart)
+
+			if got := scrapeMetric(t, "cart_size_bytes_count") - savesBefore; got != 1 {
+				t.Errorf("cart_size_bytes observed %v saves, want 1", got)
+			}
+			warned := scrapeMetric(t, "cart_size_warnings_total") - warningsBefore
+			if tt.wantWarn != (warned == 1) {
+				t.Errorf("cart_size_warnings_total grew by %v, want warning %v", warned, tt.wantWarn)
+			}
+			if tt.wantWarn != strings.Contains(logs.String(), "exceeding CART_SIZE_WARN_BYTES") {
+				t.Errorf("warning logged = %v, want %v: %q", !tt.wantWarn, tt.wantWarn, logs.String())
+			}
+		})
+	}
+}
+
+func TestSaveCartValueHistogram(t *testing.T) {
+	newTestRedis(t)
+
+	// Buckets are cumulative, so each counts every cart at or below it
+	wantGrowth := map[string]float64{
+		`cart_value_bucket{le="10"}`:   1,
+		`cart_value_bucket{le="25"}`:   1,
+		`cart_value_bucket{le="50"}`:   2,
+		`cart_value_bucket{le="2500"}`: 2,
+		`cart_value_bucket{le="5000"}`: 3,
+		`cart_value_bucket{le="+Inf"}`: 3,
+		"cart_value_count":             3,
+		"cart_value_sum":               3048,
+	}
+	before := map[string]float64{}
+	for name := range wantGrowth {
+		before[name] = scrapeMetric(t, name)
+	}
+
+	for i, total := range []float64{8, 40, 3000} {
+		cart := models.NewCart(testUserID)
+		cart.Items = []models.CartItem{testItem(1, total, 1)}
+		storeCart(t, namedCartKeyFor(testUserID, fmt.Sprintf("cart%d", i)), cart)
+	}
+
+	for name, want := range wantGrowth {
+		if got := scrapeMetric(t, name) - before[name]; got != want {
+			t.Errorf("%s grew by %v, want %v", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"cart-service/models"
+	"fmt"
+	"net/http"
+	"sort"
+	"testing"
+	"time"
+)
+
+// sortedKeys returns the keys of a decoded JSON object in order
+func sortedKeys(object map[string]interface{}) []string {
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func TestGetMobileCart(t *testing.T) {
+	wantCartFields := []string{"currency", "discount", "items", "subtotal", "tax", "total", "total_items"}
+
+	tests := []struct {
+		name          string
+		seed          bool
+		wantItems     int
+		wantTotal     float64
+		wantItemField []string
+	}{
+		{
+			name:          "cart with verbose fields",
+			seed:          true,
+			wantItems:     2,
+			wantTotal:     25,
+			wantItemField: []string{"name", "price", "product_id", "qty", "subtotal", "thumbnail"},
+		},
+		{name: "no cart"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newTestRedis(t)
+			if tt.seed {
+				lamp := testItem(1, 10, 2)
+				lamp.ImageURL = "https://img.example.com/1-thumb.jpg"
+				lamp.AddedAt = time.Now().Format(time.RFC3339)
+				cart := models.NewCart(testUserID)
+				cart.Items = []models.CartItem{lamp, testItem(2, 5, 1)}
+				cart.Metadata = map[string]string{"source": "ads"}
+				cart.GiftWrap = &models.GiftWrap{Message: "Happy birthday"}
+				cart.CalculateTotals()
+				storeCart(t, cartKeyFor(testUserID), cart)
+			}
+
+			w := serve(t, GetMobileCart, testRequest{route: "/mobile"})
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d: %s", w.Code, w.Body)
+			}
+			cart := decodeResponse(t, w)["cart"].(map[string]interface{})
+			if fields := sortedKeys(cart); fmt.Sprint(fields) != fmt.Sprint(wantCartFields) {
+				t.Errorf("cart fields = %v, want %v", fields, wantCartFields)
+			}
+			if cart["total"] != tt.wantTotal {
+				t.Errorf("total = %v, want %v", cart["total"], tt.wantTotal)
+			}
+
+			items := cart["items"].([]interface{})
+			if len(items) != tt.wantItems {
+				t.Fatalf("items = %v, want %d", items, tt.wantItems)
+			}
+			if tt.wantItems == 0 {
+				return
+			}
+			var lamp map[string]interface{}
+			for _, entry := range items {
+				if item := entry.(map[string]interface{}); item["product_id"] == float64(1) {
+					lamp = item
+				}
+			}
+			if fields := sortedKeys(lamp); fmt.Sprint(fields) != fmt.Sprint(tt.wantItemField) {
+				t.Errorf("item fields = %v, want %v", fields, tt.wantItemField)
+			}
+			if lamp["qty"] != float64(2) || lamp["subtotal"] != float64(20) || lamp["thumbnail"] != "https://img.example.com/1-thumb.jpg" {
+				t.Errorf("lamp item = %v", lamp)
+			}
+		})
+	}
+}
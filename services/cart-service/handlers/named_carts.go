@@ -0,0 +1,198 @@
+package handlers
+
+import (
+	"cart-service/models"
+	"cart-service/utils"
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// scanNamedCartKeys returns the keys of all of a user's named carts
+func scanNamedCartKeys(ctx context.Context, userID interface{}) ([]string, error) {
+	return utils.ScanKeys(ctx, utils.Key("cart", utils.UserKey(fmt.Sprintf("%v", userID)), "*"))
+}
+
+// withinCartLimit writes a 422 and returns false if creating the named cart
+// would take the user past MAX_CARTS_PER_USER. The default cart is not
+// counted; 0 disables the limit.
+func withinCartLimit(c *gin.Context, userID interface{}, name string, creating bool) bool {
+	limit := utils.GetEnvInt("MAX_CARTS_PER_USER", 0)
+	if !creating || name == "" || limit <= 0 {
+		return true
+	}
+
+	keys, err := scanNamedCartKeys(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list carts"})
+		return false
+	}
+	if len(keys) >= limit {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error":     "Maximum number of carts reached",
+			"max_carts": limit,
+		})
+		return false
+	}
+	return true
+}
+
+// ListCarts returns every named cart for the user with aggregate stats
+func ListCarts(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	keys, err := scanNamedCartKeys(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list carts"})
+		return
+	}
+
+	summaries := []models.CartSummary{}
+	if len(keys) > 0 {
+		values, err := utils.GetMany(c.Request.Context(), keys...)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list carts"})
+			return
+		}
+
+		for _, key := range keys {
+			cartData, ok := values[key]
+			if !ok {
+				// Expired between SCAN and GET
+				continue
+			}
+			cart, err := decodeCart(cartData)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse cart data"})
+				return
+			}
+			summaries = append(summaries, cart.Summary())
+		}
+	}
+
+	sort.Slice(summaries, func(i, j int) bool {
+		return summaries[i].Name < summaries[j].Name
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"carts": summaries,
+		"count": len(summaries),
+	})
+}
+
+// clearNamedCarts deletes the listed named carts and reports how many
+// existed
+func clearNamedCarts(c *gin.Context, userID interface{}, names []string) {
+	keys := []string{}
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if !cartNamePattern.MatchString(name) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cart name: " + name})
+			return
+		}
+		keys = append(keys, namedCartKeyFor(userID, name))
+	}
+
+	if len(keys) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No cart names given"})
+		return
+	}
+
+	deleteCarts(c, keys)
+}
+
+// deleteCarts removes the given carts unless any is frozen, giving back
+// what they hold, and writes the number cleared
+func deleteCarts(c *gin.Context, keys []string) {
+	var cleared int64
+	if len(keys) > 0 {
+		// Every cart is locked, in key order like SplitCart, so a
+		// concurrent writer can't save a cart back after it is cleared
+		keys = sortedUniqueKeys(keys)
+		for _, key := range keys {
+			lock, ok := lockCart(c, key)
+			if !ok {
+				return
+			}
+			defer lock.Release(c.Request.Context())
+		}
+		if !ensureNotFrozen(c, keys...) {
+			return
+		}
+
+		// Load the carts to give back what they hold. Corrupt carts are
+		// still cleared.
+		carts := make(map[string]*models.Cart, len(keys))
+		for _, key := range keys {
+			if cart, err := loadCart(c.Request.Context(), key); err == nil {
+				carts[key] = cart
+			}
+		}
+
+		var err error
+		cleared, err = utils.DeleteKeys(c.Request.Context(), keys...)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to clear carts"})
+			return
+		}
+		for key, cart := range carts {
+			releaseClearedCart(c.Request.Context(), key, cart)
+		}
+
+		// Stale counts heal on read, so failing to drop them isn't fatal
+		countKeys := make([]string, 0, 2*len(keys))
+		for _, key := range keys {
+			countKeys = append(countKeys, countKeyFor(key), lastViewedKeyFor(key))
+		}
+		if _, err := utils.DeleteKeys(c.Request.Context(), countKeys...); err != nil {
+			log.Printf("Failed to clear cart counts: %v", err)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Carts cleared successfully",
+		"cleared": cleared,
+	})
+}
+
+// sortedUniqueKeys returns keys sorted, without duplicates
+func sortedUniqueKeys(keys []string) []string {
+	sorted := append([]string(nil), keys...)
+	sort.Strings(sorted)
+	unique := sorted[:0]
+	for i, key := range sorted {
+		if i == 0 || key != sorted[i-1] {
+			unique = append(unique, key)
+		}
+	}
+	return unique
+}
+
+// ClearAllCarts clears every named cart for the user
+func ClearAllCarts(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	keys, err := scanNamedCartKeys(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list carts"})
+		return
+	}
+
+	deleteCarts(c, keys)
+}
@@ -0,0 +1,211 @@
+package handlers
+
+import (
+	"cart-service/models"
+	"cart-service/utils"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestListCarts(t *testing.T) {
+	tests := []struct {
+		name      string
+		carts     map[string][]models.CartItem
+		wantNames []string
+		wantItems []float64
+	}{
+		{
+			name: "several named carts",
+			carts: map[string][]models.CartItem{
+				"work":     {testItem(1, 10, 2)},
+				"birthday": {testItem(2, 5, 1), testItem(3, 7.5, 4)},
+			},
+			wantNames: []string{"birthday", "work"},
+			wantItems: []float64{5, 2},
+		},
+		{
+			name:      "no named carts",
+			carts:     map[string][]models.CartItem{},
+			wantNames: []string{},
+			wantItems: []float64{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newTestRedis(t)
+			// The default cart is not a named cart and isn't listed
+			seedCart(t, cartKeyFor(testUserID), testItem(9, 1, 1))
+			for name, items := range tt.carts {
+				cart := models.NewCart(testUserID)
+				cart.Name = name
+				cart.Items = items
+				storeCart(t, namedCartKeyFor(testUserID, name), cart)
+			}
+
+			w := serve(t, ListCarts, testRequest{route: "/all"})
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d: %s", w.Code, w.Body)
+			}
+			body := decodeResponse(t, w)
+			if body["count"] != float64(len(tt.wantNames)) {
+				t.Errorf("count = %v, want %d", body["count"], len(tt.wantNames))
+			}
+			carts := body["carts"].([]interface{})
+			if len(carts) != len(tt.wantNames) {
+				t.Fatalf("listed %d carts, want %d", len(carts), len(tt.wantNames))
+			}
+			for i, entry := range carts {
+				summary := entry.(map[string]interface{})
+				if summary["name"] != tt.wantNames[i] {
+					t.Errorf("cart %d name = %v, want %s", i, summary["name"], tt.wantNames[i])
+				}
+				if summary["total_items"] != tt.wantItems[i] {
+					t.Errorf("cart %s total_items = %v, want %v", tt.wantNames[i], summary["total_items"], tt.wantItems[i])
+				}
+				if summary["updated_at"] == "" {
+					t.Errorf("cart %s has no updated_at", tt.wantNames[i])
+				}
+			}
+		})
+	}
+}
+
+func TestBulkClearCarts(t *testing.T) {
+	tests := []struct {
+		name        string
+		handler     gin.HandlerFunc
+		target      string
+		locked      string
+		wantStatus  int
+		wantCleared float64
+		wantLeft    []string
+	}{
+		{
+			name:        "clear every named cart",
+			handler:     ClearAllCarts,
+			target:      "/all",
+			wantStatus:  http.StatusOK,
+			wantCleared: 3,
+			wantLeft:    []string{},
+		},
+		{
+			name:        "clear a subset",
+			handler:     ClearCart,
+			target:      "/?names=work,gifts",
+			wantStatus:  http.StatusOK,
+			wantCleared: 2,
+			wantLeft:    []string{"later"},
+		},
+		{
+			name:        "names that don't exist are not counted",
+			handler:     ClearCart,
+			target:      "/?names=work,nope",
+			wantStatus:  http.StatusOK,
+			wantCleared: 1,
+			wantLeft:    []string{"gifts", "later"},
+		},
+		{
+			name:       "invalid name",
+			handler:    ClearCart,
+			target:     "/?names=work,bad:name",
+			wantStatus: http.StatusBadRequest,
+			wantLeft:   []string{"gifts", "later", "work"},
+		},
+		{
+			name:       "a cart being written is left alone",
+			handler:    ClearAllCarts,
+			target:     "/all",
+			locked:     "later",
+			wantStatus: http.StatusConflict,
+			wantLeft:   []string{"gifts", "later", "work"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newTestRedis(t)
+			for _, name := range []string{"work", "gifts", "later"} {
+				cart := models.NewCart(testUserID)
+				cart.Name = name
+				cart.Items = []models.CartItem{testItem(1, 10, 1)}
+				storeCart(t, namedCartKeyFor(testUserID, name), cart)
+			}
+
+			if tt.locked != "" {
+				t.Setenv("CART_LOCK_WAIT_MS", "50")
+				lock, err := acquireCartLock(utils.Ctx, namedCartKeyFor(testUserID, tt.locked))
+				if err != nil {
+					t.Fatalf("failed to lock cart: %v", err)
+				}
+				defer lock.Release(utils.Ctx)
+			}
+
+			route := "/"
+			if tt.target == "/all" {
+				route = "/all"
+			}
+			w := serve(t, tt.handler, testRequest{method: http.MethodDelete, route: route, target: tt.target})
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+			if tt.wantStatus == http.StatusOK {
+				if cleared := decodeResponse(t, w)["cleared"]; cleared != tt.wantCleared {
+					t.Errorf("cleared = %v, want %v", cleared, tt.wantCleared)
+				}
+			}
+
+			left := []string{}
+			for _, name := range []string{"gifts", "later", "work"} {
+				if utils.RedisClient.Exists(utils.Ctx, namedCartKeyFor(testUserID, name)).Val() == 1 {
+					left = append(left, name)
+				}
+			}
+			if fmt.Sprint(left) != fmt.Sprint(tt.wantLeft) {
+				t.Errorf("carts left = %v, want %v", left, tt.wantLeft)
+			}
+		})
+	}
+}
+
+func TestMaxCartsPerUser(t *testing.T) {
+	newTestRedis(t)
+	newProductService(t, map[int]gin.H{1: {"name": "Kettle", "price": 20, "quantity": 10}})
+	newPromotionsService(t, promotionsFixture{})
+	t.Setenv("MAX_CARTS_PER_USER", "2")
+
+	steps := []struct {
+		name       string
+		cart       string
+		wantStatus int
+	}{
+		{name: "first named cart", cart: "work", wantStatus: http.StatusOK},
+		{name: "second named cart reaches the limit", cart: "gifts", wantStatus: http.StatusOK},
+		{name: "third named cart is refused", cart: "later", wantStatus: http.StatusUnprocessableEntity},
+		{name: "existing named cart is still usable", cart: "work", wantStatus: http.StatusOK},
+		{name: "default cart is not counted", cart: "", wantStatus: http.StatusOK},
+	}
+	for _, step := range steps {
+		w := serve(t, AddItem, testRequest{
+			method: http.MethodPost,
+			route:  "/items",
+			target: "/items?name=" + step.cart,
+			body:   `{"product_id": 1, "quantity": 1}`,
+		})
+		if w.Code != step.wantStatus {
+			t.Fatalf("%s: status = %d, want %d: %s", step.name, w.Code, step.wantStatus, w.Body)
+		}
+		if step.wantStatus == http.StatusUnprocessableEntity {
+			if max := decodeResponse(t, w)["max_carts"]; max != float64(2) {
+				t.Errorf("%s: max_carts = %v, want 2", step.name, max)
+			}
+		}
+	}
+
+	if utils.RedisClient.Exists(utils.Ctx, namedCartKeyFor(testUserID, "later")).Val() != 0 {
+		t.Error("the refused cart was created")
+	}
+}
@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"cart-service/models"
+	"cart-service/utils"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+)
+
+// GetAvailablePromotions lists the coupons the cart qualifies for and the
+// bundles it has started, with the projected savings of each, best first
+func GetAvailablePromotions(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	cartKey, name, ok := requestCart(c, userID)
+	if !ok {
+		return
+	}
+
+	cart, err := loadCart(c.Request.Context(), cartKey)
+	if err == errCartCorrupt {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse cart data"})
+		return
+	}
+	if err != nil {
+		cart = newCart(userID, name)
+	}
+
+	offers := []models.Offer{}
+	if len(cart.Items) == 0 {
+		c.JSON(http.StatusOK, gin.H{"offers": offers})
+		return
+	}
+
+	available, err := utils.FetchAvailablePromotions(c.Request.Context(), cart)
+	if err != nil {
+		log.Printf("Failed to fetch available promotions: %v", err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to fetch promotions"})
+		return
+	}
+	if available == nil {
+		c.JSON(http.StatusOK, gin.H{"offers": offers})
+		return
+	}
+
+	for _, coupon := range available.Coupons {
+		if cart.Coupon != nil && cart.Coupon.Code == coupon.Code {
+			continue
+		}
+		if coupon.UsageLimit > 0 {
+			uses, err := utils.RedisClient.Get(c.Request.Context(), couponUsesKeyFor(coupon.Code)).Int()
+			if err != nil && err != redis.Nil {
+				log.Printf("Failed to load usage for coupon %s: %v", coupon.Code, err)
+				continue
+			}
+			if coupon.CheckUsage(uses) != nil {
+				continue
+			}
+		}
+		if offer, ok := cart.CouponOffer(coupon); ok {
+			offers = append(offers, offer)
+		}
+	}
+	for _, bundle := range available.Bundles {
+		if offer, ok := cart.BundleOffer(bundle); ok {
+			offers = append(offers, offer)
+		}
+	}
+	models.SortOffers(offers)
+
+	c.JSON(http.StatusOK, gin.H{"offers": offers})
+}
@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"cart-service/models"
+	"cart-service/utils"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestGetAvailablePromotions(t *testing.T) {
+	available := &utils.AvailablePromotions{
+		Coupons: []models.Coupon{
+			{Code: "SAVE10", Type: models.CouponTypePercent, Value: 10, ProductIDs: []int{1}},
+			{Code: "BIG", Type: models.CouponTypeFixed, Value: 20, MinOrderValue: 100},
+		},
+		Bundles: []models.Bundle{
+			{ID: "kettle-set", Name: "Kettle set", ProductIDs: []int{1, 3}, DiscountAmount: 5},
+			{ID: "tea-set", Name: "Tea set", ProductIDs: []int{4, 5}, DiscountAmount: 3},
+		},
+	}
+
+	tests := []struct {
+		name       string
+		items      []models.CartItem
+		wantOffers []models.Offer
+	}{
+		{
+			name:  "eligible cart",
+			items: []models.CartItem{testItem(1, 10, 2), testItem(2, 5, 1)},
+			wantOffers: []models.Offer{
+				{Type: models.OfferTypeBundle, Code: "kettle-set", Name: "Kettle set", ProjectedSavings: 5, MissingProductIDs: []int{3}},
+				{Type: models.OfferTypeCoupon, Code: "SAVE10", ProjectedSavings: 2.5},
+			},
+		},
+		{
+			name:       "ineligible cart",
+			items:      []models.CartItem{testItem(7, 10, 1)},
+			wantOffers: []models.Offer{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newTestRedis(t)
+			newPromotionsService(t, promotionsFixture{available: available})
+			seedCart(t, cartKeyFor(testUserID), tt.items...)
+
+			w := serve(t, GetAvailablePromotions, testRequest{route: "/available-promotions"})
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d: %s", w.Code, w.Body)
+			}
+			var body struct {
+				Offers []models.Offer `json:"offers"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatalf("response is not JSON: %v", err)
+			}
+			if !reflect.DeepEqual(body.Offers, tt.wantOffers) {
+				t.Errorf("offers = %+v, want %+v", body.Offers, tt.wantOffers)
+			}
+		})
+	}
+}
+
+func TestGetAvailablePromotionsServiceDown(t *testing.T) {
+	newTestRedis(t)
+	newJSONService(t, "PROMOTIONS_SERVICE_URL", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	seedCart(t, cartKeyFor(testUserID), testItem(1, 10, 2))
+
+	w := serve(t, GetAvailablePromotions, testRequest{route: "/available-promotions"})
+	if w.Code != http.StatusBadGateway {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusBadGateway)
+	}
+}
@@ -0,0 +1,27 @@
+package handlers
+
+import (
+	"cart-service/utils"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetOperations returns the user's recent cart writes, newest first, with
+// their idempotency keys and outcomes, for diagnosing duplicate submits
+func GetOperations(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	operations, err := utils.RecentOperations(c.Request.Context(), fmt.Sprintf("%v", userID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load operations"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"operations": operations})
+}
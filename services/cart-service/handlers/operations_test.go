@@ -0,0 +1,191 @@
+package handlers
+
+import (
+	"cart-service/middleware"
+	"cart-service/utils"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// operationsCall is one request to the idempotent API and the response
+// expected
+type operationsCall struct {
+	method       string
+	path         string
+	body         string
+	key          string
+	wantStatus   int
+	wantReplayed bool
+}
+
+// newIdempotentRouter mounts AddItem and GetOperations behind the
+// Idempotency middleware, authenticated as the test user
+func newIdempotentRouter() *gin.Engine {
+	router := gin.New()
+	api := router.Group("/api/cart")
+	api.Use(func(c *gin.Context) { c.Set("user_id", testUserID) })
+	api.Use(middleware.Idempotency())
+	api.POST("/items", AddItem)
+	api.GET("/operations", GetOperations)
+	return router
+}
+
+func TestOperationsLog(t *testing.T) {
+	tests := []struct {
+		name    string
+		calls   []operationsCall
+		wantQty int
+		wantLog []utils.Operation
+	}{
+		{
+			name: "write without a key",
+			calls: []operationsCall{
+				{method: http.MethodPost, path: "/api/cart/items", body: `{"product_id": 1}`, wantStatus: http.StatusOK},
+			},
+			wantQty: 1,
+			wantLog: []utils.Operation{{Method: http.MethodPost, Path: "/api/cart/items", Status: http.StatusOK}},
+		},
+		{
+			name: "retry replayed",
+			calls: []operationsCall{
+				{method: http.MethodPost, path: "/api/cart/items", body: `{"product_id": 1}`, key: "k-1", wantStatus: http.StatusOK},
+				{method: http.MethodPost, path: "/api/cart/items", body: `{"product_id": 1}`, key: "k-1", wantStatus: http.StatusOK, wantReplayed: true},
+			},
+			wantQty: 1,
+			wantLog: []utils.Operation{
+				{Method: http.MethodPost, Path: "/api/cart/items", IdempotencyKey: "k-1", Status: http.StatusOK, Replayed: true},
+				{Method: http.MethodPost, Path: "/api/cart/items", IdempotencyKey: "k-1", Status: http.StatusOK},
+			},
+		},
+		{
+			name: "distinct keys both run",
+			calls: []operationsCall{
+				{method: http.MethodPost, path: "/api/cart/items", body: `{"product_id": 1}`, key: "k-1", wantStatus: http.StatusOK},
+				{method: http.MethodPost, path: "/api/cart/items", body: `{"product_id": 1}`, key: "k-2", wantStatus: http.StatusOK},
+			},
+			wantQty: 2,
+			wantLog: []utils.Operation{
+				{Method: http.MethodPost, Path: "/api/cart/items", IdempotencyKey: "k-2", Status: http.StatusOK},
+				{Method: http.MethodPost, Path: "/api/cart/items", IdempotencyKey: "k-1", Status: http.StatusOK},
+			},
+		},
+		{
+			name: "server error is not replayed",
+			calls: []operationsCall{
+				{method: http.MethodPost, path: "/api/cart/items", body: `{"product_id": 5}`, key: "k-1", wantStatus: http.StatusBadGateway},
+				{method: http.MethodPost, path: "/api/cart/items", body: `{"product_id": 5}`, key: "k-1", wantStatus: http.StatusBadGateway},
+			},
+			wantLog: []utils.Operation{
+				{Method: http.MethodPost, Path: "/api/cart/items", IdempotencyKey: "k-1", Status: http.StatusBadGateway},
+				{Method: http.MethodPost, Path: "/api/cart/items", IdempotencyKey: "k-1", Status: http.StatusBadGateway},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newTestRedis(t)
+			products := map[int]gin.H{1: {"name": "Pen", "price": 2, "quantity": 10}}
+			newJSONService(t, "PRODUCT_SERVICE_URL", func(w http.ResponseWriter, r *http.Request) {
+				if strings.HasSuffix(r.URL.Path, "/5") {
+					w.WriteHeader(http.StatusInternalServerError)
+					return
+				}
+				json.NewEncoder(w).Encode(gin.H{"product": products[1]})
+			})
+			router := newIdempotentRouter()
+
+			for i, call := range tt.calls {
+				r := httptest.NewRequest(call.method, call.path, strings.NewReader(call.body))
+				r.Header.Set("Content-Type", "application/json")
+				if call.key != "" {
+					r.Header.Set("Idempotency-Key", call.key)
+				}
+				w := httptest.NewRecorder()
+				router.ServeHTTP(w, r)
+				if w.Code != call.wantStatus {
+					t.Fatalf("call %d: status = %d, want %d: %s", i, w.Code, call.wantStatus, w.Body)
+				}
+				if replayed := w.Header().Get("Idempotent-Replayed") == "true"; replayed != call.wantReplayed {
+					t.Errorf("call %d: replayed = %v, want %v", i, replayed, call.wantReplayed)
+				}
+			}
+
+			if tt.wantQty > 0 {
+				assertQuantities(t, "stored", storedCart(t, cartKeyFor(testUserID)), map[int]int{1: tt.wantQty})
+			}
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/cart/operations", nil))
+			if w.Code != http.StatusOK {
+				t.Fatalf("operations status = %d: %s", w.Code, w.Body)
+			}
+			var body struct {
+				Operations []utils.Operation `json:"operations"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatalf("response is not JSON: %v", err)
+			}
+			if len(body.Operations) != len(tt.wantLog) {
+				t.Fatalf("operations = %+v, want %+v", body.Operations, tt.wantLog)
+			}
+			for i, op := range body.Operations {
+				if op.At == "" {
+					t.Errorf("operation %d has no time", i)
+				}
+				op.At = ""
+				if op != tt.wantLog[i] {
+					t.Errorf("operation %d = %+v, want %+v", i, op, tt.wantLog[i])
+				}
+			}
+		})
+	}
+}
+
+func TestIdempotencyKeyReleasedOnPanic(t *testing.T) {
+	newTestRedis(t)
+	t.Setenv("IDEMPOTENCY_PENDING_SECONDS", "5")
+	router := gin.New()
+	router.Use(gin.RecoveryWithWriter(io.Discard))
+	api := router.Group("/api/cart")
+	api.Use(func(c *gin.Context) { c.Set("user_id", testUserID) })
+	api.Use(middleware.Idempotency())
+	calls := 0
+	api.POST("/items", func(c *gin.Context) {
+		calls++
+		if calls == 1 {
+			panic("handler failed")
+		}
+		c.JSON(http.StatusOK, gin.H{"calls": calls})
+	})
+
+	// The panic's 500 reaches the client and the retry runs for real
+	for i, want := range []int{http.StatusInternalServerError, http.StatusOK} {
+		r := httptest.NewRequest(http.MethodPost, "/api/cart/items", nil)
+		r.Header.Set("Idempotency-Key", "k-1")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		if w.Code != want {
+			t.Fatalf("call %d: status = %d, want %d: %s", i, w.Code, want, w.Body)
+		}
+	}
+	if calls != 2 {
+		t.Errorf("handler ran %d times, want 2", calls)
+	}
+
+	// The stored response outlives the pending claim
+	keys := utils.RedisClient.Keys(utils.Ctx, "*idempotency*").Val()
+	if len(keys) != 1 {
+		t.Fatalf("idempotency keys = %v, want one", keys)
+	}
+	if ttl := utils.RedisClient.TTL(utils.Ctx, keys[0]).Val(); ttl <= 5*time.Second {
+		t.Errorf("stored response TTL = %v, want the full replay window", ttl)
+	}
+}
@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"bytes"
+	"cart-service/models"
+	"cart-service/utils"
+	"html/template"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// packingSlipTemplate renders a packing slip for printing in the warehouse
+var packingSlipTemplate = template.Must(template.New("packing-slip").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Packing slip</title>
+<style>
+body { font-family: sans-serif; }
+table { border-collapse: collapse; width: 100%; margin-bottom: 1em; }
+th, td { border-bottom: 1px solid #ccc; padding: 4px 8px; text-align: left; }
+td.num, th.num { text-align: right; }
+</style>
+</head>
+<body>
+<h1>Packing slip (preview)</h1>
+<p>{{.TotalItems}} items</p>
+{{- range .Bins}}
+<h2>{{if .Bin}}{{.Location}} / {{.Bin}}{{else}}No bin{{end}}</h2>
+<table>
+<thead>
+<tr><th>SKU</th><th>Product</th><th class="num">Quantity</th></tr>
+</thead>
+<tbody>
+{{- range .Lines}}
+<tr><td>{{.SKU}}</td><td>{{.Name}}</td><td class="num">{{.Quantity}}</td></tr>
+{{- end}}
+</tbody>
+</table>
+{{- end}}
+</body>
+</html>
+`))
+
+// GetPackingSlip previews the packing slip for the cart, with items grouped
+// by the warehouse bin they are picked from, as JSON or, with
+// ?format=html, a printable page
+func GetPackingSlip(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	format := c.DefaultQuery("format", "json")
+	if format != "json" && format != "html" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be 'json' or 'html'"})
+		return
+	}
+
+	cartKey, _, ok := requestCart(c, userID)
+	if !ok {
+		return
+	}
+
+	cart, err := loadCart(c.Request.Context(), cartKey)
+	if err == errCartCorrupt {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse cart data"})
+		return
+	}
+	if err != nil || len(cart.Items) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Cart is empty", "code": codeCartEmpty})
+		return
+	}
+
+	// Bins come from product-service; products that can't be fetched are
+	// listed without one
+	productIDs := make([]int, 0, len(cart.Items))
+	for _, item := range cart.Items {
+		productIDs = append(productIDs, item.ProductID)
+	}
+	products, errs := utils.FetchProducts(c.Request.Context(), productIDs)
+	for productID, err := range errs {
+		log.Printf("Failed to fetch product %d: %v", productID, err)
+	}
+
+	locations := make(map[int]models.BinLocation, len(products))
+	for productID, product := range products {
+		locations[productID] = models.BinLocation{Location: product.Location, Bin: product.Bin}
+	}
+	slip := cart.PackingSlip(locations)
+
+	if format == "json" {
+		c.JSON(http.StatusOK, gin.H{"packing_slip": slip})
+		return
+	}
+
+	var page bytes.Buffer
+	if err := packingSlipTemplate.Execute(&page, slip); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to render packing slip"})
+		return
+	}
+	c.Data(http.StatusOK, "text/html; charset=utf-8", page.Bytes())
+}
@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"cart-service/models"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// packingItem returns a cart item with a SKU, for a packing slip
+func packingItem(productID, quantity int) models.CartItem {
+	item := testItem(productID, 5, quantity)
+	item.SKU = fmt.Sprintf("SKU-%d", productID)
+	return item
+}
+
+func TestGetPackingSlip(t *testing.T) {
+	tests := []struct {
+		name       string
+		query      string
+		items      []models.CartItem
+		wantStatus int
+		wantBins   []string
+		wantTotal  int
+		wantHTML   []string
+	}{
+		{
+			name:       "grouped by bin",
+			items:      []models.CartItem{packingItem(4, 1), packingItem(1, 2), packingItem(2, 3), packingItem(3, 1), packingItem(9, 1)},
+			wantStatus: http.StatusOK,
+			wantBins: []string{
+				"A/A-01: SKU-2 x3",
+				"A/A-03: SKU-1 x2, SKU-4 x1",
+				"B/B-01: SKU-3 x1",
+				"/: SKU-9 x1",
+			},
+			wantTotal: 8,
+		},
+		{
+			name:       "printable page",
+			query:      "?format=html",
+			items:      []models.CartItem{packingItem(1, 2), packingItem(3, 1)},
+			wantStatus: http.StatusOK,
+			wantHTML:   []string{"<h2>A / A-03</h2>", "<td>SKU-1</td>", `<td class="num">2</td>`, "<h2>B / B-01</h2>", "<p>3 items</p>"},
+		},
+		{
+			name:       "unknown format",
+			query:      "?format=pdf",
+			items:      []models.CartItem{packingItem(1, 2)},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "empty cart",
+			wantStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newTestRedis(t)
+			newProductService(t, map[int]gin.H{
+				1: {"name": "Mug", "price": 5, "location": "A", "bin": "A-03"},
+				2: {"name": "Plate", "price": 5, "location": "A", "bin": "A-01"},
+				3: {"name": "Bowl", "price": 5, "location": "B", "bin": "B-01"},
+				4: {"name": "Cup", "price": 5, "location": "A", "bin": "A-03"},
+			})
+			if tt.items != nil {
+				seedCart(t, cartKeyFor(testUserID), tt.items...)
+			}
+
+			w := serve(t, GetPackingSlip, testRequest{route: "/packing-slip", target: "/packing-slip" + tt.query})
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+
+			if tt.wantHTML != nil {
+				if contentType := w.Header().Get("Content-Type"); !strings.HasPrefix(contentType, "text/html") {
+					t.Errorf("Content-Type = %q, want HTML", contentType)
+				}
+				for _, want := range tt.wantHTML {
+					if !strings.Contains(w.Body.String(), want) {
+						t.Errorf("page is missing %q:\n%s", want, w.Body)
+					}
+				}
+			}
+
+			if tt.wantBins != nil {
+				var body struct {
+					PackingSlip models.PackingSlip `json:"packing_slip"`
+				}
+				if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+					t.Fatalf("response is not JSON: %v", err)
+				}
+				bins := []string{}
+				for _, bin := range body.PackingSlip.Bins {
+					lines := []string{}
+					for _, line := range bin.Lines {
+						lines = append(lines, fmt.Sprintf("%s x%d", line.SKU, line.Quantity))
+					}
+					bins = append(bins, fmt.Sprintf("%s/%s: %s", bin.Location, bin.Bin, strings.Join(lines, ", ")))
+				}
+				if fmt.Sprint(bins) != fmt.Sprint(tt.wantBins) {
+					t.Errorf("bins = %q, want %q", bins, tt.wantBins)
+				}
+				if body.PackingSlip.TotalItems != tt.wantTotal {
+					t.Errorf("total items = %d, want %d", body.PackingSlip.TotalItems, tt.wantTotal)
+				}
+			}
+		})
+	}
+}
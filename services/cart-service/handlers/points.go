@@ -0,0 +1,181 @@
+package handlers
+
+import (
+	"cart-service/models"
+	"cart-service/utils"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RedeemPoints redeems loyalty points for a discount on the cart, worth
+// LOYALTY_POINT_VALUE each and capped at LOYALTY_POINTS_MAX_PER_ORDER
+// points. The points are held against the user's balance until the
+// redemption is reversed; redeeming again replaces the earlier redemption.
+func RedeemPoints(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req models.RedeemPointsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if maxPoints := utils.GetEnvInt("LOYALTY_POINTS_MAX_PER_ORDER", 0); maxPoints > 0 && req.Points > maxPoints {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error":      "Redemption exceeds the per-order limit",
+			"max_points": maxPoints,
+		})
+		return
+	}
+
+	cartKey, _, ok := requestCart(c, userID)
+	if !ok {
+		return
+	}
+	lock, ok := lockCart(c, cartKey)
+	if !ok {
+		return
+	}
+	defer lock.Release(c.Request.Context())
+	if !ensureNotFrozen(c, cartKey) {
+		return
+	}
+
+	cart, err := loadCart(c.Request.Context(), cartKey)
+	if err == errCartCorrupt {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse cart data"})
+		return
+	}
+	if err != nil || len(cart.Items) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Cart is empty", "code": codeCartEmpty})
+		return
+	}
+
+	// Points worth more than the cart would be lost, so refuse them
+	previous := cart.PointsRedemption
+	cart.PointsRedemption = &models.PointsRedemption{
+		Points:     req.Points,
+		PointValue: utils.GetEnvFloat("LOYALTY_POINT_VALUE", 0.01),
+	}
+	cart.CalculateTotals()
+	if cart.PointsDiscount < cart.PointsRedemption.Value() {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Points are worth more than the cart total"})
+		return
+	}
+
+	// Only the change from any earlier redemption is held or released
+	uid := fmt.Sprintf("%v", userID)
+	delta := req.Points
+	if previous != nil {
+		delta -= previous.Points
+	}
+	if delta > 0 {
+		balance, err := utils.FetchLoyaltyPoints(c.Request.Context(), uid)
+		if err == utils.ErrLoyaltyUnavailable {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Loyalty points are not available"})
+			return
+		}
+		if err != nil {
+			log.Printf("Failed to fetch loyalty points for user %s: %v", uid, err)
+			c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to fetch loyalty points"})
+			return
+		}
+
+		held, available, err := utils.HoldPoints(c.Request.Context(), uid, delta, balance, cartTTL)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to hold loyalty points"})
+			return
+		}
+		if !held {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{
+				"error":            "Not enough loyalty points",
+				"available_points": available,
+			})
+			return
+		}
+	}
+
+	if err := saveCart(c.Request.Context(), cartKey, cart); err != nil {
+		if delta > 0 {
+			releasePoints(c, uid, delta)
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save cart"})
+		return
+	}
+	if delta < 0 {
+		releasePoints(c, uid, -delta)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "Points redeemed",
+		"checkout": cart.CheckoutTotal(shippingPolicy()),
+	})
+}
+
+// CancelPointsRedemption reverses the cart's points redemption, returning
+// the points to the user's balance
+func CancelPointsRedemption(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	cartKey, _, ok := requestCart(c, userID)
+	if !ok {
+		return
+	}
+	lock, ok := lockCart(c, cartKey)
+	if !ok {
+		return
+	}
+	defer lock.Release(c.Request.Context())
+	if !ensureNotFrozen(c, cartKey) {
+		return
+	}
+
+	cart, err := loadCart(c.Request.Context(), cartKey)
+	if err == errCartCorrupt {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse cart data"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Cart not found", "code": codeCartEmpty})
+		return
+	}
+
+	redemption := cart.PointsRedemption
+	if redemption == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No points redeemed on this cart"})
+		return
+	}
+
+	cart.PointsRedemption = nil
+	cart.CalculateTotals()
+
+	if err := saveCart(c.Request.Context(), cartKey, cart); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save cart"})
+		return
+	}
+	releasePoints(c, fmt.Sprintf("%v", userID), redemption.Points)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "Points redemption reversed",
+		"checkout": cart.CheckoutTotal(shippingPolicy()),
+	})
+}
+
+// releasePoints returns held points to the user's balance. Failures are
+// logged; the hold lapses with the cart anyway.
+func releasePoints(c *gin.Context, userID string, points int) {
+	if err := utils.ReleasePoints(c.Request.Context(), userID, points); err != nil {
+		log.Printf("Failed to release %d loyalty points for user %s: %v", points, userID, err)
+	}
+}
@@ -0,0 +1,252 @@
+package handlers
+
+import (
+	"cart-service/models"
+	"cart-service/utils"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// loyaltyDebit is a points debit the fake loyalty service received
+type loyaltyDebit struct {
+	Points         int    `json:"points"`
+	OrderID        string `json:"order_id"`
+	IdempotencyKey string `json:"-"`
+}
+
+// fakeLoyaltyService answers points balances and records debits, applying
+// each idempotency key once
+type fakeLoyaltyService struct {
+	mu      sync.Mutex
+	balance int
+	fail    bool
+	debits  []loyaltyDebit
+}
+
+// newLoyaltyService serves balance as the test user's points
+func newLoyaltyService(t *testing.T, balance int) *fakeLoyaltyService {
+	t.Helper()
+	service := &fakeLoyaltyService{balance: balance}
+	newJSONService(t, "LOYALTY_SERVICE_URL", func(w http.ResponseWriter, r *http.Request) {
+		service.mu.Lock()
+		defer service.mu.Unlock()
+		switch r.URL.Path {
+		case "/api/loyalty/" + testUserID + "/points":
+			json.NewEncoder(w).Encode(gin.H{"points": service.balance})
+		case "/api/loyalty/" + testUserID + "/points/debit":
+			if service.fail {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			var debit loyaltyDebit
+			json.NewDecoder(r.Body).Decode(&debit)
+			debit.IdempotencyKey = r.Header.Get("Idempotency-Key")
+			for _, applied := range service.debits {
+				if applied.IdempotencyKey == debit.IdempotencyKey {
+					return
+				}
+			}
+			service.debits = append(service.debits, debit)
+			service.balance -= debit.Points
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+	return service
+}
+
+// state returns the debits applied and the balance left
+func (s *fakeLoyaltyService) state() ([]loyaltyDebit, int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]loyaltyDebit{}, s.debits...), s.balance
+}
+
+// failDebits makes debits fail
+func (s *fakeLoyaltyService) failDebits() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.fail = true
+}
+
+// heldPoints returns the test user's points held against carts
+func heldPoints(t *testing.T) int {
+	t.Helper()
+	held, _ := utils.RedisClient.Get(utils.Ctx, utils.Key("points_held", utils.UserKey(testUserID))).Int()
+	return held
+}
+
+// redeemPoints redeems points on the test user's cart
+func redeemPoints(t *testing.T, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	return serve(t, RedeemPoints, testRequest{method: http.MethodPost, route: "/redeem-points", body: body})
+}
+
+func TestRedeemPoints(t *testing.T) {
+	tests := []struct {
+		name         string
+		body         string
+		noService    bool
+		wantStatus   int
+		wantHeld     int
+		wantDiscount float64
+	}{
+		{name: "within balance", body: `{"points": 300}`, wantStatus: http.StatusOK, wantHeld: 300, wantDiscount: 3},
+		{name: "exceeds balance", body: `{"points": 600}`, wantStatus: http.StatusUnprocessableEntity},
+		{name: "exceeds per-order cap", body: `{"points": 1500}`, wantStatus: http.StatusUnprocessableEntity},
+		{name: "worth more than the cart", body: `{"points": 1000}`, wantStatus: http.StatusUnprocessableEntity},
+		{name: "no loyalty service", body: `{"points": 100}`, noService: true, wantStatus: http.StatusServiceUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newTestRedis(t)
+			t.Setenv("LOYALTY_POINT_VALUE", "0.01")
+			t.Setenv("LOYALTY_POINTS_MAX_PER_ORDER", "1000")
+			if tt.noService {
+				t.Setenv("LOYALTY_SERVICE_URL", "")
+			} else {
+				newLoyaltyService(t, 500)
+			}
+			cartKey := cartKeyFor(testUserID)
+			seedCart(t, cartKey, testItem(1, 5, 1))
+
+			w := redeemPoints(t, tt.body)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+			if held := heldPoints(t); held != tt.wantHeld {
+				t.Errorf("held points = %d, want %d", held, tt.wantHeld)
+			}
+			if cart := storedCart(t, cartKey); cart.PointsDiscount != tt.wantDiscount {
+				t.Errorf("points discount = %v, want %v", cart.PointsDiscount, tt.wantDiscount)
+			}
+		})
+	}
+}
+
+func TestPointsHoldReleased(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler gin.HandlerFunc
+		request testRequest
+	}{
+		{name: "redemption reversed", handler: CancelPointsRedemption, request: testRequest{method: http.MethodDelete, route: "/redeem-points"}},
+		{name: "cart cleared", handler: ClearCart, request: testRequest{method: http.MethodDelete, route: "/"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newTestRedis(t)
+			newLoyaltyService(t, 500)
+			seedCart(t, cartKeyFor(testUserID), testItem(1, 5, 1))
+			if w := redeemPoints(t, `{"points": 200}`); w.Code != http.StatusOK {
+				t.Fatalf("redeem status = %d: %s", w.Code, w.Body)
+			}
+
+			if w := serve(t, tt.handler, tt.request); w.Code != http.StatusOK {
+				t.Fatalf("status = %d: %s", w.Code, w.Body)
+			}
+			if held := heldPoints(t); held != 0 {
+				t.Errorf("held points = %d, want 0", held)
+			}
+		})
+	}
+}
+
+func TestCompleteCheckoutDebitsPoints(t *testing.T) {
+	tests := []struct {
+		name        string
+		body        string
+		failDebit   bool
+		wantStatus  int
+		wantDebits  []loyaltyDebit
+		wantBalance int
+		wantHeld    int
+	}{
+		{
+			name:        "debited for the order",
+			body:        `{"order_id": "o-1"}`,
+			wantStatus:  http.StatusOK,
+			wantDebits:  []loyaltyDebit{{Points: 200, OrderID: "o-1", IdempotencyKey: "order:o-1"}},
+			wantBalance: 300,
+		},
+		{
+			name:        "no order ID",
+			wantStatus:  http.StatusBadRequest,
+			wantBalance: 500,
+			wantHeld:    200,
+		},
+		{
+			name:        "loyalty service failing",
+			body:        `{"order_id": "o-1"}`,
+			failDebit:   true,
+			wantStatus:  http.StatusBadGateway,
+			wantBalance: 500,
+			wantHeld:    200,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newTestRedis(t)
+			loyalty := newLoyaltyService(t, 500)
+			cartKey := cartKeyFor(testUserID)
+			seedCart(t, cartKey, testItem(1, 5, 1))
+			if w := redeemPoints(t, `{"points": 200}`); w.Code != http.StatusOK {
+				t.Fatalf("redeem status = %d: %s", w.Code, w.Body)
+			}
+			if tt.failDebit {
+				loyalty.failDebits()
+			}
+
+			w := serve(t, CompleteCheckout, testRequest{method: http.MethodPost, route: "/checkout-complete", body: tt.body})
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+
+			debits, balance := loyalty.state()
+			if fmt.Sprint(debits) != fmt.Sprint(tt.wantDebits) {
+				t.Errorf("debits = %+v, want %+v", debits, tt.wantDebits)
+			}
+			if balance != tt.wantBalance {
+				t.Errorf("balance = %d, want %d", balance, tt.wantBalance)
+			}
+			if held := heldPoints(t); held != tt.wantHeld {
+				t.Errorf("held points = %d, want %d", held, tt.wantHeld)
+			}
+			// A failed completion leaves the cart to retry
+			if exists := utils.RedisClient.Exists(utils.Ctx, cartKey).Val(); (exists == 1) != (tt.wantStatus != http.StatusOK) {
+				t.Errorf("cart exists = %v after status %d", exists == 1, w.Code)
+			}
+		})
+	}
+}
+
+func TestCompleteCheckoutRetryDebitsOnce(t *testing.T) {
+	newTestRedis(t)
+	loyalty := newLoyaltyService(t, 500)
+	cartKey := cartKeyFor(testUserID)
+	cart := models.NewCart(testUserID)
+	cart.Items = []models.CartItem{testItem(1, 5, 1)}
+	cart.PointsRedemption = &models.PointsRedemption{Points: 200, PointValue: 0.01}
+	cart.CalculateTotals()
+
+	// The first completion debits the points but fails to convert the
+	// cart; the retry for the same order must not debit them again
+	for attempt := 0; attempt < 2; attempt++ {
+		storeCart(t, cartKey, cart)
+		if w := serve(t, CompleteCheckout, testRequest{method: http.MethodPost, route: "/checkout-complete", body: `{"order_id": "o-1"}`}); w.Code != http.StatusOK {
+			t.Fatalf("attempt %d: status = %d: %s", attempt, w.Code, w.Body)
+		}
+	}
+	if debits, balance := loyalty.state(); len(debits) != 1 || balance != 300 {
+		t.Errorf("debits = %+v leaving %d points, want one debit of 200", debits, balance)
+	}
+}
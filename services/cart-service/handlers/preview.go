@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"cart-service/utils"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PreviewAddItem returns the totals the cart would have if a product were
+// added, without saving anything
+func PreviewAddItem(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	productID, err := strconv.Atoi(c.Query("product_id"))
+	if err != nil || productID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "product_id must be a positive integer"})
+		return
+	}
+
+	quantity := utils.GetEnvInt("CART_DEFAULT_QUANTITY", 1)
+	if raw := c.Query("quantity"); raw != "" {
+		quantity, err = strconv.Atoi(raw)
+		if err != nil || quantity < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "quantity must be a positive integer"})
+			return
+		}
+	}
+
+	product, err := utils.FetchProduct(c.Request.Context(), productID)
+	if err == utils.ErrProductNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to fetch product %d: %v", productID, err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to fetch product details"})
+		return
+	}
+
+	cartKey, name, ok := requestCart(c, userID)
+	if !ok {
+		return
+	}
+
+	cart, err := loadCart(c.Request.Context(), cartKey)
+	if err == errCartCorrupt {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse cart data"})
+		return
+	}
+	if err != nil {
+		cart = newCart(userID, name)
+	}
+	current := cart.Summary()
+
+	// Stage the item on the loaded copy only; it is never saved
+	if !stageItem(c, cart, product, quantity, false, false) {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"current":   current,
+		"projected": cart,
+	})
+}
@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestPreviewAddItem(t *testing.T) {
+	tests := []struct {
+		name           string
+		query          string
+		wantStatus     int
+		wantTotalItems float64
+		wantTotalPrice float64
+	}{
+		{name: "new product", query: "?product_id=2&quantity=3", wantStatus: http.StatusOK, wantTotalItems: 5, wantTotalPrice: 35},
+		{name: "product already in cart", query: "?product_id=1&quantity=1", wantStatus: http.StatusOK, wantTotalItems: 3, wantTotalPrice: 30},
+		{name: "default quantity", query: "?product_id=2", wantStatus: http.StatusOK, wantTotalItems: 3, wantTotalPrice: 25},
+		{name: "unknown product", query: "?product_id=9", wantStatus: http.StatusNotFound},
+		{name: "invalid quantity", query: "?product_id=2&quantity=0", wantStatus: http.StatusBadRequest},
+		{name: "missing product", query: "", wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newTestRedis(t)
+			newProductService(t, map[int]gin.H{
+				1: {"name": "Kettle", "price": 10, "quantity": 50},
+				2: {"name": "Mug", "price": 5, "quantity": 50},
+			})
+			cartKey := cartKeyFor(testUserID)
+			seedCart(t, cartKey, testItem(1, 10, 2))
+			before := storedCart(t, cartKey)
+
+			w := serve(t, PreviewAddItem, testRequest{route: "/preview-add", target: "/preview-add" + tt.query})
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+
+			after := storedCart(t, cartKey)
+			assertQuantities(t, "stored", after, map[int]int{1: 2})
+			if after.Version != before.Version {
+				t.Errorf("stored version changed from %d to %d", before.Version, after.Version)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			body := decodeResponse(t, w)
+			current := body["current"].(map[string]interface{})
+			if current["total_items"] != float64(2) || current["total_price"] != float64(20) {
+				t.Errorf("current = %v, want 2 items at 20", current)
+			}
+			projected := body["projected"].(map[string]interface{})
+			if projected["total_items"] != tt.wantTotalItems || projected["total_price"] != tt.wantTotalPrice {
+				t.Errorf("projected = %v items at %v, want %v at %v",
+					projected["total_items"], projected["total_price"], tt.wantTotalItems, tt.wantTotalPrice)
+			}
+		})
+	}
+}
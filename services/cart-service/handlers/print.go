@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// printTemplate renders a cart for printing. html/template escapes product
+// names and other values.
+var printTemplate = template.Must(template.New("print").Funcs(template.FuncMap{
+	"money": func(amount float64) string {
+		return fmt.Sprintf("%.2f", amount)
+	},
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Your cart</title>
+<style>
+body { font-family: sans-serif; }
+table { border-collapse: collapse; width: 100%; }
+th, td { border-bottom: 1px solid #ccc; padding: 4px 8px; text-align: left; }
+td.num, th.num { text-align: right; }
+</style>
+</head>
+<body>
+<h1>Your cart</h1>
+<p>Updated {{.UpdatedAt}}</p>
+<table>
+<thead>
+<tr><th>Product</th><th class="num">Unit price</th><th class="num">Quantity</th><th class="num">Subtotal</th></tr>
+</thead>
+<tbody>
+{{- range .Items}}
+<tr><td>{{.ProductName}}</td><td class="num">{{money .Price}}</td><td class="num">{{.Quantity}}</td><td class="num">{{money .Subtotal}}</td></tr>
+{{- end}}
+</tbody>
+<tfoot>
+{{- if .ItemSavings}}
+<tr><td colspan="3">Item savings</td><td class="num">-{{money .ItemSavings}}</td></tr>
+{{- end}}
+{{- if .BundleDiscount}}
+<tr><td colspan="3">Bundle discounts</td><td class="num">-{{money .BundleDiscount}}</td></tr>
+{{- end}}
+{{- if .LoyaltyDiscount}}
+<tr><td colspan="3">Loyalty discount</td><td class="num">-{{money .LoyaltyDiscount}}</td></tr>
+{{- end}}
+{{- if .MemberDiscount}}
+<tr><td colspan="3">Member discount</td><td class="num">-{{money .MemberDiscount}}</td></tr>
+{{- end}}
+{{- if .CouponDiscount}}
+<tr><td colspan="3">Coupon</td><td class="num">-{{money .CouponDiscount}}</td></tr>
+{{- end}}
+{{- if .PointsDiscount}}
+<tr><td colspan="3">Loyalty points</td><td class="num">-{{money .PointsDiscount}}</td></tr>
+{{- end}}
+<tr><th colspan="3">Total ({{.Currency}})</th><th class="num">{{money .FinalPrice}}</th></tr>
+</tfoot>
+</table>
+</body>
+</html>
+`))
+
+// PrintCart returns the cart as a printer-friendly HTML page
+func PrintCart(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	cartKey, name, ok := requestCart(c, userID)
+	if !ok {
+		return
+	}
+
+	cart, err := loadCart(c.Request.Context(), cartKey)
+	if err == errCartCorrupt {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse cart data"})
+		return
+	}
+	if err != nil {
+		cart = newCart(userID, name)
+	}
+
+	var page bytes.Buffer
+	if err := printTemplate.Execute(&page, cart); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to render cart"})
+		return
+	}
+
+	c.Data(http.StatusOK, "text/html; charset=utf-8", page.Bytes())
+}
@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestPrintCart(t *testing.T) {
+	newTestRedis(t)
+	kettle := testItem(1, 20, 2)
+	kettle.ProductName = "Kettle"
+	tricky := testItem(2, 5, 1)
+	tricky.ProductName = `<script>alert("x")</script> & Co`
+	seedCart(t, cartKeyFor(testUserID), kettle, tricky)
+
+	w := serve(t, PrintCart, testRequest{route: "/print"})
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body)
+	}
+	if contentType := w.Header().Get("Content-Type"); !strings.HasPrefix(contentType, "text/html") {
+		t.Errorf("Content-Type = %q, want text/html", contentType)
+	}
+
+	page := w.Body.String()
+	for _, want := range []string{
+		`<tr><td>Kettle</td><td class="num">20.00</td><td class="num">2</td><td class="num">40.00</td></tr>`,
+		`&lt;script&gt;alert(&#34;x&#34;)&lt;/script&gt; &amp; Co`,
+		`<th class="num">45.00</th>`,
+	} {
+		if !strings.Contains(page, want) {
+			t.Errorf("page is missing %s", want)
+		}
+	}
+	if strings.Contains(page, "<script>") {
+		t.Error("product name was not escaped")
+	}
+}
+
+func TestPrintEmptyCart(t *testing.T) {
+	newTestRedis(t)
+
+	w := serve(t, PrintCart, testRequest{route: "/print"})
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body)
+	}
+	if strings.Contains(w.Body.String(), "<tr><td>") {
+		t.Error("empty cart rendered item rows")
+	}
+}
@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"cart-service/models"
+	"encoding/json"
+	"strings"
+)
+
+// projectCart returns the cart with each item trimmed to the given JSON
+// fields, for clients that don't need the full item payload. product_id
+// is always kept so items stay identifiable; unknown fields are ignored.
+func projectCart(cart *models.Cart, fields []string) (map[string]interface{}, error) {
+	data, err := json.Marshal(cart)
+	if err != nil {
+		return nil, err
+	}
+	var projected map[string]interface{}
+	if err := json.Unmarshal(data, &projected); err != nil {
+		return nil, err
+	}
+
+	keep := map[string]bool{"product_id": true}
+	for _, field := range fields {
+		keep[strings.TrimSpace(field)] = true
+	}
+
+	items, _ := projected["items"].([]interface{})
+	for _, item := range items {
+		fields, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		for field := range fields {
+			if !keep[field] {
+				delete(fields, field)
+			}
+		}
+	}
+	return projected, nil
+}
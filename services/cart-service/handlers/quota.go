@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"cart-service/models"
+	"cart-service/utils"
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// dailyQuota returns how many units of a product one user may add per UTC
+// day: the product's own quota, else DAILY_PRODUCT_QUOTA. 0 means no quota.
+func dailyQuota(productQuota int) int {
+	if productQuota > 0 {
+		return productQuota
+	}
+	return utils.GetEnvInt("DAILY_PRODUCT_QUOTA", 0)
+}
+
+// claimDailyQuota counts units added to an item against the user's daily
+// quota for its product, to curb scalping. Writes a 429 and returns
+// ok=false if the quota is used up; claimed reports whether anything was
+// counted and must be released if the change isn't saved.
+func claimDailyQuota(c *gin.Context, userID interface{}, item *models.CartItem, units int, now time.Time) (claimed, ok bool) {
+	quota := dailyQuota(item.DailyQuota)
+	if quota <= 0 || units <= 0 {
+		return false, true
+	}
+
+	allowed, remaining, err := utils.ClaimDailyQuota(c.Request.Context(), fmt.Sprintf("%v", userID), item.ProductID, units, quota, now)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check daily quota"})
+		return false, false
+	}
+	if !allowed {
+		resetAt := utils.QuotaResetAt(now)
+		c.Header("Retry-After", strconv.Itoa(int(resetAt.Sub(now).Seconds())+1))
+		c.JSON(http.StatusTooManyRequests, gin.H{
+			"error":     "Daily quota for this product exceeded",
+			"quota":     quota,
+			"remaining": remaining,
+			"resets_at": resetAt.Format(time.RFC3339),
+		})
+		return false, false
+	}
+	return true, true
+}
+
+// releaseDailyQuota gives back quota claimed for a change that wasn't saved
+func releaseDailyQuota(ctx context.Context, userID interface{}, productID, units int, now time.Time) {
+	if err := utils.ReleaseDailyQuota(ctx, fmt.Sprintf("%v", userID), productID, units, now); err != nil {
+		log.Printf("Failed to release daily quota for product %d: %v", productID, err)
+	}
+}
+
+// quotaClaims counts the units a batch of items adds against the user's
+// daily quotas, remembering them so they can be given back if the cart
+// isn't saved
+type quotaClaims struct {
+	userID  interface{}
+	now     time.Time
+	claimed map[int]int
+
+	// Units each product had in the cart before it was replaced, which
+	// count towards the batch without being claimed again
+	replaced map[int]int
+}
+
+// newQuotaClaims starts counting a batch of adds by userID
+func newQuotaClaims(userID interface{}) *quotaClaims {
+	return &quotaClaims{userID: userID, now: time.Now(), claimed: map[int]int{}, replaced: map[int]int{}}
+}
+
+// replacing records the items a batch replaces
+func (q *quotaClaims) replacing(items []models.CartItem) {
+	for _, item := range items {
+		if !item.PromoGift {
+			q.replaced[item.ProductID] += item.Quantity
+		}
+	}
+}
+
+// claim counts units of a product against its daily quota. Returns false
+// if they would exceed it.
+func (q *quotaClaims) claim(ctx context.Context, productID, productQuota, units int) (bool, error) {
+	credit := q.replaced[productID]
+	if credit > units {
+		credit = units
+	}
+	q.replaced[productID] -= credit
+	units -= credit
+
+	quota := dailyQuota(productQuota)
+	if quota <= 0 || units <= 0 {
+		return true, nil
+	}
+	allowed, _, err := utils.ClaimDailyQuota(ctx, fmt.Sprintf("%v", q.userID), productID, units, quota, q.now)
+	if err != nil || !allowed {
+		q.replaced[productID] += credit
+		return false, err
+	}
+	q.claimed[productID] += units
+	return true, nil
+}
+
+// release gives back everything claimed for the batch
+func (q *quotaClaims) release(ctx context.Context) {
+	for productID, units := range q.claimed {
+		releaseDailyQuota(ctx, q.userID, productID, units, q.now)
+	}
+}
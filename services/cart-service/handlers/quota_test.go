@@ -0,0 +1,239 @@
+package handlers
+
+import (
+	"cart-service/models"
+	"cart-service/utils"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// quotaUsed returns the units of a product the test user added today
+func quotaUsed(t *testing.T, productID int) int {
+	t.Helper()
+	key := utils.Key("quota", utils.UserKey(testUserID), fmt.Sprint(productID), time.Now().UTC().Format("2006-01-02"))
+	used, _ := utils.RedisClient.Get(utils.Ctx, key).Int()
+	return used
+}
+
+func TestDailyQuota(t *testing.T) {
+	tests := []struct {
+		name        string
+		handler     gin.HandlerFunc
+		request     testRequest
+		envQuota    string
+		wantStatus  int
+		wantQty     map[int]int
+		wantUsed    int
+		wantSkipped string
+	}{
+		{
+			name:       "add within the quota",
+			handler:    AddItem,
+			request:    testRequest{method: http.MethodPost, route: "/items", body: `{"product_id": 1, "quantity": 3}`},
+			wantStatus: http.StatusOK,
+			wantQty:    map[int]int{1: 5},
+			wantUsed:   5,
+		},
+		{
+			name:       "add beyond the quota",
+			handler:    AddItem,
+			request:    testRequest{method: http.MethodPost, route: "/items", body: `{"product_id": 1, "quantity": 4}`},
+			wantStatus: http.StatusTooManyRequests,
+			wantQty:    map[int]int{1: 2},
+			wantUsed:   2,
+		},
+		{
+			name:       "replace claims the increase",
+			handler:    AddItem,
+			request:    testRequest{method: http.MethodPost, route: "/items", target: "/items?on_duplicate=replace", body: `{"product_id": 1, "quantity": 5}`},
+			wantStatus: http.StatusOK,
+			wantQty:    map[int]int{1: 5},
+			wantUsed:   5,
+		},
+		{
+			name:       "replace with fewer claims nothing",
+			handler:    AddItem,
+			request:    testRequest{method: http.MethodPost, route: "/items", target: "/items?on_duplicate=replace", body: `{"product_id": 1, "quantity": 1}`},
+			wantStatus: http.StatusOK,
+			wantQty:    map[int]int{1: 1},
+			wantUsed:   2,
+		},
+		{
+			name:       "replace beyond the quota",
+			handler:    AddItem,
+			request:    testRequest{method: http.MethodPost, route: "/items", target: "/items?on_duplicate=replace", body: `{"product_id": 1, "quantity": 6}`},
+			wantStatus: http.StatusTooManyRequests,
+			wantQty:    map[int]int{1: 2},
+			wantUsed:   2,
+		},
+		{
+			name:       "fallback quota",
+			handler:    AddItem,
+			request:    testRequest{method: http.MethodPost, route: "/items", body: `{"product_id": 2, "quantity": 2}`},
+			envQuota:   "1",
+			wantStatus: http.StatusTooManyRequests,
+			wantQty:    map[int]int{1: 2},
+			wantUsed:   2,
+		},
+		{
+			name:       "update within the quota",
+			handler:    UpdateItem,
+			request:    testRequest{method: http.MethodPut, route: "/items/:product_id", target: "/items/1", body: `{"quantity": 5}`},
+			wantStatus: http.StatusOK,
+			wantQty:    map[int]int{1: 5},
+			wantUsed:   5,
+		},
+		{
+			name:       "update beyond the quota",
+			handler:    UpdateItem,
+			request:    testRequest{method: http.MethodPut, route: "/items/:product_id", target: "/items/1", body: `{"quantity": 6}`},
+			wantStatus: http.StatusTooManyRequests,
+			wantQty:    map[int]int{1: 2},
+			wantUsed:   2,
+		},
+		{
+			name:       "update decrease",
+			handler:    UpdateItem,
+			request:    testRequest{method: http.MethodPut, route: "/items/:product_id", target: "/items/1", body: `{"quantity": 1}`},
+			wantStatus: http.StatusOK,
+			wantQty:    map[int]int{1: 1},
+			wantUsed:   2,
+		},
+		{
+			name:       "adjust within the quota",
+			handler:    AdjustItem,
+			request:    testRequest{method: http.MethodPatch, route: "/items/:product_id", target: "/items/1", body: `{"delta": 3}`},
+			wantStatus: http.StatusOK,
+			wantQty:    map[int]int{1: 5},
+			wantUsed:   5,
+		},
+		{
+			name:       "adjust beyond the quota",
+			handler:    AdjustItem,
+			request:    testRequest{method: http.MethodPatch, route: "/items/:product_id", target: "/items/1", body: `{"delta": 4}`},
+			wantStatus: http.StatusTooManyRequests,
+			wantQty:    map[int]int{1: 2},
+			wantUsed:   2,
+		},
+		{
+			name:       "adjust down",
+			handler:    AdjustItem,
+			request:    testRequest{method: http.MethodPatch, route: "/items/:product_id", target: "/items/1", body: `{"delta": -1}`},
+			wantStatus: http.StatusOK,
+			wantQty:    map[int]int{1: 1},
+			wantUsed:   2,
+		},
+		{
+			name:       "flash sale add within the quota",
+			handler:    FlashSaleAddItem,
+			request:    testRequest{method: http.MethodPost, route: "/flash-sale/items", body: `{"product_id": 1, "quantity": 3}`},
+			wantStatus: http.StatusOK,
+			wantQty:    map[int]int{1: 5},
+			wantUsed:   5,
+		},
+		{
+			name:       "flash sale add beyond the quota",
+			handler:    FlashSaleAddItem,
+			request:    testRequest{method: http.MethodPost, route: "/flash-sale/items", body: `{"product_id": 1, "quantity": 4}`},
+			wantStatus: http.StatusTooManyRequests,
+			wantQty:    map[int]int{1: 2},
+			wantUsed:   2,
+		},
+		{
+			name:       "import within the quota",
+			handler:    ImportCart,
+			request:    testRequest{method: http.MethodPost, route: "/import", body: "1,3\n"},
+			wantStatus: http.StatusOK,
+			wantQty:    map[int]int{1: 5},
+			wantUsed:   5,
+		},
+		{
+			name:       "import beyond the quota",
+			handler:    ImportCart,
+			request:    testRequest{method: http.MethodPost, route: "/import", body: "1,4\n2,1\n"},
+			wantStatus: http.StatusOK,
+			wantQty:    map[int]int{1: 2, 2: 1},
+			wantUsed:   2,
+		},
+		{
+			name:       "import replace claims the increase",
+			handler:    ImportCart,
+			request:    testRequest{method: http.MethodPost, route: "/import", target: "/import?mode=replace", body: "1,5\n"},
+			wantStatus: http.StatusOK,
+			wantQty:    map[int]int{1: 5},
+			wantUsed:   5,
+		},
+		{
+			name:        "apply list beyond the quota",
+			handler:     ApplyList,
+			request:     testRequest{method: http.MethodPost, route: "/lists/:list_id/apply", target: "/lists/weekly/apply"},
+			wantStatus:  http.StatusOK,
+			wantQty:     map[int]int{1: 2, 2: 1},
+			wantUsed:    2,
+			wantSkipped: listSkipDailyQuota,
+		},
+		{
+			name:        "sync beyond the quota",
+			handler:     SyncCart,
+			request:     testRequest{method: http.MethodPost, route: "/sync", body: `{"items": [{"product_id": 1, "quantity": 4}, {"product_id": 2, "quantity": 1}]}`},
+			wantStatus:  http.StatusOK,
+			wantQty:     map[int]int{1: 2, 2: 1},
+			wantUsed:    2,
+			wantSkipped: listSkipDailyQuota,
+		},
+		{
+			name:       "sync within the quota",
+			handler:    SyncCart,
+			request:    testRequest{method: http.MethodPost, route: "/sync", body: `{"items": [{"product_id": 1, "quantity": 3}]}`},
+			wantStatus: http.StatusOK,
+			wantQty:    map[int]int{1: 5},
+			wantUsed:   5,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newTestRedis(t)
+			t.Setenv("DAILY_PRODUCT_QUOTA", tt.envQuota)
+			newFlashSale(t, 100)
+			newProductService(t, map[int]gin.H{
+				1: {"name": "Console", "price": 100, "quantity": 1000, "daily_quota": 5},
+				2: {"name": "Cable", "price": 10, "quantity": 1000},
+			})
+			list, _ := json.Marshal(models.ListTemplate{ID: "weekly", Items: []models.ListItem{{ProductID: 1, Quantity: 4}, {ProductID: 2, Quantity: 1}}})
+			utils.RedisClient.Set(utils.Ctx, listKeyFor(testUserID, "weekly"), list, 0)
+
+			// Two units were added earlier today
+			cartKey := cartKeyFor(testUserID)
+			item := testItem(1, 100, 2)
+			item.DailyQuota = 5
+			seedCart(t, cartKey, item)
+			if allowed, _, err := utils.ClaimDailyQuota(utils.Ctx, testUserID, 1, 2, 5, time.Now()); err != nil || !allowed {
+				t.Fatalf("failed to seed quota: allowed = %v, err = %v", allowed, err)
+			}
+
+			w := serve(t, tt.handler, tt.request)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+			assertQuantities(t, "stored", storedCart(t, cartKey), tt.wantQty)
+			if used := quotaUsed(t, 1); used != tt.wantUsed {
+				t.Errorf("quota used = %d, want %d", used, tt.wantUsed)
+			}
+			if w.Code == http.StatusTooManyRequests && w.Header().Get("Retry-After") == "" {
+				t.Error("no Retry-After on a quota refusal")
+			}
+			if tt.wantSkipped != "" {
+				skipped := decodeResponse(t, w)["skipped"].([]interface{})
+				if len(skipped) != 1 || skipped[0].(map[string]interface{})["reason"] != tt.wantSkipped {
+					t.Errorf("skipped = %v, want product 1 %s", skipped, tt.wantSkipped)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"cart-service/models"
+	"cart-service/utils"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+)
+
+// quoteKeyFor builds the Redis key holding a quote. Quotes are keyed by ID
+// alone so the link can be shared with other users.
+func quoteKeyFor(quoteID string) string {
+	return utils.Key("quote", quoteID)
+}
+
+// quoteLink returns the shareable link to a quote
+func quoteLink(quoteID string) string {
+	baseURL := os.Getenv("QUOTE_BASE_URL")
+	if baseURL == "" {
+		baseURL = "/api/cart/quote/"
+	}
+	return baseURL + quoteID
+}
+
+// CreateQuote snapshots the cart with its current prices as a quote, kept
+// for QUOTE_TTL_DAYS, and returns a shareable link. The cart itself is left
+// as it is. If an email address is given and a notification service is
+// configured, the link is emailed too.
+func CreateQuote(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	// The body is optional
+	var req models.CreateQuoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil && err != io.EOF {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	cartKey, _, ok := requestCart(c, userID)
+	if !ok {
+		return
+	}
+
+	cart, err := loadCart(c.Request.Context(), cartKey)
+	if err == errCartCorrupt {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse cart data"})
+		return
+	}
+	if err != nil || len(cart.Items) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Cart is empty", "code": codeCartEmpty})
+		return
+	}
+
+	quoteID, err := utils.RandomID(12)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create quote"})
+		return
+	}
+
+	now := time.Now()
+	ttl := time.Duration(utils.GetEnvInt("QUOTE_TTL_DAYS", 30)) * 24 * time.Hour
+	quote := models.Quote{
+		ID:        quoteID,
+		CreatedBy: fmt.Sprintf("%v", userID),
+		CreatedAt: now.Format(time.RFC3339),
+		ExpiresAt: now.Add(ttl).Format(time.RFC3339),
+		Cart:      cart,
+		Checkout:  cart.CheckoutTotal(shippingPolicy()),
+	}
+	quoteData, err := json.Marshal(quote)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to encode quote"})
+		return
+	}
+
+	if err := utils.RedisClient.Set(c.Request.Context(), quoteKeyFor(quoteID), quoteData, ttl).Err(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save quote"})
+		return
+	}
+
+	link := quoteLink(quoteID)
+	emailed := false
+	if req.Email != "" && utils.NotificationsEnabled() {
+		data := gin.H{"quote_id": quoteID, "link": link, "expires_at": quote.ExpiresAt}
+		if err := utils.SendEmail(c.Request.Context(), req.Email, "cart_quote", data); err != nil {
+			log.Printf("Failed to email quote %s: %v", quoteID, err)
+		} else {
+			emailed = true
+		}
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Quote created",
+		"quote":   quote,
+		"link":    link,
+		"emailed": emailed,
+	})
+}
+
+// GetQuote returns a saved quote as it was created
+func GetQuote(c *gin.Context) {
+	if _, exists := c.Get("user_id"); !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	quoteID := c.Param("quote_id")
+	quoteData, err := utils.RedisClient.Get(c.Request.Context(), quoteKeyFor(quoteID)).Result()
+	if err == redis.Nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Quote not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load quote"})
+		return
+	}
+
+	var quote models.Quote
+	if err := json.Unmarshal([]byte(quoteData), &quote); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse quote data"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"quote": quote})
+}
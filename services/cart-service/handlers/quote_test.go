@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"cart-service/models"
+	"cart-service/utils"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestCreateAndGetQuote(t *testing.T) {
+	tests := []struct {
+		name        string
+		body        string
+		wantEmailed bool
+	}{
+		{name: "without email"},
+		{name: "emailed to the buyer", body: `{"email": "buyer@example.com"}`, wantEmailed: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newTestRedis(t)
+			t.Setenv("QUOTE_TTL_DAYS", "10")
+			var sent map[string]interface{}
+			newJSONService(t, "NOTIFICATION_SERVICE_URL", func(w http.ResponseWriter, r *http.Request) {
+				json.NewDecoder(r.Body).Decode(&sent)
+			})
+			cartKey := cartKeyFor(testUserID)
+			seedCart(t, cartKey, testItem(1, 10, 2), testItem(2, 5, 1))
+
+			w := serve(t, CreateQuote, testRequest{method: http.MethodPost, route: "/quote", body: tt.body})
+			if w.Code != http.StatusCreated {
+				t.Fatalf("create status = %d: %s", w.Code, w.Body)
+			}
+			var created struct {
+				Quote   models.Quote `json:"quote"`
+				Link    string       `json:"link"`
+				Emailed bool         `json:"emailed"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &created); err != nil {
+				t.Fatalf("response is not JSON: %v", err)
+			}
+			quoteID := created.Quote.ID
+			if quoteID == "" || created.Link != "/api/cart/quote/"+quoteID {
+				t.Fatalf("quote %q has link %q", quoteID, created.Link)
+			}
+			if created.Emailed != tt.wantEmailed || (tt.wantEmailed && sent["to"] != "buyer@example.com") {
+				t.Errorf("emailed = %v with %v, want %v", created.Emailed, sent, tt.wantEmailed)
+			}
+			if ttl := utils.RedisClient.TTL(utils.Ctx, quoteKeyFor(quoteID)).Val(); ttl <= 9*24*time.Hour {
+				t.Errorf("quote TTL = %v, want 10 days", ttl)
+			}
+
+			// Prices in the quote stay as quoted when the cart changes
+			cart := storedCart(t, cartKey)
+			cart.Items[0].Price = 99
+			storeCart(t, cartKey, cart)
+
+			w = serve(t, GetQuote, testRequest{route: "/quote/:quote_id", target: "/quote/" + quoteID, userID: "7"})
+			if w.Code != http.StatusOK {
+				t.Fatalf("get status = %d: %s", w.Code, w.Body)
+			}
+			var got struct {
+				Quote models.Quote `json:"quote"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+				t.Fatalf("response is not JSON: %v", err)
+			}
+			if price := got.Quote.Cart.Items[0].Price; price != 10 {
+				t.Errorf("quoted price = %v, want 10", price)
+			}
+			if got.Quote.Checkout.Subtotal != 25 {
+				t.Errorf("quoted subtotal = %v, want 25", got.Quote.Checkout.Subtotal)
+			}
+		})
+	}
+}
+
+func TestGetQuoteNotFound(t *testing.T) {
+	newTestRedis(t)
+
+	w := serve(t, GetQuote, testRequest{route: "/quote/:quote_id", target: "/quote/missing"})
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+}
@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"cart-service/utils"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetReorderDiff compares the cart with a past order from order-service,
+// so customers reordering with changes can review what differs
+func GetReorderDiff(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	orderID := c.Query("order_id")
+	if orderID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "order_id is required"})
+		return
+	}
+
+	cartKey, name, ok := requestCart(c, userID)
+	if !ok {
+		return
+	}
+
+	cart, err := loadCart(c.Request.Context(), cartKey)
+	if err == errCartCorrupt {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse cart data"})
+		return
+	}
+	if err != nil {
+		cart = newCart(userID, name)
+	}
+
+	order, err := utils.FetchOrder(c.Request.Context(), orderID, c.GetHeader("Authorization"))
+	if err == utils.ErrOrdersUnavailable {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Order history is not available"})
+		return
+	}
+	if err == utils.ErrOrderNotFound || (err == nil && order.UserID != fmt.Sprintf("%v", userID)) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to fetch order %s: %v", orderID, err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to load order"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"order_id": orderID,
+		"diff":     cart.ReorderDiff(order),
+	})
+}
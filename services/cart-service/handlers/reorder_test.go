@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"cart-service/models"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newOrderService serves orders the way order-service does, as
+// {"order": {...}} under /api/orders/:id, answering only requests that
+// carry the caller's Authorization header
+func newOrderService(t *testing.T, orders map[string]models.OrderPayload) {
+	t.Helper()
+	newJSONService(t, "ORDER_SERVICE_URL", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		orderID := path.Base(r.URL.Path)
+		if orderID == "broken" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		order, ok := orders[orderID]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(gin.H{"order": order})
+	})
+}
+
+func TestGetReorderDiff(t *testing.T) {
+	orders := map[string]models.OrderPayload{
+		"o-1": {UserID: testUserID, LineItems: []models.OrderLineItem{
+			{ProductID: 1, Name: "Coffee", Quantity: 2},
+			{ProductID: 2, Name: "Filters", Quantity: 1},
+			{ProductID: 3, Name: "Mug", Quantity: 1},
+		}},
+		"o-2": {UserID: "7", LineItems: []models.OrderLineItem{{ProductID: 1, Quantity: 1}}},
+	}
+
+	tests := []struct {
+		name          string
+		query         string
+		noService     bool
+		wantStatus    int
+		wantAdded     []models.ReorderLine
+		wantRemoved   []models.ReorderLine
+		wantChanged   []models.ReorderLine
+		wantUnchanged int
+	}{
+		{
+			name:          "added, removed and changed",
+			query:         "?order_id=o-1",
+			wantStatus:    http.StatusOK,
+			wantAdded:     []models.ReorderLine{{ProductID: 4, Name: "Product 4", CartQuantity: 1}},
+			wantRemoved:   []models.ReorderLine{{ProductID: 3, Name: "Mug", OrderQuantity: 1}},
+			wantChanged:   []models.ReorderLine{{ProductID: 1, Name: "Product 1", OrderQuantity: 2, CartQuantity: 3}},
+			wantUnchanged: 1,
+		},
+		{name: "missing order_id", wantStatus: http.StatusBadRequest},
+		{name: "unknown order", query: "?order_id=o-9", wantStatus: http.StatusNotFound},
+		{name: "another user's order", query: "?order_id=o-2", wantStatus: http.StatusNotFound},
+		{name: "order-service failing", query: "?order_id=broken", wantStatus: http.StatusBadGateway},
+		{name: "no order-service", query: "?order_id=o-1", noService: true, wantStatus: http.StatusServiceUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newTestRedis(t)
+			if tt.noService {
+				t.Setenv("ORDER_SERVICE_URL", "")
+			} else {
+				newOrderService(t, orders)
+			}
+			seedCart(t, cartKeyFor(testUserID), testItem(1, 8, 3), testItem(2, 3, 1), testItem(4, 12, 1))
+
+			w := serve(t, GetReorderDiff, testRequest{
+				route:   "/reorder-diff",
+				target:  "/reorder-diff" + tt.query,
+				headers: map[string]string{"Authorization": "Bearer token"},
+			})
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			var body struct {
+				Diff models.ReorderDiff `json:"diff"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatalf("response is not JSON: %v", err)
+			}
+			diff := body.Diff
+			if fmt.Sprint(diff.Added) != fmt.Sprint(tt.wantAdded) {
+				t.Errorf("added = %+v, want %+v", diff.Added, tt.wantAdded)
+			}
+			if fmt.Sprint(diff.Removed) != fmt.Sprint(tt.wantRemoved) {
+				t.Errorf("removed = %+v, want %+v", diff.Removed, tt.wantRemoved)
+			}
+			if fmt.Sprint(diff.QuantityChanged) != fmt.Sprint(tt.wantChanged) {
+				t.Errorf("quantity_changed = %+v, want %+v", diff.QuantityChanged, tt.wantChanged)
+			}
+			if diff.Unchanged != tt.wantUnchanged {
+				t.Errorf("unchanged = %d, want %d", diff.Unchanged, tt.wantUnchanged)
+			}
+		})
+	}
+}
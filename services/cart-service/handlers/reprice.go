@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"cart-service/models"
+	"cart-service/utils"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RepriceCart moves every item to its product's current price, even if its
+// price lock hasn't expired, and reports which prices changed. Nothing else
+// about the cart is modified.
+func RepriceCart(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	cartKey, _, ok := requestCart(c, userID)
+	if !ok {
+		return
+	}
+	lock, ok := lockCart(c, cartKey)
+	if !ok {
+		return
+	}
+	defer lock.Release(c.Request.Context())
+	if !ensureNotFrozen(c, cartKey) {
+		return
+	}
+
+	cart, err := loadCart(c.Request.Context(), cartKey)
+	if err == errCartCorrupt {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse cart data"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Cart not found", "code": codeCartEmpty})
+		return
+	}
+
+	now := time.Now()
+	changes := []models.PriceChange{}
+	for i := range cart.Items {
+		item := &cart.Items[i]
+		if item.Pending || item.PromoGift {
+			continue
+		}
+
+		product, err := utils.FetchProduct(c.Request.Context(), item.ProductID)
+		if err != nil {
+			log.Printf("Failed to fetch product %d: %v", item.ProductID, err)
+			continue
+		}
+
+		// An explicit reprice overrides the lock and starts a new one
+		oldPrice := item.Price
+		item.PriceLockedUntil = ""
+		price, validUntil := product.CurrentPrice(now)
+		item.PriceValidUntil = validUntil
+		item.RegularPrice = float64(product.RegularPrice)
+		if item.Reprice(price, now, priceLockDuration()) {
+			changes = append(changes, models.PriceChange{
+				ProductID: item.ProductID,
+				OldPrice:  oldPrice,
+				NewPrice:  item.Price,
+			})
+		}
+	}
+
+	cart.CalculateTotals()
+
+	if err := saveCart(c.Request.Context(), cartKey, cart); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save cart"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Cart repriced",
+		"changes": changes,
+		"cart":    cart,
+	})
+}
@@ -0,0 +1,296 @@
+package handlers
+
+import (
+	"cart-service/models"
+	"cart-service/utils"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// seedLockedItem stores product 1 at 10, its price locked until lockedUntil
+func seedLockedItem(t *testing.T, lockedUntil time.Time) {
+	t.Helper()
+	item := testItem(1, 10, 1)
+	item.PriceLockedUntil = lockedUntil.Format(time.RFC3339)
+	seedCart(t, cartKeyFor(testUserID), item)
+}
+
+// readCartPrice returns product 1's price as GetCart reports it
+func readCartPrice(t *testing.T) float64 {
+	t.Helper()
+	w := serve(t, GetCart, testRequest{route: "/"})
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body)
+	}
+	items := decodeResponse(t, w)["cart"].(map[string]interface{})["items"].([]interface{})
+	return items[0].(map[string]interface{})["price"].(float64)
+}
+
+func TestRepriceOnReadHonorsPriceLock(t *testing.T) {
+	tests := []struct {
+		name        string
+		lockedUntil time.Duration
+		wantPrice   float64
+		wantLocked  bool
+	}{
+		{name: "price change within the lock window is ignored", lockedUntil: 10 * time.Minute, wantPrice: 10},
+		{name: "price change after the lock window applies", lockedUntil: -time.Minute, wantPrice: 12, wantLocked: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newTestRedis(t)
+			t.Setenv("CART_REPRICE_ON_READ", "true")
+			newProductService(t, map[int]gin.H{1: {"name": "Kettle", "price": 12, "quantity": 10}})
+			seedLockedItem(t, time.Now().Add(tt.lockedUntil))
+
+			if price := readCartPrice(t); price != tt.wantPrice {
+				t.Errorf("read price = %v, want %v", price, tt.wantPrice)
+			}
+			item := storedCart(t, cartKeyFor(testUserID)).Items[0]
+			if item.Price != tt.wantPrice {
+				t.Errorf("stored price = %v, want %v", item.Price, tt.wantPrice)
+			}
+			// A new price is locked in for another window
+			if tt.wantLocked && !item.PriceLocked(time.Now().Add(29*time.Minute)) {
+				t.Errorf("new price is not locked: %q", item.PriceLockedUntil)
+			}
+		})
+	}
+}
+
+func TestRepriceOnReadSkipsSaveWhenLocked(t *testing.T) {
+	newTestRedis(t)
+	t.Setenv("CART_REPRICE_ON_READ", "true")
+	t.Setenv("CART_LOCK_WAIT_MS", "20")
+	newProductService(t, map[int]gin.H{1: {"name": "Kettle", "price": 12, "quantity": 10}})
+	seedLockedItem(t, time.Now().Add(-time.Minute))
+	cartKey := cartKeyFor(testUserID)
+
+	held, err := acquireCartLock(utils.Ctx, cartKey)
+	if err != nil {
+		t.Fatalf("failed to hold lock: %v", err)
+	}
+	if price := readCartPrice(t); price != 12 {
+		t.Errorf("read price = %v, want 12", price)
+	}
+	if item := storedCart(t, cartKey).Items[0]; item.Price != 10 {
+		t.Errorf("refresh was saved over a held lock: price %v", item.Price)
+	}
+
+	// The next read after the writer is done saves the refresh
+	held.Release(utils.Ctx)
+	readCartPrice(t)
+	if item := storedCart(t, cartKey).Items[0]; item.Price != 12 {
+		t.Errorf("stored price after release = %v, want 12", item.Price)
+	}
+}
+
+func TestSaveRefreshedCartSkipsStaleCopy(t *testing.T) {
+	newTestRedis(t)
+	cartKey := cartKeyFor(testUserID)
+	seedCart(t, cartKey, testItem(1, 10, 1))
+
+	refreshed := storedCart(t, cartKey)
+	refreshed.Items[0].Price = 12
+
+	// A writer changes the cart between the read and the refresh save
+	concurrent := storedCart(t, cartKey)
+	concurrent.Items = append(concurrent.Items, testItem(2, 5, 1))
+	storeCart(t, cartKey, concurrent)
+
+	saveRefreshedCart(utils.Ctx, cartKey, refreshed)
+	assertQuantities(t, "stored", storedCart(t, cartKey), map[int]int{1: 1, 2: 1})
+}
+
+func TestDiscontinuedItemsOnRead(t *testing.T) {
+	tests := []struct {
+		name             string
+		autoRemove       string
+		want             map[int]int
+		wantRemoved      bool
+		wantDiscontinued bool
+	}{
+		{name: "auto-remove", autoRemove: "true", want: map[int]int{1: 1}, wantRemoved: true},
+		{name: "leave in place", autoRemove: "false", want: map[int]int{1: 1, 2: 2}, wantDiscontinued: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newTestRedis(t)
+			t.Setenv("CART_REPRICE_ON_READ", "true")
+			t.Setenv("AUTO_REMOVE_DISCONTINUED", tt.autoRemove)
+			newProductService(t, map[int]gin.H{
+				1: {"name": "Kettle", "price": 20, "quantity": 10},
+				2: {"name": "Old mug", "price": 5, "quantity": 10, "discontinued": true},
+			})
+			cartKey := cartKeyFor(testUserID)
+			seedCart(t, cartKey, testItem(1, 20, 1), testItem(2, 5, 2))
+
+			w := serve(t, GetCart, testRequest{route: "/"})
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d: %s", w.Code, w.Body)
+			}
+			body := decodeResponse(t, w)
+
+			removed, _ := body["removed_items"].([]interface{})
+			if tt.wantRemoved {
+				if len(removed) != 1 {
+					t.Fatalf("removed_items = %v, want product 2", body["removed_items"])
+				}
+				notice := removed[0].(map[string]interface{})
+				if notice["product_id"] != float64(2) || notice["reason"] != models.NoticeReasonDiscontinued || notice["product_name"] != "Product 2" {
+					t.Errorf("notice = %v", notice)
+				}
+			} else if removed != nil {
+				t.Errorf("removed_items = %v, want none", removed)
+			}
+
+			cart := storedCart(t, cartKey)
+			assertQuantities(t, "stored", cart, tt.want)
+			if i := cart.FindItem(2); i != -1 && cart.Items[i].Discontinued != tt.wantDiscontinued {
+				t.Errorf("discontinued flag = %v, want %v", cart.Items[i].Discontinued, tt.wantDiscontinued)
+			}
+			if tt.wantRemoved && cart.TotalPrice != 20 {
+				t.Errorf("total = %v, want 20 after removal", cart.TotalPrice)
+			}
+		})
+	}
+}
+
+func TestRepriceCart(t *testing.T) {
+	tests := []struct {
+		name        string
+		prices      map[int]float64
+		wantChanges []models.PriceChange
+		wantTotal   float64
+	}{
+		{
+			name:        "some prices changed",
+			prices:      map[int]float64{1: 12, 2: 5, 3: 3},
+			wantChanges: []models.PriceChange{{ProductID: 1, OldPrice: 10, NewPrice: 12}, {ProductID: 3, OldPrice: 4, NewPrice: 3}},
+			wantTotal:   12*2 + 5 + 3*3,
+		},
+		{
+			name:        "nothing changed",
+			prices:      map[int]float64{1: 10, 2: 5, 3: 4},
+			wantChanges: []models.PriceChange{},
+			wantTotal:   10*2 + 5 + 4*3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newTestRedis(t)
+			products := map[int]gin.H{}
+			for productID, price := range tt.prices {
+				products[productID] = gin.H{"name": "Product", "price": price, "quantity": 10}
+			}
+			newProductService(t, products)
+			cartKey := cartKeyFor(testUserID)
+			seedCart(t, cartKey, testItem(1, 10, 2), testItem(2, 5, 1), testItem(3, 4, 3))
+
+			w := serve(t, RepriceCart, testRequest{method: http.MethodPost, route: "/reprice"})
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d: %s", w.Code, w.Body)
+			}
+			var body struct {
+				Changes []models.PriceChange `json:"changes"`
+				Cart    models.Cart          `json:"cart"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatalf("response is not JSON: %v", err)
+			}
+			if fmt.Sprint(body.Changes) != fmt.Sprint(tt.wantChanges) {
+				t.Errorf("changes = %+v, want %+v", body.Changes, tt.wantChanges)
+			}
+			if body.Cart.TotalPrice != tt.wantTotal {
+				t.Errorf("response total_price = %v, want %v", body.Cart.TotalPrice, tt.wantTotal)
+			}
+			if stored := storedCart(t, cartKey); stored.TotalPrice != tt.wantTotal {
+				t.Errorf("stored total_price = %v, want %v", stored.TotalPrice, tt.wantTotal)
+			}
+		})
+	}
+}
+
+func TestFlashSalePriceWindow(t *testing.T) {
+	tests := []struct {
+		name         string
+		windowLeft   time.Duration
+		wantPrice    float64
+		wantReverted bool
+	}{
+		{name: "sale price still valid is kept", windowLeft: time.Hour, wantPrice: 8},
+		{name: "expired sale price reverts", windowLeft: -time.Minute, wantPrice: 10, wantReverted: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newTestRedis(t)
+			t.Setenv("CART_REPRICE_ON_READ", "true")
+			validUntil := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+			product := gin.H{
+				"name": "Kettle", "price": 8, "quantity": 10,
+				"price_valid_until": validUntil, "regular_price": "10",
+			}
+			newProductService(t, map[int]gin.H{1: product})
+			newPromotionsService(t, promotionsFixture{})
+			cartKey := cartKeyFor(testUserID)
+
+			w := serve(t, AddItem, testRequest{method: http.MethodPost, route: "/items", body: `{"product_id": 1, "quantity": 1}`})
+			if w.Code != http.StatusOK {
+				t.Fatalf("add status = %d: %s", w.Code, w.Body)
+			}
+			cart := storedCart(t, cartKey)
+			if item := cart.Items[0]; item.PriceValidUntil != validUntil || item.RegularPrice != 10 {
+				t.Fatalf("sale window not recorded: valid until %q, regular price %v", item.PriceValidUntil, item.RegularPrice)
+			}
+
+			// Move the window relative to now. product-service goes on
+			// reporting the sale price either way.
+			validUntil = time.Now().Add(tt.windowLeft).UTC().Format(time.RFC3339)
+			product["price_valid_until"] = validUntil
+			cart.Items[0].PriceValidUntil = validUntil
+			storeCart(t, cartKey, cart)
+
+			if price := readCartPrice(t); price != tt.wantPrice {
+				t.Errorf("read price = %v, want %v", price, tt.wantPrice)
+			}
+			if item := storedCart(t, cartKey).Items[0]; item.Price != tt.wantPrice || item.PriceReverted != tt.wantReverted {
+				t.Errorf("stored price = %v reverted %v, want %v reverted %v", item.Price, item.PriceReverted, tt.wantPrice, tt.wantReverted)
+			}
+		})
+	}
+}
+
+func TestUnmappedProductResponseKeepsItems(t *testing.T) {
+	newTestRedis(t)
+	t.Setenv("CART_REPRICE_ON_READ", "true")
+	t.Setenv("AUTO_REMOVE_DISCONTINUED", "true")
+	// product-service answers in a shape PRODUCT_FIELD_MAP doesn't match
+	t.Setenv("PRODUCT_FIELD_MAP", "")
+	newJSONService(t, "PRODUCT_SERVICE_URL", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(gin.H{"data": gin.H{"title": "Kettle", "price": 25}})
+	})
+	cartKey := cartKeyFor(testUserID)
+	seedCart(t, cartKey, testItem(1, 20, 1))
+
+	w := serve(t, GetCart, testRequest{route: "/"})
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body)
+	}
+	if removed, _ := decodeResponse(t, w)["removed_items"].([]interface{}); len(removed) != 0 {
+		t.Errorf("removed_items = %v, want none", removed)
+	}
+	cart := storedCart(t, cartKey)
+	assertQuantities(t, "stored", cart, map[int]int{1: 1})
+	if item := cart.Items[0]; item.Discontinued || item.Price != 20 {
+		t.Errorf("item = %+v, want it left as it was", item)
+	}
+}
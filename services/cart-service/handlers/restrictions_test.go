@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestGetCartRestrictions(t *testing.T) {
+	products := map[int]gin.H{
+		1: {"name": "Bread", "price": 3, "quantity": 10},
+		2: {"name": "Wine", "price": 12, "quantity": 10, "age_restricted": true},
+		3: {"name": "Whisky", "price": 40, "quantity": 10, "age_restricted": true, "minimum_age": 21},
+	}
+
+	tests := []struct {
+		name        string
+		products    []int
+		wantItems   string
+		wantMinimum float64
+	}{
+		{name: "unrestricted cart", products: []int{1}, wantItems: "[]"},
+		{name: "restricted item with the default age", products: []int{1, 2}, wantItems: "[2]", wantMinimum: 18},
+		{name: "highest minimum age wins", products: []int{1, 2, 3}, wantItems: "[2 3]", wantMinimum: 21},
+		{name: "empty cart", wantItems: "[]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newTestRedis(t)
+			newProductService(t, products)
+			for _, productID := range tt.products {
+				body := fmt.Sprintf(`{"product_id": %d}`, productID)
+				if w := serve(t, AddItem, testRequest{method: http.MethodPost, route: "/items", body: body}); w.Code != http.StatusOK {
+					t.Fatalf("add status = %d: %s", w.Code, w.Body)
+				}
+			}
+
+			w := serve(t, GetCartRestrictions, testRequest{route: "/restrictions"})
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d: %s", w.Code, w.Body)
+			}
+			restrictions := decodeResponse(t, w)["restrictions"].(map[string]interface{})
+
+			var restricted []int
+			for _, item := range restrictions["items"].([]interface{}) {
+				restricted = append(restricted, int(item.(map[string]interface{})["product_id"].(float64)))
+			}
+			if got := fmt.Sprint(restricted); got != tt.wantItems {
+				t.Errorf("restricted items = %s, want %s", got, tt.wantItems)
+			}
+			if restrictions["requires_age_verification"] != (tt.wantMinimum > 0) {
+				t.Errorf("requires_age_verification = %v", restrictions["requires_age_verification"])
+			}
+			if minimum, _ := restrictions["minimum_age"].(float64); minimum != tt.wantMinimum {
+				t.Errorf("minimum_age = %v, want %v", minimum, tt.wantMinimum)
+			}
+		})
+	}
+}
@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"cart-service/models"
+	"cart-service/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// shippingPolicy returns the configured flat-rate shipping policy
+func shippingPolicy() models.ShippingPolicy {
+	return models.ShippingPolicy{
+		FlatRate:      utils.GetEnvFloat("SHIPPING_FLAT_RATE", 5.99),
+		FreeThreshold: utils.GetEnvFloat("FREE_SHIPPING_THRESHOLD", 50),
+	}
+}
+
+// GetSavings returns a breakdown of what the user saves on their cart
+func GetSavings(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	cartKey, name, ok := requestCart(c, userID)
+	if !ok {
+		return
+	}
+
+	cart, err := loadCart(c.Request.Context(), cartKey)
+	if err == errCartCorrupt {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse cart data"})
+		return
+	}
+	if err != nil {
+		cart = newCart(userID, name)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"original_price": cart.OriginalPrice,
+		"final_price":    cart.FinalPrice,
+		"savings":        cart.Savings(shippingPolicy()),
+	})
+}
@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"cart-service/models"
+	"net/http"
+	"testing"
+)
+
+func TestGetSavings(t *testing.T) {
+	promoted := testItem(1, 50, 2)
+	promoted.DiscountPercent = 20
+
+	tests := []struct {
+		name   string
+		items  []models.CartItem
+		coupon *models.Coupon
+		want   models.SavingsBreakdown
+	}{
+		{
+			name:   "layered discounts",
+			items:  []models.CartItem{promoted},
+			coupon: &models.Coupon{Code: "TEN", Type: models.CouponTypeFixed, Value: 10},
+			want: models.SavingsBreakdown{
+				ItemPromotions: 20,
+				Coupon:         10,
+				FreeShipping:   5.99,
+				Total:          35.99,
+			},
+		},
+		{
+			name:  "no discounts below free shipping",
+			items: []models.CartItem{testItem(2, 10, 2)},
+			want:  models.SavingsBreakdown{},
+		},
+		{
+			name: "empty cart",
+			want: models.SavingsBreakdown{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newTestRedis(t)
+			if tt.items != nil {
+				cart := models.NewCart(testUserID)
+				cart.Items = tt.items
+				cart.Coupon = tt.coupon
+				storeCart(t, cartKeyFor(testUserID), cart)
+			}
+
+			w := serve(t, GetSavings, testRequest{route: "/savings"})
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d: %s", w.Code, w.Body)
+			}
+			savings := decodeResponse(t, w)["savings"].(map[string]interface{})
+			checks := map[string]float64{
+				"item_promotions": tt.want.ItemPromotions,
+				"coupon":          tt.want.Coupon,
+				"free_shipping":   tt.want.FreeShipping,
+				"total":           tt.want.Total,
+			}
+			for field, want := range checks {
+				if savings[field] != want {
+					t.Errorf("%s = %v, want %v", field, savings[field], want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"cart-service/models"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetShippingAllocation splits a shipping cost across the cart's items,
+// by price (default) or weight, for invoicing
+func GetShippingAllocation(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	by := c.DefaultQuery("by", models.AllocateByPrice)
+	if by != models.AllocateByPrice && by != models.AllocateByWeight {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "by must be 'price' or 'weight'"})
+		return
+	}
+
+	cartKey, _, ok := requestCart(c, userID)
+	if !ok {
+		return
+	}
+
+	cart, err := loadCart(c.Request.Context(), cartKey)
+	if err == errCartCorrupt {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse cart data"})
+		return
+	}
+	if err != nil || len(cart.Items) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Cart is empty", "code": codeCartEmpty})
+		return
+	}
+
+	// Default to the cart's own shipping charge
+	shipping := shippingPolicy().Cost(cart.FinalPrice)
+	if shippingParam := c.Query("shipping"); shippingParam != "" {
+		shipping, err = strconv.ParseFloat(shippingParam, 64)
+		if err != nil || shipping < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "shipping must be a non-negative number"})
+			return
+		}
+	}
+
+	allocations, err := models.AllocateShipping(cart.Items, shipping, by)
+	if err == models.ErrNoAllocationBasis {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Cannot allocate shipping by " + by + " for this cart"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to allocate shipping"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"shipping":    models.RoundPrice(shipping),
+		"by":          by,
+		"allocations": allocations,
+	})
+}
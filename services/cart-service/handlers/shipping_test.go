@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"cart-service/models"
+	"math"
+	"net/http"
+	"testing"
+)
+
+func TestGetShippingAllocation(t *testing.T) {
+	light, mid, heavy := testItem(1, 10, 1), testItem(2, 20, 1), testItem(3, 30, 1)
+	light.Weight, mid.Weight, heavy.Weight = 3, 1, 1
+
+	tests := []struct {
+		name       string
+		target     string
+		wantStatus int
+		wantTotal  float64
+		want       map[float64]float64
+	}{
+		{
+			name:       "by price with the remainder on the largest item",
+			target:     "/shipping-allocation?shipping=10",
+			wantStatus: http.StatusOK,
+			wantTotal:  10,
+			want:       map[float64]float64{1: 1.66, 2: 3.33, 3: 5.01},
+		},
+		{
+			name:       "by weight",
+			target:     "/shipping-allocation?shipping=10&by=weight",
+			wantStatus: http.StatusOK,
+			wantTotal:  10,
+			want:       map[float64]float64{1: 6, 2: 2, 3: 2},
+		},
+		{
+			name:       "defaults to the cart's shipping charge",
+			target:     "/shipping-allocation",
+			wantStatus: http.StatusOK,
+			wantTotal:  0,
+			want:       map[float64]float64{1: 0, 2: 0, 3: 0},
+		},
+		{
+			name:       "invalid basis",
+			target:     "/shipping-allocation?by=volume",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "negative shipping",
+			target:     "/shipping-allocation?shipping=-1",
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newTestRedis(t)
+			seedCart(t, cartKeyFor(testUserID), []models.CartItem{light, mid, heavy}...)
+
+			w := serve(t, GetShippingAllocation, testRequest{route: "/shipping-allocation", target: tt.target})
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			body := decodeResponse(t, w)
+			var sum float64
+			for _, entry := range body["allocations"].([]interface{}) {
+				allocation := entry.(map[string]interface{})
+				productID, amount := allocation["product_id"].(float64), allocation["amount"].(float64)
+				if amount != tt.want[productID] {
+					t.Errorf("product %v allocated %v, want %v", productID, amount, tt.want[productID])
+				}
+				sum += amount
+			}
+			if math.Round(sum*100) != math.Round(tt.wantTotal*100) || body["shipping"] != tt.wantTotal {
+				t.Errorf("allocations sum to %v of %v, want %v", sum, body["shipping"], tt.wantTotal)
+			}
+		})
+	}
+}
+
+func TestGetShippingAllocationEmptyCart(t *testing.T) {
+	newTestRedis(t)
+
+	w := serve(t, GetShippingAllocation, testRequest{route: "/shipping-allocation"})
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+	if code := decodeResponse(t, w)["code"]; code != codeCartEmpty {
+		t.Errorf("code = %v, want %s", code, codeCartEmpty)
+	}
+}
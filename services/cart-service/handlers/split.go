@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"cart-service/models"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+const defaultSplitTarget = "checkout"
+
+// SplitCart moves a subset of items into a separate named cart so they
+// can be checked out on their own
+func SplitCart(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req models.SplitCartRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	target := req.Target
+	if target == "" {
+		target = defaultSplitTarget
+	}
+	if !cartNamePattern.MatchString(target) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cart name"})
+		return
+	}
+
+	sourceKey, _, ok := requestCart(c, userID)
+	if !ok {
+		return
+	}
+	targetKey := namedCartKeyFor(userID, target)
+	if sourceKey == targetKey {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Cannot split a cart into itself"})
+		return
+	}
+
+	// Both carts are written, so both are locked, always in key order so
+	// opposing splits cannot each hold one lock waiting on the other
+	first, second := sourceKey, targetKey
+	if second < first {
+		first, second = second, first
+	}
+	firstLock, ok := lockCart(c, first)
+	if !ok {
+		return
+	}
+	defer firstLock.Release(c.Request.Context())
+	secondLock, ok := lockCart(c, second)
+	if !ok {
+		return
+	}
+	defer secondLock.Release(c.Request.Context())
+	if !ensureNotFrozen(c, sourceKey, targetKey) {
+		return
+	}
+
+	source, err := loadCart(c.Request.Context(), sourceKey)
+	if err == errCartCorrupt {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse cart data"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Cart not found", "code": codeCartEmpty})
+		return
+	}
+
+	// Items are merged into the target cart if it already exists
+	dest, err := loadCart(c.Request.Context(), targetKey)
+	if err == errCartCorrupt {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse cart data"})
+		return
+	}
+	if err != nil {
+		if !withinCartLimit(c, userID, target, true) {
+			return
+		}
+		dest = newCart(userID, target)
+	}
+
+	// Make sure every requested product is in the cart before moving any
+	missing := []int{}
+	for _, productID := range req.ProductIDs {
+		if source.FindItem(productID) == -1 {
+			missing = append(missing, productID)
+		}
+	}
+	if len(missing) > 0 {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":       "Items not found in cart",
+			"code":        codeItemNotFound,
+			"product_ids": missing,
+		})
+		return
+	}
+
+	for _, productID := range req.ProductIDs {
+		i := source.FindItem(productID)
+		if i == -1 {
+			// Listed twice in the request
+			continue
+		}
+		item := source.Items[i]
+		source.Items = append(source.Items[:i], source.Items[i+1:]...)
+
+		if j := dest.FindItem(productID); j != -1 {
+			dest.Items[j].Quantity += item.Quantity
+		} else {
+			dest.Items = append(dest.Items, item)
+		}
+	}
+
+	source.CalculateTotals()
+	dest.CalculateTotals()
+
+	if err := saveCarts(c.Request.Context(), map[string]*models.Cart{sourceKey: source, targetKey: dest}); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save carts"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":    "Cart split",
+		"cart":       source,
+		"split_cart": dest,
+	})
+}
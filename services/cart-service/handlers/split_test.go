@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"cart-service/models"
+	"net/http"
+	"testing"
+)
+
+func TestSplitCart(t *testing.T) {
+	tests := []struct {
+		name         string
+		body         string
+		existing     []models.CartItem
+		targetName   string
+		wantStatus   int
+		wantSource   map[int]int
+		wantTarget   map[int]int
+		wantSrcTotal float64
+		wantDstTotal float64
+	}{
+		{
+			name:         "move a subset into the checkout cart",
+			body:         `{"product_ids": [1, 3]}`,
+			targetName:   defaultSplitTarget,
+			wantStatus:   http.StatusOK,
+			wantSource:   map[int]int{2: 1},
+			wantTarget:   map[int]int{1: 2, 3: 3},
+			wantSrcTotal: 5,
+			wantDstTotal: 26,
+		},
+		{
+			name:         "merge into an existing named cart",
+			body:         `{"product_ids": [1], "target": "later"}`,
+			existing:     []models.CartItem{testItem(1, 10, 1)},
+			targetName:   "later",
+			wantStatus:   http.StatusOK,
+			wantSource:   map[int]int{2: 1, 3: 3},
+			wantTarget:   map[int]int{1: 3},
+			wantSrcTotal: 11,
+			wantDstTotal: 30,
+		},
+		{
+			name:       "unknown product",
+			body:       `{"product_ids": [1, 9]}`,
+			targetName: defaultSplitTarget,
+			wantStatus: http.StatusNotFound,
+			wantSource: map[int]int{1: 2, 2: 1, 3: 3},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newTestRedis(t)
+			sourceKey := cartKeyFor(testUserID)
+			targetKey := namedCartKeyFor(testUserID, tt.targetName)
+			seedCart(t, sourceKey, testItem(1, 10, 2), testItem(2, 5, 1), testItem(3, 2, 3))
+			if tt.existing != nil {
+				cart := models.NewCart(testUserID)
+				cart.Name = tt.targetName
+				cart.Items = tt.existing
+				storeCart(t, targetKey, cart)
+			}
+
+			w := serve(t, SplitCart, testRequest{method: http.MethodPost, route: "/split", body: tt.body})
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+
+			source := storedCart(t, sourceKey)
+			assertQuantities(t, "source", source, tt.wantSource)
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			target := storedCart(t, targetKey)
+			assertQuantities(t, "target", target, tt.wantTarget)
+			if source.TotalPrice != tt.wantSrcTotal || target.TotalPrice != tt.wantDstTotal {
+				t.Errorf("totals = %v/%v, want %v/%v", source.TotalPrice, target.TotalPrice, tt.wantSrcTotal, tt.wantDstTotal)
+			}
+		})
+	}
+}
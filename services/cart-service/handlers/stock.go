@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"cart-service/utils"
+	"log"
+	"net/http"
+	"sort"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetStockCheck checks every item against current stock in one pass and
+// reports each one the cart wants more of than is available. Products that
+// no longer exist count as having no stock; products whose stock couldn't
+// be fetched are listed as unchecked.
+func GetStockCheck(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	cartKey, _, ok := requestCart(c, userID)
+	if !ok {
+		return
+	}
+
+	cart, err := loadCart(c.Request.Context(), cartKey)
+	if err == errCartCorrupt {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse cart data"})
+		return
+	}
+	if err != nil || len(cart.Items) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Cart is empty", "code": codeCartEmpty})
+		return
+	}
+
+	productIDs := make([]int, 0, len(cart.Items))
+	for _, item := range cart.Items {
+		productIDs = append(productIDs, item.ProductID)
+	}
+	products, errs := utils.FetchProducts(c.Request.Context(), productIDs)
+
+	available := make(map[int]int, len(cart.Items))
+	for productID, product := range products {
+		available[productID] = product.Quantity
+	}
+	unchecked := []int{}
+	for productID, err := range errs {
+		if err == utils.ErrProductNotFound {
+			available[productID] = 0
+			continue
+		}
+		log.Printf("Failed to fetch product %d: %v", productID, err)
+		unchecked = append(unchecked, productID)
+	}
+	sort.Ints(unchecked)
+
+	shortfalls := cart.StockShortfalls(available)
+	c.JSON(http.StatusOK, gin.H{
+		"available":  len(shortfalls) == 0 && len(unchecked) == 0,
+		"shortfalls": shortfalls,
+		"unchecked":  unchecked,
+	})
+}
@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"cart-service/models"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestGetStockCheck(t *testing.T) {
+	tests := []struct {
+		name          string
+		items         []models.CartItem
+		wantAvailable bool
+		// product ID, quantity, available and shortfall of each shortfall
+		wantShortfalls [][4]int
+	}{
+		{
+			name:           "several shortfalls",
+			items:          []models.CartItem{testItem(1, 10, 5), testItem(2, 5, 1), testItem(3, 4, 3), testItem(4, 2, 1)},
+			wantShortfalls: [][4]int{{1, 5, 2, 3}, {3, 3, 0, 3}, {4, 1, 0, 1}},
+		},
+		{
+			name:           "everything available",
+			items:          []models.CartItem{testItem(1, 10, 2), testItem(2, 5, 10)},
+			wantAvailable:  true,
+			wantShortfalls: [][4]int{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newTestRedis(t)
+			// Product 4 no longer exists
+			newProductService(t, map[int]gin.H{
+				1: {"name": "Kettle", "price": 10, "quantity": 2},
+				2: {"name": "Mug", "price": 5, "quantity": 10},
+				3: {"name": "Teapot", "price": 4, "quantity": 0},
+			})
+			seedCart(t, cartKeyFor(testUserID), tt.items...)
+
+			w := serve(t, GetStockCheck, testRequest{route: "/stock-check"})
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d: %s", w.Code, w.Body)
+			}
+			var body struct {
+				Available  bool                    `json:"available"`
+				Shortfalls []models.StockShortfall `json:"shortfalls"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatalf("response is not JSON: %v", err)
+			}
+			if body.Available != tt.wantAvailable {
+				t.Errorf("available = %v, want %v", body.Available, tt.wantAvailable)
+			}
+			shortfalls := [][4]int{}
+			for _, s := range body.Shortfalls {
+				shortfalls = append(shortfalls, [4]int{s.ProductID, s.Quantity, s.Available, s.Shortfall})
+			}
+			if fmt.Sprint(shortfalls) != fmt.Sprint(tt.wantShortfalls) {
+				t.Errorf("shortfalls = %v, want %v", shortfalls, tt.wantShortfalls)
+			}
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestAddSubscriptionItem(t *testing.T) {
+	tests := []struct {
+		name         string
+		product      gin.H
+		body         string
+		wantStatus   int
+		wantInterval string
+		wantSubtotal float64
+	}{
+		{
+			name:         "eligible product on a valid interval",
+			product:      gin.H{"name": "Coffee", "price": 10, "quantity": 10, "subscription_eligible": true, "subscription_discount_percent": "15"},
+			body:         `{"product_id": 1, "quantity": 2, "is_subscription": true, "interval": "monthly"}`,
+			wantStatus:   http.StatusOK,
+			wantInterval: "monthly",
+			wantSubtotal: 17,
+		},
+		{
+			name:       "ineligible product",
+			product:    gin.H{"name": "Coffee", "price": 10, "quantity": 10},
+			body:       `{"product_id": 1, "quantity": 2, "is_subscription": true, "interval": "monthly"}`,
+			wantStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:       "unsupported interval",
+			product:    gin.H{"name": "Coffee", "price": 10, "quantity": 10, "subscription_eligible": true},
+			body:       `{"product_id": 1, "quantity": 2, "is_subscription": true, "interval": "daily"}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "interval without a subscription",
+			product:    gin.H{"name": "Coffee", "price": 10, "quantity": 10, "subscription_eligible": true},
+			body:       `{"product_id": 1, "quantity": 2, "interval": "weekly"}`,
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newTestRedis(t)
+			newProductService(t, map[int]gin.H{1: tt.product})
+			newPromotionsService(t, promotionsFixture{})
+			cartKey := cartKeyFor(testUserID)
+
+			w := serve(t, AddItem, testRequest{method: http.MethodPost, route: "/items", body: tt.body})
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			item := storedCart(t, cartKey).Items[0]
+			if !item.IsSubscription || item.Interval != tt.wantInterval {
+				t.Errorf("item subscription = %v every %q, want %s", item.IsSubscription, item.Interval, tt.wantInterval)
+			}
+			if item.Subtotal != tt.wantSubtotal {
+				t.Errorf("subtotal = %v, want %v", item.Subtotal, tt.wantSubtotal)
+			}
+		})
+	}
+}
@@ -0,0 +1,175 @@
+package handlers
+
+import (
+	"cart-service/models"
+	"cart-service/utils"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SyncCart merges a cart kept in the client's local storage into the
+// user's server cart, typically on login. Quantities of products in both
+// are summed and capped at available stock and the product's order limit.
+// Every merged item takes the product's current price, the newest either
+// side could have. Returns the merged cart as the authoritative copy.
+func SyncCart(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req models.SyncCartRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	localItems := req.Combined()
+
+	cartKey, name, ok := requestCart(c, userID)
+	if !ok {
+		return
+	}
+	lock, ok := lockCart(c, cartKey)
+	if !ok {
+		return
+	}
+	defer lock.Release(c.Request.Context())
+	if !ensureNotFrozen(c, cartKey) {
+		return
+	}
+
+	cart, err := loadCart(c.Request.Context(), cartKey)
+	if err == errCartCorrupt {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse cart data"})
+		return
+	}
+	creating := err != nil
+	if creating {
+		cart = newCart(userID, name)
+	}
+
+	localQuantity := 0
+	for _, localItem := range localItems {
+		localQuantity += localItem.Quantity
+	}
+	if shedUnderMemoryPressure(c, creating, localQuantity) {
+		return
+	}
+	if !withinCartLimit(c, userID, name, creating) {
+		return
+	}
+
+	skipped := []gin.H{}
+	capped := []gin.H{}
+	var trimmed []models.CartItem
+	claims := newQuotaClaims(userID)
+	for _, localItem := range localItems {
+		product, err := utils.FetchProduct(c.Request.Context(), localItem.ProductID)
+		if err == utils.ErrProductNotFound {
+			skipped = append(skipped, gin.H{"product_id": localItem.ProductID, "reason": listSkipNotFound})
+			continue
+		}
+		if err != nil {
+			log.Printf("Failed to fetch product %d: %v", localItem.ProductID, err)
+			skipped = append(skipped, gin.H{"product_id": localItem.ProductID, "reason": listSkipUnavailable})
+			continue
+		}
+		if !priceAllowed(product) {
+			skipped = append(skipped, gin.H{"product_id": localItem.ProductID, "reason": listSkipNoPrice})
+			continue
+		}
+
+		itemIndex := cart.FindItem(localItem.ProductID)
+		requested := localItem.Quantity
+		current := 0
+		if itemIndex != -1 {
+			current = cart.Items[itemIndex].PaidQuantity()
+			requested += current
+		}
+
+		// Cap at what can actually be ordered, keeping to the quantity step
+		quantity := requested
+		if quantity > product.Quantity {
+			quantity = product.Quantity
+		}
+		if product.MaxPerOrder > 0 && quantity > product.MaxPerOrder {
+			quantity = product.MaxPerOrder
+		}
+		if product.QuantityStep > 1 {
+			quantity -= quantity % product.QuantityStep
+		}
+		if quantity <= 0 {
+			skipped = append(skipped, gin.H{"product_id": localItem.ProductID, "reason": listSkipInsufficientStock})
+			continue
+		}
+
+		// Units the merge adds count against the daily quota
+		allowed, err := claims.claim(c.Request.Context(), localItem.ProductID, product.DailyQuota, quantity-current)
+		if err != nil {
+			log.Printf("Failed to check daily quota for product %d: %v", localItem.ProductID, err)
+			skipped = append(skipped, gin.H{"product_id": localItem.ProductID, "reason": listSkipUnavailable})
+			continue
+		}
+		if !allowed {
+			skipped = append(skipped, gin.H{"product_id": localItem.ProductID, "reason": listSkipDailyQuota})
+			continue
+		}
+		if quantity < requested {
+			capped = append(capped, gin.H{
+				"product_id": localItem.ProductID,
+				"requested":  requested,
+				"quantity":   quantity,
+			})
+		}
+
+		if itemIndex == -1 {
+			addedAt := localItem.AddedAt
+			if _, err := time.Parse(time.RFC3339, addedAt); err != nil {
+				addedAt = time.Now().Format(time.RFC3339)
+			}
+			cart.Items = append(cart.Items, models.CartItem{
+				ProductID: localItem.ProductID,
+				AddedAt:   addedAt,
+				AddedBy:   fmt.Sprintf("%v", userID),
+			})
+			itemIndex = len(cart.Items) - 1
+		}
+
+		// A cap below the units already in the cart gives up any reserved
+		// flash-sale units beyond it once the cart is saved
+		item := &cart.Items[itemIndex]
+		if item.FlashReserved > quantity {
+			trimmed = append(trimmed, *item)
+			item.TrimHold(quantity)
+		}
+		refreshProductDetails(item, product)
+		item.SetPaidQuantity(quantity)
+		item.Pending = false
+		applyPromotion(c.Request.Context(), item)
+	}
+
+	applyBundles(c.Request.Context(), cart)
+	cart.CalculateTotals()
+	cart.SortItems()
+
+	if err := saveCart(c.Request.Context(), cartKey, cart); err != nil {
+		claims.release(c.Request.Context())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save cart"})
+		return
+	}
+	for _, previous := range trimmed {
+		trimFlashHold(c.Request.Context(), cartKey, previous, cart.Items[cart.FindItem(previous.ProductID)].Quantity)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Cart synced",
+		"cart":    cart,
+		"capped":  capped,
+		"skipped": skipped,
+	})
+}
@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"cart-service/models"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestSyncCart(t *testing.T) {
+	tests := []struct {
+		name       string
+		server     []models.CartItem
+		local      string
+		wantQty    map[int]int
+		wantPrices map[int]float64
+		wantCapped int
+	}{
+		{
+			name:       "disjoint carts",
+			server:     []models.CartItem{testItem(1, 10, 1)},
+			local:      `{"items": [{"product_id": 2, "quantity": 2}]}`,
+			wantQty:    map[int]int{1: 1, 2: 2},
+			wantPrices: map[int]float64{2: 5},
+		},
+		{
+			name:       "overlapping carts sum and take the current price",
+			server:     []models.CartItem{testItem(1, 9, 2)},
+			local:      `{"items": [{"product_id": 1, "quantity": 3, "price": 1}]}`,
+			wantQty:    map[int]int{1: 5},
+			wantPrices: map[int]float64{1: 10},
+		},
+		{
+			name:       "merged quantity capped at stock",
+			server:     []models.CartItem{testItem(3, 4, 3)},
+			local:      `{"items": [{"product_id": 3, "quantity": 2}, {"product_id": 3, "quantity": 2}]}`,
+			wantQty:    map[int]int{3: 4},
+			wantPrices: map[int]float64{3: 4},
+			wantCapped: 1,
+		},
+		{
+			name:       "empty server cart",
+			local:      `{"items": [{"product_id": 1, "quantity": 1}, {"product_id": 2, "quantity": 1}]}`,
+			wantQty:    map[int]int{1: 1, 2: 1},
+			wantPrices: map[int]float64{1: 10, 2: 5},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newTestRedis(t)
+			newProductService(t, map[int]gin.H{
+				1: {"name": "Kettle", "price": 10, "quantity": 10},
+				2: {"name": "Mug", "price": 5, "quantity": 10},
+				3: {"name": "Teapot", "price": 4, "quantity": 4},
+			})
+			newPromotionsService(t, promotionsFixture{})
+			cartKey := cartKeyFor(testUserID)
+			if tt.server != nil {
+				seedCart(t, cartKey, tt.server...)
+			}
+
+			w := serve(t, SyncCart, testRequest{method: http.MethodPost, route: "/sync", body: tt.local})
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d: %s", w.Code, w.Body)
+			}
+			if capped := decodeResponse(t, w)["capped"].([]interface{}); len(capped) != tt.wantCapped {
+				t.Errorf("capped = %v, want %d entries", capped, tt.wantCapped)
+			}
+
+			cart := storedCart(t, cartKey)
+			assertQuantities(t, "merged", cart, tt.wantQty)
+			for _, item := range cart.Items {
+				if want, ok := tt.wantPrices[item.ProductID]; ok && item.Price != want {
+					t.Errorf("product %d price = %v, want %v", item.ProductID, item.Price, want)
+				}
+			}
+		})
+	}
+}
+
+func TestSyncCartBuysGiftProduct(t *testing.T) {
+	newTestRedis(t)
+	newProductService(t, map[int]gin.H{
+		1:  {"name": "Tea", "price": 30, "quantity": 100},
+		99: {"name": "Tote bag", "price": 8, "quantity": 100},
+	})
+	newPromotionsService(t, promotionsFixture{})
+	cartKey := cartKeyFor(testUserID)
+	cart := models.NewCart(testUserID)
+	cart.Items = []models.CartItem{testItem(1, 30, 2)}
+	cart.ApplyGiftPromotions([]models.GiftPromotion{{ID: "tote", ProductID: 99, ProductName: "Tote bag", MinOrderValue: 50}})
+	storeCart(t, cartKey, cart)
+
+	w := serve(t, SyncCart, testRequest{method: http.MethodPost, route: "/sync", body: `{"items": [{"product_id": 99, "quantity": 3}]}`})
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body)
+	}
+
+	cart = storedCart(t, cartKey)
+	assertQuantities(t, "merged", cart, map[int]int{1: 2, 99: 3})
+	if item := cart.Items[cart.FindItem(99)]; item.PromoGift || item.Price != 8 {
+		t.Errorf("tote line = %+v, want a paid line at 8", item)
+	}
+}
+
+func TestSyncCartCapReleasesFlashUnits(t *testing.T) {
+	newTestRedis(t)
+	newFlashSale(t, 5)
+	cartKey := cartKeyFor(testUserID)
+	if w := flashAdd(t, testUserID, `{"product_id": 1, "quantity": 4}`); w.Code != http.StatusOK {
+		t.Fatalf("add status = %d: %s", w.Code, w.Body)
+	}
+	// The order limit has since dropped below what the cart holds
+	newProductService(t, map[int]gin.H{1: {"name": "Console", "price": 100, "quantity": 1000, "max_per_order": 2}})
+
+	w := serve(t, SyncCart, testRequest{method: http.MethodPost, route: "/sync", body: `{"items": [{"product_id": 1, "quantity": 1}]}`})
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body)
+	}
+
+	if item := storedCart(t, cartKey).Items[0]; item.Quantity != 2 || item.FlashReserved != 2 {
+		t.Errorf("item = %+v, want 2 units, both reserved", item)
+	}
+	if stock := flashStock(t); stock != 3 {
+		t.Errorf("flash stock = %d, want the 2 capped units back", stock)
+	}
+	if want := []string{"1|2|" + cartKey}; fmt.Sprint(trackedHolds(t)) != fmt.Sprint(want) {
+		t.Errorf("tracked holds = %v, want %v", trackedHolds(t), want)
+	}
+	expireAllHolds(t)
+	if stock := flashStock(t); stock != 5 {
+		t.Errorf("flash stock after the hold expires = %d, want 5", stock)
+	}
+}
@@ -0,0 +1,174 @@
+package handlers
+
+import (
+	"cart-service/models"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// addVendorItems adds products 1 and 2 from acme and 3 from globex, through
+// product-service so each item picks up its vendor
+func addVendorItems(t *testing.T) {
+	t.Helper()
+	newProductService(t, map[int]gin.H{
+		1: {"name": "Kettle", "price": 20, "quantity": 10, "vendor_id": "acme"},
+		2: {"name": "Mug", "price": 5, "quantity": 10, "vendor_id": "acme"},
+		3: {"name": "Teapot", "price": 30, "quantity": 10, "vendor_id": "globex"},
+	})
+	newPromotionsService(t, promotionsFixture{})
+	for _, add := range []string{
+		`{"product_id": 1, "quantity": 1}`,
+		`{"product_id": 2, "quantity": 4}`,
+		`{"product_id": 3, "quantity": 2}`,
+	} {
+		if w := serve(t, AddItem, testRequest{method: http.MethodPost, route: "/items", body: add}); w.Code != http.StatusOK {
+			t.Fatalf("add status = %d: %s", w.Code, w.Body)
+		}
+	}
+}
+
+func TestGetVendorCart(t *testing.T) {
+	tests := []struct {
+		vendorID     string
+		wantItems    int
+		wantUnits    int
+		wantSubtotal float64
+	}{
+		{vendorID: "acme", wantItems: 2, wantUnits: 5, wantSubtotal: 40},
+		{vendorID: "globex", wantItems: 1, wantUnits: 2, wantSubtotal: 60},
+		{vendorID: "initech", wantItems: 0, wantUnits: 0, wantSubtotal: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.vendorID, func(t *testing.T) {
+			newTestRedis(t)
+			addVendorItems(t)
+
+			w := serve(t, GetVendorCart, testRequest{route: "/vendor/:vendor_id", target: "/vendor/" + tt.vendorID})
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d: %s", w.Code, w.Body)
+			}
+			var body struct {
+				Vendor models.VendorCart `json:"vendor"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatalf("response is not JSON: %v", err)
+			}
+			vendor := body.Vendor
+			if len(vendor.Items) != tt.wantItems || vendor.TotalItems != tt.wantUnits || vendor.Subtotal != tt.wantSubtotal {
+				t.Errorf("vendor %s has %d items, %d units, subtotal %v; want %d, %d, %v",
+					tt.vendorID, len(vendor.Items), vendor.TotalItems, vendor.Subtotal, tt.wantItems, tt.wantUnits, tt.wantSubtotal)
+			}
+			for _, item := range vendor.Items {
+				if item.VendorID != tt.vendorID {
+					t.Errorf("product %d from vendor %q listed under %s", item.ProductID, item.VendorID, tt.vendorID)
+				}
+			}
+		})
+	}
+}
+
+func TestGetCartByVendor(t *testing.T) {
+	newTestRedis(t)
+	addVendorItems(t)
+
+	w := serve(t, GetCartByVendor, testRequest{route: "/by-vendor"})
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body)
+	}
+	var body struct {
+		Vendors    []models.VendorCart `json:"vendors"`
+		TotalPrice float64             `json:"total_price"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("response is not JSON: %v", err)
+	}
+
+	want := []struct {
+		vendorID string
+		units    int
+		subtotal float64
+	}{
+		{vendorID: "acme", units: 5, subtotal: 40},
+		{vendorID: "globex", units: 2, subtotal: 60},
+	}
+	if len(body.Vendors) != len(want) {
+		t.Fatalf("got %d vendors, want %d: %+v", len(body.Vendors), len(want), body.Vendors)
+	}
+	for i, vendor := range body.Vendors {
+		if vendor.VendorID != want[i].vendorID || vendor.TotalItems != want[i].units || vendor.Subtotal != want[i].subtotal {
+			t.Errorf("vendor %d = %s with %d units at %v, want %s with %d at %v",
+				i, vendor.VendorID, vendor.TotalItems, vendor.Subtotal, want[i].vendorID, want[i].units, want[i].subtotal)
+		}
+	}
+	if body.TotalPrice != 100 {
+		t.Errorf("total_price = %v, want 100", body.TotalPrice)
+	}
+}
+
+func TestOrderPayloadVendorMinimums(t *testing.T) {
+	tests := []struct {
+		name       string
+		minimums   map[string]float64
+		wantStatus int
+		wantBelow  []models.VendorShortfall
+	}{
+		{
+			name:       "some vendors below their minimum",
+			minimums:   map[string]float64{"acme": 50, "globex": 50},
+			wantStatus: http.StatusUnprocessableEntity,
+			wantBelow:  []models.VendorShortfall{{VendorID: "acme", Subtotal: 40, Minimum: 50, Shortfall: 10}},
+		},
+		{
+			name:       "every minimum met",
+			minimums:   map[string]float64{"acme": 40, "globex": 25},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "vendors without minimums",
+			minimums:   map[string]float64{},
+			wantStatus: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newTestRedis(t)
+			addVendorItems(t)
+			cartKey := cartKeyFor(testUserID)
+			cart := storedCart(t, cartKey)
+			cart.ShippingAddress = testAddress()
+			storeCart(t, cartKey, cart)
+
+			var requested string
+			newJSONService(t, "VENDOR_SERVICE_URL", func(w http.ResponseWriter, r *http.Request) {
+				requested = r.URL.Query().Get("ids")
+				json.NewEncoder(w).Encode(gin.H{"minimums": tt.minimums})
+			})
+
+			w := serve(t, GetOrderPayload, testRequest{route: "/order-payload"})
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+			if requested != "acme,globex" {
+				t.Errorf("requested minimums for %q, want acme,globex", requested)
+			}
+			if tt.wantStatus == http.StatusOK {
+				return
+			}
+			var body struct {
+				Below []models.VendorShortfall `json:"vendors_below_minimum"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatalf("response is not JSON: %v", err)
+			}
+			if !reflect.DeepEqual(body.Below, tt.wantBelow) {
+				t.Errorf("vendors_below_minimum = %+v, want %+v", body.Below, tt.wantBelow)
+			}
+		})
+	}
+}
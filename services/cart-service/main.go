@@ -3,25 +3,90 @@ package main
 import (
 	"cart-service/handlers"
 	"cart-service/middleware"
+	"cart-service/models"
 	"cart-service/utils"
 	"fmt"
 	"log"
 	"os"
+	"runtime"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
 )
 
+// Build information, injected at build time via -ldflags
+var (
+	Version   = "dev"
+	GitCommit = "unknown"
+	BuildTime = "unknown"
+)
+
 func main() {
 	// Load environment variables
 	godotenv.Load()
 
+	// Select the storage format for carts
+	if err := models.SetSerializer(os.Getenv("CART_SERIALIZER")); err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+
+	// Select how monetary amounts are rounded to cents
+	if err := models.SetRoundingMode(os.Getenv("ROUNDING_MODE")); err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+
+	// Marketing ending for display prices, e.g. "0.99"; exact prices are
+	// kept for totals
+	if err := models.SetPriceEnding(os.Getenv("PRICE_ENDING")); err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+
+	// Sales tax: TAX_RATE by default, overridden per region and category
+	// by the TAX_TABLE JSON
+	if err := models.SetTaxRates(utils.GetEnvFloat("TAX_RATE", 0), os.Getenv("TAX_TABLE")); err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+
+	// Tier pricing by loyalty tier, e.g. "gold=10,silver=5"
+	if err := models.SetLoyaltyDiscounts(os.Getenv("LOYALTY_TIER_DISCOUNTS")); err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+
+	// Present prices inclusive (EU) or exclusive (US) of tax
+	if err := models.SetPriceDisplay(os.Getenv("PRICE_DISPLAY")); err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+
+	// Countries shown tax-inclusive prices with PRICE_DISPLAY_BY_REGION
+	if err := models.SetInclusiveCountries(os.Getenv("PRICE_INCLUSIVE_COUNTRIES")); err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+
+	// Automatic discounts by JWT role claim, e.g. "employee=20"
+	if err := models.SetMemberDiscounts(os.Getenv("MEMBER_DISCOUNTS"), utils.GetEnvBool("MEMBER_DISCOUNT_STACKS", false)); err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+
+	// Installment plans offered on larger carts, e.g. "3:0,12:14.99"
+	if err := models.SetFinancingPlans(os.Getenv("FINANCING_PLANS")); err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+
 	// Initialize Redis
 	if err := utils.InitRedis(); err != nil {
 		log.Fatalf("Failed to connect to Redis: %v", err)
 	}
 
+	// Shed cart growth while Redis is short of memory
+	utils.StartMemoryMonitor()
+
+	// Return expired flash-sale holds to the sale
+	handlers.StartHoldReaper()
+
+	// Initialize shared HTTP client for service calls
+	utils.InitHTTPClient()
+
 	// Create Gin router
 	router := gin.Default()
 
@@ -29,11 +94,17 @@ func main() {
 	router.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{"*"},
 		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization"},
-		ExposeHeaders:    []string{"Content-Length"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization", "Idempotency-Key", "X-Feature-Flags"},
+		ExposeHeaders:    []string{"Content-Length", "Idempotent-Replayed"},
 		AllowCredentials: true,
 	}))
 
+	// Shed load beyond MAX_CONCURRENT_REQUESTS in-flight requests
+	router.Use(middleware.ConcurrencyLimit())
+
+	// Dependency timings in responses (DEBUG=true only)
+	router.Use(middleware.DebugTimings())
+
 	// Health check
 	router.GET("/health", func(c *gin.Context) {
 		c.JSON(200, gin.H{
@@ -42,15 +113,79 @@ func main() {
 		})
 	})
 
+	// Prometheus metrics
+	router.GET("/metrics", gin.WrapF(utils.MetricsHandler))
+
+	// Build and dependency info
+	router.GET("/info", buildInfo)
+
 	// API routes (protected)
 	api := router.Group("/api/cart")
 	api.Use(middleware.AuthMiddleware())
+	// Log writes and replay retries sent with an Idempotency-Key
+	api.Use(middleware.Idempotency())
+	// Per-request toggles for allowlisted behaviors
+	api.Use(middleware.FeatureFlags())
 	{
 		api.GET("", handlers.GetCart)
+		api.GET("/all", handlers.ListCarts)
+		api.GET("/meta", handlers.GetCartMeta)
+		api.GET("/count", handlers.GetCartCount)
+		api.GET("/mobile", handlers.GetMobileCart)
+		api.GET("/events", handlers.StreamCartEvents)
+		api.GET("/operations", handlers.GetOperations)
 		api.POST("/items", handlers.AddItem)
+		api.POST("/flash-sale/items", handlers.FlashSaleAddItem)
+		api.GET("/preview-add", handlers.PreviewAddItem)
+		api.GET("/vendor/:vendor_id", handlers.GetVendorCart)
+		api.GET("/by-vendor", handlers.GetCartByVendor)
+		api.GET("/by-fulfillment", handlers.GetCartByFulfillment)
+		api.GET("/items/:product_id", handlers.GetItem)
 		api.PUT("/items/:product_id", handlers.UpdateItem)
 		api.DELETE("/items/:product_id", handlers.RemoveItem)
+		api.POST("/items/:product_id/adjust", handlers.AdjustItem)
+		api.PUT("/items/:product_id/delivery-date", handlers.SetItemDeliveryDate)
 		api.DELETE("", handlers.ClearCart)
+		api.DELETE("/all", handlers.ClearAllCarts)
+		api.GET("/coupon/preview", handlers.PreviewCoupon)
+		api.GET("/coupon/check", handlers.CheckCoupon)
+		api.GET("/available-promotions", handlers.GetAvailablePromotions)
+		api.POST("/coupon", handlers.ApplyCoupon)
+		api.DELETE("/coupon", handlers.RemoveCoupon)
+		api.GET("/savings", handlers.GetSavings)
+		api.POST("/split", handlers.SplitCart)
+		api.POST("/save-as-list", handlers.SaveCartAsList)
+		api.POST("/apply-list/:list_id", handlers.ApplyList)
+		api.POST("/sync", handlers.SyncCart)
+		api.POST("/import", handlers.ImportCart)
+		api.GET("/reorder-diff", handlers.GetReorderDiff)
+		api.POST("/recalculate", handlers.RecalculateCart)
+		api.POST("/reprice", handlers.RepriceCart)
+		api.GET("/shipping-allocation", handlers.GetShippingAllocation)
+		api.GET("/delivery-estimate", handlers.GetDeliveryEstimate)
+		api.GET("/dimensions", handlers.GetCartDimensions)
+		api.GET("/carbon", handlers.GetCartCarbon)
+		api.GET("/restrictions", handlers.GetCartRestrictions)
+		api.GET("/stock-check", handlers.GetStockCheck)
+		api.GET("/checkout-total", handlers.GetCheckoutTotal)
+		api.GET("/payment-split", handlers.GetPaymentSplit)
+		api.GET("/financing", handlers.GetFinancingOptions)
+		api.POST("/roundup", handlers.SetRoundupDonation)
+		api.DELETE("/roundup", handlers.RemoveRoundupDonation)
+		api.POST("/redeem-points", handlers.RedeemPoints)
+		api.DELETE("/redeem-points", handlers.CancelPointsRedemption)
+		api.POST("/gift-wrap", handlers.SetGiftWrap)
+		api.PUT("/address", handlers.SetShippingAddress)
+		api.GET("/order-payload", handlers.GetOrderPayload)
+		api.GET("/print", handlers.PrintCart)
+		api.GET("/packing-slip", handlers.GetPackingSlip)
+		api.POST("/quote", handlers.CreateQuote)
+		api.GET("/quote/:quote_id", handlers.GetQuote)
+		api.PUT("/metadata", handlers.SetCartMetadata)
+		api.DELETE("/metadata/:key", handlers.DeleteCartMetadata)
+		api.POST("/freeze", handlers.FreezeCart)
+		api.POST("/unfreeze", handlers.UnfreezeCart)
+		api.POST("/checkout-complete", handlers.CompleteCheckout)
 	}
 
 	// Start server
@@ -61,4 +196,21 @@ func main() {
 
 	fmt.Printf("🚀 Cart Service running on port %s\n", port)
 	router.Run(fmt.Sprintf(":%s", port))
-}
\ No newline at end of file
+}
+
+// buildInfo reports the running build and the connected Redis version
+func buildInfo(c *gin.Context) {
+	redisVersion, err := utils.RedisVersion()
+	if err != nil {
+		redisVersion = "unavailable"
+	}
+
+	c.JSON(200, gin.H{
+		"service":       "cart-service",
+		"version":       Version,
+		"git_commit":    GitCommit,
+		"build_time":    BuildTime,
+		"go_version":    runtime.Version(),
+		"redis_version": redisVersion,
+	})
+}
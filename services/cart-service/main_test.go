@@ -0,0 +1,80 @@
+package main
+
+import (
+	"cart-service/utils"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/alicebob/miniredis/v2/server"
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+)
+
+func TestBuildInfo(t *testing.T) {
+	tests := []struct {
+		name        string
+		redisInfo   string
+		redisDown   bool
+		wantVersion string
+	}{
+		{
+			name:        "redis version from INFO",
+			redisInfo:   "# Server\r\nredis_version:7.2.4\r\nredis_mode:standalone\r\n",
+			wantVersion: "7.2.4",
+		},
+		{
+			name:        "redis unreachable",
+			redisDown:   true,
+			wantVersion: "unavailable",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mr := miniredis.RunT(t)
+			infoCalls := 0
+			mr.Server().SetPreHook(func(c *server.Peer, cmd string, args ...string) bool {
+				if cmd != "INFO" {
+					return false
+				}
+				infoCalls++
+				c.WriteBulk(tt.redisInfo)
+				return true
+			})
+			previous := utils.RedisClient
+			utils.RedisClient = redis.NewClient(&redis.Options{Addr: mr.Addr(), MaxRetries: -1})
+			t.Cleanup(func() { utils.RedisClient = previous })
+			if tt.redisDown {
+				mr.Close()
+			}
+
+			gin.SetMode(gin.TestMode)
+			router := gin.New()
+			router.GET("/info", buildInfo)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/info", nil))
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d", w.Code)
+			}
+			var body map[string]interface{}
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatalf("response is not JSON: %v", err)
+			}
+			for _, field := range []string{"service", "version", "git_commit", "build_time", "go_version", "redis_version"} {
+				if value, ok := body[field].(string); !ok || value == "" {
+					t.Errorf("%s missing from response", field)
+				}
+			}
+			if body["redis_version"] != tt.wantVersion {
+				t.Errorf("redis_version = %v, want %s", body["redis_version"], tt.wantVersion)
+			}
+			if !tt.redisDown && infoCalls != 1 {
+				t.Errorf("INFO called %d times, want 1", infoCalls)
+			}
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package middleware
 
 import (
+	"cart-service/utils"
 	"fmt"
 	"net/http"
 	"os"
@@ -57,6 +58,23 @@ func AuthMiddleware() gin.HandlerFunc {
 				// Convert to string (could be float64 from JSON)
 				userID := fmt.Sprintf("%v", sub)
 				c.Set("user_id", userID)
+				c.Request = c.Request.WithContext(utils.WithActor(c.Request.Context(), userID))
+
+				// Optional role, e.g. "employee", for member pricing
+				if role, ok := claims["role"].(string); ok {
+					c.Set("role", role)
+					c.Request = c.Request.WithContext(utils.WithRole(c.Request.Context(), role))
+				}
+
+				// Optional loyalty tier, e.g. "gold"; looked up if absent
+				if tier, ok := claims["loyalty_tier"].(string); ok {
+					c.Request = c.Request.WithContext(utils.WithLoyaltyTier(c.Request.Context(), tier))
+				}
+
+				// Optional preferred currency, e.g. "EUR"; looked up if absent
+				if currency, ok := claims["preferred_currency"].(string); ok {
+					c.Request = c.Request.WithContext(utils.WithPreferredCurrency(c.Request.Context(), currency))
+				}
 			} else {
 				c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token claims"})
 				c.Abort()
@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"cart-service/utils"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// eventStreamRoute is the server-sent events route, which holds its
+// connection open for as long as the client listens
+const eventStreamRoute = "/api/cart/events"
+
+// ConcurrencyLimit caps the number of in-flight requests at
+// MAX_CONCURRENT_REQUESTS, answering 503 with Retry-After when saturated.
+// /health is always served so the instance isn't marked dead under load,
+// and the long-lived event stream route is not counted.
+// A limit of 0 (the default) disables the limiter.
+func ConcurrencyLimit() gin.HandlerFunc {
+	limit := utils.GetEnvInt("MAX_CONCURRENT_REQUESTS", 0)
+	if limit <= 0 {
+		return func(c *gin.Context) {
+			c.Next()
+		}
+	}
+
+	slots := make(chan struct{}, limit)
+	retryAfter := utils.GetEnvInt("CONCURRENCY_RETRY_AFTER_SECONDS", 1)
+
+	return func(c *gin.Context) {
+		// Event streams stay open indefinitely and would pin a slot each
+		if c.FullPath() == "/health" || c.FullPath() == eventStreamRoute {
+			c.Next()
+			return
+		}
+
+		select {
+		case slots <- struct{}{}:
+			defer func() { <-slots }()
+			c.Next()
+		default:
+			c.Header("Retry-After", strconv.Itoa(retryAfter))
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{
+				"error": "Server is busy, please retry",
+			})
+		}
+	}
+}
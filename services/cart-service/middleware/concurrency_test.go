@@ -0,0 +1,97 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newLimitedRouter serves /slow, which holds its slot until release is
+// closed, and /fast, /health and the event stream, which answer at once
+func newLimitedRouter(t *testing.T, limit string) (*gin.Engine, chan struct{}, chan struct{}) {
+	t.Helper()
+	t.Setenv("MAX_CONCURRENT_REQUESTS", limit)
+	t.Setenv("CONCURRENCY_RETRY_AFTER_SECONDS", "3")
+
+	entered := make(chan struct{}, 1)
+	release := make(chan struct{})
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(ConcurrencyLimit())
+	router.GET("/slow", func(c *gin.Context) {
+		entered <- struct{}{}
+		<-release
+		c.Status(http.StatusOK)
+	})
+	router.GET("/fast", func(c *gin.Context) { c.Status(http.StatusOK) })
+	router.GET("/health", func(c *gin.Context) { c.Status(http.StatusOK) })
+	router.GET(eventStreamRoute, func(c *gin.Context) { c.Status(http.StatusOK) })
+	return router, entered, release
+}
+
+func get(router *gin.Engine, path string) *httptest.ResponseRecorder {
+	return getAccepting(router, path, "")
+}
+
+func getAccepting(router *gin.Engine, path, accept string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodGet, path, nil)
+	if accept != "" {
+		r.Header.Set("Accept", accept)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+	return w
+}
+
+func TestConcurrencyLimit(t *testing.T) {
+	router, entered, release := newLimitedRouter(t, "1")
+
+	done := make(chan *httptest.ResponseRecorder)
+	go func() { done <- get(router, "/slow") }()
+	<-entered
+
+	tests := []struct {
+		path           string
+		accept         string
+		wantStatus     int
+		wantRetryAfter string
+	}{
+		{path: "/fast", wantStatus: http.StatusServiceUnavailable, wantRetryAfter: "3"},
+		{path: "/fast", accept: "text/event-stream", wantStatus: http.StatusServiceUnavailable, wantRetryAfter: "3"},
+		{path: "/health", wantStatus: http.StatusOK},
+		{path: eventStreamRoute, accept: "text/event-stream", wantStatus: http.StatusOK},
+	}
+	for _, tt := range tests {
+		w := getAccepting(router, tt.path, tt.accept)
+		if w.Code != tt.wantStatus {
+			t.Errorf("saturated %s status = %d, want %d", tt.path, w.Code, tt.wantStatus)
+		}
+		if got := w.Header().Get("Retry-After"); got != tt.wantRetryAfter {
+			t.Errorf("saturated %s Retry-After = %q, want %q", tt.path, got, tt.wantRetryAfter)
+		}
+	}
+
+	close(release)
+	if w := <-done; w.Code != http.StatusOK {
+		t.Errorf("held request status = %d, want %d", w.Code, http.StatusOK)
+	}
+	if w := get(router, "/fast"); w.Code != http.StatusOK {
+		t.Errorf("status after recovery = %d, want %d", w.Code, http.StatusOK)
+	}
+}
+
+func TestConcurrencyLimitDisabled(t *testing.T) {
+	router, entered, release := newLimitedRouter(t, "0")
+
+	done := make(chan *httptest.ResponseRecorder)
+	go func() { done <- get(router, "/slow") }()
+	<-entered
+
+	if w := get(router, "/fast"); w.Code != http.StatusOK {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+	close(release)
+	<-done
+}
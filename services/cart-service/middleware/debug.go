@@ -0,0 +1,77 @@
+package middleware
+
+import (
+	"bytes"
+	"cart-service/utils"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// bufferedWriter holds the response body so it can be amended
+type bufferedWriter struct {
+	gin.ResponseWriter
+	body   bytes.Buffer
+	status int
+}
+
+func (w *bufferedWriter) WriteHeader(code int) {
+	w.status = code
+}
+
+func (w *bufferedWriter) Write(data []byte) (int, error) {
+	return w.body.Write(data)
+}
+
+func (w *bufferedWriter) WriteString(s string) (int, error) {
+	return w.body.WriteString(s)
+}
+
+// DebugTimings adds a _debug section with dependency timings to JSON
+// responses when DEBUG=true. In any other mode it does nothing.
+func DebugTimings() gin.HandlerFunc {
+	if !utils.GetEnvBool("DEBUG", false) {
+		return func(c *gin.Context) {
+			c.Next()
+		}
+	}
+
+	return func(c *gin.Context) {
+		// Event streams must reach the client as they are written
+		if c.FullPath() == eventStreamRoute {
+			c.Next()
+			return
+		}
+
+		start := time.Now()
+		timings := utils.NewTimings()
+		c.Request = c.Request.WithContext(utils.WithTimings(c.Request.Context(), timings))
+
+		original := c.Writer
+		writer := &bufferedWriter{ResponseWriter: original, status: original.Status()}
+		c.Writer = writer
+
+		c.Next()
+
+		c.Writer = original
+		body := writer.body.Bytes()
+
+		// Only JSON objects can carry the debug section
+		var payload map[string]interface{}
+		if strings.HasPrefix(original.Header().Get("Content-Type"), "application/json") &&
+			json.Unmarshal(body, &payload) == nil {
+			debug := timings.Summary()
+			debug["total_ms"] = float64(time.Since(start).Microseconds()) / 1000
+			payload["_debug"] = debug
+
+			if amended, err := json.Marshal(payload); err == nil {
+				body = amended
+			}
+		}
+
+		original.WriteHeader(writer.status)
+		original.Write(body)
+	}
+}
@@ -0,0 +1,127 @@
+package middleware
+
+import (
+	"cart-service/utils"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestDebugTimings(t *testing.T) {
+	tests := []struct {
+		name      string
+		debug     string
+		wantDebug bool
+	}{
+		{name: "enabled", debug: "true", wantDebug: true},
+		{name: "disabled", debug: "false", wantDebug: false},
+		{name: "unset", wantDebug: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			product := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, `{"product": {"name": "Kettle", "price": 20}}`)
+			}))
+			t.Cleanup(product.Close)
+			t.Setenv("PRODUCT_SERVICE_URL", product.URL)
+			t.Setenv("DEBUG", tt.debug)
+
+			gin.SetMode(gin.TestMode)
+			router := gin.New()
+			router.Use(DebugTimings())
+			router.GET("/cart", func(c *gin.Context) {
+				utils.RecordTiming(c.Request.Context(), "redis", time.Now())
+				if _, err := utils.FetchProduct(c.Request.Context(), 1); err != nil {
+					c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+					return
+				}
+				c.JSON(http.StatusOK, gin.H{"items": []int{}})
+			})
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/cart", nil))
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d: %s", w.Code, w.Body)
+			}
+
+			var body map[string]interface{}
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatalf("response is not JSON: %v", err)
+			}
+			if _, ok := body["items"]; !ok {
+				t.Error("handler payload was lost")
+			}
+			debug, ok := body["_debug"].(map[string]interface{})
+			if ok != tt.wantDebug {
+				t.Fatalf("_debug present = %v, want %v", ok, tt.wantDebug)
+			}
+			if !tt.wantDebug {
+				return
+			}
+			for _, key := range []string{"redis", "product_service", "total_ms"} {
+				if _, ok := debug[key]; !ok {
+					t.Errorf("_debug is missing %s: %v", key, debug)
+				}
+			}
+			if calls := debug["product_service"].(map[string]interface{})["calls"]; calls != float64(1) {
+				t.Errorf("product_service calls = %v, want 1", calls)
+			}
+		})
+	}
+}
+
+func TestDebugTimingsLeavesNonJSONAlone(t *testing.T) {
+	t.Setenv("DEBUG", "true")
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(DebugTimings())
+	router.GET("/print", func(c *gin.Context) {
+		c.Data(http.StatusOK, "text/html; charset=utf-8", []byte("<p>cart</p>"))
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/print", nil))
+	if w.Body.String() != "<p>cart</p>" {
+		t.Errorf("body = %q, want it unchanged", w.Body.String())
+	}
+}
+
+func TestDebugTimingsExemptsEventStreamRoute(t *testing.T) {
+	t.Setenv("DEBUG", "true")
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(DebugTimings())
+	handler := func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"items": []int{}})
+	}
+	router.GET(eventStreamRoute, handler)
+	router.GET("/cart", handler)
+
+	tests := []struct {
+		path      string
+		wantDebug bool
+	}{
+		{path: eventStreamRoute, wantDebug: false},
+		{path: "/cart", wantDebug: true},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodGet, tt.path, nil)
+		r.Header.Set("Accept", "text/event-stream")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+
+		var body map[string]interface{}
+		if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+			t.Fatalf("%s response is not JSON: %v", tt.path, err)
+		}
+		if _, ok := body["_debug"]; ok != tt.wantDebug {
+			t.Errorf("%s _debug present = %v, want %v", tt.path, ok, tt.wantDebug)
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package middleware
+
+import (
+	"cart-service/utils"
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+)
+
+// FeatureFlags toggles overridable behaviors for a single request, from
+// the user's flags in the flags service overridden by the X-Feature-Flags
+// header, e.g. "zero_qty_removes,price_display_by_region=false". Flags not
+// set either way keep their environment defaults. Must run after
+// AuthMiddleware.
+func FeatureFlags() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		flags := map[string]bool{}
+		for name, enabled := range utils.FetchFeatureFlags(c.Request.Context(), fmt.Sprintf("%v", c.MustGet("user_id"))) {
+			flags[name] = enabled
+		}
+		for name, enabled := range utils.ParseFeatureFlags(c.GetHeader("X-Feature-Flags")) {
+			flags[name] = enabled
+		}
+
+		if len(flags) > 0 {
+			c.Request = c.Request.WithContext(utils.WithFeatureFlags(c.Request.Context(), flags))
+		}
+		c.Next()
+	}
+}
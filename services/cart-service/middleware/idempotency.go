@@ -0,0 +1,114 @@
+package middleware
+
+import (
+	"cart-service/utils"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxIdempotencyKeyLength bounds the Idempotency-Key header
+const maxIdempotencyKeyLength = 255
+
+// Idempotency logs every cart write to the user's operations log and makes
+// writes sent with an Idempotency-Key header safe to retry: the first
+// response is stored for IDEMPOTENCY_TTL_HOURS and replayed, marked with
+// Idempotent-Replayed, to retries with the same key. Retries get a 409
+// while the first request runs, for at most IDEMPOTENCY_PENDING_SECONDS.
+// Server errors and panics aren't stored so the request can be retried for
+// real. Must run after AuthMiddleware.
+func Idempotency() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			c.Next()
+			return
+		}
+
+		userID := fmt.Sprintf("%v", c.MustGet("user_id"))
+		clientKey := c.GetHeader("Idempotency-Key")
+		op := utils.Operation{
+			Method:         c.Request.Method,
+			Path:           c.Request.URL.Path,
+			IdempotencyKey: clientKey,
+		}
+		defer func() {
+			op.Status = c.Writer.Status()
+			if err := utils.RecordOperation(c.Request.Context(), userID, op); err != nil {
+				log.Printf("Failed to record operation: %v", err)
+			}
+		}()
+
+		if clientKey == "" {
+			c.Next()
+			return
+		}
+		if len(clientKey) > maxIdempotencyKeyLength {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Idempotency-Key is too long"})
+			return
+		}
+
+		ttl := time.Duration(utils.GetEnvInt("IDEMPOTENCY_TTL_HOURS", 24)) * time.Hour
+		pendingTTL := time.Duration(utils.GetEnvInt("IDEMPOTENCY_PENDING_SECONDS", 30)) * time.Second
+		stored, pending, err := utils.ClaimIdempotencyKey(c.Request.Context(), userID, clientKey, pendingTTL)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Failed to check idempotency key"})
+			return
+		}
+		if pending {
+			c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": "A request with this Idempotency-Key is in progress"})
+			return
+		}
+		if stored != nil {
+			if stored.Method != c.Request.Method || stored.Path != c.Request.URL.Path {
+				c.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{"error": "Idempotency-Key was used for a different request"})
+				return
+			}
+			op.Replayed = true
+			c.Header("Idempotent-Replayed", "true")
+			c.Data(stored.Status, stored.ContentType, stored.Body)
+			c.Abort()
+			return
+		}
+
+		original := c.Writer
+		writer := &bufferedWriter{ResponseWriter: original, status: original.Status()}
+		c.Writer = writer
+
+		// Deferred so a panicking handler releases the key too, and the
+		// recovery response isn't left in the buffer
+		finished := false
+		defer func() {
+			c.Writer = original
+			if !finished || writer.status >= http.StatusInternalServerError {
+				if err := utils.ReleaseIdempotencyKey(c.Request.Context(), userID, clientKey); err != nil {
+					log.Printf("Failed to release idempotency key: %v", err)
+				}
+			}
+		}()
+
+		c.Next()
+
+		finished = true
+		body := writer.body.Bytes()
+
+		if writer.status < http.StatusInternalServerError {
+			response := utils.StoredResponse{
+				Method:      c.Request.Method,
+				Path:        c.Request.URL.Path,
+				Status:      writer.status,
+				ContentType: original.Header().Get("Content-Type"),
+				Body:        body,
+			}
+			if err := utils.StoreIdempotentResponse(c.Request.Context(), userID, clientKey, response, ttl); err != nil {
+				log.Printf("Failed to store idempotent response: %v", err)
+			}
+		}
+
+		original.WriteHeader(writer.status)
+		original.Write(body)
+	}
+}
@@ -0,0 +1,12 @@
+package models
+
+// Address is a shipping destination
+type Address struct {
+	Name       string `json:"name"`
+	Line1      string `json:"line1" binding:"required"`
+	Line2      string `json:"line2,omitempty"`
+	City       string `json:"city" binding:"required"`
+	State      string `json:"state,omitempty"`
+	PostalCode string `json:"postal_code" binding:"required"`
+	Country    string `json:"country" binding:"required,len=2"`
+}
@@ -0,0 +1,64 @@
+package models
+
+import (
+	"errors"
+	"math"
+)
+
+// Shipping allocation bases
+const (
+	AllocateByPrice  = "price"
+	AllocateByWeight = "weight"
+)
+
+// ErrNoAllocationBasis is returned when no item has a positive basis to
+// allocate against (e.g. allocating by weight with no item weights)
+var ErrNoAllocationBasis = errors.New("no items have a basis for allocation")
+
+// ShippingAllocation is one line item's share of the shipping cost
+type ShippingAllocation struct {
+	ProductID int     `json:"product_id"`
+	Basis     float64 `json:"basis"`
+	Amount    float64 `json:"amount"`
+}
+
+// AllocateShipping splits a shipping cost across items proportionally to
+// their subtotal or total weight. Shares are computed in cents and always
+// sum exactly to the total; the rounding remainder goes to the item with
+// the largest basis (the first such item on ties).
+func AllocateShipping(items []CartItem, total float64, by string) ([]ShippingAllocation, error) {
+	allocations := make([]ShippingAllocation, len(items))
+	var basisSum float64
+	largest := -1
+
+	for i, item := range items {
+		basis := item.Subtotal
+		if by == AllocateByWeight {
+			basis = item.Weight * float64(item.Quantity)
+		}
+		allocations[i] = ShippingAllocation{ProductID: item.ProductID, Basis: basis}
+		basisSum += basis
+
+		if largest == -1 || basis > allocations[largest].Basis {
+			largest = i
+		}
+	}
+
+	if basisSum <= 0 {
+		return nil, ErrNoAllocationBasis
+	}
+
+	totalCents := int64(math.Round(total * 100))
+	var allocatedCents int64
+	cents := make([]int64, len(items))
+	for i := range allocations {
+		cents[i] = int64(math.Floor(float64(totalCents) * allocations[i].Basis / basisSum))
+		allocatedCents += cents[i]
+	}
+	cents[largest] += totalCents - allocatedCents
+
+	for i := range allocations {
+		allocations[i].Amount = float64(cents[i]) / 100
+	}
+	return allocations, nil
+}
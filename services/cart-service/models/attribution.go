@@ -0,0 +1,9 @@
+package models
+
+// Attribution records the marketing campaign a cart came from
+type Attribution struct {
+	Source     string `json:"utm_source,omitempty"`
+	Medium     string `json:"utm_medium,omitempty"`
+	Campaign   string `json:"utm_campaign,omitempty"`
+	CapturedAt string `json:"captured_at"`
+}
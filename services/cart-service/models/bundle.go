@@ -0,0 +1,69 @@
+package models
+
+// Bundle is a promotion giving a discount when every one of its products
+// is in the cart
+type Bundle struct {
+	ID              string  `json:"id"`
+	Name            string  `json:"name"`
+	ProductIDs      []int   `json:"product_ids"`
+	DiscountAmount  float64 `json:"discount_amount,omitempty"`
+	DiscountPercent float64 `json:"discount_percent,omitempty"`
+	AppliedDiscount float64 `json:"applied_discount"`
+}
+
+// bundleSubtotal returns the combined subtotal of the bundle's products,
+// and false if any of them is missing from the cart
+func (c *Cart) bundleSubtotal(b Bundle) (float64, bool) {
+	if len(b.ProductIDs) == 0 {
+		return 0, false
+	}
+
+	var subtotal float64
+	for _, productID := range b.ProductIDs {
+		i := c.FindItem(productID)
+		if i == -1 {
+			return 0, false
+		}
+		subtotal += c.Items[i].Subtotal
+	}
+	return subtotal, true
+}
+
+// ApplyBundles records which of the configured bundles the cart completes.
+// Call CalculateTotals afterwards to apply their discounts.
+func (c *Cart) ApplyBundles(bundles []Bundle) {
+	c.Bundles = nil
+	for _, b := range bundles {
+		if _, complete := c.bundleSubtotal(b); complete {
+			c.Bundles = append(c.Bundles, b)
+		}
+	}
+}
+
+// calculateBundleDiscount prices the applied bundles against the current
+// items, dropping any bundle that is no longer complete
+func (c *Cart) calculateBundleDiscount() float64 {
+	var total float64
+	kept := c.Bundles[:0]
+
+	for _, b := range c.Bundles {
+		subtotal, complete := c.bundleSubtotal(b)
+		if !complete {
+			continue
+		}
+
+		discount := b.DiscountAmount + subtotal*b.DiscountPercent/100
+		if discount > subtotal {
+			discount = subtotal
+		}
+		b.AppliedDiscount = RoundPrice(discount)
+		total += b.AppliedDiscount
+		kept = append(kept, b)
+	}
+
+	if len(kept) == 0 {
+		kept = nil
+	}
+	c.Bundles = kept
+	return RoundPrice(total)
+}
@@ -0,0 +1,32 @@
+package models
+
+// CarbonFootprint is the estimated CO2 emitted producing and shipping a
+// cart's items
+type CarbonFootprint struct {
+	TotalGrams float64 `json:"total_grams"`
+	// OffsetCost is the price of offsetting the footprint, when offsets
+	// are offered
+	OffsetCost float64 `json:"offset_cost,omitempty"`
+	// MissingFootprint lists products without a carbon estimate, which are
+	// left out of the total
+	MissingFootprint []int `json:"missing_footprint"`
+}
+
+// CarbonFootprint sums the per-unit carbon estimates of the cart's items
+// and prices the offset at offsetPricePerKg. A price of 0 offers no offset.
+func (c *Cart) CarbonFootprint(offsetPricePerKg float64) CarbonFootprint {
+	footprint := CarbonFootprint{MissingFootprint: []int{}}
+	for _, item := range c.Items {
+		if item.CarbonGrams <= 0 {
+			footprint.MissingFootprint = append(footprint.MissingFootprint, item.ProductID)
+			continue
+		}
+		footprint.TotalGrams += item.CarbonGrams * float64(item.Quantity)
+	}
+
+	footprint.TotalGrams = roundTo(footprint.TotalGrams, 3)
+	if offsetPricePerKg > 0 {
+		footprint.OffsetCost = RoundPrice(footprint.TotalGrams / 1000 * offsetPricePerKg)
+	}
+	return footprint
+}
@@ -1,57 +1,422 @@
 package models
 
-import "time"
+import (
+	"sort"
+	"strings"
+	"time"
+)
 
 // CartItem represents a single item in the cart
 type CartItem struct {
-	ProductID   int     `json:"product_id"`
-	ProductName string  `json:"product_name"`
-	Price       float64 `json:"price"`
-	Quantity    int     `json:"quantity"`
-	Subtotal    float64 `json:"subtotal"`
-	AddedAt     string  `json:"added_at"`
+	ProductID        int     `json:"product_id"`
+	ProductName      string  `json:"product_name"`
+	Price            float64 `json:"price"`
+	Quantity         int     `json:"quantity"`
+	QuantityStep     int     `json:"quantity_step,omitempty"`
+	MaxPerOrder      int     `json:"max_per_order,omitempty"`
+	Weight           float64 `json:"weight,omitempty"`
+	Version          int     `json:"version"`
+	DiscountPercent  float64 `json:"discount_percent,omitempty"`
+	DiscountAmount   float64 `json:"discount_amount,omitempty"`
+	PromoPrice       float64 `json:"promo_price,omitempty"`
+	PromoLimit       int     `json:"promo_limit,omitempty"`
+	PromoUnits       int     `json:"promo_units,omitempty"`
+	RegularUnits     int     `json:"regular_units,omitempty"`
+	OriginalSubtotal float64 `json:"original_subtotal"`
+	Subtotal         float64 `json:"subtotal"`
+	AddedAt          string  `json:"added_at"`
+	AddedBy          string  `json:"added_by,omitempty"`
+	Pending          bool    `json:"pending,omitempty"`
+	FlashReserved    int     `json:"flash_reserved,omitempty"`
+	FlashSoldOut     bool    `json:"flash_sold_out,omitempty"`
+
+	// Reserved flash-sale units are held until ReservedUntil (RFC3339);
+	// HoldExpired marks an item whose hold lapsed and was released
+	ReservedUntil string `json:"reserved_until,omitempty"`
+	HoldExpired   bool   `json:"hold_expired,omitempty"`
+
+	// DisplayPrice is Price rounded to the marketing ending, for display
+	// only; totals use the exact Price
+	DisplayPrice float64 `json:"display_price,omitempty"`
+
+	// Price is guaranteed not to be repriced before this RFC3339 time
+	PriceLockedUntil string `json:"price_locked_until,omitempty"`
+
+	// A sale price is only valid until PriceValidUntil (RFC3339), after
+	// which the item reverts to RegularPrice and is flagged PriceReverted
+	PriceValidUntil string  `json:"price_valid_until,omitempty"`
+	RegularPrice    float64 `json:"regular_price,omitempty"`
+	PriceReverted   bool    `json:"price_reverted,omitempty"`
+
+	// Package measurements, for shipping
+	Dimensions *Dimensions `json:"dimensions,omitempty"`
+
+	// PromoGift marks a free item added by a gift promotion
+	PromoGift bool `json:"promo_gift,omitempty"`
+
+	// Tax category from product-service and the estimated tax on the item
+	TaxCategory string  `json:"tax_category,omitempty"`
+	Tax         float64 `json:"tax"`
+
+	// Discontinued marks a product that can no longer be purchased
+	Discontinued bool `json:"discontinued,omitempty"`
+
+	// Age verification required at checkout, e.g. for alcohol
+	AgeRestricted bool `json:"age_restricted,omitempty"`
+	MinimumAge    int  `json:"minimum_age,omitempty"`
+
+	// Display details from product-service
+	ImageURL string `json:"image_url,omitempty"`
+	SKU      string `json:"sku,omitempty"`
+	Category string `json:"category,omitempty"`
+
+	// Scheduled delivery for pre-orders, as YYYY-MM-DD
+	RequestedDeliveryDate string `json:"requested_delivery_date,omitempty"`
+
+	// Subscribe-and-save recurrence and the discount it earns
+	IsSubscription              bool    `json:"is_subscription,omitempty"`
+	Interval                    string  `json:"interval,omitempty"`
+	SubscriptionDiscountPercent float64 `json:"subscription_discount_percent,omitempty"`
+
+	// Estimated CO2 per unit, in grams
+	CarbonGrams float64 `json:"carbon_grams,omitempty"`
+
+	// Marketplace vendor selling the product
+	VendorID string `json:"vendor_id,omitempty"`
+
+	// Per-unit handling fee for oversized or hazardous goods
+	HandlingFee float64 `json:"handling_fee,omitempty"`
+
+	// How the item is fulfilled: ship, pickup or digital
+	FulfillmentType string `json:"fulfillment_type,omitempty"`
+
+	// Units of the product one user may add per UTC day, if capped
+	DailyQuota int `json:"daily_quota,omitempty"`
 }
 
 // Cart represents a user's shopping cart
 type Cart struct {
-	UserID     string     `json:"user_id"`
-	Items      []CartItem `json:"items"`
-	TotalItems int        `json:"total_items"`
-	TotalPrice float64    `json:"total_price"`
-	UpdatedAt  string     `json:"updated_at"`
+	SchemaVersion  int        `json:"schema_version"`
+	Version        int        `json:"version"`
+	UserID         string     `json:"user_id"`
+	Name           string     `json:"name,omitempty"`
+	Currency       string     `json:"currency"`
+	Items          []CartItem `json:"items"`
+	TotalItems     int        `json:"total_items"`
+	OriginalPrice  float64    `json:"original_price"`
+	ItemSavings    float64    `json:"item_savings"`
+	Bundles        []Bundle   `json:"bundles,omitempty"`
+	BundleDiscount float64    `json:"bundle_discount"`
+	TotalPrice     float64    `json:"total_price"`
+	Coupon         *Coupon    `json:"coupon,omitempty"`
+	CouponDiscount float64    `json:"coupon_discount"`
+	FinalPrice     float64    `json:"final_price"`
+
+	// Automatic discount for the user's role, tracked apart from coupons
+	MemberRole     string  `json:"member_role,omitempty"`
+	MemberDiscount float64 `json:"member_discount"`
+
+	// Tier pricing for the user's loyalty tier, taken off the total price
+	LoyaltyTier     string  `json:"loyalty_tier,omitempty"`
+	LoyaltyDiscount float64 `json:"loyalty_discount"`
+
+	// Loyalty points redeemed for a discount off the final price
+	PointsRedemption *PointsRedemption `json:"points_redemption,omitempty"`
+	PointsDiscount   float64           `json:"points_discount"`
+
+	// Estimated sales tax, the sum of the items' taxes
+	Tax float64 `json:"tax"`
+
+	// Gift cards and store credit offered as payment, consumed at checkout
+	Credits []Credit `json:"credits,omitempty"`
+
+	// Charity donation added to the checkout total
+	Donation *Donation `json:"donation,omitempty"`
+
+	// Whole-order gift wrapping and its fee, charged at checkout
+	GiftWrap    *GiftWrap `json:"gift_wrap,omitempty"`
+	GiftWrapFee float64   `json:"gift_wrap_fee"`
+
+	// Item handling fees, charged at checkout as their own line
+	HandlingTotal float64 `json:"handling_total"`
+
+	// Free-gift promotions evaluated by CalculateTotals
+	GiftPromotions []GiftPromotion `json:"gift_promotions,omitempty"`
+
+	ShippingAddress *Address          `json:"shipping_address,omitempty"`
+	Attribution     *Attribution      `json:"attribution,omitempty"`
+	Metadata        map[string]string `json:"metadata,omitempty"`
+	CreatedAt       string            `json:"created_at,omitempty"`
+	UpdatedAt       string            `json:"updated_at"`
+	UpdatedBy       string            `json:"updated_by,omitempty"`
+
+	// Change log used for delta sync
+	RemovedItems    []ItemRemoval `json:"removed_items,omitempty"`
+	LogStartVersion int           `json:"log_start_version,omitempty"`
+
+	// Items as loaded from storage, for change detection
+	loaded map[int]CartItem
+}
+
+// CartSummary holds aggregate stats for a cart without its items
+type CartSummary struct {
+	Name       string  `json:"name"`
+	TotalItems int     `json:"total_items"`
+	TotalPrice float64 `json:"total_price"`
+	UpdatedAt  string  `json:"updated_at"`
 }
 
-// AddItemRequest represents the request to add an item
+// AddItemRequest represents the request to add an item.
+// Quantity may be omitted, in which case the configured default is used.
 type AddItemRequest struct {
-	ProductID int `json:"product_id" binding:"required"`
-	Quantity  int `json:"quantity" binding:"required,min=1"`
+	ProductID int  `json:"product_id" binding:"required"`
+	Quantity  *int `json:"quantity" binding:"omitempty,min=1"`
+
+	// Subscribe-and-save, delivered every Interval (weekly or monthly)
+	IsSubscription bool   `json:"is_subscription"`
+	Interval       string `json:"interval"`
+}
+
+// QuantityOrDefault returns the requested quantity, or fallback if omitted
+func (r *AddItemRequest) QuantityOrDefault(fallback int) int {
+	if r.Quantity == nil {
+		return fallback
+	}
+	return *r.Quantity
 }
 
-// UpdateItemRequest represents the request to update item quantity
+// UpdateItemRequest represents the request to update item quantity.
+// Quantity is a pointer so an explicit 0 passes the required check.
 type UpdateItemRequest struct {
-	Quantity int `json:"quantity" binding:"required,min=0"`
+	Quantity *int `json:"quantity" binding:"required,min=0"`
+}
+
+// AdjustItemRequest represents a relative change to an item's quantity
+type AdjustItemRequest struct {
+	Delta int `json:"delta" binding:"required"`
+}
+
+// SplitCartRequest represents the request to move items into another cart
+type SplitCartRequest struct {
+	ProductIDs []int  `json:"product_ids" binding:"required,min=1"`
+	Target     string `json:"target"`
 }
 
 // NewCart creates a new empty cart
 func NewCart(userID string) *Cart {
 	return &Cart{
-		UserID:     userID,
-		Items:      []CartItem{},
-		TotalItems: 0,
-		TotalPrice: 0,
-		UpdatedAt:  time.Now().Format(time.RFC3339),
+		SchemaVersion: CurrentSchemaVersion,
+		UserID:        userID,
+		Currency:      DefaultCurrency,
+		Items:         []CartItem{},
+		TotalItems:    0,
+		TotalPrice:    0,
+		CreatedAt:     time.Now().Format(time.RFC3339),
+		UpdatedAt:     time.Now().Format(time.RFC3339),
+	}
+}
+
+// ValidStep reports whether quantity is a multiple of the item's
+// quantity step. Items without a step accept any quantity.
+func (i *CartItem) ValidStep(quantity int) bool {
+	return i.QuantityStep <= 1 || quantity%i.QuantityStep == 0
+}
+
+// PriceLocked reports whether the item's captured price is still
+// guaranteed at now
+func (i *CartItem) PriceLocked(now time.Time) bool {
+	lockedUntil, err := time.Parse(time.RFC3339, i.PriceLockedUntil)
+	return err == nil && now.Before(lockedUntil)
+}
+
+// Reprice moves the item to the product's current price unless its price
+// is locked, then locks the new price for lockFor. Returns true if the
+// price changed.
+func (i *CartItem) Reprice(price float64, now time.Time, lockFor time.Duration) bool {
+	if i.PriceLocked(now) {
+		return false
 	}
+	changed := i.Price != price
+	i.Price = price
+	i.PriceLockedUntil = now.Add(lockFor).Format(time.RFC3339)
+	return changed
 }
 
-// CalculateTotals recalculates cart totals
+// ExpireSalePrice reverts the item to its regular price once its sale
+// window has passed, regardless of any price lock. Returns true if the
+// price reverted.
+func (i *CartItem) ExpireSalePrice(now time.Time) bool {
+	validUntil, err := time.Parse(time.RFC3339, i.PriceValidUntil)
+	if err != nil || now.Before(validUntil) || i.RegularPrice <= 0 {
+		return false
+	}
+	i.Price = i.RegularPrice
+	i.PriceValidUntil = ""
+	i.PriceLockedUntil = ""
+	i.PriceReverted = true
+	return true
+}
+
+// WithinOrderLimit reports whether quantity respects the product's
+// per-order cap. Items without a cap accept any quantity.
+func (i *CartItem) WithinOrderLimit(quantity int) bool {
+	return i.MaxPerOrder <= 0 || quantity <= i.MaxPerOrder
+}
+
+// CalculateSubtotal recomputes the item's subtotal, applying any
+// item-level promotion to the undiscounted price. A quantity-limited promo
+// prices the first PromoLimit units at PromoPrice and the rest normally.
+func (i *CartItem) CalculateSubtotal() {
+	i.DisplayPrice = MarketingPrice(i.Price)
+	i.OriginalSubtotal = RoundPrice(float64(i.Quantity) * i.Price)
+
+	unitPrice := i.Price - i.Price*i.DiscountPercent/100 - i.DiscountAmount
+	if unitPrice < 0 {
+		unitPrice = 0
+	}
+
+	i.PromoUnits = 0
+	if i.PromoLimit > 0 {
+		i.PromoUnits = i.Quantity
+		if i.PromoUnits > i.PromoLimit {
+			i.PromoUnits = i.PromoLimit
+		}
+	}
+	i.RegularUnits = i.Quantity - i.PromoUnits
+
+	i.Subtotal = RoundPrice(float64(i.PromoUnits)*i.PromoPrice + float64(i.RegularUnits)*unitPrice)
+
+	// Subscriptions save a further percentage on the whole line
+	if i.IsSubscription && i.SubscriptionDiscountPercent > 0 {
+		i.Subtotal = RoundPrice(i.Subtotal - i.Subtotal*i.SubscriptionDiscountPercent/100)
+	}
+}
+
+// CalculateTotals recalculates cart totals. Item-level discounts are
+// applied first, then bundles, so cart-level discounts work from the
+// discounted total.
 func (c *Cart) CalculateTotals() {
+	// Gift items depend on the paid items, so settle them first
+	c.syncGiftItems()
+
 	c.TotalItems = 0
+	c.OriginalPrice = 0
 	c.TotalPrice = 0
 
-	for _, item := range c.Items {
-		c.TotalItems += item.Quantity
-		c.TotalPrice += item.Subtotal
+	for i := range c.Items {
+		c.Items[i].CalculateSubtotal()
+		c.TotalItems += c.Items[i].Quantity
+		c.OriginalPrice += c.Items[i].OriginalSubtotal
+		c.TotalPrice += c.Items[i].Subtotal
+	}
+
+	c.OriginalPrice = RoundPrice(c.OriginalPrice)
+	c.TotalPrice = RoundPrice(c.TotalPrice)
+	c.ItemSavings = RoundPrice(c.OriginalPrice - c.TotalPrice)
+
+	// Bundle discounts come off the discounted item total. Overlapping
+	// bundles never take it below zero.
+	c.BundleDiscount = c.calculateBundleDiscount()
+	if c.BundleDiscount > c.TotalPrice {
+		c.BundleDiscount = c.TotalPrice
 	}
+	c.TotalPrice = RoundPrice(c.TotalPrice - c.BundleDiscount)
+
+	// Loyalty tier pricing applies to every order, before coupons
+	c.LoyaltyDiscount = RoundPrice(c.TotalPrice * LoyaltyDiscountPercent(c.LoyaltyTier) / 100)
+	c.TotalPrice = RoundPrice(c.TotalPrice - c.LoyaltyDiscount)
+
+	// Member and coupon discounts, stacked or whichever saves more
+	c.calculateMemberAndCouponDiscounts()
+	c.FinalPrice = RoundPrice(c.TotalPrice - c.MemberDiscount - c.CouponDiscount)
+
+	// Redeemed points pay for what's left after every other discount
+	c.PointsDiscount = c.pointsDiscount()
+	c.FinalPrice = RoundPrice(c.FinalPrice - c.PointsDiscount)
+
+	// Tax is estimated per item from its category and the shipping region
+	c.calculateTax()
+
+	// Gift wrapping is a flat fee on the order, not part of the merchandise
+	c.GiftWrapFee = c.giftWrapFee()
+
+	// Handling fees are likewise charged apart from the merchandise
+	c.HandlingTotal = c.handlingTotal()
 
 	c.UpdatedAt = time.Now().Format(time.RFC3339)
-}
\ No newline at end of file
+}
+
+// SortItems orders items by when they were added, oldest first, so
+// responses list them in the same order however the slice was mutated.
+// Items added in the same second are ordered by product ID.
+func (c *Cart) SortItems() {
+	sort.SliceStable(c.Items, func(i, j int) bool {
+		a, b := c.Items[i], c.Items[j]
+		if a.AddedAt != b.AddedAt {
+			return addedTime(a) < addedTime(b)
+		}
+		return a.ProductID < b.ProductID
+	})
+}
+
+// addedTime returns when an item was added as a Unix time, or 0 for items
+// saved before AddedAt was recorded
+func addedTime(item CartItem) int64 {
+	addedAt, err := time.Parse(time.RFC3339, item.AddedAt)
+	if err != nil {
+		return 0
+	}
+	return addedAt.Unix()
+}
+
+// ItemsInCategory returns the items whose category matches, ignoring case
+func (c *Cart) ItemsInCategory(category string) []CartItem {
+	items := []CartItem{}
+	for _, item := range c.Items {
+		if strings.EqualFold(item.Category, category) {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// FindItem returns the index of the product's line item, or -1 if the
+// product is not in the cart
+func (c *Cart) FindItem(productID int) int {
+	for i, item := range c.Items {
+		if item.ProductID == productID {
+			return i
+		}
+	}
+	return -1
+}
+
+// HasPendingItems reports whether any item is awaiting product details
+func (c *Cart) HasPendingItems() bool {
+	for _, item := range c.Items {
+		if item.Pending {
+			return true
+		}
+	}
+	return false
+}
+
+// HasDiscontinuedItems reports whether any item can no longer be purchased
+func (c *Cart) HasDiscontinuedItems() bool {
+	for _, item := range c.Items {
+		if item.Discontinued {
+			return true
+		}
+	}
+	return false
+}
+
+// Summary returns the cart's aggregate stats
+func (c *Cart) Summary() CartSummary {
+	return CartSummary{
+		Name:       c.Name,
+		TotalItems: c.TotalItems,
+		TotalPrice: c.TotalPrice,
+		UpdatedAt:  c.UpdatedAt,
+	}
+}
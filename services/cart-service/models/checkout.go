@@ -0,0 +1,51 @@
+package models
+
+// CheckoutTotal is the full price breakdown the checkout page binds to
+type CheckoutTotal struct {
+	Subtotal        float64 `json:"subtotal"`
+	ItemDiscounts   float64 `json:"item_discounts"`
+	BundleDiscounts float64 `json:"bundle_discounts"`
+	CouponDiscount  float64 `json:"coupon_discount"`
+	MemberDiscount  float64 `json:"member_discount"`
+	LoyaltyDiscount float64 `json:"loyalty_discount"`
+	PointsDiscount  float64 `json:"points_discount"`
+	Tax             float64 `json:"tax"`
+	Shipping        float64 `json:"shipping"`
+	GiftWrap        float64 `json:"gift_wrap"`
+	Handling        float64 `json:"handling"`
+	Donation        float64 `json:"donation"`
+	CreditApplied   float64 `json:"credit_applied"`
+	UnusedCredit    float64 `json:"unused_credit"`
+	AmountDue       float64 `json:"amount_due"`
+	Currency        string  `json:"currency"`
+}
+
+// CheckoutTotal composes every charge and discount into the amount due.
+// Tax is charged per item on the discounted merchandise, not on shipping.
+func (c *Cart) CheckoutTotal(shipping ShippingPolicy) CheckoutTotal {
+	total := CheckoutTotal{
+		Subtotal:        c.OriginalPrice,
+		ItemDiscounts:   c.ItemSavings,
+		BundleDiscounts: c.BundleDiscount,
+		CouponDiscount:  c.CouponDiscount,
+		MemberDiscount:  c.MemberDiscount,
+		LoyaltyDiscount: c.LoyaltyDiscount,
+		PointsDiscount:  c.PointsDiscount,
+		Tax:             c.calculateTax(),
+		Shipping:        shipping.Cost(c.FinalPrice),
+		GiftWrap:        c.giftWrapFee(),
+		Handling:        c.handlingTotal(),
+		Currency:        c.Currency,
+	}
+
+	// A donation goes on top of the order, rounding it up if asked to
+	due := RoundPrice(c.FinalPrice + total.Tax + total.Shipping + total.GiftWrap + total.Handling)
+	total.Donation = c.Donation.For(due)
+	due = RoundPrice(due + total.Donation)
+
+	// Gift cards and credit pay what remains; any balance they don't use
+	// is reported so it can be refunded
+	total.CreditApplied, total.UnusedCredit = ApplyCredits(c.Credits, due)
+	total.AmountDue = RoundPrice(due - total.CreditApplied)
+	return total
+}
@@ -0,0 +1,26 @@
+package models
+
+// ConvertedTotals are the cart's totals presented in another currency.
+// They are estimates at the current rate; the cart is still charged in its
+// own currency.
+type ConvertedTotals struct {
+	Currency      string  `json:"currency"`
+	Rate          float64 `json:"rate"`
+	OriginalPrice float64 `json:"original_price"`
+	TotalPrice    float64 `json:"total_price"`
+	Tax           float64 `json:"tax"`
+	FinalPrice    float64 `json:"final_price"`
+}
+
+// ConvertedTotals converts the cart's totals to currency at rate units of
+// currency per unit of the cart's currency
+func (c *Cart) ConvertedTotals(currency string, rate float64) ConvertedTotals {
+	return ConvertedTotals{
+		Currency:      currency,
+		Rate:          rate,
+		OriginalPrice: RoundPrice(c.OriginalPrice * rate),
+		TotalPrice:    RoundPrice(c.TotalPrice * rate),
+		Tax:           RoundPrice(c.Tax * rate),
+		FinalPrice:    RoundPrice(c.FinalPrice * rate),
+	}
+}
@@ -0,0 +1,117 @@
+package models
+
+import (
+	"math"
+	"time"
+)
+
+// Coupon discount types
+const (
+	CouponTypePercent = "percent"
+	CouponTypeFixed   = "fixed"
+)
+
+// Coupon validation reasons
+const (
+	CouponReasonExpired        = "expired"
+	CouponReasonMinOrderNotMet = "min_order_not_met"
+	CouponReasonNotApplicable  = "not_applicable"
+	CouponReasonUsageLimit     = "usage_limit_reached"
+)
+
+// Coupon represents a discount code that can be applied to a cart
+type Coupon struct {
+	Code          string  `json:"code"`
+	Type          string  `json:"type"`
+	Value         float64 `json:"value"`
+	MinOrderValue float64 `json:"min_order_value"`
+	ExpiresAt     string  `json:"expires_at,omitempty"`
+	// ProductIDs restricts the coupon to carts containing one of these
+	// products. Empty means any cart.
+	ProductIDs []int `json:"product_ids,omitempty"`
+	// UsageLimit caps how many times the coupon can be redeemed in total.
+	// Zero means unlimited.
+	UsageLimit int `json:"usage_limit,omitempty"`
+}
+
+// ApplyCouponRequest represents the request to apply a coupon to the cart
+type ApplyCouponRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// CouponError describes why a coupon cannot be used on a cart
+type CouponError struct {
+	Reason  string
+	Message string
+}
+
+func (e *CouponError) Error() string {
+	return e.Message
+}
+
+// Validate checks whether the coupon can be applied to the given cart
+func (cp *Coupon) Validate(cart *Cart) error {
+	if problems := cp.Check(cart); len(problems) > 0 {
+		return problems[0]
+	}
+	return nil
+}
+
+// Check returns every reason the coupon cannot be applied to the cart,
+// or nil if it can. Redemption limits are checked separately.
+func (cp *Coupon) Check(cart *Cart) []*CouponError {
+	var problems []*CouponError
+
+	if cp.ExpiresAt != "" {
+		expiresAt, err := time.Parse(time.RFC3339, cp.ExpiresAt)
+		if err == nil && time.Now().After(expiresAt) {
+			problems = append(problems, &CouponError{Reason: CouponReasonExpired, Message: "Coupon has expired"})
+		}
+	}
+
+	if cart.TotalPrice < cp.MinOrderValue {
+		problems = append(problems, &CouponError{Reason: CouponReasonMinOrderNotMet, Message: "Cart total is below the coupon minimum"})
+	}
+
+	if !cp.appliesTo(cart) {
+		problems = append(problems, &CouponError{Reason: CouponReasonNotApplicable, Message: "Coupon does not apply to any item in the cart"})
+	}
+
+	return problems
+}
+
+// CheckUsage returns an error if the coupon has been redeemed uses times
+// and has no redemptions left
+func (cp *Coupon) CheckUsage(uses int) error {
+	if cp.UsageLimit > 0 && uses >= cp.UsageLimit {
+		return &CouponError{Reason: CouponReasonUsageLimit, Message: "Coupon has reached its usage limit"}
+	}
+	return nil
+}
+
+// appliesTo reports whether the cart holds a product the coupon is for
+func (cp *Coupon) appliesTo(cart *Cart) bool {
+	if len(cp.ProductIDs) == 0 {
+		return true
+	}
+	for _, productID := range cp.ProductIDs {
+		if cart.FindItem(productID) != -1 {
+			return true
+		}
+	}
+	return false
+}
+
+// Discount calculates the discount the coupon gives on a subtotal
+func (cp *Coupon) Discount(subtotal float64) float64 {
+	var discount float64
+	switch cp.Type {
+	case CouponTypePercent:
+		discount = subtotal * cp.Value / 100
+	case CouponTypeFixed:
+		discount = cp.Value
+	}
+
+	// Never discount more than the subtotal
+	return RoundPrice(math.Min(discount, subtotal))
+}
@@ -0,0 +1,103 @@
+package models
+
+// Credit types
+const (
+	CreditTypeGiftCard    = "gift_card"
+	CreditTypeStoreCredit = "store_credit"
+)
+
+// Credit is a gift card or store credit balance offered as payment
+type Credit struct {
+	Type   string  `json:"type"`
+	Code   string  `json:"code,omitempty"`
+	Amount float64 `json:"amount"`
+}
+
+// ApplyCredits consumes credits against amount. Returns how much
+// of the amount they cover and how much credit is left over, which must be
+// returned to the customer's balance. The amount covered never exceeds
+// the amount, so the total due can't go negative.
+func ApplyCredits(credits []Credit, amount float64) (applied, unused float64) {
+	if amount < 0 {
+		amount = 0
+	}
+
+	var offered float64
+	for _, credit := range credits {
+		if credit.Amount > 0 {
+			offered += credit.Amount
+		}
+	}
+
+	applied = offered
+	if applied > amount {
+		applied = amount
+	}
+	return RoundPrice(applied), RoundPrice(offered - applied)
+}
+
+// CreditAllocation is how much of the total one credit pays
+type CreditAllocation struct {
+	Type    string  `json:"type"`
+	Code    string  `json:"code,omitempty"`
+	Offered float64 `json:"offered"`
+	Applied float64 `json:"applied"`
+	// Remaining is the balance left on the credit after checkout
+	Remaining float64 `json:"remaining"`
+}
+
+// PaymentSplit is how a checkout total divides between the credits
+// offered and the primary payment method
+type PaymentSplit struct {
+	Total         float64            `json:"total"`
+	Credits       []CreditAllocation `json:"credits"`
+	CreditApplied float64            `json:"credit_applied"`
+	UnusedCredit  float64            `json:"unused_credit"`
+	PrimaryDue    float64            `json:"primary_due"`
+	Currency      string             `json:"currency"`
+}
+
+// AllocateCredits spends credits against amount in the order they were
+// offered, each up to its balance, until the amount is covered. Credits
+// without a positive balance pay nothing.
+func AllocateCredits(credits []Credit, amount float64) []CreditAllocation {
+	remaining := RoundPrice(amount)
+	allocations := make([]CreditAllocation, 0, len(credits))
+	for _, credit := range credits {
+		offered := credit.Amount
+		if offered < 0 {
+			offered = 0
+		}
+		applied := offered
+		if applied > remaining {
+			applied = remaining
+		}
+		if applied < 0 {
+			applied = 0
+		}
+		remaining = RoundPrice(remaining - applied)
+		allocations = append(allocations, CreditAllocation{
+			Type:      credit.Type,
+			Code:      credit.Code,
+			Offered:   RoundPrice(offered),
+			Applied:   RoundPrice(applied),
+			Remaining: RoundPrice(offered - applied),
+		})
+	}
+	return allocations
+}
+
+// PaymentSplit allocates the cart's credits against its checkout total,
+// leaving the rest due on the primary payment method
+func (c *Cart) PaymentSplit(shipping ShippingPolicy) PaymentSplit {
+	checkout := c.CheckoutTotal(shipping)
+	total := RoundPrice(checkout.AmountDue + checkout.CreditApplied)
+	return PaymentSplit{
+		Total:         total,
+		Credits:       AllocateCredits(c.Credits, total),
+		CreditApplied: checkout.CreditApplied,
+		UnusedCredit:  checkout.UnusedCredit,
+		PrimaryDue:    checkout.AmountDue,
+		Currency:      c.Currency,
+	}
+}
@@ -0,0 +1,91 @@
+package models
+
+import "testing"
+
+func TestDiscountsNeverGoNegative(t *testing.T) {
+	tests := []struct {
+		name          string
+		setup         func(cart *Cart)
+		wantFinal     float64
+		wantCoupon    float64
+		wantBundle    float64
+		wantPoints    float64
+		wantCredit    float64
+		wantUnused    float64
+		wantAmountDue float64
+	}{
+		{
+			name: "coupon larger than the subtotal",
+			setup: func(cart *Cart) {
+				cart.Coupon = &Coupon{Code: "HUGE", Type: CouponTypeFixed, Value: 100}
+			},
+			wantCoupon: 30,
+		},
+		{
+			name: "bundles larger than the subtotal",
+			setup: func(cart *Cart) {
+				cart.ApplyBundles([]Bundle{
+					{ID: "a", ProductIDs: []int{1, 2}, DiscountAmount: 20},
+					{ID: "b", ProductIDs: []int{1, 2}, DiscountAmount: 25},
+				})
+			},
+			wantBundle: 30,
+		},
+		{
+			name: "promo item and coupon stacked",
+			setup: func(cart *Cart) {
+				cart.Items[0].DiscountAmount = 15
+				cart.Coupon = &Coupon{Code: "TEN", Type: CouponTypeFixed, Value: 10}
+			},
+			wantCoupon:    10,
+			wantFinal:     5,
+			wantAmountDue: 5,
+		},
+		{
+			name: "points worth more than what is left",
+			setup: func(cart *Cart) {
+				cart.Coupon = &Coupon{Code: "TWENTY", Type: CouponTypeFixed, Value: 20}
+				cart.PointsRedemption = &PointsRedemption{Points: 5000, PointValue: 0.01}
+			},
+			wantCoupon: 20,
+			wantPoints: 10,
+		},
+		{
+			name: "gift card larger than the total",
+			setup: func(cart *Cart) {
+				cart.Coupon = &Coupon{Code: "TWENTY", Type: CouponTypeFixed, Value: 20}
+				cart.Credits = []Credit{{Type: CreditTypeGiftCard, Code: "GC1", Amount: 50}}
+			},
+			wantCoupon: 20,
+			wantFinal:  10,
+			wantCredit: 10,
+			wantUnused: 40,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cart := NewCart("1")
+			cart.Items = []CartItem{
+				{ProductID: 1, Price: 20, Quantity: 1},
+				{ProductID: 2, Price: 10, Quantity: 1},
+			}
+			tt.setup(cart)
+			cart.CalculateTotals()
+
+			if cart.FinalPrice != tt.wantFinal || cart.FinalPrice < 0 {
+				t.Errorf("FinalPrice = %v, want %v", cart.FinalPrice, tt.wantFinal)
+			}
+			if cart.CouponDiscount != tt.wantCoupon || cart.BundleDiscount != tt.wantBundle || cart.PointsDiscount != tt.wantPoints {
+				t.Errorf("discounts: coupon %v, bundle %v, points %v; want %v, %v, %v",
+					cart.CouponDiscount, cart.BundleDiscount, cart.PointsDiscount, tt.wantCoupon, tt.wantBundle, tt.wantPoints)
+			}
+
+			checkout := cart.CheckoutTotal(ShippingPolicy{})
+			if checkout.AmountDue != tt.wantAmountDue || checkout.CreditApplied != tt.wantCredit || checkout.UnusedCredit != tt.wantUnused {
+				t.Errorf("checkout = due %v, credit %v, unused %v; want %v, %v, %v",
+					checkout.AmountDue, checkout.CreditApplied, checkout.UnusedCredit, tt.wantAmountDue, tt.wantCredit, tt.wantUnused)
+			}
+		})
+	}
+}
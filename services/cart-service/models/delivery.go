@@ -0,0 +1,105 @@
+package models
+
+import "time"
+
+// deliveryDateFormat is the layout used for estimated delivery dates
+const deliveryDateFormat = "2006-01-02"
+
+// TransitTime is the range of days a shipping method takes in transit
+type TransitTime struct {
+	MinDays int
+	MaxDays int
+}
+
+// ShippingMethods maps supported shipping methods to their transit times
+var ShippingMethods = map[string]TransitTime{
+	"standard":  {MinDays: 3, MaxDays: 7},
+	"express":   {MinDays: 1, MaxDays: 3},
+	"overnight": {MinDays: 1, MaxDays: 1},
+}
+
+// DeliveryEstimate is the window in which a whole cart should arrive
+type DeliveryEstimate struct {
+	Method       string `json:"method"`
+	Earliest     string `json:"earliest"`
+	Latest       string `json:"latest"`
+	LeadTimeDays int    `json:"lead_time_days"`
+	// Estimated is true if any item's lead time was unknown and the
+	// conservative fallback was used
+	Estimated bool `json:"estimated"`
+}
+
+// EstimateDelivery computes the delivery window for items with the given
+// lead times. The slowest item dominates, since the cart ships together.
+// A nil lead time is unknown and counts as unknownLeadDays.
+func EstimateDelivery(leadTimes []*int, unknownLeadDays int, method string, transit TransitTime, from time.Time) DeliveryEstimate {
+	estimate := DeliveryEstimate{Method: method}
+	for _, leadTime := range leadTimes {
+		days := unknownLeadDays
+		if leadTime != nil {
+			days = *leadTime
+		} else {
+			estimate.Estimated = true
+		}
+		if days > estimate.LeadTimeDays {
+			estimate.LeadTimeDays = days
+		}
+	}
+
+	estimate.Earliest = from.AddDate(0, 0, estimate.LeadTimeDays+transit.MinDays).Format(deliveryDateFormat)
+	estimate.Latest = from.AddDate(0, 0, estimate.LeadTimeDays+transit.MaxDays).Format(deliveryDateFormat)
+	return estimate
+}
+
+// Requested delivery date validation reasons
+const (
+	DeliveryDateReasonInvalid       = "invalid_date"
+	DeliveryDateReasonPast          = "date_in_past"
+	DeliveryDateReasonOutsideWindow = "outside_delivery_window"
+)
+
+// SetDeliveryDateRequest represents the request to schedule an item's
+// delivery
+type SetDeliveryDateRequest struct {
+	Date string `json:"date" binding:"required"`
+}
+
+// DeliveryDateError describes why a requested delivery date was rejected
+type DeliveryDateError struct {
+	Reason   string
+	Message  string
+	Earliest string
+	Latest   string
+}
+
+func (e *DeliveryDateError) Error() string {
+	return e.Message
+}
+
+// ValidateDeliveryDate checks a requested YYYY-MM-DD delivery date is in
+// the future and falls between minDays and maxDays from today
+func ValidateDeliveryDate(date string, today time.Time, minDays, maxDays int) error {
+	today = time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, time.UTC)
+	earliest := today.AddDate(0, 0, minDays)
+	latest := today.AddDate(0, 0, maxDays)
+	window := func(reason, message string) error {
+		return &DeliveryDateError{
+			Reason:   reason,
+			Message:  message,
+			Earliest: earliest.Format(deliveryDateFormat),
+			Latest:   latest.Format(deliveryDateFormat),
+		}
+	}
+
+	requested, err := time.Parse(deliveryDateFormat, date)
+	if err != nil {
+		return window(DeliveryDateReasonInvalid, "Delivery date must be formatted YYYY-MM-DD")
+	}
+	if !requested.After(today) {
+		return window(DeliveryDateReasonPast, "Delivery date must be in the future")
+	}
+	if requested.Before(earliest) || requested.After(latest) {
+		return window(DeliveryDateReasonOutsideWindow, "Delivery date is outside the product's delivery window")
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package models
+
+import "math"
+
+// Dimensions are a product's package measurements in centimeters
+type Dimensions struct {
+	Length float64 `json:"length"`
+	Width  float64 `json:"width"`
+	Height float64 `json:"height"`
+}
+
+// Volume returns the package volume in cubic centimeters
+func (d Dimensions) Volume() float64 {
+	return d.Length * d.Width * d.Height
+}
+
+// CartDimensions are the aggregate physical measurements of a cart, for
+// shipping integrations
+type CartDimensions struct {
+	TotalWeight      float64 `json:"total_weight"`
+	TotalVolume      float64 `json:"total_volume"`
+	VolumetricWeight float64 `json:"volumetric_weight"`
+	BillableWeight   float64 `json:"billable_weight"`
+	// MissingDimensions lists products without measurements, which are
+	// left out of the volume
+	MissingDimensions []int `json:"missing_dimensions"`
+}
+
+// Dimensions sums the weight and volume of the cart's items. Volumetric
+// weight is the volume divided by the carrier's divisor (5000 for cm/kg is
+// common); carriers bill whichever of it and the actual weight is greater.
+func (c *Cart) Dimensions(volumetricDivisor float64) CartDimensions {
+	dims := CartDimensions{MissingDimensions: []int{}}
+	for _, item := range c.Items {
+		dims.TotalWeight += item.Weight * float64(item.Quantity)
+		if item.Dimensions == nil {
+			dims.MissingDimensions = append(dims.MissingDimensions, item.ProductID)
+			continue
+		}
+		dims.TotalVolume += item.Dimensions.Volume() * float64(item.Quantity)
+	}
+
+	if volumetricDivisor > 0 {
+		dims.VolumetricWeight = dims.TotalVolume / volumetricDivisor
+	}
+	dims.BillableWeight = dims.TotalWeight
+	if dims.VolumetricWeight > dims.BillableWeight {
+		dims.BillableWeight = dims.VolumetricWeight
+	}
+
+	// Report to three decimals, grams for kilogram weights
+	dims.TotalWeight = roundTo(dims.TotalWeight, 3)
+	dims.TotalVolume = roundTo(dims.TotalVolume, 3)
+	dims.VolumetricWeight = roundTo(dims.VolumetricWeight, 3)
+	dims.BillableWeight = roundTo(dims.BillableWeight, 3)
+	return dims
+}
+
+// roundTo rounds a measurement to the given number of decimal places
+func roundTo(value float64, places int) float64 {
+	scale := math.Pow(10, float64(places))
+	return math.Round(value*scale) / scale
+}
@@ -0,0 +1,132 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+// How prices are presented to customers. Carts always store tax-exclusive
+// prices; inclusive display adds each item's tax when presenting them.
+const (
+	PriceDisplayExclusive = "exclusive"
+	PriceDisplayInclusive = "inclusive"
+)
+
+var defaultPriceDisplay = PriceDisplayExclusive
+
+// SetPriceDisplay selects whether prices are presented inclusive of tax,
+// as in the EU, or exclusive, as in the US. Empty selects exclusive.
+func SetPriceDisplay(mode string) error {
+	switch mode {
+	case "":
+		defaultPriceDisplay = PriceDisplayExclusive
+	case PriceDisplayExclusive, PriceDisplayInclusive:
+		defaultPriceDisplay = mode
+	default:
+		return fmt.Errorf("unknown price display %q", mode)
+	}
+	return nil
+}
+
+// DefaultPriceDisplay returns the configured price display mode
+func DefaultPriceDisplay() string {
+	return defaultPriceDisplay
+}
+
+// defaultInclusiveCountries are the EU and EEA members and the UK, where
+// prices are customarily shown with VAT included
+const defaultInclusiveCountries = "AT,BE,BG,CH,CY,CZ,DE,DK,EE,ES,FI,FR,GB,GR,HR,HU,IE,IS,IT,LI,LT,LU,LV,MT,NL,NO,PL,PT,RO,SE,SI,SK"
+
+var inclusiveCountries = map[string]bool{}
+
+// SetInclusiveCountries configures the ISO country codes whose customers
+// see tax-inclusive prices when the display mode is detected by region,
+// e.g. "DE,FR,GB". Empty selects the EU, the EEA and the UK.
+func SetInclusiveCountries(spec string) error {
+	if spec == "" {
+		spec = defaultInclusiveCountries
+	}
+	countries := map[string]bool{}
+	for _, country := range strings.Split(spec, ",") {
+		country = strings.ToUpper(strings.TrimSpace(country))
+		if country == "" {
+			continue
+		}
+		if len(country) != 2 {
+			return fmt.Errorf("invalid country code %q", country)
+		}
+		countries[country] = true
+	}
+	inclusiveCountries = countries
+	return nil
+}
+
+// PriceDisplayForCountry returns the display mode customary in a country,
+// or the configured default if the country is unknown
+func PriceDisplayForCountry(country string) string {
+	if country == "" {
+		return defaultPriceDisplay
+	}
+	if inclusiveCountries[strings.ToUpper(country)] {
+		return PriceDisplayInclusive
+	}
+	return PriceDisplayExclusive
+}
+
+// ItemPriceView is an item's price and subtotal both net and gross of
+// tax, with the figures to display under the chosen mode
+type ItemPriceView struct {
+	ProductID     int     `json:"product_id"`
+	UnitNet       float64 `json:"unit_net"`
+	UnitGross     float64 `json:"unit_gross"`
+	SubtotalNet   float64 `json:"subtotal_net"`
+	SubtotalGross float64 `json:"subtotal_gross"`
+	UnitPrice     float64 `json:"unit_price"`
+	Subtotal      float64 `json:"subtotal"`
+}
+
+// PriceView presents a cart's prices in a display mode
+type PriceView struct {
+	Display string          `json:"display"`
+	Items   []ItemPriceView `json:"items"`
+	Net     float64         `json:"net"`
+	Tax     float64         `json:"tax"`
+	Gross   float64         `json:"gross"`
+	Total   float64         `json:"total"`
+}
+
+// PriceView derives net and gross figures for the cart's items and totals
+// from the stored tax-exclusive prices, displaying gross figures when mode
+// is inclusive
+func (c *Cart) PriceView(mode string) PriceView {
+	inclusive := mode == PriceDisplayInclusive
+	view := PriceView{
+		Display: mode,
+		Items:   make([]ItemPriceView, 0, len(c.Items)),
+		Net:     c.FinalPrice,
+		Tax:     c.Tax,
+		Gross:   RoundPrice(c.FinalPrice + c.Tax),
+	}
+
+	for _, item := range c.Items {
+		rate := TaxRate(c.ShippingAddress, item.TaxCategory)
+		line := ItemPriceView{
+			ProductID:     item.ProductID,
+			UnitNet:       item.Price,
+			UnitGross:     RoundPrice(item.Price * (1 + rate)),
+			SubtotalNet:   item.Subtotal,
+			SubtotalGross: RoundPrice(item.Subtotal * (1 + rate)),
+		}
+		line.UnitPrice, line.Subtotal = line.UnitNet, line.SubtotalNet
+		if inclusive {
+			line.UnitPrice, line.Subtotal = line.UnitGross, line.SubtotalGross
+		}
+		view.Items = append(view.Items, line)
+	}
+
+	view.Total = view.Net
+	if inclusive {
+		view.Total = view.Gross
+	}
+	return view
+}
@@ -0,0 +1,29 @@
+package models
+
+import "math"
+
+// Donation is a charity contribution added at checkout: either rounding
+// the total up to the next whole unit, or a fixed Amount
+type Donation struct {
+	RoundUp bool    `json:"round_up"`
+	Amount  float64 `json:"amount,omitempty"`
+}
+
+// RoundupRequest represents the request to add a donation. Without an
+// amount the total is rounded up.
+type RoundupRequest struct {
+	Amount *float64 `json:"amount" binding:"omitempty,gt=0"`
+}
+
+// For returns the donation on top of total. A round-up of a total that is
+// already whole is zero.
+func (d *Donation) For(total float64) float64 {
+	if d == nil {
+		return 0
+	}
+	if !d.RoundUp {
+		return d.Amount
+	}
+	total = RoundPrice(total)
+	return RoundPrice(math.Ceil(total) - total)
+}
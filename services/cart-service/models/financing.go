@@ -0,0 +1,84 @@
+package models
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// FinancingPlan is an installment plan offered at checkout
+type FinancingPlan struct {
+	Months int     `json:"months"`
+	APR    float64 `json:"apr"`
+}
+
+// FinancingOption is what a plan costs for a particular amount
+type FinancingOption struct {
+	Months      int     `json:"months"`
+	APR         float64 `json:"apr"`
+	Installment float64 `json:"installment"`
+	TotalCost   float64 `json:"total_cost"`
+	Interest    float64 `json:"interest"`
+}
+
+var financingPlans []FinancingPlan
+
+// SetFinancingPlans configures the installment plans on offer. spec lists
+// months and APR percentage pairs, e.g. "3:0,6:9.99,12:14.99".
+func SetFinancingPlans(spec string) error {
+	plans := []FinancingPlan{}
+	for _, pair := range strings.Split(spec, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		monthsText, aprText, ok := strings.Cut(pair, ":")
+		months, err := strconv.Atoi(strings.TrimSpace(monthsText))
+		if !ok || err != nil || months <= 0 {
+			return fmt.Errorf("invalid financing plan %q", pair)
+		}
+		apr, err := strconv.ParseFloat(strings.TrimSpace(aprText), 64)
+		if err != nil || apr < 0 {
+			return fmt.Errorf("invalid financing plan %q", pair)
+		}
+		plans = append(plans, FinancingPlan{Months: months, APR: apr})
+	}
+	sort.Slice(plans, func(i, j int) bool {
+		return plans[i].Months < plans[j].Months
+	})
+	financingPlans = plans
+	return nil
+}
+
+// FinancingOptions prices every configured plan for amount. Nothing is
+// offered below minimum.
+func FinancingOptions(amount, minimum float64) []FinancingOption {
+	options := []FinancingOption{}
+	if amount <= 0 || amount < minimum {
+		return options
+	}
+	for _, plan := range financingPlans {
+		options = append(options, plan.Price(amount))
+	}
+	return options
+}
+
+// Price returns the monthly installment and total cost of financing amount
+// on the plan, using standard amortization at APR/12 per month
+func (p FinancingPlan) Price(amount float64) FinancingOption {
+	installment := amount / float64(p.Months)
+	if rate := p.APR / 100 / 12; rate > 0 {
+		installment = amount * rate / (1 - math.Pow(1+rate, -float64(p.Months)))
+	}
+	installment = RoundPrice(installment)
+	totalCost := RoundPrice(installment * float64(p.Months))
+	return FinancingOption{
+		Months:      p.Months,
+		APR:         p.APR,
+		Installment: installment,
+		TotalCost:   totalCost,
+		Interest:    RoundPrice(totalCost - amount),
+	}
+}
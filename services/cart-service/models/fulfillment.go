@@ -0,0 +1,65 @@
+package models
+
+// How an item reaches the customer. Items without a fulfillment type ship.
+const (
+	FulfillmentShip    = "ship"
+	FulfillmentPickup  = "pickup"
+	FulfillmentDigital = "digital"
+)
+
+// fulfillmentOrder lists the fulfillment groups in the order they are
+// presented
+var fulfillmentOrder = []string{FulfillmentShip, FulfillmentPickup, FulfillmentDigital}
+
+// FulfillmentGroup is the part of a cart fulfilled one way. The subtotal
+// includes item-level discounts but not cart-level ones.
+type FulfillmentGroup struct {
+	FulfillmentType string     `json:"fulfillment_type"`
+	Items           []CartItem `json:"items"`
+	TotalItems      int        `json:"total_items"`
+	Subtotal        float64    `json:"subtotal"`
+	Tax             float64    `json:"tax"`
+}
+
+// Fulfillment returns how the item is fulfilled, defaulting to shipping
+func (i *CartItem) Fulfillment() string {
+	if i.FulfillmentType == "" {
+		return FulfillmentShip
+	}
+	return i.FulfillmentType
+}
+
+// ItemsByFulfillment groups the cart's items by fulfillment type, shipped
+// items first, then pickup, then digital, then any other types. Empty
+// groups are left out.
+func (c *Cart) ItemsByFulfillment() []FulfillmentGroup {
+	groups := map[string]*FulfillmentGroup{}
+	var order []string
+	for _, item := range c.Items {
+		fulfillment := item.Fulfillment()
+		group, ok := groups[fulfillment]
+		if !ok {
+			group = &FulfillmentGroup{FulfillmentType: fulfillment, Items: []CartItem{}}
+			groups[fulfillment] = group
+			order = append(order, fulfillment)
+		}
+		group.Items = append(group.Items, item)
+		group.TotalItems += item.Quantity
+		group.Subtotal = RoundPrice(group.Subtotal + item.Subtotal)
+		group.Tax = RoundPrice(group.Tax + item.Tax)
+	}
+
+	result := []FulfillmentGroup{}
+	for _, fulfillment := range fulfillmentOrder {
+		if group, ok := groups[fulfillment]; ok {
+			result = append(result, *group)
+			delete(groups, fulfillment)
+		}
+	}
+	for _, fulfillment := range order {
+		if group, ok := groups[fulfillment]; ok {
+			result = append(result, *group)
+		}
+	}
+	return result
+}
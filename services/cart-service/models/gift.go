@@ -0,0 +1,84 @@
+package models
+
+import "time"
+
+// GiftPromotion adds a free product to carts whose item total reaches
+// MinOrderValue, e.g. "free tote bag on orders over $50"
+type GiftPromotion struct {
+	ID            string  `json:"id"`
+	ProductID     int     `json:"product_id"`
+	ProductName   string  `json:"product_name"`
+	MinOrderValue float64 `json:"min_order_value"`
+}
+
+// ApplyGiftPromotions records the configured gift promotions on the cart.
+// CalculateTotals adds or removes the gift items as the cart changes.
+func (c *Cart) ApplyGiftPromotions(gifts []GiftPromotion) {
+	c.GiftPromotions = gifts
+}
+
+// PaidQuantity returns the units of the line the customer pays for; a
+// gift line has none
+func (i *CartItem) PaidQuantity() int {
+	if i.PromoGift {
+		return 0
+	}
+	return i.Quantity
+}
+
+// SetPaidQuantity sets the units the customer pays for. Buying a product
+// received as a gift turns the gift line into a paid one.
+func (i *CartItem) SetPaidQuantity(quantity int) {
+	i.PromoGift = false
+	i.Quantity = quantity
+}
+
+// syncGiftItems adds a free line for every gift promotion the cart
+// qualifies for and removes gift lines it no longer qualifies for. The
+// threshold is checked against the discounted total of the paid items.
+// A gift is not added for a product the customer is already buying.
+func (c *Cart) syncGiftItems() {
+	var paidTotal float64
+	for i := range c.Items {
+		if !c.Items[i].PromoGift {
+			c.Items[i].CalculateSubtotal()
+			paidTotal += c.Items[i].Subtotal
+		}
+	}
+
+	eligible := map[int]GiftPromotion{}
+	for _, gift := range c.GiftPromotions {
+		if paidTotal >= gift.MinOrderValue {
+			eligible[gift.ProductID] = gift
+		}
+	}
+
+	kept := c.Items[:0]
+	for _, item := range c.Items {
+		if item.PromoGift {
+			if _, ok := eligible[item.ProductID]; !ok {
+				continue
+			}
+			// Gifts are always a single free unit
+			item.Quantity = 1
+			delete(eligible, item.ProductID)
+		}
+		kept = append(kept, item)
+	}
+	c.Items = kept
+
+	for _, gift := range c.GiftPromotions {
+		if _, ok := eligible[gift.ProductID]; !ok || c.FindItem(gift.ProductID) != -1 {
+			continue
+		}
+		c.Items = append(c.Items, CartItem{
+			ProductID:   gift.ProductID,
+			ProductName: gift.ProductName,
+			Price:       0,
+			Quantity:    1,
+			AddedAt:     time.Now().Format(time.RFC3339),
+			PromoGift:   true,
+		})
+		delete(eligible, gift.ProductID)
+	}
+}
@@ -0,0 +1,23 @@
+package models
+
+// GiftWrap wraps the whole order for a single flat fee, fixed when
+// wrapping was requested
+type GiftWrap struct {
+	Message string  `json:"message,omitempty"`
+	Fee     float64 `json:"fee"`
+}
+
+// GiftWrapRequest represents the request to turn order gift wrapping on
+// or off
+type GiftWrapRequest struct {
+	Enabled *bool  `json:"enabled" binding:"required"`
+	Message string `json:"message" binding:"max=250"`
+}
+
+// giftWrapFee returns the fee for wrapping the order, if requested
+func (c *Cart) giftWrapFee() float64 {
+	if c.GiftWrap == nil || len(c.Items) == 0 {
+		return 0
+	}
+	return RoundPrice(c.GiftWrap.Fee)
+}
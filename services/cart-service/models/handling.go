@@ -0,0 +1,12 @@
+package models
+
+// handlingTotal sums the per-unit handling fees of the cart's items, e.g.
+// for oversized or hazardous goods. Handling is charged on top of the
+// merchandise and is not discounted or taxed.
+func (c *Cart) handlingTotal() float64 {
+	total := 0.0
+	for _, item := range c.Items {
+		total += item.HandlingFee * float64(item.Quantity)
+	}
+	return RoundPrice(total)
+}
@@ -0,0 +1,69 @@
+package models
+
+import "time"
+
+// ItemHold is the countdown on an item's reserved flash-sale units
+type ItemHold struct {
+	ProductID        int    `json:"product_id"`
+	Reserved         int    `json:"reserved"`
+	ReservedUntil    string `json:"reserved_until"`
+	RemainingSeconds int    `json:"remaining_seconds"`
+}
+
+// HoldExpiredAt reports whether the item's reserved units are past their
+// hold at now
+func (i *CartItem) HoldExpiredAt(now time.Time) bool {
+	reservedUntil, err := time.Parse(time.RFC3339, i.ReservedUntil)
+	return err == nil && !now.Before(reservedUntil)
+}
+
+// ReleaseHold drops the item's reserved units, returning it to the
+// regular price. Returns the number of units released.
+func (i *CartItem) ReleaseHold() int {
+	released := i.FlashReserved
+	i.FlashReserved = 0
+	i.PromoLimit = 0
+	i.ReservedUntil = ""
+	i.HoldExpired = released > 0
+	return released
+}
+
+// TrimHold keeps at most quantity of the item's reserved units, for when
+// its quantity drops below them. Returns the number of units given up.
+func (i *CartItem) TrimHold(quantity int) int {
+	if i.FlashReserved <= quantity {
+		return 0
+	}
+	trimmed := i.FlashReserved - quantity
+	i.FlashReserved = quantity
+	i.PromoLimit = quantity
+	if quantity == 0 {
+		i.ReservedUntil = ""
+	}
+	return trimmed
+}
+
+// Holds returns the countdown for every item with reserved units
+func (c *Cart) Holds(now time.Time) []ItemHold {
+	holds := []ItemHold{}
+	for _, item := range c.Items {
+		if item.FlashReserved == 0 || item.ReservedUntil == "" {
+			continue
+		}
+		reservedUntil, err := time.Parse(time.RFC3339, item.ReservedUntil)
+		if err != nil {
+			continue
+		}
+		remaining := int(reservedUntil.Sub(now).Seconds())
+		if remaining < 0 {
+			remaining = 0
+		}
+		holds = append(holds, ItemHold{
+			ProductID:        item.ProductID,
+			Reserved:         item.FlashReserved,
+			ReservedUntil:    item.ReservedUntil,
+			RemainingSeconds: remaining,
+		})
+	}
+	return holds
+}
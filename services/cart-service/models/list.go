@@ -0,0 +1,36 @@
+package models
+
+import "time"
+
+// ListTemplate is a saved set of products, like a weekly grocery list,
+// that can be added to a cart in one go
+type ListTemplate struct {
+	ID        string     `json:"id"`
+	Items     []ListItem `json:"items"`
+	CreatedAt string     `json:"created_at"`
+}
+
+// ListItem is one product and quantity in a list template
+type ListItem struct {
+	ProductID int `json:"product_id"`
+	Quantity  int `json:"quantity"`
+}
+
+// SaveListRequest represents the request to save the cart as a list
+type SaveListRequest struct {
+	ListID string `json:"list_id" binding:"required"`
+}
+
+// ToList snapshots the cart's products and quantities as a list template.
+// Prices are not kept; they are re-read when the list is applied.
+func (c *Cart) ToList(id string) *ListTemplate {
+	list := &ListTemplate{
+		ID:        id,
+		Items:     make([]ListItem, 0, len(c.Items)),
+		CreatedAt: time.Now().Format(time.RFC3339),
+	}
+	for _, item := range c.Items {
+		list.Items = append(list.Items, ListItem{ProductID: item.ProductID, Quantity: item.Quantity})
+	}
+	return list
+}
@@ -0,0 +1,56 @@
+package models
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// priceEndingCents is the cents every display price ends in, e.g. 99 for
+// ".99" pricing, or -1 when display prices are off
+var priceEndingCents = -1
+
+// SetPriceEnding configures the marketing ending display prices are
+// rounded to, e.g. "0.99". Empty turns display prices off.
+func SetPriceEnding(spec string) error {
+	if spec == "" {
+		priceEndingCents = -1
+		return nil
+	}
+	ending, err := strconv.ParseFloat(spec, 64)
+	if err != nil || ending < 0 || ending >= 1 {
+		return fmt.Errorf("invalid price ending %q", spec)
+	}
+	priceEndingCents = int(math.Round(ending * 100))
+	return nil
+}
+
+// MarketingPrice returns the price ending in the configured cents nearest
+// to price, preferring the lower on a tie, or 0 when display prices are
+// off or the item is free. It is for display only; totals always use the
+// exact price.
+func MarketingPrice(price float64) float64 {
+	if priceEndingCents < 0 || price <= 0 {
+		return 0
+	}
+
+	cents := int(math.Round(price * 100))
+	best := -1
+	for dollars := cents/100 - 1; dollars <= cents/100+1; dollars++ {
+		candidate := dollars*100 + priceEndingCents
+		if candidate <= 0 {
+			continue
+		}
+		if best == -1 || abs(candidate-cents) < abs(best-cents) {
+			best = candidate
+		}
+	}
+	return float64(best) / 100
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
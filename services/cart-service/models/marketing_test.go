@@ -0,0 +1,46 @@
+package models
+
+import "testing"
+
+// usePriceEnding switches the display price ending for the duration of the
+// test
+func usePriceEnding(t *testing.T, spec string) {
+	t.Helper()
+	if err := SetPriceEnding(spec); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { SetPriceEnding("") })
+}
+
+func TestMarketingPrice(t *testing.T) {
+	tests := []struct {
+		ending string
+		price  float64
+		want   float64
+	}{
+		{ending: "0.99", price: 10, want: 9.99},
+		{ending: "0.99", price: 10.49, want: 9.99},
+		{ending: "0.99", price: 10.51, want: 10.99},
+		{ending: "0.99", price: 12.49, want: 11.99},
+		{ending: "0.99", price: 0.5, want: 0.99},
+		{ending: "0.95", price: 20.2, want: 19.95},
+		{ending: "0.99", price: 0, want: 0},
+		{ending: "", price: 10, want: 0},
+	}
+
+	for _, tt := range tests {
+		usePriceEnding(t, tt.ending)
+		if got := MarketingPrice(tt.price); got != tt.want {
+			t.Errorf("ending %q: MarketingPrice(%v) = %v, want %v", tt.ending, tt.price, got, tt.want)
+		}
+	}
+}
+
+func TestSetPriceEndingRejectsInvalid(t *testing.T) {
+	for _, spec := range []string{"abc", "1", "1.99", "-0.01"} {
+		if err := SetPriceEnding(spec); err == nil {
+			t.Errorf("SetPriceEnding(%q) succeeded", spec)
+		}
+	}
+	SetPriceEnding("")
+}
@@ -0,0 +1,92 @@
+package models
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var (
+	memberDiscounts      = map[string]float64{}
+	memberDiscountStacks bool
+	loyaltyDiscounts     = map[string]float64{}
+)
+
+// SetMemberDiscounts configures automatic discounts by role. spec maps
+// roles to a percentage off, e.g. "employee=20,member=5". With stacks, a
+// coupon applies on top of the member discount; otherwise the cart gets
+// whichever of the two saves more.
+func SetMemberDiscounts(spec string, stacks bool) error {
+	discounts, err := parsePercentages(spec)
+	if err != nil {
+		return fmt.Errorf("invalid member discounts: %v", err)
+	}
+	memberDiscounts = discounts
+	memberDiscountStacks = stacks
+	return nil
+}
+
+// SetLoyaltyDiscounts configures tier pricing. spec maps loyalty tiers to
+// a percentage off, e.g. "gold=10,silver=5".
+func SetLoyaltyDiscounts(spec string) error {
+	discounts, err := parsePercentages(spec)
+	if err != nil {
+		return fmt.Errorf("invalid loyalty discounts: %v", err)
+	}
+	loyaltyDiscounts = discounts
+	return nil
+}
+
+// parsePercentages parses "name=percent" pairs separated by commas
+func parsePercentages(spec string) (map[string]float64, error) {
+	percentages := map[string]float64{}
+	for _, pair := range strings.Split(spec, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		name, percentText, ok := strings.Cut(pair, "=")
+		percent, err := strconv.ParseFloat(strings.TrimSpace(percentText), 64)
+		if !ok || err != nil || percent < 0 || percent > 100 {
+			return nil, fmt.Errorf("bad entry %q", pair)
+		}
+		percentages[strings.TrimSpace(name)] = percent
+	}
+	return percentages, nil
+}
+
+// MemberDiscountPercent returns the discount a role qualifies for, or 0
+func MemberDiscountPercent(role string) float64 {
+	if role == "" {
+		return 0
+	}
+	return memberDiscounts[role]
+}
+
+// LoyaltyDiscountPercent returns the discount a loyalty tier earns, or 0
+func LoyaltyDiscountPercent(tier string) float64 {
+	if tier == "" {
+		return 0
+	}
+	return loyaltyDiscounts[tier]
+}
+
+// calculateMemberAndCouponDiscounts sets the member and coupon discounts
+// off the discounted total. The coupon only counts while it remains valid
+// for the cart, and neither discount takes the total below zero.
+func (c *Cart) calculateMemberAndCouponDiscounts() {
+	c.MemberDiscount = RoundPrice(c.TotalPrice * MemberDiscountPercent(c.MemberRole) / 100)
+
+	c.CouponDiscount = 0
+	if c.Coupon != nil && c.Coupon.Validate(c) == nil {
+		if memberDiscountStacks {
+			c.CouponDiscount = c.Coupon.Discount(c.TotalPrice - c.MemberDiscount)
+		} else if discount := c.Coupon.Discount(c.TotalPrice); discount > c.MemberDiscount {
+			c.CouponDiscount = discount
+			c.MemberDiscount = 0
+		}
+	}
+	if c.CouponDiscount > c.TotalPrice-c.MemberDiscount {
+		c.CouponDiscount = RoundPrice(c.TotalPrice - c.MemberDiscount)
+	}
+}
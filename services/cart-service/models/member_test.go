@@ -0,0 +1,71 @@
+package models
+
+import "testing"
+
+func TestMemberDiscount(t *testing.T) {
+	tests := []struct {
+		name       string
+		role       string
+		stacks     bool
+		coupon     *Coupon
+		wantMember float64
+		wantCoupon float64
+		wantFinal  float64
+	}{
+		{name: "qualifying role", role: "employee", wantMember: 20, wantFinal: 80},
+		{name: "non-qualifying role", role: "guest", wantFinal: 100},
+		{name: "no role", wantFinal: 100},
+		{
+			name:       "coupon stacks on the member discount",
+			role:       "employee",
+			stacks:     true,
+			coupon:     &Coupon{Code: "TEN", Type: CouponTypePercent, Value: 10},
+			wantMember: 20,
+			wantCoupon: 8,
+			wantFinal:  72,
+		},
+		{
+			name:       "member discount beats a smaller coupon",
+			role:       "employee",
+			coupon:     &Coupon{Code: "TEN", Type: CouponTypePercent, Value: 10},
+			wantMember: 20,
+			wantFinal:  80,
+		},
+		{
+			name:       "larger coupon replaces the member discount",
+			role:       "employee",
+			coupon:     &Coupon{Code: "THIRTY", Type: CouponTypeFixed, Value: 30},
+			wantCoupon: 30,
+			wantFinal:  70,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := SetMemberDiscounts("employee=20,member=5", tt.stacks); err != nil {
+				t.Fatalf("SetMemberDiscounts: %v", err)
+			}
+			t.Cleanup(func() { SetMemberDiscounts("", false) })
+
+			cart := NewCart("42")
+			cart.Items = []CartItem{{ProductID: 1, Price: 25, Quantity: 4}}
+			cart.MemberRole = tt.role
+			cart.Coupon = tt.coupon
+			cart.CalculateTotals()
+
+			if cart.MemberDiscount != tt.wantMember || cart.CouponDiscount != tt.wantCoupon || cart.FinalPrice != tt.wantFinal {
+				t.Errorf("member = %v, coupon = %v, final = %v; want %v, %v, %v",
+					cart.MemberDiscount, cart.CouponDiscount, cart.FinalPrice, tt.wantMember, tt.wantCoupon, tt.wantFinal)
+			}
+		})
+	}
+}
+
+func TestSetMemberDiscountsRejectsInvalidSpec(t *testing.T) {
+	for _, spec := range []string{"employee", "employee=abc", "employee=120", "employee=-5"} {
+		if err := SetMemberDiscounts(spec, false); err == nil {
+			t.Errorf("SetMemberDiscounts accepted %q", spec)
+		}
+	}
+	SetMemberDiscounts("", false)
+}
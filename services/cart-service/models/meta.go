@@ -0,0 +1,24 @@
+package models
+
+// CartMeta is the lightweight change metadata of a cart, for sync
+// heuristics and conflict detection without transferring items
+type CartMeta struct {
+	Version    int    `json:"version"`
+	CreatedAt  string `json:"created_at,omitempty"`
+	UpdatedAt  string `json:"updated_at"`
+	UpdatedBy  string `json:"updated_by,omitempty"`
+	ItemCount  int    `json:"item_count"`
+	TotalItems int    `json:"total_items"`
+}
+
+// Meta returns the cart's change metadata
+func (c *Cart) Meta() CartMeta {
+	return CartMeta{
+		Version:    c.Version,
+		CreatedAt:  c.CreatedAt,
+		UpdatedAt:  c.UpdatedAt,
+		UpdatedBy:  c.UpdatedBy,
+		ItemCount:  len(c.Items),
+		TotalItems: c.TotalItems,
+	}
+}
@@ -0,0 +1,44 @@
+package models
+
+import "fmt"
+
+// MetadataLimits bounds the custom metadata an integration can store on
+// a cart
+type MetadataLimits struct {
+	MaxKeys        int
+	MaxKeyLength   int
+	MaxValueLength int
+}
+
+// SetMetadataRequest represents the request to merge custom metadata
+type SetMetadataRequest struct {
+	Metadata map[string]string `json:"metadata" binding:"required"`
+}
+
+// MergeMetadata adds or overwrites the given keys in the cart's metadata.
+// Nothing is changed if the result would exceed the limits.
+func (c *Cart) MergeMetadata(values map[string]string, limits MetadataLimits) error {
+	keys := len(c.Metadata)
+	for key, value := range values {
+		if key == "" || len(key) > limits.MaxKeyLength {
+			return fmt.Errorf("metadata keys must be 1-%d characters", limits.MaxKeyLength)
+		}
+		if len(value) > limits.MaxValueLength {
+			return fmt.Errorf("metadata value for %q exceeds %d characters", key, limits.MaxValueLength)
+		}
+		if _, exists := c.Metadata[key]; !exists {
+			keys++
+		}
+	}
+	if keys > limits.MaxKeys {
+		return fmt.Errorf("cart metadata is limited to %d keys", limits.MaxKeys)
+	}
+
+	if c.Metadata == nil {
+		c.Metadata = make(map[string]string, len(values))
+	}
+	for key, value := range values {
+		c.Metadata[key] = value
+	}
+	return nil
+}
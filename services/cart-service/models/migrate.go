@@ -0,0 +1,56 @@
+package models
+
+import "encoding/json"
+
+// CurrentSchemaVersion is the cart schema version written by this service.
+// Carts stored before versioning was introduced have version 0.
+const CurrentSchemaVersion = 2
+
+// DefaultCurrency is assumed for carts that predate currency support
+const DefaultCurrency = "USD"
+
+// MigrateCart decodes stored cart JSON and upgrades carts written by older
+// versions of the service, filling defaults for fields they lack
+func MigrateCart(raw []byte) (*Cart, error) {
+	var cart Cart
+	if err := json.Unmarshal(raw, &cart); err != nil {
+		return nil, err
+	}
+
+	migrate(&cart)
+	return &cart, nil
+}
+
+// migrate upgrades a decoded cart to the current schema version
+func migrate(cart *Cart) {
+	if cart.SchemaVersion < 2 {
+		migrateToV2(cart)
+	}
+
+	cart.snapshot()
+}
+
+// migrateToV2 adds currency and the undiscounted price fields
+func migrateToV2(cart *Cart) {
+	if cart.Items == nil {
+		cart.Items = []CartItem{}
+	}
+	if cart.Currency == "" {
+		cart.Currency = DefaultCurrency
+	}
+
+	// Legacy items had no promotions, so the original subtotal is the subtotal
+	for i := range cart.Items {
+		if cart.Items[i].OriginalSubtotal == 0 {
+			cart.Items[i].OriginalSubtotal = cart.Items[i].Subtotal
+		}
+	}
+	if cart.OriginalPrice == 0 {
+		cart.OriginalPrice = cart.TotalPrice
+	}
+	if cart.FinalPrice == 0 {
+		cart.FinalPrice = cart.TotalPrice
+	}
+
+	cart.SchemaVersion = 2
+}
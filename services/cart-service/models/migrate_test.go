@@ -0,0 +1,68 @@
+package models
+
+import "testing"
+
+func TestMigrateCart(t *testing.T) {
+	tests := []struct {
+		name         string
+		raw          string
+		wantCurrency string
+		wantOriginal float64
+		wantFinal    float64
+		wantItemOrig float64
+	}{
+		{
+			name:         "legacy cart without versioning",
+			raw:          `{"user_id":"1","items":[{"product_id":7,"price":5,"quantity":2,"subtotal":10}],"total_items":2,"total_price":10}`,
+			wantCurrency: DefaultCurrency,
+			wantOriginal: 10,
+			wantFinal:    10,
+			wantItemOrig: 10,
+		},
+		{
+			name:         "current cart is left alone",
+			raw:          `{"schema_version":2,"user_id":"1","currency":"EUR","items":[{"product_id":7,"price":5,"quantity":2,"original_subtotal":10,"subtotal":8}],"original_price":10,"total_price":8,"final_price":8}`,
+			wantCurrency: "EUR",
+			wantOriginal: 10,
+			wantFinal:    8,
+			wantItemOrig: 10,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cart, err := MigrateCart([]byte(tt.raw))
+			if err != nil {
+				t.Fatalf("MigrateCart: %v", err)
+			}
+			if cart.SchemaVersion != CurrentSchemaVersion {
+				t.Errorf("schema_version = %d, want %d", cart.SchemaVersion, CurrentSchemaVersion)
+			}
+			if cart.Currency != tt.wantCurrency {
+				t.Errorf("currency = %q, want %q", cart.Currency, tt.wantCurrency)
+			}
+			if cart.OriginalPrice != tt.wantOriginal || cart.FinalPrice != tt.wantFinal {
+				t.Errorf("original/final = %v/%v, want %v/%v", cart.OriginalPrice, cart.FinalPrice, tt.wantOriginal, tt.wantFinal)
+			}
+			if got := cart.Items[0].OriginalSubtotal; got != tt.wantItemOrig {
+				t.Errorf("item original_subtotal = %v, want %v", got, tt.wantItemOrig)
+			}
+		})
+	}
+}
+
+func TestMigrateCartWithoutItems(t *testing.T) {
+	cart, err := MigrateCart([]byte(`{"user_id":"1"}`))
+	if err != nil {
+		t.Fatalf("MigrateCart: %v", err)
+	}
+	if cart.Items == nil {
+		t.Error("items decoded as nil, want an empty list")
+	}
+}
+
+func TestMigrateCartRejectsInvalidJSON(t *testing.T) {
+	if _, err := MigrateCart([]byte(`{"items":`)); err == nil {
+		t.Error("MigrateCart accepted truncated JSON")
+	}
+}
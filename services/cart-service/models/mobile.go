@@ -0,0 +1,47 @@
+package models
+
+// MobileItem is a cart line trimmed to what mobile clients render
+type MobileItem struct {
+	ProductID int     `json:"product_id"`
+	Name      string  `json:"name"`
+	Thumbnail string  `json:"thumbnail,omitempty"`
+	Price     float64 `json:"price"`
+	Qty       int     `json:"qty"`
+	Subtotal  float64 `json:"subtotal"`
+}
+
+// MobileCart is the cart trimmed to its items and totals, leaving out
+// timestamps, notes, metadata and other verbose fields
+type MobileCart struct {
+	Items      []MobileItem `json:"items"`
+	TotalItems int          `json:"total_items"`
+	Subtotal   float64      `json:"subtotal"`
+	Discount   float64      `json:"discount"`
+	Tax        float64      `json:"tax"`
+	Total      float64      `json:"total"`
+	Currency   string       `json:"currency"`
+}
+
+// Mobile trims the cart for mobile clients
+func (c *Cart) Mobile() MobileCart {
+	mobile := MobileCart{
+		Items:      make([]MobileItem, len(c.Items)),
+		TotalItems: c.TotalItems,
+		Subtotal:   c.OriginalPrice,
+		Discount:   RoundPrice(c.OriginalPrice - c.FinalPrice),
+		Tax:        c.Tax,
+		Total:      c.FinalPrice,
+		Currency:   c.Currency,
+	}
+	for i, item := range c.Items {
+		mobile.Items[i] = MobileItem{
+			ProductID: item.ProductID,
+			Name:      item.ProductName,
+			Thumbnail: item.ImageURL,
+			Price:     item.Price,
+			Qty:       item.Quantity,
+			Subtotal:  item.Subtotal,
+		}
+	}
+	return mobile
+}
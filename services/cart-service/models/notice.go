@@ -0,0 +1,13 @@
+package models
+
+// Reasons an item was removed from the cart on the customer's behalf
+const (
+	NoticeReasonDiscontinued = "discontinued"
+)
+
+// ItemNotice tells the customer an item was removed from their cart and why
+type ItemNotice struct {
+	ProductID   int    `json:"product_id"`
+	ProductName string `json:"product_name"`
+	Reason      string `json:"reason"`
+}
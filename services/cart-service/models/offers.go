@@ -0,0 +1,81 @@
+package models
+
+import "sort"
+
+// Offer types
+const (
+	OfferTypeCoupon = "coupon"
+	OfferTypeBundle = "bundle"
+)
+
+// Offer is a promotion the shopper could still take advantage of, with
+// what it would save on the current cart
+type Offer struct {
+	Type             string  `json:"type"`
+	Code             string  `json:"code"`
+	Name             string  `json:"name,omitempty"`
+	ProjectedSavings float64 `json:"projected_savings"`
+	// MissingProductIDs are the products a bundle still needs
+	MissingProductIDs []int `json:"missing_product_ids,omitempty"`
+}
+
+// projectedCopy returns a copy of the cart that can be recalculated without
+// touching the original's items or bundles
+func (c *Cart) projectedCopy() *Cart {
+	projected := *c
+	projected.Items = append([]CartItem(nil), c.Items...)
+	projected.Bundles = append([]Bundle(nil), c.Bundles...)
+	return &projected
+}
+
+// CouponOffer projects the savings from applying coupon in place of any
+// current one. Returns false if the coupon can't be used on the cart.
+func (c *Cart) CouponOffer(coupon Coupon) (Offer, bool) {
+	if len(coupon.Check(c)) > 0 {
+		return Offer{}, false
+	}
+
+	projected := c.projectedCopy()
+	projected.Coupon = &coupon
+	projected.CalculateTotals()
+
+	savings := RoundPrice(c.FinalPrice - projected.FinalPrice)
+	if savings <= 0 {
+		return Offer{}, false
+	}
+	return Offer{Type: OfferTypeCoupon, Code: coupon.Code, ProjectedSavings: savings}, true
+}
+
+// BundleOffer describes a bundle the cart has started but not completed.
+// The projected savings are priced on the bundle products already in the
+// cart, so they are a lower bound for percentage bundles. Returns false if
+// the cart has none or all of the bundle's products.
+func (c *Cart) BundleOffer(b Bundle) (Offer, bool) {
+	var subtotal float64
+	missing := []int{}
+	for _, productID := range b.ProductIDs {
+		if i := c.FindItem(productID); i != -1 {
+			subtotal += c.Items[i].Subtotal
+		} else {
+			missing = append(missing, productID)
+		}
+	}
+	if len(missing) == 0 || len(missing) == len(b.ProductIDs) {
+		return Offer{}, false
+	}
+
+	return Offer{
+		Type:              OfferTypeBundle,
+		Code:              b.ID,
+		Name:              b.Name,
+		ProjectedSavings:  RoundPrice(b.DiscountAmount + subtotal*b.DiscountPercent/100),
+		MissingProductIDs: missing,
+	}, true
+}
+
+// SortOffers orders offers by projected savings, largest first
+func SortOffers(offers []Offer) {
+	sort.SliceStable(offers, func(i, j int) bool {
+		return offers[i].ProjectedSavings > offers[j].ProjectedSavings
+	})
+}
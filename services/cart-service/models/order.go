@@ -0,0 +1,134 @@
+package models
+
+import (
+	"strconv"
+	"strings"
+)
+
+// OrderLineItem is a cart line in order-service's schema
+type OrderLineItem struct {
+	ProductID int     `json:"product_id"`
+	Name      string  `json:"name"`
+	Quantity  int     `json:"quantity"`
+	UnitPrice float64 `json:"unit_price"`
+	Discount  float64 `json:"discount"`
+	Total     float64 `json:"total"`
+
+	RequestedDeliveryDate string `json:"requested_delivery_date,omitempty"`
+
+	// Recurrence for subscribe-and-save lines
+	Interval string `json:"interval,omitempty"`
+}
+
+// OrderDiscount is a cart-level discount in order-service's schema
+type OrderDiscount struct {
+	Type   string  `json:"type"`
+	Code   string  `json:"code,omitempty"`
+	Amount float64 `json:"amount"`
+}
+
+// OrderTotals are the order amounts in order-service's schema
+type OrderTotals struct {
+	Subtotal float64 `json:"subtotal"`
+	Discount float64 `json:"discount"`
+	Tax      float64 `json:"tax"`
+	Shipping float64 `json:"shipping"`
+	GiftWrap float64 `json:"gift_wrap,omitempty"`
+	Handling float64 `json:"handling,omitempty"`
+	Donation float64 `json:"donation,omitempty"`
+	Total    float64 `json:"total"`
+}
+
+// OrderPayload is the cart reshaped for hand-off to order-service
+type OrderPayload struct {
+	UserID          string          `json:"user_id"`
+	Currency        string          `json:"currency"`
+	LineItems       []OrderLineItem `json:"line_items"`
+	Discounts       []OrderDiscount `json:"discounts"`
+	ShippingAddress *Address        `json:"shipping_address"`
+	Totals          OrderTotals     `json:"totals"`
+}
+
+// IncompleteCartError lists what a cart is missing before it can become
+// an order
+type IncompleteCartError struct {
+	Missing []string
+}
+
+func (e *IncompleteCartError) Error() string {
+	return "cart is incomplete: missing " + strings.Join(e.Missing, ", ")
+}
+
+// OrderPayload maps the cart and its checkout total onto order-service's
+// schema, returning an IncompleteCartError if the cart cannot be ordered
+func (c *Cart) OrderPayload(checkout CheckoutTotal) (*OrderPayload, error) {
+	var missing []string
+	if len(c.Items) == 0 {
+		missing = append(missing, "items")
+	}
+	if c.ShippingAddress == nil {
+		missing = append(missing, "shipping_address")
+	}
+	// Pending items have no confirmed price and discontinued ones can't
+	// be fulfilled, so neither can be ordered
+	if c.HasPendingItems() {
+		missing = append(missing, "pending_items")
+	}
+	if c.HasDiscontinuedItems() {
+		missing = append(missing, "discontinued_items")
+	}
+	if len(missing) > 0 {
+		return nil, &IncompleteCartError{Missing: missing}
+	}
+
+	payload := &OrderPayload{
+		UserID:          c.UserID,
+		Currency:        c.Currency,
+		LineItems:       make([]OrderLineItem, len(c.Items)),
+		Discounts:       []OrderDiscount{},
+		ShippingAddress: c.ShippingAddress,
+	}
+
+	for i, item := range c.Items {
+		payload.LineItems[i] = OrderLineItem{
+			ProductID: item.ProductID,
+			Name:      item.ProductName,
+			Quantity:  item.Quantity,
+			UnitPrice: item.Price,
+			Discount:  RoundPrice(item.OriginalSubtotal - item.Subtotal),
+			Total:     item.Subtotal,
+
+			RequestedDeliveryDate: item.RequestedDeliveryDate,
+			Interval:              item.Interval,
+		}
+	}
+
+	for _, b := range c.Bundles {
+		payload.Discounts = append(payload.Discounts, OrderDiscount{Type: "bundle", Code: b.ID, Amount: b.AppliedDiscount})
+	}
+	if c.Coupon != nil && c.CouponDiscount > 0 {
+		payload.Discounts = append(payload.Discounts, OrderDiscount{Type: "coupon", Code: c.Coupon.Code, Amount: c.CouponDiscount})
+	}
+	if c.LoyaltyDiscount > 0 {
+		payload.Discounts = append(payload.Discounts, OrderDiscount{Type: "loyalty", Code: c.LoyaltyTier, Amount: c.LoyaltyDiscount})
+	}
+	if c.MemberDiscount > 0 {
+		payload.Discounts = append(payload.Discounts, OrderDiscount{Type: "member", Code: c.MemberRole, Amount: c.MemberDiscount})
+	}
+	if c.PointsDiscount > 0 {
+		payload.Discounts = append(payload.Discounts, OrderDiscount{Type: "points", Code: strconv.Itoa(c.PointsRedemption.Points), Amount: c.PointsDiscount})
+	}
+
+	payload.Totals = OrderTotals{
+		Subtotal: checkout.Subtotal,
+		Discount: RoundPrice(checkout.ItemDiscounts + checkout.BundleDiscounts + checkout.CouponDiscount + checkout.MemberDiscount + checkout.LoyaltyDiscount + checkout.PointsDiscount),
+		Tax:      checkout.Tax,
+		Shipping: checkout.Shipping,
+		GiftWrap: checkout.GiftWrap,
+		Handling: checkout.Handling,
+		Donation: checkout.Donation,
+		Total:    checkout.AmountDue,
+	}
+
+	return payload, nil
+}
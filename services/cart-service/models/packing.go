@@ -0,0 +1,68 @@
+package models
+
+import "sort"
+
+// BinLocation is where a product is stored in the warehouse
+type BinLocation struct {
+	Location string `json:"location"`
+	Bin      string `json:"bin"`
+}
+
+// PackingLine is one product to pick
+type PackingLine struct {
+	ProductID int    `json:"product_id"`
+	SKU       string `json:"sku"`
+	Name      string `json:"name"`
+	Quantity  int    `json:"quantity"`
+}
+
+// PackingBin is everything to pick from one bin
+type PackingBin struct {
+	BinLocation
+	Lines []PackingLine `json:"lines"`
+}
+
+// PackingSlip lists a cart's items by bin in picking order
+type PackingSlip struct {
+	Bins       []PackingBin `json:"bins"`
+	TotalItems int          `json:"total_items"`
+}
+
+// PackingSlip groups the cart's items by the bin each product is stored
+// in, ordered by location and bin so a picker walks the warehouse once.
+// Products without a known bin are listed last.
+func (c *Cart) PackingSlip(locations map[int]BinLocation) PackingSlip {
+	index := map[BinLocation]int{}
+	slip := PackingSlip{Bins: []PackingBin{}}
+	for _, item := range c.Items {
+		location := locations[item.ProductID]
+		i, ok := index[location]
+		if !ok {
+			i = len(slip.Bins)
+			index[location] = i
+			slip.Bins = append(slip.Bins, PackingBin{BinLocation: location, Lines: []PackingLine{}})
+		}
+		slip.Bins[i].Lines = append(slip.Bins[i].Lines, PackingLine{
+			ProductID: item.ProductID,
+			SKU:       item.SKU,
+			Name:      item.ProductName,
+			Quantity:  item.Quantity,
+		})
+		slip.TotalItems += item.Quantity
+	}
+
+	sort.Slice(slip.Bins, func(i, j int) bool {
+		a, b := slip.Bins[i].BinLocation, slip.Bins[j].BinLocation
+		if (a.Bin == "") != (b.Bin == "") {
+			return b.Bin == ""
+		}
+		if a.Location != b.Location {
+			return a.Location < b.Location
+		}
+		return a.Bin < b.Bin
+	})
+	for _, bin := range slip.Bins {
+		sort.Slice(bin.Lines, func(i, j int) bool { return bin.Lines[i].SKU < bin.Lines[j].SKU })
+	}
+	return slip
+}
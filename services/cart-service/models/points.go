@@ -0,0 +1,31 @@
+package models
+
+// PointsRedemption is loyalty points redeemed against the cart, at the
+// point value in effect when they were redeemed
+type PointsRedemption struct {
+	Points     int     `json:"points"`
+	PointValue float64 `json:"point_value"`
+}
+
+// RedeemPointsRequest represents the request to redeem loyalty points
+type RedeemPointsRequest struct {
+	Points int `json:"points" binding:"required,gt=0"`
+}
+
+// Value returns what the redeemed points are worth
+func (r *PointsRedemption) Value() float64 {
+	if r == nil {
+		return 0
+	}
+	return RoundPrice(float64(r.Points) * r.PointValue)
+}
+
+// pointsDiscount returns the discount from redeemed points, which never
+// takes the final price below zero
+func (c *Cart) pointsDiscount() float64 {
+	discount := c.PointsRedemption.Value()
+	if discount > c.FinalPrice {
+		discount = c.FinalPrice
+	}
+	return discount
+}
@@ -0,0 +1,18 @@
+package models
+
+// Quote is a cart snapshot with its prices fixed, shared with a buyer in
+// B2B sales. Quotes are read-only once created.
+type Quote struct {
+	ID        string        `json:"id"`
+	CreatedBy string        `json:"created_by"`
+	CreatedAt string        `json:"created_at"`
+	ExpiresAt string        `json:"expires_at"`
+	Cart      *Cart         `json:"cart"`
+	Checkout  CheckoutTotal `json:"checkout"`
+}
+
+// CreateQuoteRequest represents the request to create a quote. If Email is
+// given the quote link is emailed to it.
+type CreateQuoteRequest struct {
+	Email string `json:"email" binding:"omitempty,email"`
+}
@@ -0,0 +1,66 @@
+package models
+
+import "sort"
+
+// ReorderLine is a product whose quantity differs between a past order and
+// the cart. A quantity of 0 means the product is absent on that side.
+type ReorderLine struct {
+	ProductID     int    `json:"product_id"`
+	Name          string `json:"name"`
+	OrderQuantity int    `json:"order_quantity"`
+	CartQuantity  int    `json:"cart_quantity"`
+}
+
+// ReorderDiff is what changed between a past order and the cart: products
+// added to the cart, removed from it, and kept with a different quantity
+type ReorderDiff struct {
+	Added           []ReorderLine `json:"added"`
+	Removed         []ReorderLine `json:"removed"`
+	QuantityChanged []ReorderLine `json:"quantity_changed"`
+	Unchanged       int           `json:"unchanged"`
+}
+
+// ReorderDiff compares the cart against a past order's line items
+func (c *Cart) ReorderDiff(order *OrderPayload) ReorderDiff {
+	lines := map[int]*ReorderLine{}
+	line := func(productID int, name string) *ReorderLine {
+		l, ok := lines[productID]
+		if !ok {
+			l = &ReorderLine{ProductID: productID, Name: name}
+			lines[productID] = l
+		}
+		return l
+	}
+	for _, item := range order.LineItems {
+		line(item.ProductID, item.Name).OrderQuantity += item.Quantity
+	}
+	for _, item := range c.Items {
+		// Prefer the cart's current product name
+		l := line(item.ProductID, item.ProductName)
+		l.Name = item.ProductName
+		l.CartQuantity += item.Quantity
+	}
+
+	diff := ReorderDiff{
+		Added:           []ReorderLine{},
+		Removed:         []ReorderLine{},
+		QuantityChanged: []ReorderLine{},
+	}
+	for _, l := range lines {
+		switch {
+		case l.OrderQuantity == 0:
+			diff.Added = append(diff.Added, *l)
+		case l.CartQuantity == 0:
+			diff.Removed = append(diff.Removed, *l)
+		case l.OrderQuantity != l.CartQuantity:
+			diff.QuantityChanged = append(diff.QuantityChanged, *l)
+		default:
+			diff.Unchanged++
+		}
+	}
+
+	for _, group := range [][]ReorderLine{diff.Added, diff.Removed, diff.QuantityChanged} {
+		sort.Slice(group, func(i, j int) bool { return group[i].ProductID < group[j].ProductID })
+	}
+	return diff
+}
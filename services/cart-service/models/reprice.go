@@ -0,0 +1,39 @@
+package models
+
+// PriceChange records an item whose price moved when the cart was repriced
+type PriceChange struct {
+	ProductID int     `json:"product_id"`
+	OldPrice  float64 `json:"old_price"`
+	NewPrice  float64 `json:"new_price"`
+}
+
+// PriceIncrease flags an item that costs more than when the customer last
+// viewed the cart
+type PriceIncrease struct {
+	ProductID       int     `json:"product_id"`
+	ProductName     string  `json:"product_name"`
+	LastViewedPrice float64 `json:"last_viewed_price"`
+	Price           float64 `json:"price"`
+	Increase        float64 `json:"increase"`
+}
+
+// PriceIncreasesSince compares item prices with the prices last viewed,
+// keyed by product ID. Items not seen before and items that got cheaper
+// or stayed the same are not flagged.
+func (c *Cart) PriceIncreasesSince(lastViewed map[int]float64) []PriceIncrease {
+	increases := []PriceIncrease{}
+	for _, item := range c.Items {
+		viewed, ok := lastViewed[item.ProductID]
+		if !ok || item.Price <= viewed {
+			continue
+		}
+		increases = append(increases, PriceIncrease{
+			ProductID:       item.ProductID,
+			ProductName:     item.ProductName,
+			LastViewedPrice: viewed,
+			Price:           item.Price,
+			Increase:        RoundPrice(item.Price - viewed),
+		})
+	}
+	return increases
+}
@@ -0,0 +1,45 @@
+package models
+
+// DefaultMinimumAge applies to age-restricted products that don't specify
+// their own minimum age
+const DefaultMinimumAge = 18
+
+// RestrictedItem is a cart item that needs age verification
+type RestrictedItem struct {
+	ProductID   int    `json:"product_id"`
+	ProductName string `json:"product_name"`
+	MinimumAge  int    `json:"minimum_age"`
+}
+
+// CartRestrictions summarizes the verification checkout must perform
+type CartRestrictions struct {
+	RequiresAgeVerification bool             `json:"requires_age_verification"`
+	MinimumAge              int              `json:"minimum_age,omitempty"`
+	Items                   []RestrictedItem `json:"items"`
+}
+
+// Restrictions lists the cart's age-restricted items and the highest
+// minimum age among them
+func (c *Cart) Restrictions() CartRestrictions {
+	restrictions := CartRestrictions{Items: []RestrictedItem{}}
+	for _, item := range c.Items {
+		if !item.AgeRestricted {
+			continue
+		}
+
+		minimumAge := item.MinimumAge
+		if minimumAge <= 0 {
+			minimumAge = DefaultMinimumAge
+		}
+		restrictions.Items = append(restrictions.Items, RestrictedItem{
+			ProductID:   item.ProductID,
+			ProductName: item.ProductName,
+			MinimumAge:  minimumAge,
+		})
+		if minimumAge > restrictions.MinimumAge {
+			restrictions.MinimumAge = minimumAge
+		}
+	}
+	restrictions.RequiresAgeVerification = len(restrictions.Items) > 0
+	return restrictions
+}
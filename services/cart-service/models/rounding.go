@@ -0,0 +1,43 @@
+package models
+
+import (
+	"fmt"
+	"math"
+)
+
+// Rounding modes for monetary amounts
+const (
+	// RoundHalfUp rounds halves away from zero (1.005 -> 1.01)
+	RoundHalfUp = "half_up"
+	// RoundHalfEven rounds halves to the nearest even cent, as accounting
+	// does (1.005 -> 1.00, 1.015 -> 1.02)
+	RoundHalfEven = "half_even"
+)
+
+var roundingMode = RoundHalfEven
+
+// SetRoundingMode selects how RoundPrice treats amounts halfway between
+// two cents
+func SetRoundingMode(mode string) error {
+	switch mode {
+	case "", RoundHalfEven:
+		roundingMode = RoundHalfEven
+	case RoundHalfUp:
+		roundingMode = RoundHalfUp
+	default:
+		return fmt.Errorf("unknown rounding mode %q", mode)
+	}
+	return nil
+}
+
+// RoundPrice rounds a monetary amount to cents using the configured mode
+func RoundPrice(amount float64) float64 {
+	// Amounts like 1.005 are stored as 1.00499999..., so settle the
+	// binary representation error before deciding which way a half goes
+	cents := math.Round(amount*100*1e6) / 1e6
+
+	if roundingMode == RoundHalfUp {
+		return math.Round(cents) / 100
+	}
+	return math.RoundToEven(cents) / 100
+}
@@ -0,0 +1,88 @@
+package models
+
+import "testing"
+
+// useRoundingMode switches the rounding mode for the duration of the test
+func useRoundingMode(t *testing.T, mode string) {
+	t.Helper()
+	if err := SetRoundingMode(mode); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { SetRoundingMode(RoundHalfEven) })
+}
+
+func TestRoundPrice(t *testing.T) {
+	tests := []struct {
+		amount   float64
+		halfUp   float64
+		halfEven float64
+	}{
+		{amount: 1.005, halfUp: 1.01, halfEven: 1.00},
+		{amount: 1.015, halfUp: 1.02, halfEven: 1.02},
+		{amount: 2.675, halfUp: 2.68, halfEven: 2.68},
+		{amount: 0.125, halfUp: 0.13, halfEven: 0.12},
+		{amount: 1.004, halfUp: 1.00, halfEven: 1.00},
+		{amount: 1.006, halfUp: 1.01, halfEven: 1.01},
+	}
+
+	for _, mode := range []string{RoundHalfUp, RoundHalfEven} {
+		useRoundingMode(t, mode)
+		for _, tt := range tests {
+			want := tt.halfEven
+			if mode == RoundHalfUp {
+				want = tt.halfUp
+			}
+			if got := RoundPrice(tt.amount); got != want {
+				t.Errorf("%s: RoundPrice(%v) = %v, want %v", mode, tt.amount, got, want)
+			}
+		}
+	}
+}
+
+func TestRoundingAppliedToTotals(t *testing.T) {
+	tests := []struct {
+		mode      string
+		wantTotal float64
+		wantTax   float64
+	}{
+		{mode: RoundHalfUp, wantTotal: 10.06, wantTax: 1.01},
+		{mode: RoundHalfEven, wantTotal: 10.04, wantTax: 1.00},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			useRoundingMode(t, tt.mode)
+			if err := SetTaxRates(0.1, ""); err != nil {
+				t.Fatal(err)
+			}
+			t.Cleanup(func() { SetTaxRates(0, "") })
+
+			// 0.335 x 3 and 9.045 x 1 both land on half a cent
+			cart := NewCart("1")
+			cart.Items = []CartItem{
+				{ProductID: 1, Price: 0.335, Quantity: 3},
+				{ProductID: 2, Price: 9.045, Quantity: 1},
+			}
+			cart.CalculateTotals()
+			if cart.TotalPrice != tt.wantTotal {
+				t.Errorf("TotalPrice = %v, want %v", cart.TotalPrice, tt.wantTotal)
+			}
+
+			// 10.05 taxed at 10% is 1.005
+			cart.Items = []CartItem{{ProductID: 1, Price: 10.05, Quantity: 1}}
+			cart.CalculateTotals()
+			if cart.Tax != tt.wantTax {
+				t.Errorf("Tax = %v, want %v", cart.Tax, tt.wantTax)
+			}
+		})
+	}
+}
+
+func TestSetRoundingModeRejectsUnknownMode(t *testing.T) {
+	if err := SetRoundingMode("half_down"); err == nil {
+		t.Error("SetRoundingMode accepted an unknown mode")
+	}
+	if roundingMode != RoundHalfEven {
+		t.Errorf("rounding mode changed to %s", roundingMode)
+	}
+}
@@ -0,0 +1,29 @@
+package models
+
+// SavingsBreakdown itemizes everything a customer saves on their cart
+type SavingsBreakdown struct {
+	ItemPromotions float64 `json:"item_promotions"`
+	Bundles        float64 `json:"bundles"`
+	Coupon         float64 `json:"coupon"`
+	Member         float64 `json:"member"`
+	Loyalty        float64 `json:"loyalty"`
+	Points         float64 `json:"points"`
+	FreeShipping   float64 `json:"free_shipping"`
+	Total          float64 `json:"total"`
+}
+
+// Savings computes the cart's savings breakdown under a shipping policy
+func (c *Cart) Savings(shipping ShippingPolicy) SavingsBreakdown {
+	breakdown := SavingsBreakdown{
+		ItemPromotions: c.ItemSavings,
+		Bundles:        c.BundleDiscount,
+		Coupon:         c.CouponDiscount,
+		Member:         c.MemberDiscount,
+		Loyalty:        c.LoyaltyDiscount,
+		Points:         c.PointsDiscount,
+		FreeShipping:   shipping.FreeShippingValue(c.FinalPrice),
+	}
+	breakdown.Total = RoundPrice(breakdown.ItemPromotions + breakdown.Bundles +
+		breakdown.Coupon + breakdown.Member + breakdown.Loyalty + breakdown.Points + breakdown.FreeShipping)
+	return breakdown
+}
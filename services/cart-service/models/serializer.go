@@ -0,0 +1,67 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/ugorji/go/codec"
+)
+
+// Storage serialization formats
+const (
+	SerializerJSON    = "json"
+	SerializerMsgpack = "msgpack"
+)
+
+var (
+	serializer    = SerializerJSON
+	msgpackHandle = &codec.MsgpackHandle{}
+)
+
+func init() {
+	// Decode msgpack strings as Go strings rather than []byte
+	msgpackHandle.RawToString = true
+	msgpackHandle.WriteExt = true
+}
+
+// SetSerializer selects the format carts are stored in. Carts written in
+// either format can always be read back.
+func SetSerializer(name string) error {
+	switch name {
+	case "", SerializerJSON:
+		serializer = SerializerJSON
+	case SerializerMsgpack:
+		serializer = SerializerMsgpack
+	default:
+		return fmt.Errorf("unknown cart serializer %q", name)
+	}
+	return nil
+}
+
+// Serialize encodes a cart for storage in the configured format
+func Serialize(cart *Cart) ([]byte, error) {
+	if serializer == SerializerMsgpack {
+		var data []byte
+		if err := codec.NewEncoderBytes(&data, msgpackHandle).Encode(cart); err != nil {
+			return nil, err
+		}
+		return data, nil
+	}
+	return json.Marshal(cart)
+}
+
+// Deserialize decodes a stored cart in either format, migrating carts
+// written under older schema versions
+func Deserialize(raw []byte) (*Cart, error) {
+	// JSON carts are objects; msgpack maps never start with '{'
+	if len(raw) > 0 && raw[0] == '{' {
+		return MigrateCart(raw)
+	}
+
+	var cart Cart
+	if err := codec.NewDecoderBytes(raw, msgpackHandle).Decode(&cart); err != nil {
+		return nil, err
+	}
+	migrate(&cart)
+	return &cart, nil
+}
@@ -0,0 +1,102 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+)
+
+// sampleCart returns a cart exercising nested and optional fields
+func sampleCart(items int) *Cart {
+	cart := NewCart("42")
+	cart.Coupon = &Coupon{Code: "SAVE10", Type: CouponTypePercent, Value: 10}
+	cart.Metadata = map[string]string{"channel": "web"}
+	for i := 1; i <= items; i++ {
+		cart.Items = append(cart.Items, CartItem{
+			ProductID:   i,
+			ProductName: "Product",
+			Price:       9.99,
+			Quantity:    2,
+			Dimensions:  &Dimensions{Length: 10, Width: 5, Height: 2},
+			AddedAt:     "2024-01-01T00:00:00Z",
+		})
+	}
+	cart.CalculateTotals()
+	return cart
+}
+
+func TestSerializeRoundTrip(t *testing.T) {
+	t.Cleanup(func() { SetSerializer(SerializerJSON) })
+
+	for _, format := range []string{SerializerJSON, SerializerMsgpack} {
+		t.Run(format, func(t *testing.T) {
+			if err := SetSerializer(format); err != nil {
+				t.Fatalf("SetSerializer: %v", err)
+			}
+			cart := sampleCart(3)
+
+			data, err := Serialize(cart)
+			if err != nil {
+				t.Fatalf("Serialize: %v", err)
+			}
+			if isJSON := data[0] == '{'; isJSON != (format == SerializerJSON) {
+				t.Errorf("stored as JSON = %v for %s", isJSON, format)
+			}
+
+			decoded, err := Deserialize(data)
+			if err != nil {
+				t.Fatalf("Deserialize: %v", err)
+			}
+			decoded.loaded, cart.loaded = nil, nil
+			if !reflect.DeepEqual(decoded, cart) {
+				t.Errorf("round trip changed the cart:\n got %+v\nwant %+v", decoded, cart)
+			}
+		})
+	}
+}
+
+func TestDeserializeReadsEitherFormat(t *testing.T) {
+	t.Cleanup(func() { SetSerializer(SerializerJSON) })
+
+	SetSerializer(SerializerJSON)
+	jsonData, _ := Serialize(sampleCart(1))
+	SetSerializer(SerializerMsgpack)
+	msgpackData, _ := Serialize(sampleCart(1))
+
+	for name, data := range map[string][]byte{"json": jsonData, "msgpack": msgpackData} {
+		for _, configured := range []string{SerializerJSON, SerializerMsgpack} {
+			SetSerializer(configured)
+			cart, err := Deserialize(data)
+			if err != nil || len(cart.Items) != 1 {
+				t.Errorf("reading %s with %s configured: %v", name, configured, err)
+			}
+		}
+	}
+}
+
+func TestSetSerializerRejectsUnknown(t *testing.T) {
+	t.Cleanup(func() { SetSerializer(SerializerJSON) })
+
+	if err := SetSerializer("xml"); err == nil {
+		t.Error("SetSerializer accepted an unknown format")
+	}
+}
+
+func BenchmarkSerialize(b *testing.B) {
+	b.Cleanup(func() { SetSerializer(SerializerJSON) })
+	cart := sampleCart(50)
+
+	for _, format := range []string{SerializerJSON, SerializerMsgpack} {
+		b.Run(format, func(b *testing.B) {
+			SetSerializer(format)
+			var size int
+			for i := 0; i < b.N; i++ {
+				data, err := Serialize(cart)
+				if err != nil {
+					b.Fatal(err)
+				}
+				size = len(data)
+			}
+			b.ReportMetric(float64(size), "bytes/cart")
+		})
+	}
+}
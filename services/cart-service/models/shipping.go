@@ -0,0 +1,26 @@
+package models
+
+// ShippingPolicy describes flat-rate shipping with a free-shipping threshold
+type ShippingPolicy struct {
+	FlatRate      float64
+	FreeThreshold float64
+}
+
+// Cost returns the shipping charge for an order total
+func (p ShippingPolicy) Cost(total float64) float64 {
+	if total <= 0 {
+		return 0
+	}
+	if p.FreeThreshold > 0 && total >= p.FreeThreshold {
+		return 0
+	}
+	return p.FlatRate
+}
+
+// FreeShippingValue returns how much the customer saves on shipping
+func (p ShippingPolicy) FreeShippingValue(total float64) float64 {
+	if total <= 0 {
+		return 0
+	}
+	return RoundPrice(p.FlatRate - p.Cost(total))
+}
@@ -0,0 +1,14 @@
+package models
+
+// CartSnapshot is a cart as it was when checkout completed
+type CartSnapshot struct {
+	ConvertedAt string `json:"converted_at"`
+	OrderID     string `json:"order_id,omitempty"`
+	Cart        *Cart  `json:"cart"`
+}
+
+// CompleteCheckoutRequest represents the request to complete checkout.
+// The order ID is required when the cart redeems loyalty points.
+type CompleteCheckoutRequest struct {
+	OrderID string `json:"order_id"`
+}
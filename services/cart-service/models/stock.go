@@ -0,0 +1,30 @@
+package models
+
+// StockShortfall is a cart item whose quantity exceeds the stock available
+type StockShortfall struct {
+	ProductID   int    `json:"product_id"`
+	ProductName string `json:"product_name"`
+	Quantity    int    `json:"quantity"`
+	Available   int    `json:"available"`
+	Shortfall   int    `json:"shortfall"`
+}
+
+// StockShortfalls compares the cart's quantities against available stock
+// by product ID. Products missing from available are not checked.
+func (c *Cart) StockShortfalls(available map[int]int) []StockShortfall {
+	shortfalls := []StockShortfall{}
+	for _, item := range c.Items {
+		stock, ok := available[item.ProductID]
+		if !ok || item.Quantity <= stock {
+			continue
+		}
+		shortfalls = append(shortfalls, StockShortfall{
+			ProductID:   item.ProductID,
+			ProductName: item.ProductName,
+			Quantity:    item.Quantity,
+			Available:   stock,
+			Shortfall:   item.Quantity - stock,
+		})
+	}
+	return shortfalls
+}
@@ -0,0 +1,20 @@
+package models
+
+// Recurrence intervals a subscribe-and-save item can be delivered on
+const (
+	IntervalWeekly  = "weekly"
+	IntervalMonthly = "monthly"
+)
+
+// ValidInterval reports whether interval is a supported recurrence
+func ValidInterval(interval string) bool {
+	return interval == IntervalWeekly || interval == IntervalMonthly
+}
+
+// SetSubscription makes the item a recurring purchase on the given
+// interval at the product's subscription discount
+func (i *CartItem) SetSubscription(interval string, discountPercent float64) {
+	i.IsSubscription = true
+	i.Interval = interval
+	i.SubscriptionDiscountPercent = discountPercent
+}
@@ -0,0 +1,31 @@
+package models
+
+// SyncCartRequest carries the anonymous cart a client kept locally, to be
+// merged into the user's server cart on login
+type SyncCartRequest struct {
+	Items []SyncItem `json:"items" binding:"dive"`
+}
+
+// SyncItem is one line of a client-side cart. Prices sent by the client
+// are ignored; the server always prices items itself.
+type SyncItem struct {
+	ProductID int    `json:"product_id" binding:"required"`
+	Quantity  int    `json:"quantity" binding:"required,min=1"`
+	AddedAt   string `json:"added_at"`
+}
+
+// Combined returns the items with duplicate products folded into one line,
+// in the order each product first appears
+func (r *SyncCartRequest) Combined() []SyncItem {
+	combined := []SyncItem{}
+	index := map[int]int{}
+	for _, item := range r.Items {
+		if i, ok := index[item.ProductID]; ok {
+			combined[i].Quantity += item.Quantity
+			continue
+		}
+		index[item.ProductID] = len(combined)
+		combined = append(combined, item)
+	}
+	return combined
+}
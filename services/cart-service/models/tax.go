@@ -0,0 +1,84 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// taxWildcard matches any region or tax category in the tax table
+const taxWildcard = "*"
+
+var (
+	defaultTaxRate float64
+	taxTable       = map[string]float64{}
+)
+
+// SetTaxRates configures sales tax. defaultRate applies when nothing in
+// the table matches. table is a JSON object mapping "region:category" to a
+// rate as a fraction, where region is a country ("US") or country and
+// state ("US-CA") and either side may be "*", e.g.
+// {"US-CA:food": 0, "US-CA:*": 0.0725}.
+func SetTaxRates(defaultRate float64, table string) error {
+	rates := map[string]float64{}
+	if table != "" {
+		if err := json.Unmarshal([]byte(table), &rates); err != nil {
+			return fmt.Errorf("invalid tax table: %v", err)
+		}
+	}
+	defaultTaxRate = defaultRate
+	taxTable = rates
+	return nil
+}
+
+// taxRegions returns the regions an address falls in, most specific first
+func taxRegions(address *Address) []string {
+	if address == nil {
+		return []string{taxWildcard}
+	}
+	country := strings.ToUpper(address.Country)
+	if address.State == "" {
+		return []string{country, taxWildcard}
+	}
+	return []string{country + "-" + strings.ToUpper(address.State), country, taxWildcard}
+}
+
+// TaxRate returns the rate for a tax category shipped to an address,
+// preferring the most specific region and then an exact category match
+func TaxRate(address *Address, category string) float64 {
+	if category == "" {
+		category = taxWildcard
+	}
+	for _, region := range taxRegions(address) {
+		for _, cat := range []string{category, taxWildcard} {
+			if rate, ok := taxTable[region+":"+cat]; ok {
+				return rate
+			}
+		}
+	}
+	return defaultTaxRate
+}
+
+// calculateTax sets each item's tax and the cart's total tax. Items are
+// taxed on their share of the final price, so cart-level discounts reduce
+// the tax proportionally.
+func (c *Cart) calculateTax() float64 {
+	var itemsTotal float64
+	for _, item := range c.Items {
+		itemsTotal += item.Subtotal
+	}
+
+	c.Tax = 0
+	for i := range c.Items {
+		item := &c.Items[i]
+		item.Tax = 0
+		if itemsTotal <= 0 {
+			continue
+		}
+		taxable := item.Subtotal * c.FinalPrice / itemsTotal
+		item.Tax = RoundPrice(taxable * TaxRate(c.ShippingAddress, item.TaxCategory))
+		c.Tax += item.Tax
+	}
+	c.Tax = RoundPrice(c.Tax)
+	return c.Tax
+}
@@ -0,0 +1,90 @@
+package models
+
+import "sort"
+
+// VendorCart is the part of a marketplace cart sold by one vendor. The
+// subtotal includes item-level discounts but not cart-level ones.
+type VendorCart struct {
+	VendorID   string     `json:"vendor_id"`
+	Items      []CartItem `json:"items"`
+	TotalItems int        `json:"total_items"`
+	Subtotal   float64    `json:"subtotal"`
+}
+
+// VendorCart returns the vendor's items and their totals
+func (c *Cart) VendorCart(vendorID string) VendorCart {
+	vendor := VendorCart{VendorID: vendorID, Items: []CartItem{}}
+	for _, item := range c.Items {
+		if item.VendorID == vendorID {
+			vendor.add(item)
+		}
+	}
+	return vendor
+}
+
+// ItemsByVendor groups the cart's items by vendor, ordered by vendor ID.
+// Items without a vendor are grouped under an empty vendor ID.
+func (c *Cart) ItemsByVendor() []VendorCart {
+	index := map[string]int{}
+	vendors := []VendorCart{}
+	for _, item := range c.Items {
+		i, ok := index[item.VendorID]
+		if !ok {
+			i = len(vendors)
+			index[item.VendorID] = i
+			vendors = append(vendors, VendorCart{VendorID: item.VendorID, Items: []CartItem{}})
+		}
+		vendors[i].add(item)
+	}
+
+	sort.Slice(vendors, func(i, j int) bool {
+		return vendors[i].VendorID < vendors[j].VendorID
+	})
+	return vendors
+}
+
+// add appends an item to the vendor's share and updates its totals
+func (v *VendorCart) add(item CartItem) {
+	v.Items = append(v.Items, item)
+	v.TotalItems += item.Quantity
+	v.Subtotal = RoundPrice(v.Subtotal + item.Subtotal)
+}
+
+// VendorShortfall is a vendor whose share of the cart is below the
+// vendor's minimum order value
+type VendorShortfall struct {
+	VendorID  string  `json:"vendor_id"`
+	Subtotal  float64 `json:"subtotal"`
+	Minimum   float64 `json:"minimum"`
+	Shortfall float64 `json:"shortfall"`
+}
+
+// VendorIDs returns the distinct vendors in the cart, in vendor ID order
+func (c *Cart) VendorIDs() []string {
+	ids := []string{}
+	for _, vendor := range c.ItemsByVendor() {
+		if vendor.VendorID != "" {
+			ids = append(ids, vendor.VendorID)
+		}
+	}
+	return ids
+}
+
+// VendorsBelowMinimum compares each vendor's subtotal against its minimum
+// order value and returns those that fall short
+func (c *Cart) VendorsBelowMinimum(minimums map[string]float64) []VendorShortfall {
+	shortfalls := []VendorShortfall{}
+	for _, vendor := range c.ItemsByVendor() {
+		minimum := minimums[vendor.VendorID]
+		if minimum <= 0 || vendor.Subtotal >= minimum {
+			continue
+		}
+		shortfalls = append(shortfalls, VendorShortfall{
+			VendorID:  vendor.VendorID,
+			Subtotal:  vendor.Subtotal,
+			Minimum:   minimum,
+			Shortfall: RoundPrice(minimum - vendor.Subtotal),
+		})
+	}
+	return shortfalls
+}
@@ -0,0 +1,99 @@
+package models
+
+import "reflect"
+
+// maxRemovalLog bounds how many removals a cart remembers for delta sync
+const maxRemovalLog = 50
+
+// ItemRemoval records that a product left the cart at a given version
+type ItemRemoval struct {
+	ProductID int `json:"product_id"`
+	Version   int `json:"version"`
+}
+
+// CartDelta lists the item changes since a client's known cart version
+type CartDelta struct {
+	Version           int        `json:"version"`
+	SinceVersion      int        `json:"since_version"`
+	ChangedItems      []CartItem `json:"changed_items"`
+	RemovedProductIDs []int      `json:"removed_product_ids"`
+	TotalItems        int        `json:"total_items"`
+	TotalPrice        float64    `json:"total_price"`
+	FinalPrice        float64    `json:"final_price"`
+}
+
+// snapshot remembers the items as loaded so changes can be detected on save
+func (c *Cart) snapshot() {
+	c.loaded = make(map[int]CartItem, len(c.Items))
+	for _, item := range c.Items {
+		c.loaded[item.ProductID] = item
+	}
+}
+
+// CommitVersion bumps the cart version before it is saved, stamping items
+// added or changed since the cart was loaded and logging removed ones
+func (c *Cart) CommitVersion() {
+	c.Version++
+
+	current := make(map[int]bool, len(c.Items))
+	for i := range c.Items {
+		item := &c.Items[i]
+		current[item.ProductID] = true
+
+		prev, existed := c.loaded[item.ProductID]
+		if existed {
+			// Compare ignoring the version stamp itself
+			prev.Version = item.Version
+		}
+		if !existed || !reflect.DeepEqual(prev, *item) {
+			item.Version = c.Version
+		}
+	}
+
+	for productID := range c.loaded {
+		if !current[productID] {
+			c.RemovedItems = append(c.RemovedItems, ItemRemoval{ProductID: productID, Version: c.Version})
+		}
+	}
+
+	// Drop the oldest removals; deltas from before them need a full refresh
+	if excess := len(c.RemovedItems) - maxRemovalLog; excess > 0 {
+		c.LogStartVersion = c.RemovedItems[excess-1].Version
+		c.RemovedItems = c.RemovedItems[excess:]
+	}
+
+	c.snapshot()
+}
+
+// DeltaSince returns the changes made after the given version. The second
+// result is false when the change log no longer reaches back that far and
+// the client must take a full snapshot instead.
+func (c *Cart) DeltaSince(version int) (CartDelta, bool) {
+	if version < c.LogStartVersion || version > c.Version {
+		return CartDelta{}, false
+	}
+
+	delta := CartDelta{
+		Version:           c.Version,
+		SinceVersion:      version,
+		ChangedItems:      []CartItem{},
+		RemovedProductIDs: []int{},
+		TotalItems:        c.TotalItems,
+		TotalPrice:        c.TotalPrice,
+		FinalPrice:        c.FinalPrice,
+	}
+
+	for _, item := range c.Items {
+		if item.Version > version {
+			delta.ChangedItems = append(delta.ChangedItems, item)
+		}
+	}
+	for _, removal := range c.RemovedItems {
+		// Skip products that were removed and later re-added
+		if removal.Version > version && c.FindItem(removal.ProductID) == -1 {
+			delta.RemovedProductIDs = append(delta.RemovedProductIDs, removal.ProductID)
+		}
+	}
+
+	return delta, true
+}
@@ -0,0 +1,29 @@
+package utils
+
+import "context"
+
+type actorKey struct{}
+
+type roleKey struct{}
+
+// WithActor records the authenticated user making a request
+func WithActor(ctx context.Context, userID string) context.Context {
+	return context.WithValue(ctx, actorKey{}, userID)
+}
+
+// Actor returns the authenticated user making a request, or "" if none
+func Actor(ctx context.Context) string {
+	userID, _ := ctx.Value(actorKey{}).(string)
+	return userID
+}
+
+// WithRole records the authenticated user's role claim
+func WithRole(ctx context.Context, role string) context.Context {
+	return context.WithValue(ctx, roleKey{}, role)
+}
+
+// Role returns the authenticated user's role, or "" if none
+func Role(ctx context.Context) string {
+	role, _ := ctx.Value(roleKey{}).(string)
+	return role
+}
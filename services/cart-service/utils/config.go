@@ -0,0 +1,36 @@
+package utils
+
+import (
+	"os"
+	"strconv"
+)
+
+// GetEnvBool reads a boolean environment variable, falling back to the
+// default when it is unset or not a valid boolean
+func GetEnvBool(key string, fallback bool) bool {
+	value, err := strconv.ParseBool(os.Getenv(key))
+	if err != nil {
+		return fallback
+	}
+	return value
+}
+
+// GetEnvInt reads an integer environment variable, falling back to the
+// default when it is unset or not a valid integer
+func GetEnvInt(key string, fallback int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil {
+		return fallback
+	}
+	return value
+}
+
+// GetEnvFloat reads a float environment variable, falling back to the
+// default when it is unset or not a valid number
+func GetEnvFloat(key string, fallback float64) float64 {
+	value, err := strconv.ParseFloat(os.Getenv(key), 64)
+	if err != nil {
+		return fallback
+	}
+	return value
+}
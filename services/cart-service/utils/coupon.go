@@ -0,0 +1,111 @@
+package utils
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// claimCouponScript counts one more redemption on the counter in KEYS[1]
+// unless it has reached the limit in ARGV[1]. Returns 1 if the use was
+// claimed and 0 if the coupon is used up.
+var claimCouponScript = redis.NewScript(`
+local uses = tonumber(redis.call("GET", KEYS[1]) or "0")
+if uses >= tonumber(ARGV[1]) then
+	return 0
+end
+redis.call("INCR", KEYS[1])
+return 1
+`)
+
+// releaseCouponScript gives back one redemption on the counter in KEYS[1],
+// never taking it below zero
+var releaseCouponScript = redis.NewScript(`
+local uses = tonumber(redis.call("GET", KEYS[1]) or "0")
+if uses <= 0 then
+	return 0
+end
+return redis.call("DECR", KEYS[1])
+`)
+
+// ClaimCouponUse atomically takes one of a coupon's limit uses, counted at
+// usesKey. Returns false if every use has been taken, so concurrent
+// redemptions can never exceed the limit.
+func ClaimCouponUse(ctx context.Context, usesKey string, limit int) (bool, error) {
+	claimed, err := claimCouponScript.Run(ctx, RedisClient, []string{usesKey}, limit).Int()
+	if err != nil {
+		return false, err
+	}
+	return claimed == 1, nil
+}
+
+// ReleaseCouponUse returns a previously claimed coupon use
+func ReleaseCouponUse(ctx context.Context, usesKey string) error {
+	return releaseCouponScript.Run(ctx, RedisClient, []string{usesKey}).Err()
+}
+
+// couponClaimsKey builds the Redis sorted set of limited coupon uses held
+// by carts, scored by when each cart expires
+func couponClaimsKey() string {
+	return Key("coupon_claims")
+}
+
+// CouponClaim identifies a limited coupon use held by a cart
+type CouponClaim struct {
+	CartKey string
+	Code    string
+}
+
+// member encodes the claim as its sorted set member, "code|cartKey"
+func (c CouponClaim) member() string {
+	return strings.ToUpper(c.Code) + "|" + c.CartKey
+}
+
+// TrackCouponClaim schedules a cart's coupon use for release at until,
+// when the cart expires. pipe lets the claim be written with the cart.
+func TrackCouponClaim(ctx context.Context, pipe redis.Cmdable, claim CouponClaim, until time.Time) error {
+	return pipe.ZAdd(ctx, couponClaimsKey(), &redis.Z{Score: float64(until.Unix()), Member: claim.member()}).Err()
+}
+
+// UntrackCouponClaim cancels a claim's scheduled release. Returns false if
+// the claim was already taken by the sweep, which gives the use back.
+func UntrackCouponClaim(ctx context.Context, claim CouponClaim) (bool, error) {
+	removed, err := RedisClient.ZRem(ctx, couponClaimsKey(), claim.member()).Result()
+	return removed > 0, err
+}
+
+// ClaimExpiredCouponClaims removes up to limit claims whose carts expired
+// by now and returns them. Each claim is taken by one caller only, so
+// several instances can sweep at once.
+func ClaimExpiredCouponClaims(ctx context.Context, now time.Time, limit int64) ([]CouponClaim, error) {
+	members, err := RedisClient.ZRangeByScore(ctx, couponClaimsKey(), &redis.ZRangeBy{
+		Min:   "-inf",
+		Max:   strconv.FormatInt(now.Unix(), 10),
+		Count: limit,
+	}).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	claims := []CouponClaim{}
+	for _, member := range members {
+		removed, err := RedisClient.ZRem(ctx, couponClaimsKey(), member).Result()
+		if err != nil {
+			return claims, err
+		}
+		if removed == 0 {
+			// Taken by another instance
+			continue
+		}
+
+		parts := strings.SplitN(member, "|", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		claims = append(claims, CouponClaim{Code: parts[0], CartKey: parts[1]})
+	}
+	return claims, nil
+}
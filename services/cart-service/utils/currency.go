@@ -0,0 +1,99 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+type preferredCurrencyKey struct{}
+
+// WithPreferredCurrency records the preferred currency claimed in the
+// user's token
+func WithPreferredCurrency(ctx context.Context, currency string) context.Context {
+	return context.WithValue(ctx, preferredCurrencyKey{}, currency)
+}
+
+// preferredCurrencyCacheKey builds the Redis key caching a user's
+// preferred currency
+func preferredCurrencyCacheKey(userID string) string {
+	return Key("preferred_currency", UserKey(userID))
+}
+
+// PreferredCurrency returns the currency the user prefers to see prices
+// in, e.g. "EUR", or "" if they have no preference. A currency claimed in
+// the token wins; otherwise the user's profile is fetched from
+// USER_SERVICE_URL on their behalf and the answer cached for
+// PREFERRED_CURRENCY_CACHE_SECONDS. Lookup failures are logged and treated
+// as no preference.
+func PreferredCurrency(ctx context.Context, userID, authorization string) string {
+	if currency, ok := ctx.Value(preferredCurrencyKey{}).(string); ok {
+		return strings.ToUpper(currency)
+	}
+	baseURL := os.Getenv("USER_SERVICE_URL")
+	if baseURL == "" || userID == "" {
+		return ""
+	}
+
+	cacheKey := preferredCurrencyCacheKey(userID)
+	currency, err := RedisClient.Get(ctx, cacheKey).Result()
+	if err == nil {
+		return currency
+	}
+	if err != redis.Nil {
+		log.Printf("Failed to read cached preferred currency: %v", err)
+	}
+
+	currency, err = fetchPreferredCurrency(ctx, baseURL, authorization)
+	if err != nil {
+		log.Printf("Failed to fetch preferred currency for user %s: %v", userID, err)
+		return ""
+	}
+
+	ttl := time.Duration(GetEnvInt("PREFERRED_CURRENCY_CACHE_SECONDS", 900)) * time.Second
+	if err := RedisClient.Set(ctx, cacheKey, currency, ttl).Err(); err != nil {
+		log.Printf("Failed to cache preferred currency: %v", err)
+	}
+	return currency
+}
+
+// fetchPreferredCurrency reads the preferred currency from the user's
+// profile in user-service
+func fetchPreferredCurrency(ctx context.Context, baseURL, authorization string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, baseURL+"/api/auth/me", nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Authorization", authorization)
+
+	start := time.Now()
+	resp, err := HTTPClient.Do(req)
+	RecordTiming(ctx, "user_service", start)
+	if err != nil {
+		return "", fmt.Errorf("failed to reach user-service: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		io.Copy(io.Discard, resp.Body)
+		return "", fmt.Errorf("user-service returned status %d", resp.StatusCode)
+	}
+
+	var body struct {
+		User struct {
+			PreferredCurrency string `json:"preferred_currency"`
+		} `json:"user"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", fmt.Errorf("failed to decode user profile: %v", err)
+	}
+	return strings.ToUpper(body.User.PreferredCurrency), nil
+}
@@ -0,0 +1,39 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+// Event is a cart lifecycle notification published for other services
+type Event struct {
+	Type       string      `json:"type"`
+	UserID     string      `json:"user_id"`
+	OccurredAt string      `json:"occurred_at"`
+	Data       interface{} `json:"data,omitempty"`
+}
+
+// EventsChannel returns the Redis pub/sub channel a user's cart events go
+// to. Other services can receive every user's events by pattern
+// subscribing to EventsChannel("*").
+func EventsChannel(userID string) string {
+	if userID == "*" {
+		return Key("events", "*")
+	}
+	return Key("events", UserKey(userID))
+}
+
+// PublishEvent announces a cart event on the user's events channel
+func PublishEvent(ctx context.Context, eventType, userID string, data interface{}) error {
+	payload, err := json.Marshal(Event{
+		Type:       eventType,
+		UserID:     userID,
+		OccurredAt: time.Now().Format(time.RFC3339),
+		Data:       data,
+	})
+	if err != nil {
+		return err
+	}
+	return RedisClient.Publish(ctx, EventsChannel(userID), payload).Err()
+}
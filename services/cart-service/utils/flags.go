@@ -0,0 +1,176 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// Feature flags, set by environment variables. Overridable ones may also
+// be toggled per request.
+const (
+	FlagRepriceOnRead          = "reprice_on_read"
+	FlagDegradedAdd            = "degraded_add"
+	FlagAutoRemoveDiscontinued = "auto_remove_discontinued"
+	FlagZeroQuantityRemoves    = "zero_qty_removes"
+	FlagPriceDisplayByRegion   = "price_display_by_region"
+)
+
+// featureFlag is a behavior, the environment variable that sets it and
+// whether requests may toggle it
+type featureFlag struct {
+	env         string
+	fallback    bool
+	overridable bool
+}
+
+// featureFlags maps each flag to its environment variable; the overridable
+// ones form the allowlist of flags requests may toggle. Behaviors that
+// change what a customer pays or what is in their cart, like repricing,
+// degraded adds and removing discontinued items, stay under operator
+// control.
+var featureFlags = map[string]featureFlag{
+	FlagRepriceOnRead:          {env: "CART_REPRICE_ON_READ"},
+	FlagDegradedAdd:            {env: "ALLOW_DEGRADED_ADD"},
+	FlagAutoRemoveDiscontinued: {env: "AUTO_REMOVE_DISCONTINUED"},
+	FlagZeroQuantityRemoves:    {env: "CART_ZERO_QTY_REMOVES", fallback: true, overridable: true},
+	FlagPriceDisplayByRegion:   {env: "PRICE_DISPLAY_BY_REGION", overridable: true},
+}
+
+// overridable reports whether requests may toggle a flag
+func overridable(name string) bool {
+	return featureFlags[name].overridable
+}
+
+type featureFlagsKey struct{}
+
+// WithFeatureFlags records flags toggled for a request
+func WithFeatureFlags(ctx context.Context, flags map[string]bool) context.Context {
+	return context.WithValue(ctx, featureFlagsKey{}, flags)
+}
+
+// FeatureEnabled reports whether a flag is on for the request: as toggled
+// for it if the flag is overridable, else as its environment variable sets
+// it
+func FeatureEnabled(ctx context.Context, name string) bool {
+	flag := featureFlags[name]
+	if flags, ok := ctx.Value(featureFlagsKey{}).(map[string]bool); ok && flag.overridable {
+		if enabled, ok := flags[name]; ok {
+			return enabled
+		}
+	}
+	return GetEnvBool(flag.env, flag.fallback)
+}
+
+// ParseFeatureFlags reads overridable flags from a header value such as
+// "zero_qty_removes,price_display_by_region=false". A bare name turns the
+// flag on. Other flags and malformed values are ignored.
+func ParseFeatureFlags(header string) map[string]bool {
+	flags := map[string]bool{}
+	for _, entry := range strings.Split(header, ",") {
+		name, value, hasValue := strings.Cut(strings.TrimSpace(entry), "=")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if !overridable(name) {
+			continue
+		}
+		enabled := true
+		if hasValue {
+			var err error
+			if enabled, err = strconv.ParseBool(strings.TrimSpace(value)); err != nil {
+				continue
+			}
+		}
+		flags[name] = enabled
+	}
+	return flags
+}
+
+// featureFlagsCacheKey builds the Redis key caching a user's flags from
+// the flags service
+func featureFlagsCacheKey(userID string) string {
+	return Key("feature_flags", UserKey(userID))
+}
+
+// FetchFeatureFlags returns the overridable flags the flags service at
+// FEATURE_FLAGS_SERVICE_URL sets for the user, cached for
+// FEATURE_FLAGS_CACHE_SECONDS. Returns nil when no flags service is
+// configured; lookup failures are logged and treated as no flags.
+func FetchFeatureFlags(ctx context.Context, userID string) map[string]bool {
+	baseURL := os.Getenv("FEATURE_FLAGS_SERVICE_URL")
+	if baseURL == "" || userID == "" {
+		return nil
+	}
+
+	cacheKey := featureFlagsCacheKey(userID)
+	cached, err := RedisClient.Get(ctx, cacheKey).Result()
+	if err == nil {
+		var flags map[string]bool
+		if json.Unmarshal([]byte(cached), &flags) == nil {
+			return flags
+		}
+	} else if err != redis.Nil {
+		log.Printf("Failed to read cached feature flags: %v", err)
+	}
+
+	flags, err := fetchFeatureFlags(ctx, baseURL, userID)
+	if err != nil {
+		log.Printf("Failed to fetch feature flags for user %s: %v", userID, err)
+		return nil
+	}
+
+	ttl := time.Duration(GetEnvInt("FEATURE_FLAGS_CACHE_SECONDS", 60)) * time.Second
+	if data, err := json.Marshal(flags); err == nil {
+		if err := RedisClient.Set(ctx, cacheKey, data, ttl).Err(); err != nil {
+			log.Printf("Failed to cache feature flags: %v", err)
+		}
+	}
+	return flags
+}
+
+// fetchFeatureFlags asks the flags service for the user's flags, keeping
+// only overridable ones
+func fetchFeatureFlags(ctx context.Context, baseURL, userID string) (map[string]bool, error) {
+	query := url.Values{"user_id": {userID}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, baseURL+"/api/flags?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	resp, err := HTTPClient.Do(req)
+	RecordTiming(ctx, "flags_service", start)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach flags service: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		io.Copy(io.Discard, resp.Body)
+		return nil, fmt.Errorf("flags service returned status %d", resp.StatusCode)
+	}
+
+	var body struct {
+		Flags map[string]bool `json:"flags"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("failed to decode feature flags: %v", err)
+	}
+
+	flags := map[string]bool{}
+	for name, enabled := range body.Flags {
+		if overridable(name) {
+			flags[name] = enabled
+		}
+	}
+	return flags, nil
+}
@@ -0,0 +1,93 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+func TestParseFeatureFlags(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		want   map[string]bool
+	}{
+		{name: "bare name turns a flag on", header: "price_display_by_region", want: map[string]bool{FlagPriceDisplayByRegion: true}},
+		{name: "explicit values", header: " Zero_Qty_Removes = false , price_display_by_region=1", want: map[string]bool{FlagZeroQuantityRemoves: false, FlagPriceDisplayByRegion: true}},
+		{name: "malformed value", header: "zero_qty_removes=maybe", want: map[string]bool{}},
+		{name: "unknown flag", header: "free_shipping", want: map[string]bool{}},
+		{name: "operator-only flags", header: "reprice_on_read,degraded_add,auto_remove_discontinued=true", want: map[string]bool{}},
+		{name: "empty", want: map[string]bool{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ParseFeatureFlags(tt.header); fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("ParseFeatureFlags(%q) = %v, want %v", tt.header, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFeatureEnabled(t *testing.T) {
+	tests := []struct {
+		name     string
+		flag     string
+		env      string
+		override map[string]bool
+		want     bool
+	}{
+		{name: "default", flag: FlagZeroQuantityRemoves, want: true},
+		{name: "environment", flag: FlagZeroQuantityRemoves, env: "false", want: false},
+		{name: "request overrides environment", flag: FlagZeroQuantityRemoves, env: "false", override: map[string]bool{FlagZeroQuantityRemoves: true}, want: true},
+		{name: "other flag toggled", flag: FlagPriceDisplayByRegion, override: map[string]bool{FlagZeroQuantityRemoves: true}, want: false},
+		{name: "operator-only flag ignores the request", flag: FlagDegradedAdd, override: map[string]bool{FlagDegradedAdd: true}, want: false},
+		{name: "operator-only flag from environment", flag: FlagDegradedAdd, env: "true", override: map[string]bool{FlagDegradedAdd: false}, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(featureFlags[tt.flag].env, tt.env)
+			ctx := context.Background()
+			if tt.override != nil {
+				ctx = WithFeatureFlags(ctx, tt.override)
+			}
+			if got := FeatureEnabled(ctx, tt.flag); got != tt.want {
+				t.Errorf("FeatureEnabled(%s) = %v, want %v", tt.flag, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFetchFeatureFlagsKeepsOverridable(t *testing.T) {
+	newTestRedis(t)
+	var calls atomic.Int32
+	service := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		if r.URL.Path != "/api/flags" || r.URL.Query().Get("user_id") != "42" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"flags": map[string]bool{
+			FlagZeroQuantityRemoves: false,
+			FlagRepriceOnRead:       true,
+			"free_shipping":         true,
+		}})
+	}))
+	t.Cleanup(service.Close)
+	t.Setenv("FEATURE_FLAGS_SERVICE_URL", service.URL)
+
+	want := map[string]bool{FlagZeroQuantityRemoves: false}
+	for i := 0; i < 2; i++ {
+		if got := FetchFeatureFlags(context.Background(), "42"); fmt.Sprint(got) != fmt.Sprint(want) {
+			t.Errorf("FetchFeatureFlags = %v, want %v", got, want)
+		}
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("flags service called %d times, want once with the answer cached", n)
+	}
+}
@@ -0,0 +1,122 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// reserveFlashScript takes ARGV[1] units from a flash-sale stock counter,
+// seeding it with ARGV[2] on first use. Returns 1 if the units were
+// reserved and 0 if not enough stock remains.
+var reserveFlashScript = redis.NewScript(`
+local stock = redis.call("GET", KEYS[1])
+if not stock then
+	stock = ARGV[2]
+	redis.call("SET", KEYS[1], stock)
+end
+if tonumber(stock) < tonumber(ARGV[1]) then
+	return 0
+end
+redis.call("DECRBY", KEYS[1], ARGV[1])
+return 1
+`)
+
+// flashStockKey builds the Redis key counting a product's flash-sale stock
+func flashStockKey(productID int) string {
+	return Key("flash_stock", fmt.Sprintf("%d", productID))
+}
+
+// ReserveFlashStock atomically takes quantity units of a product's
+// flash-sale stock, which starts at initialStock. Returns false if the
+// sale has too few units left.
+func ReserveFlashStock(ctx context.Context, productID, quantity, initialStock int) (bool, error) {
+	reserved, err := reserveFlashScript.Run(ctx, RedisClient,
+		[]string{flashStockKey(productID)}, quantity, initialStock).Int()
+	if err != nil {
+		return false, err
+	}
+	return reserved == 1, nil
+}
+
+// ReleaseFlashStock returns previously reserved units to the sale
+func ReleaseFlashStock(ctx context.Context, productID, quantity int) error {
+	return RedisClient.IncrBy(ctx, flashStockKey(productID), int64(quantity)).Err()
+}
+
+// flashHoldsKey builds the Redis sorted set of flash-sale holds, scored by
+// when each expires
+func flashHoldsKey() string {
+	return Key("flash_holds")
+}
+
+// FlashHold identifies reserved flash-sale units held by a cart
+type FlashHold struct {
+	CartKey   string
+	ProductID int
+	Quantity  int
+}
+
+// member encodes the hold as its sorted set member, "productID|quantity|cartKey"
+func (h FlashHold) member() string {
+	return fmt.Sprintf("%d|%d|%s", h.ProductID, h.Quantity, h.CartKey)
+}
+
+// TrackFlashHold schedules a cart item's reserved units for release at
+// until
+func TrackFlashHold(ctx context.Context, hold FlashHold, until time.Time) error {
+	return RedisClient.ZAdd(ctx, flashHoldsKey(), &redis.Z{Score: float64(until.Unix()), Member: hold.member()}).Err()
+}
+
+// UntrackFlashHold cancels a hold's scheduled release. Returns false if
+// the hold was already claimed, in which case its claimer returns the
+// units to the sale.
+func UntrackFlashHold(ctx context.Context, hold FlashHold) (bool, error) {
+	removed, err := RedisClient.ZRem(ctx, flashHoldsKey(), hold.member()).Result()
+	return removed > 0, err
+}
+
+// ClaimExpiredFlashHolds removes up to limit holds that expired by now and
+// returns them. Each hold is claimed by one caller only, so several
+// instances can sweep at once.
+func ClaimExpiredFlashHolds(ctx context.Context, now time.Time, limit int64) ([]FlashHold, error) {
+	members, err := RedisClient.ZRangeByScore(ctx, flashHoldsKey(), &redis.ZRangeBy{
+		Min:   "-inf",
+		Max:   strconv.FormatInt(now.Unix(), 10),
+		Count: limit,
+	}).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	holds := []FlashHold{}
+	for _, member := range members {
+		removed, err := RedisClient.ZRem(ctx, flashHoldsKey(), member).Result()
+		if err != nil {
+			return holds, err
+		}
+		if removed == 0 {
+			// Claimed by another instance
+			continue
+		}
+
+		parts := strings.SplitN(member, "|", 3)
+		if len(parts) != 3 {
+			continue
+		}
+		productID, err := strconv.Atoi(parts[0])
+		if err != nil {
+			continue
+		}
+		quantity, err := strconv.Atoi(parts[1])
+		if err != nil {
+			continue
+		}
+		holds = append(holds, FlashHold{CartKey: parts[2], ProductID: productID, Quantity: quantity})
+	}
+	return holds, nil
+}
@@ -0,0 +1,137 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	// Cached rates are kept well past freshness so they can be served
+	// while the FX service is unavailable
+	fxRetention = 7 * 24 * time.Hour
+)
+
+// cachedRate is an FX rate as stored in Redis
+type cachedRate struct {
+	Rate      float64 `json:"rate"`
+	FetchedAt int64   `json:"fetched_at"`
+}
+
+// fxFreshFor returns how long a cached rate is served without refreshing
+func fxFreshFor() time.Duration {
+	return time.Duration(GetEnvInt("FX_CACHE_TTL_SECONDS", 3600)) * time.Second
+}
+
+// GetFXRate returns the conversion rate between two currencies. Cached
+// rates are served immediately; once stale they are still served while a
+// background refresh runs, so an FX service outage falls back to the last
+// known rate.
+func GetFXRate(ctx context.Context, from, to string) (float64, error) {
+	from, to = strings.ToUpper(from), strings.ToUpper(to)
+	if from == to {
+		return 1, nil
+	}
+
+	fxKey := Key("fx", from, to)
+
+	cachedData, err := RedisClient.Get(ctx, fxKey).Result()
+	if err != nil && err != redis.Nil {
+		return 0, err
+	}
+
+	if err == nil {
+		var cached cachedRate
+		if err := json.Unmarshal([]byte(cachedData), &cached); err == nil {
+			if time.Since(time.Unix(cached.FetchedAt, 0)) > fxFreshFor() {
+				go refreshFXRate(from, to, fxKey)
+			}
+			return cached.Rate, nil
+		}
+	}
+
+	// Nothing cached yet, so fetch synchronously
+	return fetchAndCacheFXRate(ctx, from, to, fxKey)
+}
+
+// refreshFXRate updates a stale cached rate, letting only one instance
+// refresh a given pair at a time. It outlives the request that triggered
+// it, so it runs under the background context.
+func refreshFXRate(from, to, fxKey string) {
+	refreshKey := Key("fx_refresh", from, to)
+	acquired, err := RedisClient.SetNX(Ctx, refreshKey, "1", 30*time.Second).Result()
+	if err != nil || !acquired {
+		return
+	}
+	defer RedisClient.Del(Ctx, refreshKey)
+
+	if _, err := fetchAndCacheFXRate(Ctx, from, to, fxKey); err != nil {
+		log.Printf("Failed to refresh FX rate %s->%s, serving stale rate: %v", from, to, err)
+	}
+}
+
+// fetchAndCacheFXRate fetches a rate from the FX service and caches it
+func fetchAndCacheFXRate(ctx context.Context, from, to, fxKey string) (float64, error) {
+	rate, err := fetchFXRate(ctx, from, to)
+	if err != nil {
+		return 0, err
+	}
+
+	cachedJSON, err := json.Marshal(cachedRate{Rate: rate, FetchedAt: time.Now().Unix()})
+	if err != nil {
+		return 0, err
+	}
+	if err := RedisClient.Set(ctx, fxKey, cachedJSON, fxRetention).Err(); err != nil {
+		log.Printf("Failed to cache FX rate %s->%s: %v", from, to, err)
+	}
+
+	return rate, nil
+}
+
+// fetchFXRate asks the FX service for the current rate
+func fetchFXRate(ctx context.Context, from, to string) (float64, error) {
+	baseURL := os.Getenv("FX_SERVICE_URL")
+	if baseURL == "" {
+		return 0, fmt.Errorf("FX_SERVICE_URL is not configured")
+	}
+
+	query := url.Values{"from": {from}, "to": {to}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+		fmt.Sprintf("%s/api/rates?%s", baseURL, query.Encode()), nil)
+	if err != nil {
+		return 0, err
+	}
+
+	start := time.Now()
+	resp, err := HTTPClient.Do(req)
+	RecordTiming(ctx, "fx_service", start)
+	if err != nil {
+		return 0, fmt.Errorf("failed to reach FX service: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		io.Copy(io.Discard, resp.Body)
+		return 0, fmt.Errorf("FX service returned status %d", resp.StatusCode)
+	}
+
+	var body struct {
+		Rate float64 `json:"rate"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return 0, fmt.Errorf("failed to decode FX rate: %v", err)
+	}
+	if body.Rate <= 0 {
+		return 0, fmt.Errorf("FX service returned invalid rate %v", body.Rate)
+	}
+	return body.Rate, nil
+}
@@ -0,0 +1,151 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newFXService serves rate for every pair, or fails when rate is 0
+func newFXService(t *testing.T, rate float64) *atomic.Int32 {
+	t.Helper()
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		if rate == 0 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprintf(w, `{"rate": %g}`, rate)
+	}))
+	t.Cleanup(server.Close)
+	t.Setenv("FX_SERVICE_URL", server.URL)
+	return &calls
+}
+
+// cacheFXRate stores a rate for USD->EUR fetched age ago
+func cacheFXRate(t *testing.T, rate float64, age time.Duration) {
+	t.Helper()
+	data, _ := json.Marshal(cachedRate{Rate: rate, FetchedAt: time.Now().Add(-age).Unix()})
+	if err := RedisClient.Set(Ctx, Key("fx", "USD", "EUR"), data, fxRetention).Err(); err != nil {
+		t.Fatalf("failed to cache rate: %v", err)
+	}
+}
+
+// waitForFXRefresh waits for a background refresh of USD->EUR to finish
+func waitForFXRefresh(t *testing.T, calls *atomic.Int32) {
+	t.Helper()
+	refreshKey := Key("fx_refresh", "USD", "EUR")
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(5 * time.Millisecond) {
+		if calls.Load() > 0 && RedisClient.Exists(Ctx, refreshKey).Val() == 0 {
+			return
+		}
+	}
+	t.Fatal("background FX refresh did not finish")
+}
+
+func TestGetFXRate(t *testing.T) {
+	tests := []struct {
+		name        string
+		cachedRate  float64
+		cachedAge   time.Duration
+		serviceRate float64
+		wantRate    float64
+		wantErr     bool
+		wantCached  float64
+	}{
+		{
+			name:        "fresh cache hit",
+			cachedRate:  0.9,
+			cachedAge:   time.Minute,
+			serviceRate: 0.95,
+			wantRate:    0.9,
+			wantCached:  0.9,
+		},
+		{
+			name:        "stale rate served while refreshing",
+			cachedRate:  0.9,
+			cachedAge:   2 * time.Hour,
+			serviceRate: 0.95,
+			wantRate:    0.9,
+			wantCached:  0.95,
+		},
+		{
+			name:       "service down serves the last cached rate",
+			cachedRate: 0.9,
+			cachedAge:  2 * time.Hour,
+			wantRate:   0.9,
+			wantCached: 0.9,
+		},
+		{
+			name:        "nothing cached fetches synchronously",
+			serviceRate: 0.95,
+			wantRate:    0.95,
+			wantCached:  0.95,
+		},
+		{
+			name:    "nothing cached and service down",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newTestRedis(t)
+			calls := newFXService(t, tt.serviceRate)
+			if tt.cachedRate > 0 {
+				cacheFXRate(t, tt.cachedRate, tt.cachedAge)
+			}
+
+			rate, err := GetFXRate(Ctx, "usd", "eur")
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("GetFXRate returned %v, want an error", rate)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GetFXRate: %v", err)
+			}
+			if rate != tt.wantRate {
+				t.Errorf("rate = %v, want %v", rate, tt.wantRate)
+			}
+
+			// A stale rate is refreshed in the background; wait for it
+			if tt.cachedAge > fxFreshFor() {
+				waitForFXRefresh(t, calls)
+			}
+			var cached cachedRate
+			data, _ := RedisClient.Get(Ctx, Key("fx", "USD", "EUR")).Result()
+			json.Unmarshal([]byte(data), &cached)
+			if cached.Rate != tt.wantCached {
+				t.Errorf("cached rate = %v, want %v", cached.Rate, tt.wantCached)
+			}
+			if tt.cachedAge > 0 && tt.cachedAge < fxFreshFor() && calls.Load() != 0 {
+				t.Errorf("fresh cache hit called the FX service %d times", calls.Load())
+			}
+		})
+	}
+}
+
+func TestGetFXRateHonorsContext(t *testing.T) {
+	newTestRedis(t)
+	newFXService(t, 0.95)
+
+	ctx, cancel := context.WithCancel(Ctx)
+	cancel()
+	if _, err := GetFXRate(ctx, "USD", "EUR"); err == nil {
+		t.Error("GetFXRate succeeded with a cancelled context")
+	}
+}
+
+func TestGetFXRateSameCurrency(t *testing.T) {
+	if rate, err := GetFXRate(Ctx, "usd", "USD"); err != nil || rate != 1 {
+		t.Errorf("GetFXRate(usd, USD) = %v, %v; want 1", rate, err)
+	}
+}
@@ -0,0 +1,34 @@
+package utils
+
+import (
+	"net"
+	"net/http"
+	"time"
+)
+
+// HTTPClient is shared by all calls to other services so connections are
+// pooled and reused rather than re-established per request
+var HTTPClient = &http.Client{Timeout: 5 * time.Second}
+
+// InitHTTPClient configures the shared HTTP client from the environment
+func InitHTTPClient() {
+	timeout := time.Duration(GetEnvInt("HTTP_CLIENT_TIMEOUT_SECONDS", 5)) * time.Second
+
+	transport := &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   timeout,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		MaxIdleConns:          GetEnvInt("HTTP_MAX_IDLE_CONNS", 100),
+		MaxIdleConnsPerHost:   GetEnvInt("HTTP_MAX_IDLE_CONNS_PER_HOST", 20),
+		IdleConnTimeout:       time.Duration(GetEnvInt("HTTP_IDLE_CONN_TIMEOUT_SECONDS", 90)) * time.Second,
+		TLSHandshakeTimeout:   timeout,
+		ResponseHeaderTimeout: timeout,
+	}
+
+	HTTPClient = &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+	}
+}
@@ -0,0 +1,78 @@
+package utils
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestFetchProductReusesConnections(t *testing.T) {
+	var connections atomic.Int32
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"product": {"name": "Kettle", "price": 20}}`)
+	}))
+	server.Config.ConnState = func(conn net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			connections.Add(1)
+		}
+	}
+	server.Start()
+	t.Cleanup(server.Close)
+	t.Setenv("PRODUCT_SERVICE_URL", server.URL)
+
+	previous := HTTPClient
+	InitHTTPClient()
+	t.Cleanup(func() { HTTPClient = previous })
+
+	for productID := 1; productID <= 20; productID++ {
+		if _, err := FetchProduct(Ctx, productID); err != nil {
+			t.Fatalf("FetchProduct(%d): %v", productID, err)
+		}
+	}
+	if got := connections.Load(); got != 1 {
+		t.Errorf("opened %d connections for 20 sequential fetches, want 1", got)
+	}
+}
+
+func TestInitHTTPClientTimeouts(t *testing.T) {
+	tests := []struct {
+		name        string
+		env         map[string]string
+		wantTimeout time.Duration
+		wantPerHost int
+	}{
+		{name: "defaults", wantTimeout: 5 * time.Second, wantPerHost: 20},
+		{
+			name:        "configured",
+			env:         map[string]string{"HTTP_CLIENT_TIMEOUT_SECONDS": "2", "HTTP_MAX_IDLE_CONNS_PER_HOST": "50"},
+			wantTimeout: 2 * time.Second,
+			wantPerHost: 50,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for name, value := range tt.env {
+				t.Setenv(name, value)
+			}
+			previous := HTTPClient
+			InitHTTPClient()
+			t.Cleanup(func() { HTTPClient = previous })
+
+			if HTTPClient.Timeout != tt.wantTimeout {
+				t.Errorf("timeout = %v, want %v", HTTPClient.Timeout, tt.wantTimeout)
+			}
+			transport := HTTPClient.Transport.(*http.Transport)
+			if transport.MaxIdleConnsPerHost != tt.wantPerHost {
+				t.Errorf("MaxIdleConnsPerHost = %d, want %d", transport.MaxIdleConnsPerHost, tt.wantPerHost)
+			}
+			if transport.ResponseHeaderTimeout != tt.wantTimeout {
+				t.Errorf("ResponseHeaderTimeout = %v, want %v", transport.ResponseHeaderTimeout, tt.wantTimeout)
+			}
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package utils
+
+import "testing"
+
+func TestKey(t *testing.T) {
+	tests := []struct {
+		name   string
+		prefix string
+		parts  []string
+		want   string
+	}{
+		{name: "no prefix", parts: []string{"cart", "42"}, want: "cart:42"},
+		{name: "prefix gets a separator", prefix: "staging", parts: []string{"cart", "42"}, want: "staging:cart:42"},
+		{name: "prefix with separator", prefix: "prod:", parts: []string{"lock", "cart", "42"}, want: "prod:lock:cart:42"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetKeyPrefix(tt.prefix)
+			t.Cleanup(func() { SetKeyPrefix("") })
+
+			key := Key(tt.parts...)
+			if key != tt.want {
+				t.Errorf("Key(%v) = %q, want %q", tt.parts, key, tt.want)
+			}
+			if derived := Key("count", StripKeyPrefix(key)); derived != Key(append([]string{"count"}, tt.parts...)...) {
+				t.Errorf("key derived from %q = %q, prefix applied twice or lost", key, derived)
+			}
+		})
+	}
+}
+
+func TestUserKey(t *testing.T) {
+	tests := []struct {
+		mode string
+		want string
+	}{
+		{mode: KeyHashingNone, want: "42"},
+		{mode: KeyHashingTag, want: "{42}"},
+		{mode: KeyHashingHash, want: "{73475cb40a568e8d}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			if err := SetKeyHashing(tt.mode); err != nil {
+				t.Fatalf("SetKeyHashing: %v", err)
+			}
+			t.Cleanup(func() { SetKeyHashing("") })
+
+			if got := UserKey("42"); got != tt.want {
+				t.Errorf("UserKey(42) = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetKeyHashingRejectsUnknown(t *testing.T) {
+	if err := SetKeyHashing("md5"); err == nil {
+		t.Error("SetKeyHashing accepted an unknown mode")
+	}
+}
@@ -0,0 +1,66 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// ErrLockTimeout is returned when a lock is still held by someone else
+// after the wait period. It is distinct from Redis failures.
+var ErrLockTimeout = errors.New("timed out waiting for lock")
+
+// lockRetryInterval is how often a waiting caller retries the lock
+const lockRetryInterval = 25 * time.Millisecond
+
+// releaseScript deletes the lock only if it still holds our token, so an
+// expired lock taken over by another caller is left alone
+var releaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// Lock is a held Redis lock
+type Lock struct {
+	key   string
+	token string
+}
+
+// AcquireLock takes the lock at key, expiring after ttl, waiting up to wait
+// for a current holder to release it
+func AcquireLock(ctx context.Context, key string, ttl, wait time.Duration) (*Lock, error) {
+	token, err := RandomID(16)
+	if err != nil {
+		return nil, err
+	}
+	lock := &Lock{key: key, token: token}
+
+	deadline := time.Now().Add(wait)
+	for {
+		acquired, err := RedisClient.SetNX(ctx, key, lock.token, ttl).Result()
+		if err != nil {
+			return nil, err
+		}
+		if acquired {
+			return lock, nil
+		}
+		if time.Now().After(deadline) {
+			return nil, ErrLockTimeout
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(lockRetryInterval):
+		}
+	}
+}
+
+// Release gives up the lock if it is still ours
+func (l *Lock) Release(ctx context.Context) error {
+	return releaseScript.Run(ctx, RedisClient, []string{l.key}, l.token).Err()
+}
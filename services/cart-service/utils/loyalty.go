@@ -0,0 +1,235 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+type loyaltyTierKey struct{}
+
+// WithLoyaltyTier records the loyalty tier claimed in the user's token
+func WithLoyaltyTier(ctx context.Context, tier string) context.Context {
+	return context.WithValue(ctx, loyaltyTierKey{}, tier)
+}
+
+// loyaltyTierCacheKey builds the Redis key caching a user's loyalty tier
+func loyaltyTierCacheKey(userID string) string {
+	return Key("loyalty_tier", UserKey(userID))
+}
+
+// LoyaltyTier returns the user's loyalty tier, e.g. "gold", or "" if they
+// have none. A tier claimed in the token wins; otherwise the loyalty
+// service is asked and its answer cached for LOYALTY_TIER_CACHE_SECONDS so
+// a session doesn't look it up on every write. Lookup failures are logged
+// and treated as no tier.
+func LoyaltyTier(ctx context.Context, userID string) string {
+	if tier, ok := ctx.Value(loyaltyTierKey{}).(string); ok {
+		return tier
+	}
+	baseURL := os.Getenv("LOYALTY_SERVICE_URL")
+	if baseURL == "" || userID == "" {
+		return ""
+	}
+
+	cacheKey := loyaltyTierCacheKey(userID)
+	tier, err := RedisClient.Get(ctx, cacheKey).Result()
+	if err == nil {
+		return tier
+	}
+	if err != redis.Nil {
+		log.Printf("Failed to read cached loyalty tier: %v", err)
+	}
+
+	tier, err = fetchLoyaltyTier(ctx, baseURL, userID)
+	if err != nil {
+		log.Printf("Failed to fetch loyalty tier for user %s: %v", userID, err)
+		return ""
+	}
+
+	ttl := time.Duration(GetEnvInt("LOYALTY_TIER_CACHE_SECONDS", 900)) * time.Second
+	if err := RedisClient.Set(ctx, cacheKey, tier, ttl).Err(); err != nil {
+		log.Printf("Failed to cache loyalty tier: %v", err)
+	}
+	return tier
+}
+
+// fetchLoyaltyTier asks the loyalty service for the user's current tier
+func fetchLoyaltyTier(ctx context.Context, baseURL, userID string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+		fmt.Sprintf("%s/api/loyalty/%s/tier", baseURL, url.PathEscape(userID)), nil)
+	if err != nil {
+		return "", err
+	}
+
+	start := time.Now()
+	resp, err := HTTPClient.Do(req)
+	RecordTiming(ctx, "loyalty_service", start)
+	if err != nil {
+		return "", fmt.Errorf("failed to reach loyalty service: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		// Not enrolled
+		io.Copy(io.Discard, resp.Body)
+		return "", nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		io.Copy(io.Discard, resp.Body)
+		return "", fmt.Errorf("loyalty service returned status %d", resp.StatusCode)
+	}
+
+	var body struct {
+		Tier string `json:"tier"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", fmt.Errorf("failed to decode loyalty tier: %v", err)
+	}
+	return body.Tier, nil
+}
+
+// ErrLoyaltyUnavailable is returned when no loyalty service is configured
+var ErrLoyaltyUnavailable = errors.New("loyalty service not configured")
+
+// FetchLoyaltyPoints asks the loyalty service for the user's points
+// balance. Users who aren't enrolled have none.
+func FetchLoyaltyPoints(ctx context.Context, userID string) (int, error) {
+	baseURL := os.Getenv("LOYALTY_SERVICE_URL")
+	if baseURL == "" {
+		return 0, ErrLoyaltyUnavailable
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+		fmt.Sprintf("%s/api/loyalty/%s/points", baseURL, url.PathEscape(userID)), nil)
+	if err != nil {
+		return 0, err
+	}
+
+	start := time.Now()
+	resp, err := HTTPClient.Do(req)
+	RecordTiming(ctx, "loyalty_service", start)
+	if err != nil {
+		return 0, fmt.Errorf("failed to reach loyalty service: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		io.Copy(io.Discard, resp.Body)
+		return 0, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		io.Copy(io.Discard, resp.Body)
+		return 0, fmt.Errorf("loyalty service returned status %d", resp.StatusCode)
+	}
+
+	var body struct {
+		Points int `json:"points"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return 0, fmt.Errorf("failed to decode loyalty points: %v", err)
+	}
+	return body.Points, nil
+}
+
+// pointsHeldKey builds the Redis key counting the points a user has
+// redeemed against carts that haven't been ordered yet
+func pointsHeldKey(userID string) string {
+	return Key("points_held", UserKey(userID))
+}
+
+// holdPointsScript adds ARGV[1] points to the held count in KEYS[1] if
+// that keeps it within the balance in ARGV[2], refreshing its expiry to
+// ARGV[3] seconds. Returns the points still available after the hold, or
+// -1 if there weren't enough.
+var holdPointsScript = redis.NewScript(`
+local held = tonumber(redis.call("GET", KEYS[1]) or "0")
+local available = tonumber(ARGV[2]) - held
+if tonumber(ARGV[1]) > available then
+	return -1 - math.max(available, 0)
+end
+redis.call("INCRBY", KEYS[1], ARGV[1])
+redis.call("EXPIRE", KEYS[1], ARGV[3])
+return available - tonumber(ARGV[1])
+`)
+
+// HoldPoints atomically sets aside points from the user's balance, less
+// any already held, for ttl. Returns false and the points that were
+// available if the balance can't cover them.
+func HoldPoints(ctx context.Context, userID string, points, balance int, ttl time.Duration) (bool, int, error) {
+	result, err := holdPointsScript.Run(ctx, RedisClient, []string{pointsHeldKey(userID)},
+		points, balance, int(ttl.Seconds())).Int()
+	if err != nil {
+		return false, 0, err
+	}
+	if result < 0 {
+		return false, -1 - result, nil
+	}
+	return true, result, nil
+}
+
+// releasePointsScript takes ARGV[1] points off the held count in KEYS[1],
+// never below zero
+var releasePointsScript = redis.NewScript(`
+local held = tonumber(redis.call("GET", KEYS[1]) or "0")
+local release = math.min(held, tonumber(ARGV[1]))
+if release <= 0 then
+	return 0
+end
+return redis.call("DECRBY", KEYS[1], release)
+`)
+
+// ReleasePoints returns held points to the user's available balance
+func ReleasePoints(ctx context.Context, userID string, points int) error {
+	return releasePointsScript.Run(ctx, RedisClient, []string{pointsHeldKey(userID)}, points).Err()
+}
+
+// DebitLoyaltyPoints deducts redeemed points from the user's balance at the
+// loyalty service once their order is placed. The debit is keyed by the
+// order ID, so retrying it for the same order deducts the points once.
+func DebitLoyaltyPoints(ctx context.Context, userID string, points int, orderID string) error {
+	baseURL := os.Getenv("LOYALTY_SERVICE_URL")
+	if baseURL == "" {
+		return ErrLoyaltyUnavailable
+	}
+
+	payload, err := json.Marshal(map[string]interface{}{
+		"points":   points,
+		"order_id": orderID,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost,
+		fmt.Sprintf("%s/api/loyalty/%s/points/debit", baseURL, url.PathEscape(userID)), bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Idempotency-Key", "order:"+orderID)
+
+	start := time.Now()
+	resp, err := HTTPClient.Do(req)
+	RecordTiming(ctx, "loyalty_service", start)
+	if err != nil {
+		return fmt.Errorf("failed to reach loyalty service: %v", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("loyalty service returned status %d", resp.StatusCode)
+	}
+	return nil
+}
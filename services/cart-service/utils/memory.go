@@ -0,0 +1,72 @@
+package utils
+
+import (
+	"log"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// memoryPressure is set while Redis memory use is above the threshold
+var memoryPressure atomic.Bool
+
+// MemoryPressure reports whether Redis was above its memory threshold at
+// the last check. Writes that grow Redis should be shed while it is.
+func MemoryPressure() bool {
+	return memoryPressure.Load()
+}
+
+// parseMemoryInfo extracts used_memory and maxmemory from INFO memory
+// output. maxmemory is 0 when Redis has no limit.
+func parseMemoryInfo(info string) (used, max int64) {
+	for _, line := range strings.Split(info, "\r\n") {
+		field, value, found := strings.Cut(line, ":")
+		if !found {
+			continue
+		}
+		switch field {
+		case "used_memory":
+			used, _ = strconv.ParseInt(value, 10, 64)
+		case "maxmemory":
+			max, _ = strconv.ParseInt(value, 10, 64)
+		}
+	}
+	return used, max
+}
+
+// checkMemoryPressure samples Redis memory use and updates the pressure flag
+func checkMemoryPressure(threshold float64) {
+	info, err := RedisClient.Info(Ctx, "memory").Result()
+	if err != nil {
+		// Leave the flag as it was rather than guessing
+		log.Printf("Failed to read Redis memory info: %v", err)
+		return
+	}
+
+	used, max := parseMemoryInfo(info)
+	pressure := max > 0 && float64(used)/float64(max) >= threshold
+	if pressure != memoryPressure.Swap(pressure) {
+		log.Printf("Redis memory pressure: %v (%d of %d bytes used)", pressure, used, max)
+	}
+}
+
+// StartMemoryMonitor periodically checks Redis memory use against
+// REDIS_MEMORY_THRESHOLD, a fraction of maxmemory (default 0.9). Set
+// REDIS_MEMORY_CHECK_SECONDS to 0 to disable the check.
+func StartMemoryMonitor() {
+	interval := GetEnvInt("REDIS_MEMORY_CHECK_SECONDS", 10)
+	if interval <= 0 {
+		return
+	}
+	threshold := GetEnvFloat("REDIS_MEMORY_THRESHOLD", 0.9)
+
+	checkMemoryPressure(threshold)
+	go func() {
+		ticker := time.NewTicker(time.Duration(interval) * time.Second)
+		defer ticker.Stop()
+		for range ticker.C {
+			checkMemoryPressure(threshold)
+		}
+	}()
+}
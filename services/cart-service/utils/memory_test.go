@@ -0,0 +1,58 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/alicebob/miniredis/v2/server"
+)
+
+// fakeMemoryInfo answers INFO with used and max bytes of memory
+func fakeMemoryInfo(t *testing.T, used, max string) {
+	t.Helper()
+	mr := newTestRedis(t)
+	mr.Server().SetPreHook(func(c *server.Peer, cmd string, args ...string) bool {
+		if cmd != "INFO" {
+			return false
+		}
+		c.WriteBulk("# Memory\r\nused_memory:" + used + "\r\nused_memory_human:1M\r\nmaxmemory:" + max + "\r\n")
+		return true
+	})
+	t.Cleanup(func() { memoryPressure.Store(false) })
+}
+
+func TestCheckMemoryPressure(t *testing.T) {
+	tests := []struct {
+		name         string
+		used         string
+		max          string
+		wantPressure bool
+	}{
+		{name: "below threshold", used: "800", max: "1000", wantPressure: false},
+		{name: "at threshold", used: "900", max: "1000", wantPressure: true},
+		{name: "above threshold", used: "990", max: "1000", wantPressure: true},
+		{name: "no maxmemory", used: "99999", max: "0", wantPressure: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeMemoryInfo(t, tt.used, tt.max)
+
+			checkMemoryPressure(0.9)
+			if MemoryPressure() != tt.wantPressure {
+				t.Errorf("MemoryPressure() = %v, want %v", MemoryPressure(), tt.wantPressure)
+			}
+		})
+	}
+}
+
+func TestCheckMemoryPressureKeepsFlagWhenRedisFails(t *testing.T) {
+	mr := newTestRedis(t)
+	memoryPressure.Store(true)
+	t.Cleanup(func() { memoryPressure.Store(false) })
+	mr.Close()
+
+	checkMemoryPressure(0.9)
+	if !MemoryPressure() {
+		t.Error("a failed check cleared memory pressure")
+	}
+}
@@ -0,0 +1,54 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+)
+
+// NotificationsEnabled reports whether a notification service is configured
+func NotificationsEnabled() bool {
+	return os.Getenv("NOTIFICATION_SERVICE_URL") != ""
+}
+
+// SendEmail asks the notification service to send a templated email
+func SendEmail(ctx context.Context, to, template string, data interface{}) error {
+	baseURL := os.Getenv("NOTIFICATION_SERVICE_URL")
+	if baseURL == "" {
+		return fmt.Errorf("no notification service configured")
+	}
+
+	payload, err := json.Marshal(map[string]interface{}{
+		"to":       to,
+		"template": template,
+		"data":     data,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, baseURL+"/api/notifications/email", bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	start := time.Now()
+	resp, err := HTTPClient.Do(req)
+	RecordTiming(ctx, "notification_service", start)
+	if err != nil {
+		return fmt.Errorf("failed to reach notification service: %v", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("notification service returned status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+package utils
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// Operation is a cart write recorded for diagnosing client retries
+type Operation struct {
+	Method         string `json:"method"`
+	Path           string `json:"path"`
+	IdempotencyKey string `json:"idempotency_key,omitempty"`
+	Status         int    `json:"status"`
+	// Replayed marks a retry answered with the stored response of the
+	// original request instead of running again
+	Replayed bool   `json:"replayed,omitempty"`
+	At       string `json:"at"`
+}
+
+// operationsKey builds the Redis list of a user's recent operations,
+// newest first
+func operationsKey(userID string) string {
+	return Key("operations", UserKey(userID))
+}
+
+// RecordOperation adds an operation to the user's log, keeping the latest
+// OPERATIONS_LOG_LIMIT entries for OPERATIONS_LOG_TTL_HOURS
+func RecordOperation(ctx context.Context, userID string, op Operation) error {
+	op.At = time.Now().Format(time.RFC3339)
+	data, err := json.Marshal(op)
+	if err != nil {
+		return err
+	}
+
+	key := operationsKey(userID)
+	limit := int64(GetEnvInt("OPERATIONS_LOG_LIMIT", 50))
+	ttl := time.Duration(GetEnvInt("OPERATIONS_LOG_TTL_HOURS", 24)) * time.Hour
+	_, err = RedisClient.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.LPush(ctx, key, data)
+		pipe.LTrim(ctx, key, 0, limit-1)
+		pipe.Expire(ctx, key, ttl)
+		return nil
+	})
+	return err
+}
+
+// RecentOperations returns the user's logged operations, newest first.
+// Entries that can't be decoded are skipped.
+func RecentOperations(ctx context.Context, userID string) ([]Operation, error) {
+	entries, err := RedisClient.LRange(ctx, operationsKey(userID), 0, -1).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	operations := make([]Operation, 0, len(entries))
+	for _, entry := range entries {
+		var op Operation
+		if json.Unmarshal([]byte(entry), &op) == nil {
+			operations = append(operations, op)
+		}
+	}
+	return operations, nil
+}
+
+// StoredResponse is the response to a request made with an idempotency
+// key, replayed to retries of that request
+type StoredResponse struct {
+	Method      string `json:"method"`
+	Path        string `json:"path"`
+	Status      int    `json:"status"`
+	ContentType string `json:"content_type"`
+	Body        []byte `json:"body"`
+}
+
+// idempotencyPending marks a key whose original request is still running
+const idempotencyPending = "pending"
+
+// idempotencyKey builds the Redis key holding the response to a user's
+// request. Client keys are hashed so they can't shape the key.
+func idempotencyKey(userID, clientKey string) string {
+	digest := sha256.Sum256([]byte(clientKey))
+	return Key("idempotency", UserKey(userID), hex.EncodeToString(digest[:]))
+}
+
+// ClaimIdempotencyKey reserves a client's idempotency key for a new
+// request. If the key was used before, it returns the stored response, or
+// pending=true while the original request is still running. The claim
+// lasts pendingTTL, so a request that never finishes doesn't hold the key
+// for the full replay window.
+func ClaimIdempotencyKey(ctx context.Context, userID, clientKey string, pendingTTL time.Duration) (stored *StoredResponse, pending bool, err error) {
+	key := idempotencyKey(userID, clientKey)
+	claimed, err := RedisClient.SetNX(ctx, key, idempotencyPending, pendingTTL).Result()
+	if err != nil || claimed {
+		return nil, false, err
+	}
+
+	data, err := RedisClient.Get(ctx, key).Result()
+	if err == redis.Nil {
+		// Released between the two calls; the caller may retry
+		return nil, true, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	if data == idempotencyPending {
+		return nil, true, nil
+	}
+
+	var response StoredResponse
+	if err := json.Unmarshal([]byte(data), &response); err != nil {
+		return nil, false, err
+	}
+	return &response, false, nil
+}
+
+// StoreIdempotentResponse saves the response to a request claimed with
+// ClaimIdempotencyKey for replay to its retries for ttl
+func StoreIdempotentResponse(ctx context.Context, userID, clientKey string, response StoredResponse, ttl time.Duration) error {
+	data, err := json.Marshal(response)
+	if err != nil {
+		return err
+	}
+	return RedisClient.Set(ctx, idempotencyKey(userID, clientKey), data, ttl).Err()
+}
+
+// ReleaseIdempotencyKey forgets a claimed key, so a retry runs the request
+// again, e.g. after a server error
+func ReleaseIdempotencyKey(ctx context.Context, userID, clientKey string) error {
+	return RedisClient.Del(ctx, idempotencyKey(userID, clientKey)).Err()
+}
@@ -0,0 +1,65 @@
+package utils
+
+import (
+	"cart-service/models"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+)
+
+// ErrOrderNotFound is returned when order-service has no such order for
+// the user
+var ErrOrderNotFound = errors.New("order not found")
+
+// ErrOrdersUnavailable is returned when no order-service is configured
+var ErrOrdersUnavailable = errors.New("order service not configured")
+
+// FetchOrder retrieves a past order from order-service on the user's
+// behalf, forwarding their Authorization header so order-service only
+// returns orders they own
+func FetchOrder(ctx context.Context, orderID, authorization string) (*models.OrderPayload, error) {
+	baseURL := os.Getenv("ORDER_SERVICE_URL")
+	if baseURL == "" {
+		return nil, ErrOrdersUnavailable
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, baseURL+"/api/orders/"+url.PathEscape(orderID), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", authorization)
+
+	start := time.Now()
+	resp, err := HTTPClient.Do(req)
+	RecordTiming(ctx, "order_service", start)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach order-service: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusForbidden {
+		io.Copy(io.Discard, resp.Body)
+		return nil, ErrOrderNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		io.Copy(io.Discard, resp.Body)
+		return nil, fmt.Errorf("order-service returned status %d", resp.StatusCode)
+	}
+
+	var body struct {
+		Order *models.OrderPayload `json:"order"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("failed to decode order: %v", err)
+	}
+	if body.Order == nil {
+		return nil, ErrOrderNotFound
+	}
+	return body.Order, nil
+}
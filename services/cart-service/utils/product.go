@@ -0,0 +1,192 @@
+package utils
+
+import (
+	"cart-service/models"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ErrProductNotFound is returned when product-service has no such product
+var ErrProductNotFound = errors.New("product not found")
+
+// Product holds the product-service fields the cart relies on
+type Product struct {
+	ID           int       `json:"id"`
+	Name         string    `json:"name"`
+	SKU          string    `json:"sku"`
+	Category     string    `json:"category"`
+	ImageURL     string    `json:"image_url"`
+	Price        flexFloat `json:"price"`
+	Quantity     int       `json:"quantity"`
+	QuantityStep int       `json:"quantity_step"`
+	MaxPerOrder  int       `json:"max_per_order"`
+	Weight       flexFloat `json:"weight"`
+	LeadTimeDays *int      `json:"lead_time_days"`
+	// DeliveryWindowDays is how far ahead delivery can be scheduled
+	DeliveryWindowDays int `json:"delivery_window_days"`
+	// TaxCategory selects the item's rate from the tax table
+	TaxCategory string `json:"tax_category"`
+	// Discontinued products can no longer be purchased
+	Discontinued bool `json:"discontinued"`
+	// AgeRestricted products need the buyer's age verified at checkout
+	AgeRestricted bool `json:"age_restricted"`
+	MinimumAge    int  `json:"minimum_age"`
+	// Dimensions are the package measurements in centimeters
+	Dimensions *models.Dimensions `json:"dimensions"`
+	// Subscribe-and-save eligibility and the discount subscribers get
+	SubscriptionEligible        bool      `json:"subscription_eligible"`
+	SubscriptionDiscountPercent flexFloat `json:"subscription_discount_percent"`
+	// CarbonGrams is the estimated CO2 footprint of one unit
+	CarbonGrams flexFloat `json:"carbon_grams"`
+	// A sale Price is valid until PriceValidUntil (RFC3339), after which
+	// RegularPrice applies
+	PriceValidUntil string    `json:"price_valid_until"`
+	RegularPrice    flexFloat `json:"regular_price"`
+	// Freebie marks a product that is intentionally free
+	Freebie bool `json:"freebie"`
+	// VendorID is the marketplace vendor selling the product
+	VendorID string `json:"vendor_id"`
+	// HandlingFee is charged per unit, e.g. for oversized or hazardous goods
+	HandlingFee flexFloat `json:"handling_fee"`
+	// FulfillmentType is ship, pickup or digital; empty means ship
+	FulfillmentType string `json:"fulfillment_type"`
+	// DailyQuota caps the units one user may add per day, overriding
+	// DAILY_PRODUCT_QUOTA
+	DailyQuota int `json:"daily_quota"`
+	// Warehouse location and bin the product is picked from
+	Location string `json:"location"`
+	Bin      string `json:"bin"`
+}
+
+// CurrentPrice returns what the product sells for at now and the time that
+// price is valid until, if it's a sale price. product-service may go on
+// reporting a sale price after its window has passed; the regular price
+// applies then.
+func (p *Product) CurrentPrice(now time.Time) (float64, string) {
+	validUntil, err := time.Parse(time.RFC3339, p.PriceValidUntil)
+	if err != nil || now.Before(validUntil) || p.RegularPrice <= 0 {
+		return float64(p.Price), p.PriceValidUntil
+	}
+	return float64(p.RegularPrice), ""
+}
+
+// flexFloat decodes numbers that product-service may send as JSON strings
+// (Postgres DECIMAL columns are serialized as strings)
+type flexFloat float64
+
+func (f *flexFloat) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		data = []byte(s)
+	}
+	if string(data) == "null" || len(data) == 0 {
+		*f = 0
+		return nil
+	}
+
+	value, err := strconv.ParseFloat(string(data), 64)
+	if err != nil {
+		return err
+	}
+	*f = flexFloat(value)
+	return nil
+}
+
+// productServiceURL returns the base URL of product-service
+func productServiceURL() string {
+	baseURL := os.Getenv("PRODUCT_SERVICE_URL")
+	if baseURL == "" {
+		baseURL = "http://product-service:5002"
+	}
+	return baseURL
+}
+
+// mockProduct returns the fixed product data used when MOCK_PRODUCTS is set
+func mockProduct(productID int) *Product {
+	return &Product{
+		ID:           productID,
+		Name:         fmt.Sprintf("Product %d", productID),
+		Price:        99.99,
+		Quantity:     100,
+		QuantityStep: 1,
+	}
+}
+
+// FetchProduct retrieves a product's current details from product-service.
+// With MOCK_PRODUCTS=true it returns deterministic mock data instead, for
+// local development without a product-service.
+func FetchProduct(ctx context.Context, productID int) (*Product, error) {
+	if GetEnvBool("MOCK_PRODUCTS", false) {
+		return mockProduct(productID), nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+		fmt.Sprintf("%s/api/products/%d", productServiceURL(), productID), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	resp, err := HTTPClient.Do(req)
+	RecordTiming(ctx, "product_service", start)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach product-service: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		// Drain the body so the connection can be reused
+		io.Copy(io.Discard, resp.Body)
+		return nil, ErrProductNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		io.Copy(io.Discard, resp.Body)
+		return nil, fmt.Errorf("product-service returned status %d", resp.StatusCode)
+	}
+
+	product, err := decodeProduct(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	product.ID = productID
+	return product, nil
+}
+
+// FetchProducts retrieves several products concurrently, at most
+// PRODUCT_FETCH_CONCURRENCY at a time. Products that could not be fetched
+// are left out of the result and their errors returned by product ID.
+func FetchProducts(ctx context.Context, productIDs []int) (map[int]*Product, map[int]error) {
+	products := make(map[int]*Product, len(productIDs))
+	errs := map[int]error{}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, GetEnvInt("PRODUCT_FETCH_CONCURRENCY", 8))
+	for _, productID := range productIDs {
+		wg.Add(1)
+		go func(productID int) {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+
+			product, err := FetchProduct(ctx, productID)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errs[productID] = err
+				return
+			}
+			products[productID] = product
+		}(productID)
+	}
+	wg.Wait()
+	return products, errs
+}
@@ -0,0 +1,105 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+)
+
+// productFields are the Product JSON fields that can be mapped. They are
+// read from Product's json tags, so a new field is decoded without also
+// having to be listed here.
+var productFields = jsonFieldNames(reflect.TypeOf(Product{}))
+
+// jsonFieldNames returns the JSON names of a struct type's fields
+func jsonFieldNames(t reflect.Type) []string {
+	names := make([]string, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
+// productFieldPaths returns where each Product field is found in a
+// product-service response. Fields default to "product.<field>"; the
+// PRODUCT_FIELD_MAP env var overrides them as comma-separated
+// field=path pairs, e.g. "price=data.attributes.price,name=data.attributes.title".
+func productFieldPaths() (map[string]string, error) {
+	paths := make(map[string]string, len(productFields))
+	for _, field := range productFields {
+		paths[field] = "product." + field
+	}
+
+	mapping := os.Getenv("PRODUCT_FIELD_MAP")
+	if mapping == "" {
+		return paths, nil
+	}
+
+	for _, entry := range strings.Split(mapping, ",") {
+		field, path, found := strings.Cut(strings.TrimSpace(entry), "=")
+		if _, known := paths[field]; !found || !known || path == "" {
+			return nil, fmt.Errorf("invalid PRODUCT_FIELD_MAP entry %q", entry)
+		}
+		paths[field] = path
+	}
+	return paths, nil
+}
+
+// lookupPath follows a dot-separated path through decoded JSON objects
+func lookupPath(doc interface{}, path string) (interface{}, bool) {
+	for _, key := range strings.Split(path, ".") {
+		object, ok := doc.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if doc, ok = object[key]; !ok {
+			return nil, false
+		}
+	}
+	return doc, true
+}
+
+// decodeProduct reads a product from a product-service response body,
+// locating each field through the configured field paths. A response
+// holding none of the fields is an error rather than a missing product,
+// since it means the mapping or the upstream schema is wrong.
+func decodeProduct(r io.Reader) (*Product, error) {
+	paths, err := productFieldPaths()
+	if err != nil {
+		return nil, err
+	}
+
+	var doc interface{}
+	if err := json.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("failed to decode product: %v", err)
+	}
+
+	// Gather the mapped values into the flat shape Product decodes from,
+	// so field types are handled the same whatever the response layout
+	fields := map[string]interface{}{}
+	for field, path := range paths {
+		if value, ok := lookupPath(doc, path); ok && value != nil {
+			fields[field] = value
+		}
+	}
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("product response matched no mapped fields")
+	}
+
+	flat, err := json.Marshal(fields)
+	if err != nil {
+		return nil, err
+	}
+	var product Product
+	if err := json.Unmarshal(flat, &product); err != nil {
+		return nil, fmt.Errorf("failed to decode product: %v", err)
+	}
+	return &product, nil
+}
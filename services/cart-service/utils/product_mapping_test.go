@@ -0,0 +1,175 @@
+package utils
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFetchProductFieldMapping(t *testing.T) {
+	tests := []struct {
+		name     string
+		mapping  string
+		response string
+	}{
+		{
+			name:     "default shape",
+			response: `{"product": {"name": "Kettle", "price": "24.50", "quantity": 3, "sku": "K-1"}}`,
+		},
+		{
+			name:     "JSON:API shape",
+			mapping:  "name=data.attributes.title,price=data.attributes.price.amount,quantity=data.attributes.stock,sku=data.id",
+			response: `{"data": {"id": "K-1", "attributes": {"title": "Kettle", "price": {"amount": 24.5}, "stock": 3}}}`,
+		},
+		{
+			name:     "partially mapped shape",
+			mapping:  "price=item.cost",
+			response: `{"product": {"name": "Kettle", "quantity": 3, "sku": "K-1"}, "item": {"cost": "24.5"}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("PRODUCT_FIELD_MAP", tt.mapping)
+			newProductService(t, tt.response)
+
+			product, err := FetchProduct(context.Background(), 7)
+			if err != nil {
+				t.Fatalf("FetchProduct: %v", err)
+			}
+			if product.ID != 7 || product.Name != "Kettle" || product.Price != 24.5 || product.Quantity != 3 || product.SKU != "K-1" {
+				t.Errorf("product = %+v", product)
+			}
+		})
+	}
+}
+
+func TestFetchProductInvalidFieldMapping(t *testing.T) {
+	tests := []struct {
+		name    string
+		mapping string
+	}{
+		{name: "unknown field", mapping: "colour=product.colour"},
+		{name: "missing path", mapping: "price="},
+		{name: "no separator", mapping: "price"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("PRODUCT_FIELD_MAP", tt.mapping)
+			newProductService(t, `{"product": {"name": "Kettle", "price": 1}}`)
+
+			if _, err := FetchProduct(context.Background(), 7); err == nil {
+				t.Errorf("FetchProduct accepted PRODUCT_FIELD_MAP=%q", tt.mapping)
+			}
+		})
+	}
+}
+
+func TestFetchProductMappedFieldsMissing(t *testing.T) {
+	t.Setenv("PRODUCT_FIELD_MAP", "name=data.title,price=data.price")
+	newProductService(t, `{"product": {"name": "Kettle", "price": 1, "quantity": 3}}`)
+
+	product, err := FetchProduct(context.Background(), 7)
+	if err != nil {
+		t.Fatalf("FetchProduct: %v", err)
+	}
+	if product.Name != "" || product.Price != 0 || product.Quantity != 3 {
+		t.Errorf("unmapped fields were read from the default paths: %+v", product)
+	}
+}
+
+func TestFetchProductNoMappedFields(t *testing.T) {
+	t.Setenv("PRODUCT_FIELD_MAP", "")
+	newProductService(t, `{"data": {"title": "Kettle", "price": 1}}`)
+
+	_, err := FetchProduct(context.Background(), 7)
+	if err == nil || err == ErrProductNotFound {
+		t.Errorf("FetchProduct error = %v, want a decode error, not a missing product", err)
+	}
+}
+
+func TestFetchProductDecodesFields(t *testing.T) {
+	tests := []struct {
+		name   string
+		fields string
+		check  func(*Product) bool
+	}{
+		{
+			name:   "subscription eligibility",
+			fields: `"subscription_eligible": true, "subscription_discount_percent": "15"`,
+			check: func(p *Product) bool {
+				return p.SubscriptionEligible && p.SubscriptionDiscountPercent == 15
+			},
+		},
+		{
+			name:   "carbon footprint",
+			fields: `"carbon_grams": "250.5"`,
+			check:  func(p *Product) bool { return p.CarbonGrams == 250.5 },
+		},
+		{
+			name:   "flash sale window",
+			fields: `"price_valid_until": "2026-01-02T15:04:05Z", "regular_price": "12.5"`,
+			check: func(p *Product) bool {
+				return p.PriceValidUntil == "2026-01-02T15:04:05Z" && p.RegularPrice == 12.5
+			},
+		},
+		{
+			name:   "intentional freebie",
+			fields: `"freebie": true`,
+			check:  func(p *Product) bool { return p.Freebie },
+		},
+		{
+			name:   "marketplace vendor",
+			fields: `"vendor_id": "acme"`,
+			check:  func(p *Product) bool { return p.VendorID == "acme" },
+		},
+		{
+			name:   "handling fee",
+			fields: `"handling_fee": "4.50"`,
+			check:  func(p *Product) bool { return p.HandlingFee == 4.5 },
+		},
+		{
+			name:   "fulfillment type",
+			fields: `"fulfillment_type": "pickup"`,
+			check:  func(p *Product) bool { return p.FulfillmentType == "pickup" },
+		},
+		{
+			name:   "daily quota",
+			fields: `"daily_quota": 5`,
+			check:  func(p *Product) bool { return p.DailyQuota == 5 },
+		},
+		{
+			name:   "warehouse bin",
+			fields: `"location": "A", "bin": "A-03"`,
+			check:  func(p *Product) bool { return p.Location == "A" && p.Bin == "A-03" },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("PRODUCT_FIELD_MAP", "")
+			newProductService(t, `{"product": {"name": "Kettle", "price": 1, `+tt.fields+`}}`)
+
+			product, err := FetchProduct(context.Background(), 7)
+			if err != nil {
+				t.Fatalf("FetchProduct: %v", err)
+			}
+			if !tt.check(product) {
+				t.Errorf("fields %s were not decoded: %+v", tt.fields, product)
+			}
+		})
+	}
+}
+
+func TestEveryProductFieldIsMappable(t *testing.T) {
+	product := reflect.TypeOf(Product{})
+	for i := 0; i < product.NumField(); i++ {
+		field, _, _ := strings.Cut(product.Field(i).Tag.Get("json"), ",")
+		t.Setenv("PRODUCT_FIELD_MAP", field+"=data."+field)
+		if _, err := productFieldPaths(); err != nil {
+			t.Errorf("Product field %s cannot be mapped: %v", product.Field(i).Name, err)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newProductService serves body for every product and counts the calls
+func newProductService(t *testing.T, body string) *atomic.Int32 {
+	t.Helper()
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		fmt.Fprint(w, body)
+	}))
+	t.Cleanup(server.Close)
+	t.Setenv("PRODUCT_SERVICE_URL", server.URL)
+	return &calls
+}
+
+func TestFetchProductMockMode(t *testing.T) {
+	tests := []struct {
+		name      string
+		mock      string
+		wantCalls int32
+		wantName  string
+		wantPrice float64
+	}{
+		{name: "mock mode", mock: "true", wantCalls: 0, wantName: "Product 7", wantPrice: 99.99},
+		{name: "real mode", mock: "false", wantCalls: 1, wantName: "Kettle", wantPrice: 24.5},
+		{name: "unset", mock: "", wantCalls: 1, wantName: "Kettle", wantPrice: 24.5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("MOCK_PRODUCTS", tt.mock)
+			calls := newProductService(t, `{"product": {"name": "Kettle", "price": "24.50", "quantity": 3}}`)
+
+			product, err := FetchProduct(context.Background(), 7)
+			if err != nil {
+				t.Fatalf("FetchProduct: %v", err)
+			}
+			if calls.Load() != tt.wantCalls {
+				t.Errorf("product-service calls = %d, want %d", calls.Load(), tt.wantCalls)
+			}
+			if product.ID != 7 || product.Name != tt.wantName || float64(product.Price) != tt.wantPrice {
+				t.Errorf("product = %+v, want %s at %v", product, tt.wantName, tt.wantPrice)
+			}
+		})
+	}
+}
+
+func TestFetchProductMockModeIsDeterministic(t *testing.T) {
+	t.Setenv("MOCK_PRODUCTS", "true")
+
+	first, _ := FetchProduct(context.Background(), 3)
+	second, _ := FetchProduct(context.Background(), 3)
+	if *first != *second {
+		t.Errorf("mock products differ: %+v and %+v", first, second)
+	}
+}
+
+func TestProductCurrentPrice(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name           string
+		product        Product
+		wantPrice      float64
+		wantValidUntil string
+	}{
+		{
+			name:      "no sale",
+			product:   Product{Price: 10},
+			wantPrice: 10,
+		},
+		{
+			name:           "sale still running",
+			product:        Product{Price: 8, RegularPrice: 10, PriceValidUntil: "2026-03-01T13:00:00Z"},
+			wantPrice:      8,
+			wantValidUntil: "2026-03-01T13:00:00Z",
+		},
+		{
+			name:      "sale over",
+			product:   Product{Price: 8, RegularPrice: 10, PriceValidUntil: "2026-03-01T11:00:00Z"},
+			wantPrice: 10,
+		},
+		{
+			name:           "sale over without a regular price",
+			product:        Product{Price: 8, PriceValidUntil: "2026-03-01T11:00:00Z"},
+			wantPrice:      8,
+			wantValidUntil: "2026-03-01T11:00:00Z",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			price, validUntil := tt.product.CurrentPrice(now)
+			if price != tt.wantPrice || validUntil != tt.wantValidUntil {
+				t.Errorf("CurrentPrice = %v until %q, want %v until %q", price, validUntil, tt.wantPrice, tt.wantValidUntil)
+			}
+		})
+	}
+}
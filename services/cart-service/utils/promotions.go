@@ -0,0 +1,196 @@
+package utils
+
+import (
+	"bytes"
+	"cart-service/models"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+)
+
+// Promotion describes an item-level discount from the promotions service
+type Promotion struct {
+	ProductID       int     `json:"product_id"`
+	DiscountPercent float64 `json:"discount_percent"`
+	DiscountAmount  float64 `json:"discount_amount"`
+	PromoPrice      float64 `json:"promo_price"`
+	PromoLimit      int     `json:"promo_limit"`
+	// FlashStock, when set, makes this a flash sale: PromoPrice is only
+	// honored for units reserved from this limited stock
+	FlashStock int `json:"flash_stock"`
+}
+
+// FetchPromotion looks up the active promotion for a product.
+// Returns nil without error when the product has no promotion or no
+// promotions service is configured.
+func FetchPromotion(ctx context.Context, productID int) (*Promotion, error) {
+	baseURL := os.Getenv("PROMOTIONS_SERVICE_URL")
+	if baseURL == "" {
+		return nil, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+		fmt.Sprintf("%s/api/promotions/products/%d", baseURL, productID), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	resp, err := HTTPClient.Do(req)
+	RecordTiming(ctx, "promotions_service", start)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach promotions service: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		io.Copy(io.Discard, resp.Body)
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		io.Copy(io.Discard, resp.Body)
+		return nil, fmt.Errorf("promotions service returned status %d", resp.StatusCode)
+	}
+
+	var body struct {
+		Promotion *Promotion `json:"promotion"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("failed to decode promotion: %v", err)
+	}
+	return body.Promotion, nil
+}
+
+// FetchGiftPromotions returns the free-gift promotions currently configured
+// in the promotions service. Returns nil when no promotions service is
+// configured.
+func FetchGiftPromotions(ctx context.Context) ([]models.GiftPromotion, error) {
+	baseURL := os.Getenv("PROMOTIONS_SERVICE_URL")
+	if baseURL == "" {
+		return nil, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, baseURL+"/api/promotions/gifts", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	resp, err := HTTPClient.Do(req)
+	RecordTiming(ctx, "promotions_service", start)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach promotions service: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		io.Copy(io.Discard, resp.Body)
+		return nil, fmt.Errorf("promotions service returned status %d", resp.StatusCode)
+	}
+
+	var body struct {
+		Gifts []models.GiftPromotion `json:"gifts"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("failed to decode gift promotions: %v", err)
+	}
+	return body.Gifts, nil
+}
+
+// FetchBundles returns the bundle promotions currently configured in the
+// promotions service. Returns nil when no promotions service is configured.
+func FetchBundles(ctx context.Context) ([]models.Bundle, error) {
+	baseURL := os.Getenv("PROMOTIONS_SERVICE_URL")
+	if baseURL == "" {
+		return nil, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, baseURL+"/api/promotions/bundles", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	resp, err := HTTPClient.Do(req)
+	RecordTiming(ctx, "promotions_service", start)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach promotions service: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		io.Copy(io.Discard, resp.Body)
+		return nil, fmt.Errorf("promotions service returned status %d", resp.StatusCode)
+	}
+
+	var body struct {
+		Bundles []models.Bundle `json:"bundles"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("failed to decode bundles: %v", err)
+	}
+	return body.Bundles, nil
+}
+
+// AvailablePromotions are the coupons and bundles the promotions service
+// considers relevant to a cart
+type AvailablePromotions struct {
+	Coupons []models.Coupon `json:"coupons"`
+	Bundles []models.Bundle `json:"bundles"`
+}
+
+// FetchAvailablePromotions asks the promotions service which coupons and
+// bundles are on offer for the cart's contents. Returns nil when no
+// promotions service is configured.
+func FetchAvailablePromotions(ctx context.Context, cart *models.Cart) (*AvailablePromotions, error) {
+	baseURL := os.Getenv("PROMOTIONS_SERVICE_URL")
+	if baseURL == "" {
+		return nil, nil
+	}
+
+	type cartLine struct {
+		ProductID int     `json:"product_id"`
+		Quantity  int     `json:"quantity"`
+		Subtotal  float64 `json:"subtotal"`
+	}
+	lines := make([]cartLine, 0, len(cart.Items))
+	for _, item := range cart.Items {
+		lines = append(lines, cartLine{ProductID: item.ProductID, Quantity: item.Quantity, Subtotal: item.Subtotal})
+	}
+	payload, err := json.Marshal(map[string]interface{}{
+		"items":       lines,
+		"total_price": cart.TotalPrice,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, baseURL+"/api/promotions/available", bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	start := time.Now()
+	resp, err := HTTPClient.Do(req)
+	RecordTiming(ctx, "promotions_service", start)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach promotions service: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		io.Copy(io.Discard, resp.Body)
+		return nil, fmt.Errorf("promotions service returned status %d", resp.StatusCode)
+	}
+
+	var available AvailablePromotions
+	if err := json.NewDecoder(resp.Body).Decode(&available); err != nil {
+		return nil, fmt.Errorf("failed to decode available promotions: %v", err)
+	}
+	return &available, nil
+}
@@ -0,0 +1,57 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// claimQuotaScript adds ARGV[1] units to the day's count in KEYS[1] if
+// that stays within the quota in ARGV[2], expiring the count at the Unix
+// time in ARGV[3]. Returns the units left today, or -1 - left if the
+// units don't fit.
+var claimQuotaScript = redis.NewScript(`
+local used = tonumber(redis.call("GET", KEYS[1]) or "0")
+local left = tonumber(ARGV[2]) - used
+if tonumber(ARGV[1]) > left then
+	return -1 - math.max(left, 0)
+end
+redis.call("INCRBY", KEYS[1], ARGV[1])
+redis.call("EXPIREAT", KEYS[1], ARGV[3])
+return left - tonumber(ARGV[1])
+`)
+
+// quotaKey builds the Redis key counting the units of a product a user
+// added on a UTC day
+func quotaKey(userID string, productID int, day time.Time) string {
+	return Key("quota", UserKey(userID), fmt.Sprintf("%d", productID), day.Format("2006-01-02"))
+}
+
+// QuotaResetAt returns when the daily quotas counted at now reset:
+// midnight UTC
+func QuotaResetAt(now time.Time) time.Time {
+	year, month, day := now.UTC().Date()
+	return time.Date(year, month, day+1, 0, 0, 0, 0, time.UTC)
+}
+
+// ClaimDailyQuota atomically counts quantity units of a product against
+// the user's daily quota. Returns false if they would exceed it, along
+// with the units still allowed today.
+func ClaimDailyQuota(ctx context.Context, userID string, productID, quantity, quota int, now time.Time) (bool, int, error) {
+	result, err := claimQuotaScript.Run(ctx, RedisClient,
+		[]string{quotaKey(userID, productID, now.UTC())}, quantity, quota, QuotaResetAt(now).Unix()).Int()
+	if err != nil {
+		return false, 0, err
+	}
+	if result < 0 {
+		return false, -1 - result, nil
+	}
+	return true, result, nil
+}
+
+// ReleaseDailyQuota gives back units claimed at now that were never added
+func ReleaseDailyQuota(ctx context.Context, userID string, productID, quantity int, now time.Time) error {
+	return RedisClient.DecrBy(ctx, quotaKey(userID, productID, now.UTC()), int64(quantity)).Err()
+}
@@ -0,0 +1,62 @@
+package utils
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestClaimDailyQuota(t *testing.T) {
+	// Counts expire at midnight, so claims are made on a day still to come
+	beforeMidnight := QuotaResetAt(time.Now()).Add(24*time.Hour - time.Minute)
+
+	tests := []struct {
+		name          string
+		claims        []int
+		at            time.Time
+		quantity      int
+		wantAllowed   bool
+		wantRemaining int
+	}{
+		{name: "within quota", claims: []int{2}, at: beforeMidnight, quantity: 3, wantAllowed: true, wantRemaining: 0},
+		{name: "beyond quota", claims: []int{2}, at: beforeMidnight, quantity: 4, wantRemaining: 3},
+		{name: "quota used up", claims: []int{3, 2}, at: beforeMidnight, quantity: 1},
+		{name: "reset after midnight UTC", claims: []int{5}, at: beforeMidnight.Add(2 * time.Minute), quantity: 5, wantAllowed: true, wantRemaining: 0},
+		{name: "other time zone same UTC day", claims: []int{5}, at: beforeMidnight.In(time.FixedZone("UTC+2", 2*60*60)), quantity: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newTestRedis(t)
+			ctx := context.Background()
+			for _, quantity := range tt.claims {
+				if allowed, _, err := ClaimDailyQuota(ctx, "42", 1, quantity, 5, beforeMidnight); err != nil || !allowed {
+					t.Fatalf("claiming %d: allowed = %v, err = %v", quantity, allowed, err)
+				}
+			}
+
+			allowed, remaining, err := ClaimDailyQuota(ctx, "42", 1, tt.quantity, 5, tt.at)
+			if err != nil {
+				t.Fatalf("ClaimDailyQuota: %v", err)
+			}
+			if allowed != tt.wantAllowed || remaining != tt.wantRemaining {
+				t.Errorf("allowed = %v with %d remaining, want %v with %d", allowed, remaining, tt.wantAllowed, tt.wantRemaining)
+			}
+		})
+	}
+}
+
+func TestReleaseDailyQuota(t *testing.T) {
+	newTestRedis(t)
+	ctx := context.Background()
+	now := time.Now()
+	if allowed, _, _ := ClaimDailyQuota(ctx, "42", 1, 5, 5, now); !allowed {
+		t.Fatal("first claim refused")
+	}
+	if err := ReleaseDailyQuota(ctx, "42", 1, 2, now); err != nil {
+		t.Fatalf("ReleaseDailyQuota: %v", err)
+	}
+	if allowed, remaining, _ := ClaimDailyQuota(ctx, "42", 1, 2, 5, now); !allowed || remaining != 0 {
+		t.Errorf("allowed = %v with %d remaining, want the 2 released units claimable", allowed, remaining)
+	}
+}
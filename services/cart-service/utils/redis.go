@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/go-redis/redis/v8"
@@ -28,6 +29,12 @@ func InitRedis() error {
 
 	redisAddr := fmt.Sprintf("%s:%s", redisHost, redisPort)
 
+	// Namespace keys when environments share an instance
+	SetKeyPrefix(os.Getenv("REDIS_KEY_PREFIX"))
+	if err := SetKeyHashing(os.Getenv("REDIS_KEY_HASHING")); err != nil {
+		return err
+	}
+
 	RedisClient = redis.NewClient(&redis.Options{
 		Addr:         redisAddr,
 		Password:     os.Getenv("REDIS_PASSWORD"),
@@ -37,6 +44,11 @@ func InitRedis() error {
 		WriteTimeout: 30 * time.Second,
 	})
 
+	// Record Redis latency for debug responses
+	if GetEnvBool("DEBUG", false) {
+		RedisClient.AddHook(timingHook{})
+	}
+
 	// Test connection
 	_, err := RedisClient.Ping(Ctx).Result()
 	if err != nil {
@@ -45,4 +57,81 @@ func InitRedis() error {
 
 	fmt.Println("✅ Connected to Redis successfully")
 	return nil
-}
\ No newline at end of file
+}
+
+// GetMany reads several keys in a single pipelined round trip.
+// Keys that do not exist are left out of the returned map.
+func GetMany(ctx context.Context, keys ...string) (map[string]string, error) {
+	pipe := RedisClient.Pipeline()
+	cmds := make([]*redis.StringCmd, len(keys))
+	for i, key := range keys {
+		cmds[i] = pipe.Get(ctx, key)
+	}
+
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, err
+	}
+
+	values := make(map[string]string, len(keys))
+	for i, cmd := range cmds {
+		val, err := cmd.Result()
+		if err == redis.Nil {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		values[keys[i]] = val
+	}
+	return values, nil
+}
+
+// DeleteKeys deletes keys with one pipelined DEL per key, which stays
+// valid when keys hash to different cluster slots. Returns how many
+// keys existed and were removed.
+func DeleteKeys(ctx context.Context, keys ...string) (int64, error) {
+	pipe := RedisClient.Pipeline()
+	cmds := make([]*redis.IntCmd, len(keys))
+	for i, key := range keys {
+		cmds[i] = pipe.Del(ctx, key)
+	}
+
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, err
+	}
+
+	var deleted int64
+	for _, cmd := range cmds {
+		deleted += cmd.Val()
+	}
+	return deleted, nil
+}
+
+// ScanKeys returns all keys matching pattern using SCAN, so large
+// keyspaces are walked incrementally rather than blocking with KEYS
+func ScanKeys(ctx context.Context, pattern string) ([]string, error) {
+	var keys []string
+	iter := RedisClient.Scan(ctx, 0, pattern, 100).Iterator()
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+	}
+	if err := iter.Err(); err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// RedisVersion returns the version reported by the connected Redis server
+func RedisVersion() (string, error) {
+	info, err := RedisClient.Info(Ctx, "server").Result()
+	if err != nil {
+		return "", err
+	}
+
+	for _, line := range strings.Split(info, "\r\n") {
+		if strings.HasPrefix(line, "redis_version:") {
+			return strings.TrimPrefix(line, "redis_version:"), nil
+		}
+	}
+	return "", fmt.Errorf("redis_version not found in INFO output")
+}
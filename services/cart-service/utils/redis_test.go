@@ -0,0 +1,100 @@
+package utils
+
+import (
+	"context"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+)
+
+// newTestRedis points RedisClient at an in-memory Redis for the duration
+// of the test
+func newTestRedis(t *testing.T) *miniredis.Miniredis {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	previous := RedisClient
+	RedisClient = redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() {
+		RedisClient.Close()
+		RedisClient = previous
+	})
+	return mr
+}
+
+// roundTripHook counts round trips to Redis; a pipeline is one trip
+type roundTripHook struct {
+	trips int
+}
+
+func (h *roundTripHook) BeforeProcess(ctx context.Context, cmd redis.Cmder) (context.Context, error) {
+	h.trips++
+	return ctx, nil
+}
+
+func (h *roundTripHook) AfterProcess(ctx context.Context, cmd redis.Cmder) error {
+	return nil
+}
+
+func (h *roundTripHook) BeforeProcessPipeline(ctx context.Context, cmds []redis.Cmder) (context.Context, error) {
+	h.trips++
+	return ctx, nil
+}
+
+func (h *roundTripHook) AfterProcessPipeline(ctx context.Context, cmds []redis.Cmder) error {
+	return nil
+}
+
+func TestGetMany(t *testing.T) {
+	tests := []struct {
+		name   string
+		stored map[string]string
+		keys   []string
+		want   map[string]string
+	}{
+		{
+			name:   "all keys present",
+			stored: map[string]string{"cart:1": "a", "coupon:X": "b", "address:1": "c"},
+			keys:   []string{"cart:1", "coupon:X", "address:1"},
+			want:   map[string]string{"cart:1": "a", "coupon:X": "b", "address:1": "c"},
+		},
+		{
+			name:   "missing keys are left out",
+			stored: map[string]string{"cart:1": "a"},
+			keys:   []string{"cart:1", "coupon:X"},
+			want:   map[string]string{"cart:1": "a"},
+		},
+		{
+			name: "no keys present",
+			keys: []string{"cart:1", "coupon:X"},
+			want: map[string]string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mr := newTestRedis(t)
+			for key, value := range tt.stored {
+				mr.Set(key, value)
+			}
+			hook := &roundTripHook{}
+			RedisClient.AddHook(hook)
+
+			got, err := GetMany(Ctx, tt.keys...)
+			if err != nil {
+				t.Fatalf("GetMany: %v", err)
+			}
+			if hook.trips != 1 {
+				t.Errorf("round trips = %d, want 1", hook.trips)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+			for key, value := range tt.want {
+				if got[key] != value {
+					t.Errorf("%s = %q, want %q", key, got[key], value)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package utils
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+type timingsKey struct{}
+
+// Timings collects how long a request spent in each downstream dependency
+type Timings struct {
+	mu     sync.Mutex
+	totals map[string]float64
+	counts map[string]int
+}
+
+// NewTimings creates an empty timing collector
+func NewTimings() *Timings {
+	return &Timings{
+		totals: map[string]float64{},
+		counts: map[string]int{},
+	}
+}
+
+// WithTimings attaches a timing collector to a request context
+func WithTimings(ctx context.Context, t *Timings) context.Context {
+	return context.WithValue(ctx, timingsKey{}, t)
+}
+
+// RecordTiming adds the time elapsed since start under name. It is a no-op
+// unless the context carries a timing collector.
+func RecordTiming(ctx context.Context, name string, start time.Time) {
+	t, ok := ctx.Value(timingsKey{}).(*Timings)
+	if !ok {
+		return
+	}
+
+	elapsed := float64(time.Since(start).Microseconds()) / 1000
+	t.mu.Lock()
+	t.totals[name] += elapsed
+	t.counts[name]++
+	t.mu.Unlock()
+}
+
+// Summary returns the total milliseconds and call count per dependency
+func (t *Timings) Summary() map[string]interface{} {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	summary := make(map[string]interface{}, len(t.totals))
+	for name, total := range t.totals {
+		summary[name] = map[string]interface{}{
+			"total_ms": total,
+			"calls":    t.counts[name],
+		}
+	}
+	return summary
+}
+
+type redisStartKey struct{}
+
+// timingHook records Redis command and pipeline latency into the
+// request's timing collector
+type timingHook struct{}
+
+func (timingHook) BeforeProcess(ctx context.Context, cmd redis.Cmder) (context.Context, error) {
+	return context.WithValue(ctx, redisStartKey{}, time.Now()), nil
+}
+
+func (timingHook) AfterProcess(ctx context.Context, cmd redis.Cmder) error {
+	if start, ok := ctx.Value(redisStartKey{}).(time.Time); ok {
+		RecordTiming(ctx, "redis", start)
+	}
+	return nil
+}
+
+func (timingHook) BeforeProcessPipeline(ctx context.Context, cmds []redis.Cmder) (context.Context, error) {
+	return context.WithValue(ctx, redisStartKey{}, time.Now()), nil
+}
+
+func (timingHook) AfterProcessPipeline(ctx context.Context, cmds []redis.Cmder) error {
+	if start, ok := ctx.Value(redisStartKey{}).(time.Time); ok {
+		RecordTiming(ctx, "redis", start)
+	}
+	return nil
+}
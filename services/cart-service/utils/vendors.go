@@ -0,0 +1,50 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// FetchVendorMinimums returns the minimum order value each of the given
+// marketplace vendors requires, by vendor ID. Vendors without a minimum are
+// left out. Returns nil when no vendor service is configured.
+func FetchVendorMinimums(ctx context.Context, vendorIDs []string) (map[string]float64, error) {
+	baseURL := os.Getenv("VENDOR_SERVICE_URL")
+	if baseURL == "" || len(vendorIDs) == 0 {
+		return nil, nil
+	}
+
+	query := url.Values{"ids": {strings.Join(vendorIDs, ",")}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, baseURL+"/api/vendors/minimums?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	resp, err := HTTPClient.Do(req)
+	RecordTiming(ctx, "vendor_service", start)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach vendor service: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		io.Copy(io.Discard, resp.Body)
+		return nil, fmt.Errorf("vendor service returned status %d", resp.StatusCode)
+	}
+
+	var body struct {
+		Minimums map[string]float64 `json:"minimums"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("failed to decode vendor minimums: %v", err)
+	}
+	return body.Minimums, nil
+}